@@ -0,0 +1,569 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func runBuildCommand(t *testing.T, setup *integrationTestSetup, args ...string) string {
+	cmd := exec.Command(setup.ctxBinary, append([]string{"build"}, args...)...)
+	cmd.Dir = setup.tmpDir
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("ctx build failed: %v\nOutput: %s", err, output)
+	}
+
+	return string(output)
+}
+
+func TestBuildIntegration_DryRun(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	outputDir := filepath.Join(setup.tmpDir, "out")
+	outputPath := filepath.Join(outputDir, "TEST.md")
+
+	output := runBuildCommand(t, setup, "--non-interactive", "--tags", "typescript", "--output-file", outputPath, "--dry-run")
+
+	if !strings.Contains(output, "Global TypeScript Fragment") {
+		t.Errorf("Expected dry-run preview to contain fragment content, got: %s", output)
+	}
+
+	if !strings.Contains(output, "[dry-run] would write 1 file(s)") {
+		t.Errorf("Expected dry-run summary line, got: %s", output)
+	}
+
+	if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+		t.Error("Expected no output file to be written in dry-run mode")
+	}
+
+	if _, err := os.Stat(outputDir); !os.IsNotExist(err) {
+		t.Error("Expected no output directory to be created in dry-run mode")
+	}
+}
+
+func TestBuildIntegration_SkipIfUnchanged(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	outputPath := filepath.Join(setup.tmpDir, "AGENTS.md")
+	args := []string{"--non-interactive", "--tags", "typescript", "--output-file", outputPath, "--skip-if-unchanged"}
+
+	runBuildCommand(t, setup, args...)
+
+	// Move the modification time into the past so a rewrite would be detectable
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(outputPath, past, past); err != nil {
+		t.Fatalf("Failed to set modification time: %v", err)
+	}
+
+	output := runBuildCommand(t, setup, args...)
+	if !strings.Contains(output, "skipped: "+outputPath+" (unchanged)") {
+		t.Errorf("Expected skip message, got: %s", output)
+	}
+
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to stat output file: %v", err)
+	}
+
+	if !info.ModTime().Equal(past) {
+		t.Errorf("Expected modification time %v to be unchanged, got %v", past, info.ModTime())
+	}
+}
+
+func TestBuildIntegration_TagsFile(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	tagsFile := filepath.Join(setup.tmpDir, "tags.txt")
+	if err := os.WriteFile(tagsFile, []byte("# generated by CI\nlocal\n"), 0o600); err != nil {
+		t.Fatalf("Failed to create tags file: %v", err)
+	}
+
+	output := runBuildCommand(t, setup, "--non-interactive", "--stdout", "--tags-file", tagsFile, "--tags", "typescript")
+
+	if !strings.Contains(output, "Local Only Fragment") {
+		t.Errorf("Expected output to contain fragment selected by tags file, got: %s", output)
+	}
+
+	if !strings.Contains(output, "Global TypeScript Fragment") {
+		t.Errorf("Expected output to contain fragment selected by --tags, got: %s", output)
+	}
+}
+
+func TestBuildIntegration_Check(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	outputPath := filepath.Join(setup.tmpDir, "AGENTS.md")
+	args := []string{"build", "--non-interactive", "--tags", "typescript", "--output-file", outputPath, "--check"}
+
+	runCheck := func() (string, int) {
+		cmd := exec.Command(setup.ctxBinary, args...)
+		cmd.Dir = setup.tmpDir
+
+		output, err := cmd.CombinedOutput()
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return string(output), exitErr.ExitCode()
+		}
+
+		if err != nil {
+			t.Fatalf("ctx build --check failed to run: %v", err)
+		}
+
+		return string(output), 0
+	}
+
+	output, code := runCheck()
+	if code != 1 || !strings.Contains(output, "would create: "+outputPath) {
+		t.Errorf("Expected missing file to fail the check, got exit %d: %s", code, output)
+	}
+
+	if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+		t.Error("Expected --check not to write the output file")
+	}
+
+	runBuildCommand(t, setup, "--non-interactive", "--tags", "typescript", "--output-file", outputPath)
+
+	output, code = runCheck()
+	if code != 0 || !strings.Contains(output, "All output files are up to date.") {
+		t.Errorf("Expected up-to-date file to pass the check, got exit %d: %s", code, output)
+	}
+
+	if err := os.WriteFile(outputPath, []byte("stale"), 0o600); err != nil {
+		t.Fatalf("Failed to modify output file: %v", err)
+	}
+
+	output, code = runCheck()
+	if code != 1 || !strings.Contains(output, "would change: "+outputPath) {
+		t.Errorf("Expected changed file to fail the check, got exit %d: %s", code, output)
+	}
+}
+
+func TestBuildIntegration_OutputDir(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	outputDir := filepath.Join(setup.tmpDir, "out", "nested")
+
+	runBuildCommand(t, setup, "--non-interactive", "--tags", "typescript", "--output-format", "test", "--output-dir", outputDir)
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "TEST.md"))
+	if err != nil {
+		t.Fatalf("Expected output file in output directory: %v", err)
+	}
+
+	if !strings.Contains(string(content), "Global TypeScript Fragment") {
+		t.Errorf("Expected output to contain fragment content, got: %s", content)
+	}
+
+	if _, err := os.Stat(filepath.Join(setup.tmpDir, "TEST.md")); !os.IsNotExist(err) {
+		t.Error("Expected no output file outside the output directory")
+	}
+}
+
+func TestBuildIntegration_HTML(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	outputPath := filepath.Join(setup.tmpDir, "AGENTS.html")
+
+	runBuildCommand(t, setup, "--non-interactive", "--tags", "typescript", "--output-file", outputPath, "--html")
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+
+	if !strings.HasPrefix(string(content), "<!DOCTYPE html>") {
+		t.Errorf("Expected an HTML document, got: %s", content)
+	}
+
+	if !strings.Contains(string(content), "<h1>Global TypeScript Fragment</h1>") {
+		t.Errorf("Expected the fragment heading rendered as <h1>, got: %s", content)
+	}
+}
+
+// writeHooksConfig sets the build hooks in the global config, since hooks from the local
+// config are ignored unless the global config trusts it.
+func writeHooksConfig(t *testing.T, setup *integrationTestSetup, preHook, postHook string) {
+	hooks, err := json.Marshal(map[string]interface{}{
+		"outputFormats": map[string]string{"test": "TEST.md"},
+		"preBuildHook":  preHook,
+		"postBuildHook": postHook,
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal hooks config: %v", err)
+	}
+
+	configPath := filepath.Join(setup.tmpDir, "global-config", ".ctx", "config.json")
+	if err := os.WriteFile(configPath, hooks, 0o600); err != nil {
+		t.Fatalf("Failed to write global config: %v", err)
+	}
+}
+
+func TestBuildIntegration_Hooks(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	outputPath := filepath.Join(setup.tmpDir, "TEST.md")
+	preSentinel := filepath.Join(setup.tmpDir, "pre-ran")
+	postSentinel := filepath.Join(setup.tmpDir, "post-ran")
+
+	// Each hook only leaves its sentinel when the output file is in the expected state
+	writeHooksConfig(t, setup,
+		"test ! -e "+outputPath+" && touch "+preSentinel,
+		"test -e "+outputPath+" && touch "+postSentinel)
+
+	runBuildCommand(t, setup, "--non-interactive", "--tags", "typescript", "--output-file", outputPath)
+
+	if _, err := os.Stat(preSentinel); err != nil {
+		t.Errorf("Expected pre-build hook to run before the output was written: %v", err)
+	}
+
+	if _, err := os.Stat(postSentinel); err != nil {
+		t.Errorf("Expected post-build hook to run after the output was written: %v", err)
+	}
+}
+
+func TestBuildIntegration_FailingPreBuildHook(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	outputPath := filepath.Join(setup.tmpDir, "TEST.md")
+	writeHooksConfig(t, setup, "exit 3", "")
+
+	cmd := exec.Command(setup.ctxBinary, "build", "--non-interactive", "--tags", "typescript", "--output-file", outputPath)
+	cmd.Dir = setup.tmpDir
+
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("Expected build to fail when the pre-build hook fails, output: %s", output)
+	}
+
+	if !strings.Contains(string(output), "pre-build hook failed") {
+		t.Errorf("Expected hook failure message, got: %s", output)
+	}
+
+	if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+		t.Error("Expected no output file to be written when the pre-build hook fails")
+	}
+}
+
+func TestBuildIntegration_SkipHooks(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	sentinel := filepath.Join(setup.tmpDir, "hook-ran")
+	writeHooksConfig(t, setup, "touch "+sentinel, "touch "+sentinel)
+
+	runBuildCommand(t, setup, "--non-interactive", "--tags", "typescript", "--output-file", "TEST.md", "--skip-hooks")
+
+	if _, err := os.Stat(sentinel); !os.IsNotExist(err) {
+		t.Error("Expected no hooks to run with --skip-hooks")
+	}
+}
+
+func TestBuildIntegration_PreBuildHookSkippedWithoutOutputFiles(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	sentinel := filepath.Join(setup.tmpDir, "hook-ran")
+	writeHooksConfig(t, setup, "echo hook output; touch "+sentinel, "")
+
+	for _, flag := range []string{"--stdout", "--dry-run"} {
+		output := runBuildCommand(t, setup, "--non-interactive", "--tags", "typescript", "--output-file", "TEST.md", flag)
+
+		if strings.Contains(output, "hook output") {
+			t.Errorf("Expected no hook output with %s, got: %s", flag, output)
+		}
+
+		if _, err := os.Stat(sentinel); !os.IsNotExist(err) {
+			t.Errorf("Expected the pre-build hook not to run with %s", flag)
+		}
+	}
+}
+
+func TestBuildIntegration_LocalHooksNeedTrust(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	sentinel := filepath.Join(setup.tmpDir, "hook-ran")
+
+	local, err := json.Marshal(map[string]string{"preBuildHook": "touch " + sentinel})
+	if err != nil {
+		t.Fatalf("Failed to marshal local config: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(setup.tmpDir, ".ctx", "config.json"), local, 0o600); err != nil {
+		t.Fatalf("Failed to write local config: %v", err)
+	}
+
+	runBuildCommand(t, setup, "--non-interactive", "--tags", "typescript", "--output-file", "TEST.md")
+
+	if _, err := os.Stat(sentinel); !os.IsNotExist(err) {
+		t.Fatal("Expected the pre-build hook of the local config not to run")
+	}
+
+	global := `{"outputFormats": {"test": "TEST.md"}, "trustLocalConfig": true}`
+
+	configPath := filepath.Join(setup.tmpDir, "global-config", ".ctx", "config.json")
+	if err := os.WriteFile(configPath, []byte(global), 0o600); err != nil {
+		t.Fatalf("Failed to write global config: %v", err)
+	}
+
+	runBuildCommand(t, setup, "--non-interactive", "--tags", "typescript", "--output-file", "TEST.md")
+
+	if _, err := os.Stat(sentinel); err != nil {
+		t.Errorf("Expected the pre-build hook of a trusted local config to run: %v", err)
+	}
+}
+
+// writePreprocessorsConfig sets the preprocessors in the global config, since preprocessors
+// from the local config are ignored unless the global config trusts it.
+func writePreprocessorsConfig(t *testing.T, setup *integrationTestSetup, preprocessors ...string) {
+	data, err := json.Marshal(map[string]interface{}{
+		"outputFormats": map[string]string{"test": "TEST.md"},
+		"preprocessors": preprocessors,
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal preprocessors config: %v", err)
+	}
+
+	configPath := filepath.Join(setup.tmpDir, "global-config", ".ctx", "config.json")
+	if err := os.WriteFile(configPath, data, 0o600); err != nil {
+		t.Fatalf("Failed to write global config: %v", err)
+	}
+}
+
+func TestBuildIntegration_Preprocessors(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	// The preprocessors run in order: the second one sees the output of the first
+	writePreprocessorsConfig(t, setup, "sed 's/TypeScript/TS/g'", "sed 's/TS/Typed/g'")
+
+	output := runBuildCommand(t, setup, "--non-interactive", "--tags", "typescript", "--stdout")
+
+	if strings.Contains(output, "TypeScript") {
+		t.Errorf("Expected the preprocessors to replace TypeScript, got: %s", output)
+	}
+
+	if !strings.Contains(output, "Typed") {
+		t.Errorf("Expected the output of the second preprocessor, got: %s", output)
+	}
+
+	output = runBuildCommand(t, setup, "--non-interactive", "--tags", "typescript", "--stdout", "--skip-preprocessors")
+
+	if !strings.Contains(output, "TypeScript") {
+		t.Errorf("Expected unprocessed content with --skip-preprocessors, got: %s", output)
+	}
+
+	output = runBuildCommand(t, setup, "--non-interactive", "--tags", "typescript", "--output-file", "TEST.md", "--dry-run")
+
+	if !strings.Contains(output, "Typed") {
+		t.Errorf("Expected the preprocessors to run with --dry-run, got: %s", output)
+	}
+}
+
+func TestBuildIntegration_PreprocessorsCheck(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	writePreprocessorsConfig(t, setup, "sed 's/TypeScript/Typed/g'")
+
+	args := []string{"--non-interactive", "--tags", "typescript", "--output-format", "test"}
+	runBuildCommand(t, setup, args...)
+
+	// Check and diff render the preprocessed content, so the output just built is up to date
+	runBuildCommand(t, setup, append(args, "--check")...)
+
+	if output, code := runDiffCommand(t, setup, append(args, "--no-color")...); code != 0 {
+		t.Errorf("Expected no diff after building with preprocessors, got exit code %d: %s", code, output)
+	}
+}
+
+func TestBuildIntegration_LocalPreprocessorsNeedTrust(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	local := `{"preprocessors": ["sed 's/TypeScript/Typed/g'"]}`
+	if err := os.WriteFile(filepath.Join(setup.tmpDir, ".ctx", "config.json"), []byte(local), 0o600); err != nil {
+		t.Fatalf("Failed to write local config: %v", err)
+	}
+
+	output := runBuildCommand(t, setup, "--non-interactive", "--tags", "typescript", "--stdout")
+
+	if strings.Contains(output, "Typed") {
+		t.Errorf("Expected the preprocessors of the local config not to run, got: %s", output)
+	}
+}
+
+func TestBuildIntegration_FailingPreprocessor(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	outputPath := filepath.Join(setup.tmpDir, "TEST.md")
+	writePreprocessorsConfig(t, setup, "cat", "exit 4")
+
+	cmd := exec.Command(setup.ctxBinary, "build", "--non-interactive", "--tags", "typescript", "--output-file", outputPath)
+	cmd.Dir = setup.tmpDir
+
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("Expected build to fail when a preprocessor fails, output: %s", output)
+	}
+
+	if !strings.Contains(string(output), `preprocessor "exit 4" failed for `) || !strings.Contains(string(output), "typescript.md") {
+		t.Errorf("Expected the failing preprocessor and fragment in the error, got: %s", output)
+	}
+
+	if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+		t.Error("Expected no output file to be written when a preprocessor fails")
+	}
+}
+
+func TestBuildIntegration_EnvOverrides(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	altConfigPath := filepath.Join(setup.tmpDir, "alt-config.json")
+	if err := os.WriteFile(altConfigPath, []byte(`{"outputFormats": {"alt": "ALT.md"}}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config file: %v", err)
+	}
+
+	runWithEnv := func(args ...string) {
+		cmd := exec.Command(setup.ctxBinary, append([]string{"build", "--non-interactive", "--tags", "typescript"}, args...)...)
+		cmd.Dir = setup.tmpDir
+		cmd.Env = append(os.Environ(), "CTX_CONFIG_FILE="+altConfigPath, "CTX_OUTPUT_DIR=env-out")
+
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("ctx build failed: %v\nOutput: %s", err, output)
+		}
+	}
+
+	runWithEnv()
+
+	if _, err := os.Stat(filepath.Join(setup.tmpDir, "env-out", "ALT.md")); err != nil {
+		t.Errorf("Expected the output format of CTX_CONFIG_FILE in CTX_OUTPUT_DIR: %v", err)
+	}
+
+	globalConfigPath := filepath.Join(setup.tmpDir, "global-config", ".ctx", "config.json")
+	runWithEnv("--config-file", globalConfigPath, "--output-format", "test")
+
+	if _, err := os.Stat(filepath.Join(setup.tmpDir, "env-out", "TEST.md")); err != nil {
+		t.Errorf("Expected --config-file to take precedence over CTX_CONFIG_FILE: %v", err)
+	}
+}
+
+func TestBuildIntegration_EnvDefaultTags(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	build := func(args ...string) string {
+		cmd := exec.Command(setup.ctxBinary, append([]string{"build", "--non-interactive", "--stdout"}, args...)...)
+		cmd.Dir = setup.tmpDir
+		cmd.Env = append(os.Environ(), "CTX_DEFAULT_TAGS=typescript,testing")
+
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("ctx build failed: %v\nOutput: %s", err, output)
+		}
+
+		return string(output)
+	}
+
+	output := build()
+
+	for _, expected := range []string{"Global TypeScript Fragment", "Local Only Fragment"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected CTX_DEFAULT_TAGS to select %q, got: %s", expected, output)
+		}
+	}
+
+	if strings.Contains(output, "Local Common Fragment") {
+		t.Errorf("Expected fragments without the CTX_DEFAULT_TAGS tags to be excluded, got: %s", output)
+	}
+
+	output = build("--tags", "common")
+
+	if !strings.Contains(output, "Local Common Fragment") || strings.Contains(output, "Global TypeScript Fragment") {
+		t.Errorf("Expected --tags to take precedence over CTX_DEFAULT_TAGS, got: %s", output)
+	}
+}
+
+func TestBuildIntegration_Stdin(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	build := func(args ...string) string {
+		cmd := exec.Command(setup.ctxBinary, append([]string{"build", "--non-interactive", "--stdout", "--tags", "typescript", "--stdin"}, args...)...)
+		cmd.Dir = setup.tmpDir
+		cmd.Stdin = strings.NewReader("Current branch: main\n")
+
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("ctx build --stdin failed: %v", err)
+		}
+
+		return string(output)
+	}
+
+	output := build()
+	if !strings.Contains(output, "Global TypeScript Fragment") {
+		t.Errorf("Expected output to contain the fragment, got: %s", output)
+	}
+
+	if !strings.HasSuffix(strings.TrimRight(output, "\n"), "\n\nCurrent branch: main") {
+		t.Errorf("Expected the stdin content after the fragments, got: %s", output)
+	}
+
+	output = build("--stdin-position", "before")
+	if !strings.HasPrefix(output, "Current branch: main\n\n") {
+		t.Errorf("Expected the stdin content before the fragments, got: %s", output)
+	}
+}
+
+func TestBuildIntegration_StdinTags(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	pipeline := `printf '# tags for this build\n  typescript  \n\ntesting\n' | "$CTX" build --non-interactive --stdin-tags --stdout`
+
+	cmd := exec.Command("sh", "-c", pipeline)
+	cmd.Dir = setup.tmpDir
+	cmd.Env = append(os.Environ(), "CTX="+setup.ctxBinary)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("ctx build --stdin-tags failed: %v\nOutput: %s", err, output)
+	}
+
+	for _, expected := range []string{"Global TypeScript Fragment", "Local Only Fragment"} {
+		if !strings.Contains(string(output), expected) {
+			t.Errorf("Expected the piped tags to select %q, got: %s", expected, output)
+		}
+	}
+
+	if strings.Contains(string(output), "Local Common Fragment") {
+		t.Errorf("Expected fragments without the piped tags to be excluded, got: %s", output)
+	}
+
+	cmd = exec.Command(setup.ctxBinary, "build", "--non-interactive", "--stdin-tags", "--tags", "common", "--stdout")
+	cmd.Dir = setup.tmpDir
+	cmd.Stdin = strings.NewReader("typescript\n")
+
+	if output, err := cmd.CombinedOutput(); err == nil {
+		t.Errorf("Expected --stdin-tags combined with --tags to fail, got: %s", output)
+	}
+}
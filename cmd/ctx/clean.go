@@ -0,0 +1,37 @@
+package main
+
+import (
+	"github.com/Lewenhaupt/ctx/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cleanFormats     []string
+	cleanDryRun      bool
+	cleanBuildReport string
+)
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove generated output files",
+	Long: `Remove the output files of all configured output formats, or only those given
+with --formats. With --build-report, remove the files listed in a build report instead.
+Files that do not exist are skipped.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.CleanOptions{
+			ConfigFile:  configFile,
+			Formats:     cleanFormats,
+			DryRun:      cleanDryRun,
+			BuildReport: cleanBuildReport,
+		}
+
+		return tui.RunClean(&opts)
+	},
+}
+
+func init() {
+	cleanCmd.Flags().StringSliceVar(&cleanFormats, "formats", []string{}, "only remove the output files of these formats")
+	cleanCmd.Flags().BoolVar(&cleanDryRun, "dry-run", false, "only print the files that would be removed")
+	cleanCmd.Flags().StringVar(&cleanBuildReport, "build-report", "", "remove the output files listed in this build report instead of the configured ones")
+	cleanCmd.MarkFlagsMutuallyExclusive("formats", "build-report")
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"github.com/Lewenhaupt/ctx/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the ctx configuration",
+	Long:  `Inspect and maintain the ctx configuration file.`,
+}
+
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the configuration file to the current schema version",
+	Long: `Read the configuration file, apply all pending schema migrations
+(for example renaming snake_case keys such as default_tags to camelCase),
+write the result back and print a diff of what changed.
+A timestamped backup of the original file is created before writing.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.ConfigMigrateOptions{
+			ConfigFile: configFile,
+		}
+
+		return tui.RunConfigMigrate(&opts)
+	},
+}
+
+var (
+	configConvertTo string
+	configShowJSON  bool
+)
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective configuration",
+	Long: `Print the configuration that results from merging the global config file and a
+local .ctx config file, together with the file each field was taken from.
+Fields not set by any file show "default" as their source.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.ConfigShowOptions{
+			ConfigFile: configFile,
+			JSON:       configShowJSON,
+		}
+
+		return tui.RunConfigShow(&opts)
+	},
+}
+
+var configConvertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Convert the configuration file between JSON, YAML and TOML",
+	Long: `Rewrite the configuration file in the format given by --to. The converted file
+is written next to the original with a .json, .yaml or .toml extension, and the
+original is replaced by a timestamped backup. TOML files use snake_case keys
+(for example default_tags instead of defaultTags).`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.ConfigConvertOptions{
+			ConfigFile: configFile,
+			To:         configConvertTo,
+		}
+
+		return tui.RunConfigConvert(&opts)
+	},
+}
+
+func init() {
+	configConvertCmd.Flags().StringVar(&configConvertTo, "to", "", "target format: json, yaml or toml")
+	_ = configConvertCmd.MarkFlagRequired("to")
+	_ = configConvertCmd.RegisterFlagCompletionFunc("to", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"json", "yaml", "toml"}, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	configShowCmd.Flags().BoolVar(&configShowJSON, "json", false, "output the configuration and field sources as JSON")
+
+	configCmd.AddCommand(configMigrateCmd)
+	configCmd.AddCommand(configConvertCmd)
+	configCmd.AddCommand(configShowCmd)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"github.com/Lewenhaupt/ctx/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var noColor bool
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show how a build would change the existing output files",
+	Long: `Run the same fragment selection and splicing as build, but instead of writing
+the output files print a unified diff between the built output and the files on disk.
+
+Exit codes:
+  0  the output files are up to date
+  1  at least one output file differs
+  2  an error occurred`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.DiffOptions{
+			BuildOptions: buildOptions(cmd),
+			NoColor:      noColor,
+		}
+
+		changed, err := tui.RunDiff(&opts)
+		if err != nil {
+			return &exitError{code: 2, err: err}
+		}
+
+		if changed {
+			return &exitError{code: 1}
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	addBuildFlags(diffCmd)
+	diffCmd.Flags().BoolVar(&noColor, "no-color", false, "disable colored diff output")
+	diffCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return &exitError{code: 2, err: err}
+	})
+}
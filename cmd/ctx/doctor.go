@@ -0,0 +1,45 @@
+package main
+
+import (
+	"github.com/Lewenhaupt/ctx/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var doctorFix bool
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the configuration and environment for problems",
+	Long: `Run a series of health checks: the config file can be loaded, the fragments
+directories exist and are readable, at least one fragment has ctx-tags, every output
+format target is writable and a local .ctx/fragments directory, if present, contains fragments.
+
+With --fix, problems that can be repaired automatically (such as missing directories) are fixed.
+
+Exit codes:
+  0  all checks passed
+  1  at least one check failed`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.DoctorOptions{
+			ConfigFile: configFile,
+			Fix:        doctorFix,
+		}
+
+		passed, err := tui.RunDoctor(&opts)
+		if err != nil {
+			return err
+		}
+
+		if !passed {
+			return &exitError{code: 1}
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "repair problems that can be fixed automatically, such as missing directories")
+}
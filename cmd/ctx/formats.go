@@ -0,0 +1,28 @@
+package main
+
+import (
+	"github.com/Lewenhaupt/ctx/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var formatsJSON bool
+
+var formatsCmd = &cobra.Command{
+	Use:   "formats",
+	Short: "List the available output formats",
+	Long: `List every output format that can be passed to build --output-format: the built-in
+formats (opencode, gemini) merged with the formats from the config, each with the file
+its output is written to. No build is run.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.FormatsOptions{
+			ConfigFile: configFile,
+			JSON:       formatsJSON,
+		}
+
+		return tui.RunFormats(&opts)
+	},
+}
+
+func init() {
+	formatsCmd.Flags().BoolVar(&formatsJSON, "json", false, "output the formats as JSON")
+}
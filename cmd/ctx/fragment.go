@@ -0,0 +1,373 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Lewenhaupt/ctx/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	newName           string
+	newTags           []string
+	newDescription    string
+	newPriority       int
+	newGlobal         bool
+	newLocal          bool
+	newForce          bool
+	newNonInteractive bool
+	showJSON          bool
+	tagListJSON       bool
+	statsSort         string
+	statsJSON         bool
+	compareNoColor    bool
+	duplicateForce    bool
+	moveForce         bool
+)
+
+var fragmentCmd = &cobra.Command{
+	Use:   "fragment",
+	Short: "Manage fragments",
+	Long:  `Create and manage markdown fragments in the global and local fragments directories.`,
+}
+
+var fragmentNewCmd = &cobra.Command{
+	Use:   "new [name]",
+	Short: "Scaffold a new fragment file",
+	Long: `Create a new fragment file with properly formatted frontmatter.
+In interactive mode you will be prompted for the name, tags, description, priority
+and target directory. In non-interactive mode these are read from flags.
+The fragment is written to the global fragments directory unless --local is given.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := newFragmentOptions(cmd, args)
+		if err != nil {
+			return err
+		}
+
+		return tui.RunNewFragment(opts)
+	},
+}
+
+var fragmentFromSelectionCmd = &cobra.Command{
+	Use:   "create-from-selection [name]",
+	Short: "Create a fragment from piped content or the clipboard",
+	Long: `Create a new fragment whose content is read from stdin when it is piped, or
+otherwise from the system clipboard (via pbpaste, wl-paste, xclip or xsel).
+In interactive mode you will be prompted for the name, tags, description, priority
+and target directory. In non-interactive mode these are read from flags and the
+content must be piped to stdin.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := newFragmentOptions(cmd, args)
+		if err != nil {
+			return err
+		}
+
+		return tui.RunFragmentFromSelection(opts)
+	},
+}
+
+// newFragmentOptions assembles the options of the commands creating a fragment from their flags.
+func newFragmentOptions(cmd *cobra.Command, args []string) (*tui.NewFragmentOptions, error) {
+	if newGlobal && newLocal {
+		return nil, fmt.Errorf("--global and --local cannot be used together")
+	}
+
+	opts := &tui.NewFragmentOptions{
+		ConfigFile:     configFile,
+		Name:           newName,
+		Tags:           newTags,
+		Description:    newDescription,
+		Local:          newLocal,
+		Force:          newForce,
+		NonInteractive: newNonInteractive,
+	}
+
+	if len(args) > 0 {
+		opts.Name = args[0]
+	}
+
+	if cmd.Flags().Changed("priority") {
+		opts.Priority = &newPriority
+	}
+
+	return opts, nil
+}
+
+// addNewFragmentFlags adds the flags describing a fragment to create.
+func addNewFragmentFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&newName, "name", "", "name of the fragment file (without extension)")
+	cmd.Flags().StringSliceVar(&newTags, "tags", []string{}, "comma-separated list of tags for the fragment")
+	cmd.Flags().StringVar(&newDescription, "description", "", "short description of the fragment")
+	cmd.Flags().IntVar(&newPriority, "priority", 0, "priority controlling the fragment order")
+	cmd.Flags().BoolVar(&newGlobal, "global", false, "write the fragment to the global fragments directory (default)")
+	cmd.Flags().BoolVar(&newLocal, "local", false, "write the fragment to the local .ctx/fragments directory")
+	cmd.Flags().BoolVar(&newForce, "force", false, "overwrite an existing fragment with the same name")
+	cmd.Flags().BoolVar(&newNonInteractive, "non-interactive", false, "run in non-interactive mode")
+}
+
+var fragmentShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Print a fragment's content and metadata",
+	Long: `Print the resolved path, source (global or local), frontmatter fields and content
+of the fragment with the given name. The name is the filename without extension, or
+the path relative to the fragments directory for fragments in subdirectories.
+When several fragments share the name, all are shown and the one that takes
+precedence in a build is marked active.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.ShowFragmentOptions{
+			ConfigFile: configFile,
+			Name:       args[0],
+			JSON:       showJSON,
+		}
+
+		return tui.RunShowFragment(&opts)
+	},
+}
+
+var fragmentTagListCmd = &cobra.Command{
+	Use:     "tag-list <name>",
+	Aliases: []string{"tags"},
+	Short:   "Print the tags of a fragment",
+	Long: `Print the tags of the fragment with the given name one per line, or as a JSON array
+with --json, for use in shell scripts. The name may include the .md extension.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.FragmentTagListOptions{
+			ConfigFile: configFile,
+			Name:       args[0],
+			JSON:       tagListJSON,
+		}
+
+		return tui.RunFragmentTagList(&opts)
+	},
+}
+
+var fragmentArchiveCmd = &cobra.Command{
+	Use:   "archive <name>",
+	Short: "Move a fragment to the archive",
+	Long: `Move the fragment with the given name to the .ctx/archive directory inside its
+fragments directory, keeping its relative path. Archived fragments are not included in
+builds; restore them with 'ctx fragment restore' and list them with 'ctx fragment list --archived'.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return tui.RunArchiveFragment(&tui.ArchiveFragmentOptions{ConfigFile: configFile, Name: args[0]})
+	},
+}
+
+var fragmentRestoreCmd = &cobra.Command{
+	Use:   "restore <name>",
+	Short: "Move an archived fragment back",
+	Long:  `Move the archived fragment with the given name back to its fragments directory.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return tui.RunRestoreFragment(&tui.ArchiveFragmentOptions{ConfigFile: configFile, Name: args[0]})
+	},
+}
+
+var fragmentStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show content statistics per fragment",
+	Long: `Print the word, character, line and tag count and the file size in bytes of every
+global and local fragment, followed by a totals row. Words, characters and lines are
+counted in the content with includes resolved; the size is that of the file on disk.
+Use --sort to find oversized fragments or fragments without tags.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.FragmentStatsOptions{
+			ConfigFile: configFile,
+			Sort:       statsSort,
+			JSON:       statsJSON,
+		}
+
+		return tui.RunFragmentStats(&opts)
+	},
+}
+
+var fragmentCompareCmd = &cobra.Command{
+	Use:   "compare <a> <b>",
+	Short: "Show a unified diff between two fragments",
+	Long: `Print a unified diff between the bodies of two fragments. Each argument is a
+fragment name, resolved like for 'ctx fragment show', or the path of a fragment file.
+Names are searched in the global fragments directories, then the local one, and the
+first match is used. Passing the same name twice compares that first match with the
+fragment used by builds, e.g. a global fragment and its local override.
+
+Exit codes:
+  0  the fragments are identical
+  1  the fragments differ
+  2  an error occurred`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(2)(cmd, args); err != nil {
+			return &exitError{code: 2, err: err}
+		}
+
+		return nil
+	},
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.CompareFragmentsOptions{
+			ConfigFile: configFile,
+			A:          args[0],
+			B:          args[1],
+			NoColor:    compareNoColor,
+		}
+
+		different, err := tui.RunCompareFragments(&opts)
+		if err != nil {
+			return &exitError{code: 2, err: err}
+		}
+
+		if different {
+			return &exitError{code: 1}
+		}
+
+		return nil
+	},
+}
+
+var fragmentDuplicateCmd = &cobra.Command{
+	Use:   "duplicate <src> <dst>",
+	Short: "Copy a fragment under a new name",
+	Long: `Copy the fragment named <src> to a new fragment called <dst> in the same directory.
+The source is searched in the global fragments directories, then the local one, and the
+first match is copied. The copy gets "ctx-description: Copy of <src>" in its frontmatter;
+everything else is copied unchanged. An existing file is only overwritten with --force.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.DuplicateFragmentOptions{
+			ConfigFile: configFile,
+			Source:     args[0],
+			Name:       args[1],
+			Force:      duplicateForce,
+		}
+
+		return tui.RunDuplicateFragment(&opts)
+	},
+}
+
+var fragmentMoveCmd = &cobra.Command{
+	Use:   "move <name> <global|local>",
+	Short: "Move a fragment between the global and local fragments directories",
+	Long: `Move the fragment with the given name to the global or the local fragments directory,
+keeping its path relative to the fragments directory. Moving to global takes the fragment
+from the local .ctx/fragments directory and writes it to the first global fragments
+directory; moving to local takes the global fragment used by builds.
+The old and new paths are printed. An existing file is only overwritten with --force.`,
+	Args: cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 1 {
+			return []string{tui.FragmentSourceGlobal, tui.FragmentSourceLocal}, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.MoveFragmentOptions{
+			ConfigFile: configFile,
+			Name:       args[0],
+			Target:     args[1],
+			Force:      moveForce,
+		}
+
+		return tui.RunMoveFragment(&opts)
+	},
+}
+
+var fragmentLintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check fragments against the lint rules from the config",
+	Long: `Check every global and local fragment against the rules configured under lintRules
+in the config: require-description, max-tags, min-tags, no-whitespace-in-tags and
+unique-order-values. Each issue is printed with the fragment path and rule name.
+
+Exit codes:
+  0  no issues were found
+  1  at least one fragment violates a rule`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.LintOptions{
+			ConfigFile: configFile,
+		}
+
+		clean, err := tui.RunLint(&opts)
+		if err != nil {
+			return err
+		}
+
+		if !clean {
+			return &exitError{code: 1}
+		}
+
+		return nil
+	},
+}
+
+var fragmentVerifyIncludesCmd = &cobra.Command{
+	Use:   "verify-includes",
+	Short: "Check that all ctx-include references resolve to files",
+	Long: `Check the ctx-include references of every global and local fragment and print each
+one that does not resolve to a file, with similarly named files as hints. Includes are
+resolved relative to the directory of the fragment that lists them.
+
+Exit codes:
+  0  all includes resolve
+  1  at least one include cannot be resolved`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resolved, err := tui.RunVerifyIncludes(&tui.VerifyIncludesOptions{ConfigFile: configFile})
+		if err != nil {
+			return err
+		}
+
+		if !resolved {
+			return &exitError{code: 1}
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	addNewFragmentFlags(fragmentNewCmd)
+	addNewFragmentFlags(fragmentFromSelectionCmd)
+
+	fragmentShowCmd.Flags().BoolVar(&showJSON, "json", false, "output the fragment as JSON")
+	fragmentTagListCmd.Flags().BoolVar(&tagListJSON, "json", false, "output the tags as a JSON array")
+
+	fragmentStatsCmd.Flags().StringVar(&statsSort, "sort", tui.StatsSortPath, "sort order: path, size, words, lines or tags")
+	fragmentStatsCmd.Flags().BoolVar(&statsJSON, "json", false, "output the statistics as JSON")
+
+	_ = fragmentStatsCmd.RegisterFlagCompletionFunc("sort", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return tui.StatsSortOrders, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	fragmentCompareCmd.Flags().BoolVar(&compareNoColor, "no-color", false, "disable colored diff output")
+	fragmentCompareCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return &exitError{code: 2, err: err}
+	})
+
+	fragmentDuplicateCmd.Flags().BoolVar(&duplicateForce, "force", false, "overwrite an existing fragment with the same name")
+
+	fragmentMoveCmd.Flags().BoolVar(&moveForce, "force", false, "overwrite an existing fragment with the same name in the target directory")
+
+	fragmentCmd.AddCommand(fragmentNewCmd)
+	fragmentCmd.AddCommand(fragmentFromSelectionCmd)
+	fragmentCmd.AddCommand(fragmentShowCmd)
+	fragmentCmd.AddCommand(fragmentTagListCmd)
+	fragmentCmd.AddCommand(fragmentArchiveCmd)
+	fragmentCmd.AddCommand(fragmentRestoreCmd)
+	fragmentCmd.AddCommand(fragmentStatsCmd)
+	fragmentCmd.AddCommand(fragmentCompareCmd)
+	fragmentCmd.AddCommand(fragmentDuplicateCmd)
+	fragmentCmd.AddCommand(fragmentLintCmd)
+	fragmentCmd.AddCommand(fragmentVerifyIncludesCmd)
+	fragmentCmd.AddCommand(fragmentMoveCmd)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"github.com/Lewenhaupt/ctx/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	grepTags             []string
+	grepFilesWithMatches bool
+	grepCount            bool
+)
+
+var fragmentGrepCmd = &cobra.Command{
+	Use:   "grep <pattern>",
+	Short: "Search fragment bodies with a regular expression",
+	Long: `Match a Go regular expression against the body of all global and local fragments
+(local fragments override global ones with the same name) and print each matching
+line as path:line:content. Output is colored when stdout is a terminal.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.GrepOptions{
+			ConfigFile:       configFile,
+			Pattern:          args[0],
+			Tags:             grepTags,
+			FilesWithMatches: grepFilesWithMatches,
+			Count:            grepCount,
+		}
+
+		return tui.RunGrep(&opts)
+	},
+}
+
+func init() {
+	fragmentGrepCmd.Flags().StringSliceVar(&grepTags, "tags", []string{}, "only search fragments matching these tags (same syntax as build --tags)")
+	fragmentGrepCmd.Flags().BoolVarP(&grepFilesWithMatches, "files-with-matches", "l", false, "print only the paths of fragments with matches")
+	fragmentGrepCmd.Flags().BoolVar(&grepCount, "count", false, "print only the number of matching lines per fragment")
+	fragmentGrepCmd.MarkFlagsMutuallyExclusive("files-with-matches", "count")
+
+	_ = fragmentGrepCmd.RegisterFlagCompletionFunc("tags", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return getAvailableTags(), cobra.ShellCompDirectiveNoFileComp
+	})
+
+	fragmentCmd.AddCommand(fragmentGrepCmd)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"github.com/Lewenhaupt/ctx/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var indexOutput string
+
+var fragmentIndexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Write a JSON catalogue of all fragments",
+	Long: `Scan all global and local fragments and write a JSON index with the name, path,
+source, tags, description, priority, checksum and modification time of each, for
+editors and other tools. The index is written to .ctx/index.json unless --output is given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.IndexOptions{
+			ConfigFile: configFile,
+			Output:     indexOutput,
+			Version:    version,
+		}
+
+		return tui.RunFragmentIndex(&opts)
+	},
+}
+
+func init() {
+	fragmentIndexCmd.Flags().StringVar(&indexOutput, "output", "", "path to write the index to (default: .ctx/index.json)")
+
+	fragmentCmd.AddCommand(fragmentIndexCmd)
+}
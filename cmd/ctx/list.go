@@ -0,0 +1,48 @@
+package main
+
+import (
+	"github.com/Lewenhaupt/ctx/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listTree     bool
+	listArchived bool
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all fragments",
+	Long: `List the fragments of every global and local fragments directory with their tags.
+With --tree the fragments are shown as a directory tree. With --archived the archived
+fragments are listed instead. Also available as 'ctx fragment list'.`,
+	RunE: runList,
+}
+
+var fragmentListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all fragments",
+	Long: `List the fragments of every global and local fragments directory with their tags.
+With --tree the fragments are shown as a directory tree. With --archived the archived
+fragments are listed instead.`,
+	RunE: runList,
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	opts := tui.ListOptions{
+		ConfigFile: configFile,
+		Tree:       listTree,
+		Archived:   listArchived,
+	}
+
+	return tui.RunList(&opts)
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{listCmd, fragmentListCmd} {
+		cmd.Flags().BoolVar(&listTree, "tree", false, "show fragments as a directory tree")
+		cmd.Flags().BoolVar(&listArchived, "archived", false, "list archived fragments instead")
+	}
+
+	fragmentCmd.AddCommand(fragmentListCmd)
+}
@@ -1,12 +1,17 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/Lewenhaupt/ctx/internal/config"
 	"github.com/Lewenhaupt/ctx/internal/parser"
 	"github.com/Lewenhaupt/ctx/internal/tui"
+	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
 )
 
@@ -15,9 +20,69 @@ var (
 	tags            []string
 	nonInteractive  bool
 	outputFormats   []string
-	outputFile      string
+	outputFiles     []string
 	stdout          bool
 	noLocalOverride bool
+	deduplicate     bool
+	dryRun          bool
+	skipIfUnchanged bool
+	tagsFile        string
+	buildReport     string
+	profile         string
+	check           bool
+	ignoreTags      []string
+	outputDir       string
+	groups          []string
+	parallel        bool
+	outputHTML      bool
+	hashManifest    string
+	appendOutput    bool
+	skipHooks       bool
+	since           string
+	sortStrategy    string
+	remoteURL       string
+	remoteCacheTTL  time.Duration
+	outputTemplate  string
+	noLocal         bool
+	verbose         bool
+	stdinInput      bool
+	stdinPosition   string
+	outputEncoding  string
+	writeMetadata   bool
+	noSeparator     bool
+	sourceComments  bool
+	keepFrontmatter bool
+	commentFormat   string
+	failMissingTags bool
+	buildColor      bool
+	buildNoColor    bool
+	walkUp          bool
+	skipPreprocess  bool
+	zipOutput       string
+	tagPrefixes     []string
+	failEmptyPrefix bool
+	outputPerms     string
+	maxFragments    int
+	noHeader        bool
+	previewFrags    bool
+	updateIndex     bool
+	stdinTags       bool
+	overwritePolicy string
+	allowCollision  bool
+	noNormalize     bool
+	extraFragments  []string
+	quietBuild      bool
+
+	initNonInteractive bool
+	initForce          bool
+	initFragmentsDir   string
+	initOutputFormats  map[string]string
+	initDefaultTags    []string
+	initCreateSample   bool
+	initPreset         string
+	initListPresets    bool
+	initAddGitignore   bool
+	initOutputHeader   bool
 )
 
 var rootCmd = &cobra.Command{
@@ -35,16 +100,41 @@ var buildCmd = &cobra.Command{
 The tool will scan the fragments directory, present available tags for selection,
 and combine the matching fragments into a single output.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		opts := tui.BuildOptions{
-			ConfigFile:      configFile,
-			Tags:            tags,
-			NonInteractive:  nonInteractive,
-			OutputFormats:   outputFormats,
-			OutputFile:      outputFile,
-			Stdout:          stdout,
-			NoLocalOverride: noLocalOverride,
+		opts := buildOptions(cmd)
+		opts.BuildReport = buildReport
+		opts.Check = check
+		opts.Parallel = parallel
+		opts.HashManifest = hashManifest
+		opts.Append = appendOutput
+		opts.SkipHooks = skipHooks
+		opts.Zip = zipOutput
+		opts.OutputPermissions = outputPerms
+		opts.WriteMetadata = writeMetadata
+		opts.InteractivePreview = previewFrags
+		opts.UpdateIndex = updateIndex
+		opts.OverwritePolicy = overwritePolicy
+		opts.Quiet = quietBuild
+		opts.Version = version
+		opts.ColorEnabled = buildColor || (!buildNoColor && isatty.IsTerminal(os.Stdout.Fd()))
+
+		if since != "" {
+			sinceTime, err := time.Parse(time.RFC3339, since)
+			if err != nil {
+				return fmt.Errorf("invalid --since timestamp %q: expected RFC3339, e.g. 2024-01-02T15:04:05Z", since)
+			}
+
+			opts.Since = sinceTime
 		}
-		return tui.RunBuild(&opts)
+
+		_, err := tui.RunBuild(&opts)
+		if errors.Is(err, tui.ErrOutputOutdated) {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+
+			return &exitError{code: 1, err: err}
+		}
+
+		return err
 	},
 }
 
@@ -53,10 +143,25 @@ var initCmd = &cobra.Command{
 	Short: "Initialize ctx configuration interactively",
 	Long: `Initialize ctx configuration with an interactive questionnaire.
 This command will guide you through setting up your ctx configuration,
-creating the fragments directory, and optionally creating a sample fragment.`,
+creating the fragments directory, and optionally creating a sample fragment.
+With --non-interactive the answers are read from flags instead, e.g. in CI.
+With --preset the questionnaire is skipped and a built-in configuration is written.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if initListPresets {
+			return tui.RunListPresets()
+		}
+
 		opts := tui.InitOptions{
-			ConfigFile: configFile,
+			ConfigFile:     configFile,
+			NonInteractive: initNonInteractive,
+			Preset:         initPreset,
+			Force:          initForce,
+			FragmentsDir:   initFragmentsDir,
+			OutputFormats:  initOutputFormats,
+			DefaultTags:    initDefaultTags,
+			CreateSample:   initCreateSample,
+			AddGitignore:   initAddGitignore,
+			OutputHeader:   initOutputHeader,
 		}
 		return tui.RunInit(&opts)
 	},
@@ -127,63 +232,357 @@ PowerShell:
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVar(&configFile, "config-file", "", "config file path (default: XDG_CONFIG_HOME/.ctx/config.json)")
+	rootCmd.PersistentFlags().StringVar(&configFile, "config-file", os.Getenv(config.EnvConfigFile), "config file path (default: $CTX_CONFIG_FILE, or XDG_CONFIG_HOME/.ctx/config.json)")
+
+	addBuildFlags(buildCmd)
+	buildCmd.Flags().BoolVar(&check, "check", false, "verify the output files are up to date without writing them; exit 1 if any would change")
+	buildCmd.Flags().BoolVar(&appendOutput, "append", false, "append the output to existing output files instead of replacing them")
+	buildCmd.Flags().StringVar(&since, "since", "", "skip the build unless a selected fragment was modified after this RFC3339 timestamp")
+	buildCmd.Flags().BoolVarP(&quietBuild, "quiet", "q", false, "do not print the summary line with the fragment count and output file sizes after the build")
+	buildCmd.Flags().StringVar(&overwritePolicy, "overwrite-policy", "", "what to do with existing output files: ask (interactive only; overwrite otherwise), always or never (default: overwritePolicy from the config, or ask)")
+	buildCmd.Flags().BoolVar(&previewFrags, "interactive-preview", false, "review each selected fragment in a scrollable pager before confirming the build (interactive mode only)")
+	buildCmd.Flags().BoolVar(&buildColor, "color", false, "always color the status messages (default: only when stdout is a terminal)")
+	buildCmd.Flags().BoolVar(&buildNoColor, "no-color", false, "never color the status messages")
+	buildCmd.MarkFlagsMutuallyExclusive("color", "no-color")
+	buildCmd.Flags().BoolVar(&skipHooks, "skip-hooks", false, "do not run the pre-build and post-build hooks from the config")
+	buildCmd.Flags().StringVar(&outputPerms, "output-permissions", "", "octal mode of the written output files, e.g. 0644 (default: outputPermissions from the config, or 0600)")
+	buildCmd.Flags().StringVar(&zipOutput, "zip", "", "write the output files into a zip archive at this path instead of to disk")
+	buildCmd.MarkFlagsMutuallyExclusive("zip", "stdout")
+	buildCmd.Flags().BoolVar(&parallel, "parallel", false, "write the output files concurrently; cannot be combined with --append")
+	buildCmd.MarkFlagsMutuallyExclusive("append", "parallel")
+	buildCmd.Flags().StringVar(&hashManifest, "hash-manifest", "", "record fragment checksums in this JSON file and skip the build when none changed since the last run")
+	buildCmd.Flags().BoolVar(&writeMetadata, "write-metadata", false, "write a <output>.ctx-meta.json file next to each output file listing the tags and fragment checksums that produced it")
+	buildCmd.Flags().BoolVar(&updateIndex, "update-index", false, "regenerate the fragment index .ctx/index.json after a successful build (see 'ctx fragment index')")
+	buildCmd.Flags().StringVar(&buildReport, "build-report", "", "write a JSON build manifest (tags, fragments, output checksums) to this path")
+
+	initCmd.Flags().BoolVar(&initNonInteractive, "non-interactive", false, "run without prompts, reading the answers from flags")
+	initCmd.Flags().BoolVar(&initForce, "force", false, "overwrite an existing config file in non-interactive mode (a backup is created)")
+	initCmd.Flags().StringVar(&initFragmentsDir, "fragments-dir", "", "fragments directory to configure (default: XDG_CONFIG_HOME/.ctx/fragments)")
+	initCmd.Flags().StringToStringVar(&initOutputFormats, "output-formats", nil, "additional output formats as format=file pairs, e.g. claude=CLAUDE.md,custom=CUSTOM.md")
+	initCmd.Flags().StringSliceVar(&initDefaultTags, "default-tags", []string{}, "comma-separated list of default tags")
+	initCmd.Flags().BoolVar(&initCreateSample, "create-sample", false, "create a hello-world sample fragment")
+	initCmd.Flags().StringVar(&initPreset, "preset", "", "skip the questionnaire and write a built-in preset configuration (see --list-presets)")
+	initCmd.Flags().BoolVar(&initAddGitignore, "add-gitignore", false, "add the configured output files to the .gitignore in the current directory")
+	initCmd.Flags().BoolVar(&initOutputHeader, "output-header", false, "configure an outputHeader comment warning against manual edits of the output files")
+	initCmd.Flags().BoolVar(&initListPresets, "list-presets", false, "print the built-in presets and their output formats")
+
+	if err := buildCmd.RegisterFlagCompletionFunc("overwrite-policy", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return tui.OverwritePolicies, cobra.ShellCompDirectiveNoFileComp
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error registering overwrite-policy completion: %v\n", err)
+	}
 
-	buildCmd.Flags().StringSliceVar(&tags, "tags", []string{}, "comma-separated list of tags to include")
-	buildCmd.Flags().BoolVar(&nonInteractive, "non-interactive", false, "run in non-interactive mode")
-	buildCmd.Flags().StringSliceVar(&outputFormats, "output-format", []string{}, "output format(s) to use (e.g., opencode, gemini, custom)")
-	buildCmd.Flags().StringVar(&outputFile, "output-file", "", "output file path (overrides format-based naming)")
-	buildCmd.Flags().BoolVar(&stdout, "stdout", false, "output to stdout instead of files")
-	buildCmd.Flags().BoolVar(&noLocalOverride, "no-local-override", false, "include both local and global fragments even if they have the same name")
+	if err := initCmd.RegisterFlagCompletionFunc("preset", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return config.PresetNames(), cobra.ShellCompDirectiveNoFileComp
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error registering preset completion: %v\n", err)
+	}
+
+	rootCmd.AddCommand(initCmd)
+	rootCmd.AddCommand(buildCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(fragmentCmd)
+	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(tagsCmd)
+	rootCmd.AddCommand(profileCmd)
+	rootCmd.AddCommand(formatsCmd)
+	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(cleanCmd)
+	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(completionCmd)
+}
+
+// addBuildFlags registers the flags shared by all commands that run the build pipeline.
+func addBuildFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&tags, "tags", []string{}, "tags to include: ',' separates alternatives (OR) and '+' requires all joined tags (AND), e.g. typescript,rust+strict; glob patterns such as 'lang-*' match several tags")
+	cmd.Flags().StringSliceVar(&ignoreTags, "ignore-tag", []string{}, "exclude fragments carrying this tag, even if they match --tags (repeatable)")
+	cmd.Flags().StringSliceVar(&groups, "group", []string{}, "select the tags of a tag group from the config; merged with --tags")
+	cmd.Flags().StringVar(&tagsFile, "tags-file", "", "read tags from a file (one per line, # starts a comment); merged with --tags")
+	cmd.Flags().BoolVar(&nonInteractive, "non-interactive", false, "run in non-interactive mode")
+	cmd.Flags().StringSliceVar(&outputFormats, "output-format", []string{}, "output format(s) to use (e.g., opencode, gemini, yaml-multi-doc, json, custom)")
+	cmd.Flags().StringSliceVar(&outputFiles, "output-file", []string{}, "output file path (overrides format-based naming); repeat to write the same output to several files")
+	cmd.Flags().StringVar(&outputDir, "output-dir", "", "directory to place the output files in (default: outputDir from the config); absolute output paths are used as is")
+	cmd.Flags().BoolVar(&outputHTML, "html", false, "render the output as an HTML document instead of Markdown")
+	cmd.Flags().StringVar(&outputTemplate, "output-template", "", "wrap the spliced output in a Go text/template file ({{.Content}}, {{.Tags}} and {{.Timestamp}}, the latest modification time of the selected fragments)")
+	cmd.Flags().BoolVar(&stdout, "stdout", false, "output to stdout instead of files")
+	cmd.Flags().BoolVar(&noLocalOverride, "no-local-override", false, "include both local and global fragments even if they have the same name")
+	cmd.Flags().BoolVar(&deduplicate, "deduplicate", false, "include fragments with identical content only once")
+	cmd.Flags().BoolVar(&skipIfUnchanged, "skip-if-unchanged", false, "do not rewrite output files whose content would not change")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "preview the output files and their content without writing anything")
+	cmd.Flags().StringVar(&sortStrategy, "sort", parser.SortPriority, "fragment order in the output: alpha (by path), priority (by ctx-priority, then path) or mtime (most recently modified first)")
+	cmd.Flags().StringVar(&profile, "profile", "", "use the tags and output formats of a profile from the config; --tags and --output-format override it")
+	cmd.Flags().StringVar(&remoteURL, "remote", "", "also use the fragments of a remote directory listing or tarball URL; local and global fragments override them")
+	cmd.Flags().DurationVar(&remoteCacheTTL, "remote-cache-ttl", 0, "reuse fetched remote fragments for this duration (e.g. 1h) instead of downloading them on every build")
+	cmd.Flags().BoolVar(&noLocal, "no-local", false, "skip the local .ctx/fragments directory and the project configs and use only global (and remote) fragments; cannot be combined with --no-local-override")
+	cmd.Flags().BoolVar(&walkUp, "walk-up", false, "also use the .ctx/fragments directories of parent directories; deeper directories override parent ones")
+	cmd.MarkFlagsMutuallyExclusive("no-local", "no-local-override")
+	cmd.MarkFlagsMutuallyExclusive("no-local", "walk-up")
+	cmd.Flags().StringArrayVar(&extraFragments, "fragment", nil, "add the file at this path to the build as a fragment regardless of its tags (repeatable)")
+	cmd.Flags().BoolVar(&noNormalize, "no-normalize", false, "keep the fragment content as written instead of normalizing line endings and trailing whitespace (overrides normalizeContent from the config)")
+	cmd.Flags().BoolVar(&allowCollision, "allow-collision", false, "allow several output formats to write the same file, the last one winning, instead of failing")
+	cmd.Flags().BoolVar(&stdinTags, "stdin-tags", false, "read the tags from stdin, one per line (# starts a comment); cannot be combined with --tags or --tags-file")
+	cmd.Flags().BoolVar(&stdinInput, "stdin", false, "add the content piped to stdin to the spliced fragments, separated by a blank line")
+	cmd.Flags().StringVar(&stdinPosition, "stdin-position", tui.StdinAfter, "where to add the stdin content: before or after the fragments")
+	cmd.Flags().BoolVar(&sourceComments, "source-comments", false, "write a comment naming the source file above each fragment in the output")
+	cmd.Flags().StringVar(&commentFormat, "source-comment-format", parser.DefaultSourceComment, "format of the --source-comments comment; {{.FragmentName}} is the filename and {{.FragmentPath}} the path of the fragment")
+	cmd.Flags().StringSliceVar(&tagPrefixes, "tag-prefix", []string{}, "also select every tag starting with this prefix, e.g. lang- (repeatable)")
+	cmd.Flags().BoolVar(&failEmptyPrefix, "fail-on-empty-prefix", false, "fail when no tag starts with a --tag-prefix instead of printing a warning")
+	cmd.Flags().BoolVar(&failMissingTags, "fail-on-missing-tags", false, "fail when any selected tag matches no fragment, listing each unknown tag (catches typos in CI)")
+	cmd.Flags().BoolVar(&noSeparator, "no-separator", false, "concatenate the fragments without any separator, overriding the separator from the config")
+	cmd.Flags().BoolVar(&noHeader, "no-header", false, "leave out the outputHeader from the config for this build")
+	cmd.Flags().BoolVar(&keepFrontmatter, "no-frontmatter-strip", false, "keep the frontmatter of each fragment, wrapped in --- lines, above its content in the output")
+	cmd.Flags().StringVar(&outputEncoding, "output-encoding", tui.OutputEncodingUTF8, "encoding of the output: utf8, or ascii to transliterate non-ASCII characters (é becomes e, unknown characters ?)")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "log the scanned, excluded and spliced fragments and the output files to stderr")
+	cmd.Flags().BoolVar(&skipPreprocess, "skip-preprocessors", false, "splice the fragments without running the preprocessors from the config")
+	cmd.Flags().IntVar(&maxFragments, "max-fragments", 0, "fail when more than this many fragments match the selected tags; 0 disables the limit (default: maxFragments from the config)")
+	cmd.MarkFlagsMutuallyExclusive("stdin-tags", "tags")
+	cmd.MarkFlagsMutuallyExclusive("stdin-tags", "tags-file")
+	cmd.MarkFlagsMutuallyExclusive("stdin-tags", "stdin")
 
 	// Add custom completion for tags flag
-	if err := buildCmd.RegisterFlagCompletionFunc("tags", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if err := cmd.RegisterFlagCompletionFunc("tags", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return getAvailableTags(), cobra.ShellCompDirectiveNoFileComp
 	}); err != nil {
 		fmt.Fprintf(os.Stderr, "Error registering tags completion: %v\n", err)
 	}
 
+	// Add custom completion for ignore-tag flag
+	if err := cmd.RegisterFlagCompletionFunc("ignore-tag", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return getAvailableTags(), cobra.ShellCompDirectiveNoFileComp
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error registering ignore-tag completion: %v\n", err)
+	}
+
+	// Add custom completion for group flag
+	if err := cmd.RegisterFlagCompletionFunc("group", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return getAvailableGroups(), cobra.ShellCompDirectiveNoFileComp
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error registering group completion: %v\n", err)
+	}
+
+	// Add custom completion for profile flag
+	if err := cmd.RegisterFlagCompletionFunc("profile", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return getAvailableProfiles(), cobra.ShellCompDirectiveNoFileComp
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error registering profile completion: %v\n", err)
+	}
+
+	// Add custom completion for sort flag
+	if err := cmd.RegisterFlagCompletionFunc("sort", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return parser.SortStrategies, cobra.ShellCompDirectiveNoFileComp
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error registering sort completion: %v\n", err)
+	}
+
 	// Add custom completion for output-format flag
-	if err := buildCmd.RegisterFlagCompletionFunc("output-format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		return []string{"opencode", "gemini", "custom"}, cobra.ShellCompDirectiveNoFileComp
+	if err := cmd.RegisterFlagCompletionFunc("output-format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return getAvailableOutputFormats(), cobra.ShellCompDirectiveNoFileComp
 	}); err != nil {
 		fmt.Fprintf(os.Stderr, "Error registering output-format completion: %v\n", err)
 	}
 
-	rootCmd.AddCommand(initCmd)
-	rootCmd.AddCommand(buildCmd)
-	rootCmd.AddCommand(completionCmd)
+	if err := cmd.RegisterFlagCompletionFunc("stdin-position", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{tui.StdinBefore, tui.StdinAfter}, cobra.ShellCompDirectiveNoFileComp
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error registering stdin-position completion: %v\n", err)
+	}
+
+	if err := cmd.RegisterFlagCompletionFunc("output-encoding", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{tui.OutputEncodingUTF8, tui.OutputEncodingASCII}, cobra.ShellCompDirectiveNoFileComp
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error registering output-encoding completion: %v\n", err)
+	}
+}
+
+// buildOptions assembles the build options from the command-line flags registered by
+// addBuildFlags.
+func buildOptions(cmd *cobra.Command) tui.BuildOptions {
+	opts := tui.BuildOptions{
+		ConfigFile:          configFile,
+		Tags:                tags,
+		NonInteractive:      nonInteractive,
+		OutputFormats:       outputFormats,
+		OutputFiles:         outputFiles,
+		Stdout:              stdout,
+		NoLocalOverride:     noLocalOverride,
+		Deduplicate:         deduplicate,
+		DryRun:              dryRun,
+		SkipIfUnchanged:     skipIfUnchanged,
+		TagsFile:            tagsFile,
+		Profile:             profile,
+		IgnoreTags:          ignoreTags,
+		OutputDir:           outputDir,
+		Groups:              groups,
+		OutputHTML:          outputHTML,
+		OutputTemplate:      outputTemplate,
+		SortStrategy:        sortStrategy,
+		TagPrefixes:         tagPrefixes,
+		FailOnEmptyPrefix:   failEmptyPrefix,
+		FailOnMissingTags:   failMissingTags,
+		MaxFragments:        maxFragments,
+		Remote:              remoteURL,
+		RemoteCacheTTL:      remoteCacheTTL,
+		NoLocal:             noLocal,
+		WalkUp:              walkUp,
+		ExtraFragments:      extraFragments,
+		Stdin:               stdinInput,
+		StdinPosition:       stdinPosition,
+		StdinTags:           stdinTags,
+		NoNormalize:         noNormalize,
+		NoSeparator:         noSeparator,
+		NoHeader:            noHeader,
+		IncludeFrontmatter:  keepFrontmatter,
+		OutputEncoding:      outputEncoding,
+		SourceComments:      sourceComments,
+		SourceCommentFormat: commentFormat,
+		SkipPreprocessors:   skipPreprocess,
+		AllowCollision:      allowCollision,
+		Verbose:             verbose,
+	}
+
+	// An explicit --max-fragments 0 disables the limit from the config as well
+	if cmd.Flags().Changed("max-fragments") && maxFragments == 0 {
+		opts.MaxFragments = -1
+	}
+
+	return opts
+}
+
+// getAvailableProfiles returns the names of the configured profiles for completion.
+func getAvailableProfiles() []string {
+	cfg, err := config.LoadMergedConfig(configFile)
+	if err != nil {
+		return []string{}
+	}
+
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+
+	return names
 }
 
-// getAvailableTags returns all available tags from fragments for completion.
+// getAvailableOutputFormats returns the names of the built-in and configured output formats
+// for completion, followed by custom for use with --output-file.
+func getAvailableOutputFormats() []string {
+	formats, err := tui.AvailableOutputFormats(configFile)
+	if err != nil {
+		return []string{}
+	}
+
+	names := make([]string, 0, len(formats)+1)
+	for _, format := range formats {
+		names = append(names, format.Name)
+	}
+
+	if !slices.Contains(names, "custom") {
+		names = append(names, "custom")
+	}
+
+	return names
+}
+
+// getAvailableGroups returns the names of the configured tag groups for completion.
+func getAvailableGroups() []string {
+	cfg, err := config.LoadMergedConfig(configFile)
+	if err != nil {
+		return []string{}
+	}
+
+	names := make([]string, 0, len(cfg.TagGroups))
+	for name := range cfg.TagGroups {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// getAvailableTags returns all available tags from fragments, configured aliases and tag groups for completion,
+// sorted by name. Fragment tags are described with the number of fragments using them, aliases and tag groups
+// with the tags they expand to.
 func getAvailableTags() []string {
-	cfg, err := config.LoadConfig(configFile)
+	cfg, err := config.LoadMergedConfig(configFile)
 	if err != nil {
 		return []string{}
 	}
 
-	fragmentsDir, err := config.GetFragmentsDir(cfg)
+	fragmentsDirs, err := config.GetFragmentsDirs(cfg)
 	if err != nil {
 		return []string{}
 	}
 
-	globalFragments, err := parser.ScanFragments(fragmentsDir)
+	scanOpts := parser.ScanOptions{NamespaceFromDir: cfg.NamespaceFromDir}
+
+	globalFragments, err := parser.ScanFragmentsDirs(fragmentsDirs, false, scanOpts)
 	if err != nil {
 		return []string{}
 	}
 
-	localFragments, err := parser.ScanLocalFragments()
+	localFragments, err := parser.ScanLocalFragmentsWithOptions(scanOpts)
 	if err != nil {
 		return []string{}
 	}
 
 	fragments := parser.CombineFragments(globalFragments, localFragments, false)
 
-	return parser.GetAllTags(fragments)
+	var availableTags []string
+	for _, info := range parser.GetAllTagInfo(fragments) {
+		description := "1 fragment"
+		if info.Count != 1 {
+			description = fmt.Sprintf("%d fragments", info.Count)
+		}
+
+		availableTags = append(availableTags, info.Tag+"\t"+description)
+	}
+
+	for alias, tags := range cfg.Aliases {
+		availableTags = append(availableTags, alias+"\talias for "+strings.Join(tags, ", "))
+	}
+
+	for group, tags := range cfg.TagGroups {
+		availableTags = append(availableTags, group+"\ttag group of "+strings.Join(tags, ", "))
+	}
+
+	slices.Sort(availableTags)
+
+	return availableTags
+}
+
+// exitError carries a specific process exit code out of a command.
+// A nil err exits with the code without printing an error message.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
+	}
+
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
 }
 
 func main() {
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			if exitErr.err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", exitErr.err)
+			}
+
+			os.Exit(exitErr.code)
+		}
+
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"github.com/Lewenhaupt/ctx/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage build profiles",
+	Long:  `Inspect the named tag and output format combinations stored in the config for use with build --profile.`,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the configured build profiles",
+	Long:  `List every profile in the config together with its tags and output formats.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.ProfileListOptions{
+			ConfigFile: configFile,
+		}
+
+		return tui.RunProfileList(&opts)
+	},
+}
+
+func init() {
+	profileCmd.AddCommand(profileListCmd)
+}
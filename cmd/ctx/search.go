@@ -0,0 +1,44 @@
+package main
+
+import (
+	"github.com/Lewenhaupt/ctx/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchTags          []string
+	searchJSON          bool
+	searchCaseSensitive bool
+	searchRegex         bool
+	searchFrontmatter   bool
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search fragment content",
+	Long: `Search the content of all global and local fragments for a keyword or phrase.
+Matching lines are printed with their line numbers, grouped by fragment.
+The search is case-insensitive unless --case-sensitive is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.SearchOptions{
+			ConfigFile:    configFile,
+			Query:         args[0],
+			Tags:          searchTags,
+			JSON:          searchJSON,
+			CaseSensitive: searchCaseSensitive,
+			Regex:         searchRegex,
+			Frontmatter:   searchFrontmatter,
+		}
+
+		return tui.RunSearch(&opts)
+	},
+}
+
+func init() {
+	searchCmd.Flags().StringSliceVar(&searchTags, "tags", []string{}, "only search fragments matching these tags (same syntax as build --tags)")
+	searchCmd.Flags().BoolVar(&searchJSON, "json", false, "output results as JSON")
+	searchCmd.Flags().BoolVar(&searchCaseSensitive, "case-sensitive", false, "match case exactly")
+	searchCmd.Flags().BoolVar(&searchRegex, "regex", false, "treat the query as a regular expression")
+	searchCmd.Flags().BoolVar(&searchFrontmatter, "frontmatter", false, "also search the frontmatter block")
+}
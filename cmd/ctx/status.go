@@ -0,0 +1,28 @@
+package main
+
+import (
+	"github.com/Lewenhaupt/ctx/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var statusJSON bool
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the effective configuration and fragment summary",
+	Long: `Show which config file is used, where global and local fragments are looked up
+(and whether those directories exist), how many fragments were found, all unique
+tags and the configured output formats. Missing directories are reported, not treated as errors.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.StatusOptions{
+			ConfigFile: configFile,
+			JSON:       statusJSON,
+		}
+
+		return tui.RunStatus(&opts)
+	},
+}
+
+func init() {
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "output the status as JSON")
+}
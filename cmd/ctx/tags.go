@@ -0,0 +1,140 @@
+package main
+
+import (
+	"github.com/Lewenhaupt/ctx/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tagsSort      string
+	tagsJSON      bool
+	tagsFragments bool
+	tagsDryRun    bool
+	tagsForce     bool
+	tagsNonInter  bool
+)
+
+var tagsCmd = &cobra.Command{
+	Use:   "tags",
+	Short: "List all tags used by fragments",
+	Long: `List every unique tag across the global and local fragments together with
+the number of fragments using it. Running 'ctx tags' is the same as 'ctx tags list'.`,
+	RunE: runTagsList,
+}
+
+var tagsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all tags with their usage counts",
+	Long: `List every unique tag across the global and local fragments together with
+the number of fragments using it, sorted alphabetically or by count.`,
+	RunE: runTagsList,
+}
+
+var tagsRenameCmd = &cobra.Command{
+	Use:   "rename <old> <new>",
+	Short: "Rename a tag in all fragment files",
+	Long: `Replace a tag in the ctx-tags frontmatter of every global and local fragment
+and write the updated files back. Each modified file is printed.`,
+	Args: cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return getAvailableTags(), cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.TagsRenameOptions{
+			ConfigFile: configFile,
+			OldTag:     args[0],
+			NewTag:     args[1],
+			DryRun:     tagsDryRun,
+		}
+
+		return tui.RunTagsRename(&opts)
+	},
+}
+
+var tagsAddCmd = &cobra.Command{
+	Use:   "add <name> <tags...>",
+	Short: "Add tags to a fragment",
+	Long: `Add one or more tags to the ctx-tags frontmatter of the fragment with the given
+name, skipping tags it already has.`,
+	Args: cobra.MinimumNArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return getAvailableTags(), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.TagsAddOptions{
+			ConfigFile: configFile,
+			Name:       args[0],
+			Tags:       args[1:],
+		}
+
+		return tui.RunTagsAdd(&opts)
+	},
+}
+
+var tagsRemoveCmd = &cobra.Command{
+	Use:   "remove <name> <tags...>",
+	Short: "Remove tags from a fragment",
+	Long: `Remove one or more tags from the ctx-tags frontmatter of the fragment with the given
+name. Removing the last tags asks for confirmation, since a fragment without tags is never
+included in a build; in non-interactive mode they are only removed with --force.`,
+	Args: cobra.MinimumNArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return getAvailableTags(), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.TagsRemoveOptions{
+			ConfigFile:     configFile,
+			Name:           args[0],
+			Tags:           args[1:],
+			Force:          tagsForce,
+			NonInteractive: tagsNonInter,
+		}
+
+		return tui.RunTagsRemove(&opts)
+	},
+}
+
+func runTagsList(cmd *cobra.Command, args []string) error {
+	opts := tui.TagsOptions{
+		ConfigFile:    configFile,
+		Sort:          tagsSort,
+		JSON:          tagsJSON,
+		ShowFragments: tagsFragments,
+	}
+
+	return tui.RunTags(&opts)
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{tagsCmd, tagsListCmd} {
+		cmd.Flags().StringVar(&tagsSort, "sort", tui.TagSortAlpha, "sort order: alpha or count")
+		cmd.Flags().BoolVar(&tagsJSON, "json", false, "output the tags as JSON")
+		cmd.Flags().BoolVar(&tagsFragments, "fragments", false, "also list the fragment filenames using each tag")
+
+		_ = cmd.RegisterFlagCompletionFunc("sort", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return []string{tui.TagSortAlpha, tui.TagSortCount}, cobra.ShellCompDirectiveNoFileComp
+		})
+	}
+
+	tagsRenameCmd.Flags().BoolVar(&tagsDryRun, "dry-run", false, "print the files that would change without writing them")
+
+	tagsRemoveCmd.Flags().BoolVar(&tagsForce, "force", false, "remove the last tags of a fragment without asking")
+	tagsRemoveCmd.Flags().BoolVar(&tagsNonInter, "non-interactive", false, "run in non-interactive mode")
+
+	tagsCmd.AddCommand(tagsListCmd)
+	tagsCmd.AddCommand(tagsRenameCmd)
+	tagsCmd.AddCommand(tagsAddCmd)
+	tagsCmd.AddCommand(tagsRemoveCmd)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/Lewenhaupt/ctx/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check all fragments for errors and deprecated frontmatter",
+	Long: `Parse every global and local fragment and report errors (for example invalid
+frontmatter or circular includes) and warnings (for example deprecated frontmatter keys).
+
+Exit codes:
+  0  no errors were found (warnings are allowed)
+  1  at least one fragment has an error`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.ValidateOptions{
+			ConfigFile: configFile,
+		}
+
+		valid, err := tui.RunValidate(&opts)
+		if err != nil {
+			return err
+		}
+
+		if !valid {
+			return &exitError{code: 1}
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"github.com/Lewenhaupt/ctx/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+// Build information, set at build time with
+// -ldflags "-X main.version=v1.2.3 -X main.commit=abc123 -X main.buildTime=2024-01-01T00:00:00Z".
+var (
+	version   = tui.DevVersion
+	commit    = tui.UnknownBuildInfo
+	buildTime = tui.UnknownBuildInfo
+)
+
+var (
+	versionJSON  bool
+	versionShort bool
+)
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the version, commit and build time of ctx",
+	Long: `Print the version, git commit and build timestamp of the ctx binary.
+Binaries built without version information report "dev" and "unknown".`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := tui.VersionOptions{
+			Info: tui.VersionInfo{
+				Version:   version,
+				Commit:    commit,
+				BuildTime: buildTime,
+			},
+			JSON:  versionJSON,
+			Short: versionShort,
+		}
+
+		return tui.RunVersion(&opts)
+	},
+}
+
+func init() {
+	versionCmd.Flags().BoolVar(&versionJSON, "json", false, "output the build information as JSON")
+	versionCmd.Flags().BoolVar(&versionShort, "short", false, "print only the version string")
+	versionCmd.MarkFlagsMutuallyExclusive("json", "short")
+}
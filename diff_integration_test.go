@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runDiffCommand(t *testing.T, setup *integrationTestSetup, args ...string) (string, int) {
+	cmd := exec.Command(setup.ctxBinary, append([]string{"diff"}, args...)...)
+	cmd.Dir = setup.tmpDir
+
+	output, err := cmd.CombinedOutput()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(output), exitErr.ExitCode()
+	}
+
+	if err != nil {
+		t.Fatalf("Failed to run ctx diff: %v", err)
+	}
+
+	return string(output), 0
+}
+
+func TestDiffIntegration(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	args := []string{"--non-interactive", "--tags", "typescript", "--output-format", "test", "--no-color"}
+
+	// Missing output file counts as a difference
+	output, code := runDiffCommand(t, setup, args...)
+	if code != 1 {
+		t.Fatalf("Expected exit code 1 for missing output file, got %d: %s", code, output)
+	}
+
+	if !strings.Contains(output, "+# Global TypeScript Fragment") {
+		t.Errorf("Expected diff to contain added fragment content, got: %s", output)
+	}
+
+	// Build the file, after which diff reports no changes
+	buildCmd := exec.Command(setup.ctxBinary, append([]string{"build"}, args[:len(args)-1]...)...)
+	buildCmd.Dir = setup.tmpDir
+
+	if out, err := buildCmd.CombinedOutput(); err != nil {
+		t.Fatalf("ctx build failed: %v\nOutput: %s", err, out)
+	}
+
+	output, code = runDiffCommand(t, setup, args...)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0 for up-to-date output, got %d: %s", code, output)
+	}
+
+	// Modify the file, after which diff reports the stale line
+	outputPath := filepath.Join(setup.tmpDir, "TEST.md")
+	if err := os.WriteFile(outputPath, []byte("stale content\n"), 0o600); err != nil {
+		t.Fatalf("Failed to modify output file: %v", err)
+	}
+
+	output, code = runDiffCommand(t, setup, args...)
+	if code != 1 {
+		t.Fatalf("Expected exit code 1 for stale output, got %d: %s", code, output)
+	}
+
+	if !strings.Contains(output, "-stale content") {
+		t.Errorf("Expected diff to contain removed stale line, got: %s", output)
+	}
+
+	// Errors exit with code 2
+	_, code = runDiffCommand(t, setup, "--non-interactive", "--tags", "nonexistent", "--output-format", "test")
+	if code != 2 {
+		t.Errorf("Expected exit code 2 on error, got %d", code)
+	}
+}
+
+func TestDiffIntegration_SharesBuildFlags(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	args := []string{"--non-interactive", "--tags", "typescript,rust", "--output-format", "test", "--no-separator", "--source-comments"}
+	runBuildCommand(t, setup, args...)
+
+	// The rendering flags of the build apply to the diff as well
+	if output, code := runDiffCommand(t, setup, append(args, "--no-color")...); code != 0 {
+		t.Errorf("Expected no diff with the flags of the build, got exit code %d: %s", code, output)
+	}
+
+	if output, code := runDiffCommand(t, setup, "--non-interactive", "--tags", "typescript,rust", "--output-format", "test", "--no-color"); code != 1 {
+		t.Errorf("Expected a diff without the rendering flags of the build, got exit code %d: %s", code, output)
+	}
+}
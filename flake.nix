@@ -8,6 +8,7 @@
 
   outputs =
     {
+      self,
       nixpkgs,
       flake-utils,
       ...
@@ -19,11 +20,12 @@
           system = system;
           config.allowUnfree = true;
         };
+        version = "0.1.0";
       in
       {
         packages.default = pkgs.buildGoModule {
           pname = "ctx";
-          version = "0.1.0";
+          inherit version;
 
           src = ./.;
 
@@ -31,6 +33,11 @@
 
           subPackages = [ "cmd/ctx" ];
 
+          ldflags = [
+            "-X main.version=v${version}"
+            "-X main.commit=${self.shortRev or "dirty"}"
+          ];
+
           meta = with pkgs.lib; {
             description = "A CLI tool for combining markdown fragments based on tags";
             homepage = "https://github.com/Lewenhaupt/ctx";
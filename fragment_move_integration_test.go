@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runFragmentMove(setup *integrationTestSetup, args ...string) (string, error) {
+	cmd := exec.Command(setup.ctxBinary, append([]string{"fragment", "move"}, args...)...)
+	cmd.Dir = setup.tmpDir
+
+	output, err := cmd.CombinedOutput()
+
+	return string(output), err
+}
+
+func TestFragmentMoveIntegration(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	globalDir := filepath.Join(setup.tmpDir, "global-config", ".ctx", "fragments")
+	localDir := filepath.Join(setup.tmpDir, ".ctx", "fragments")
+
+	// Local to global
+	output, err := runFragmentMove(setup, "local-only", "global")
+	if err != nil {
+		t.Fatalf("ctx fragment move to global failed: %v\nOutput: %s", err, output)
+	}
+
+	oldPath := filepath.Join(localDir, "local-only.md")
+	newPath := filepath.Join(globalDir, "local-only.md")
+
+	if !strings.Contains(output, oldPath) || !strings.Contains(output, newPath) {
+		t.Errorf("Expected output to print %s and %s, got: %s", oldPath, newPath, output)
+	}
+
+	if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed", oldPath)
+	}
+
+	data, err := os.ReadFile(newPath)
+	if err != nil || !strings.Contains(string(data), "# Local Only Fragment") {
+		t.Errorf("Expected %s to hold the moved fragment, got %q (%v)", newPath, data, err)
+	}
+
+	// Global to local refuses to overwrite the local common.md without --force
+	output, err = runFragmentMove(setup, "common", "local")
+	if err == nil {
+		t.Fatalf("Expected moving onto an existing local fragment to fail, got: %s", output)
+	}
+
+	if !strings.Contains(output, "--force") {
+		t.Errorf("Expected error to mention --force, got: %s", output)
+	}
+
+	if _, err := os.Stat(filepath.Join(globalDir, "common.md")); err != nil {
+		t.Errorf("Expected the global fragment to be left in place: %v", err)
+	}
+
+	output, err = runFragmentMove(setup, "common", "local", "--force")
+	if err != nil {
+		t.Fatalf("ctx fragment move to local failed: %v\nOutput: %s", err, output)
+	}
+
+	if _, err := os.Stat(filepath.Join(globalDir, "common.md")); !os.IsNotExist(err) {
+		t.Error("Expected the global common fragment to be removed")
+	}
+
+	data, err = os.ReadFile(filepath.Join(localDir, "common.md"))
+	if err != nil || !strings.Contains(string(data), "# Global Common Fragment") {
+		t.Errorf("Expected the local common fragment to be replaced, got %q (%v)", data, err)
+	}
+
+	// Moving a fragment that only exists in the other store fails
+	if output, err := runFragmentMove(setup, "typescript", "global"); err == nil {
+		t.Errorf("Expected moving a global fragment to global to fail, got: %s", output)
+	}
+
+	if output, err := runFragmentMove(setup, "common", "elsewhere"); err == nil {
+		t.Errorf("Expected an invalid target to fail, got: %s", output)
+	}
+}
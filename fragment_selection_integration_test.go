@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runFragmentFromSelection(setup *integrationTestSetup, stdin string, args ...string) (string, error) {
+	cmd := exec.Command(setup.ctxBinary, append([]string{"fragment", "create-from-selection", "--non-interactive"}, args...)...)
+	cmd.Dir = setup.tmpDir
+	cmd.Stdin = strings.NewReader(stdin)
+
+	output, err := cmd.CombinedOutput()
+
+	return string(output), err
+}
+
+func TestFragmentFromSelectionIntegration(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	output, err := runFragmentFromSelection(setup, "## API Usage\n\nCall the client with a context.\n\n\n",
+		"--name", "api-usage", "--tags", "api,backend", "--description", "Copied from the API docs", "--local")
+	if err != nil {
+		t.Fatalf("ctx fragment create-from-selection failed: %v\nOutput: %s", err, output)
+	}
+
+	path := filepath.Join(setup.tmpDir, ".ctx", "fragments", "api-usage.md")
+	if !strings.Contains(output, path) {
+		t.Errorf("Expected output to print %s, got: %s", path, output)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read created fragment: %v", err)
+	}
+
+	expected := "---\nctx-tags: api, backend\nctx-description: Copied from the API docs\n---\n\n## API Usage\n\nCall the client with a context.\n"
+	if string(content) != expected {
+		t.Errorf("Expected fragment content %q, got %q", expected, content)
+	}
+
+	// The created fragment is picked up by builds
+	buildOutput := runBuildCommand(t, setup, "--non-interactive", "--tags", "api", "--stdout")
+	if !strings.Contains(buildOutput, "Call the client with a context.") {
+		t.Errorf("Expected the new fragment in the build output, got: %s", buildOutput)
+	}
+}
+
+func TestFragmentFromSelectionIntegration_EmptyStdin(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	output, err := runFragmentFromSelection(setup, "\n", "--name", "empty", "--tags", "api", "--local")
+	if err == nil {
+		t.Fatalf("Expected empty stdin to fail, output: %s", output)
+	}
+
+	if !strings.Contains(output, "the selection is empty") {
+		t.Errorf("Expected empty selection error, got: %s", output)
+	}
+
+	if _, err := os.Stat(filepath.Join(setup.tmpDir, ".ctx", "fragments", "empty.md")); !os.IsNotExist(err) {
+		t.Error("Expected no fragment to be created from an empty selection")
+	}
+}
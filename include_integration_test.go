@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeLocalFragment(t *testing.T, setup *integrationTestSetup, name, content string) {
+	path := filepath.Join(setup.tmpDir, ".ctx", "fragments", name)
+
+	err := os.MkdirAll(filepath.Dir(path), 0o750)
+	if err != nil {
+		t.Fatalf("Failed to create fragment directory: %v", err)
+	}
+
+	err = os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatalf("Failed to create fragment %s: %v", name, err)
+	}
+}
+
+func TestIncludeIntegration_NestedIncludes(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	writeLocalFragment(t, setup, "api.md", "---\nctx-tags: api\nctx-include: partials/boilerplate.md\n---\n# API Fragment")
+	writeLocalFragment(t, setup, "partials/boilerplate.md", "---\nctx-include: license.md\n---\nShared Boilerplate")
+	writeLocalFragment(t, setup, "partials/license.md", "Shared License")
+
+	output := runBuildCommand(t, setup, "--non-interactive", "--stdout", "--tags", "api")
+
+	license := strings.Index(output, "Shared License")
+	boilerplate := strings.Index(output, "Shared Boilerplate")
+	api := strings.Index(output, "# API Fragment")
+
+	if license == -1 || boilerplate == -1 || api == -1 {
+		t.Fatalf("Expected output to contain all included content, got: %s", output)
+	}
+
+	if license >= boilerplate || boilerplate >= api {
+		t.Errorf("Expected included content to be prepended in include order, got: %s", output)
+	}
+}
+
+func TestIncludeIntegration_CircularInclude(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	writeLocalFragment(t, setup, "first.md", "---\nctx-tags: loop\nctx-include: second.md\n---\nFirst")
+	writeLocalFragment(t, setup, "second.md", "---\nctx-include: first.md\n---\nSecond")
+
+	cmd := exec.Command(setup.ctxBinary, "build", "--non-interactive", "--stdout", "--tags", "loop")
+	cmd.Dir = setup.tmpDir
+
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("Expected ctx build to fail on circular include, got: %s", output)
+	}
+
+	if !strings.Contains(string(output), "circular include") || !strings.Contains(string(output), "first.md -> ") {
+		t.Errorf("Expected error to describe the include chain, got: %s", output)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInitIntegration_NonInteractive(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	configPath := filepath.Join(setup.tmpDir, "init", "config.json")
+	fragmentsDir := filepath.Join(setup.tmpDir, "init-fragments")
+	args := []string{
+		"init", "--non-interactive",
+		"--config-file", configPath,
+		"--fragments-dir", fragmentsDir,
+		"--output-formats", "claude=CLAUDE.md,custom=out/CUSTOM.md",
+		"--default-tags", "go,rust",
+		"--create-sample",
+	}
+
+	cmd := exec.Command(setup.ctxBinary, args...)
+	cmd.Dir = setup.tmpDir
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("ctx init failed: %v\nOutput: %s", err, output)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read written config: %v", err)
+	}
+
+	var written struct {
+		DefaultTags   []string                     `json:"defaultTags"`
+		FragmentsDir  string                       `json:"fragmentsDir"`
+		OutputFormats map[string]map[string]string `json:"outputFormats"`
+	}
+
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("Failed to parse written config: %v\n%s", err, data)
+	}
+
+	if !reflect.DeepEqual(written.DefaultTags, []string{"go", "rust"}) {
+		t.Errorf("Expected default tags [go rust], got %v", written.DefaultTags)
+	}
+
+	if written.FragmentsDir != fragmentsDir {
+		t.Errorf("Expected fragments dir %s, got %s", fragmentsDir, written.FragmentsDir)
+	}
+
+	expectedFiles := map[string]string{
+		"opencode": "AGENTS.md",
+		"gemini":   "GEMINI.md",
+		"claude":   "CLAUDE.md",
+		"custom":   "out/CUSTOM.md",
+	}
+
+	for format, file := range expectedFiles {
+		if written.OutputFormats[format]["file"] != file {
+			t.Errorf("Expected output format %s to write %s, got %v", format, file, written.OutputFormats[format])
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(fragmentsDir, "hello-world.md")); err != nil {
+		t.Errorf("Expected sample fragment to be created: %v", err)
+	}
+
+	// A second run must not overwrite the config without --force
+	cmd = exec.Command(setup.ctxBinary, args...)
+	cmd.Dir = setup.tmpDir
+
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("Expected ctx init to fail for an existing config, got: %s", output)
+	}
+
+	if !strings.Contains(string(output), "--force") {
+		t.Errorf("Expected error to mention --force, got: %s", output)
+	}
+
+	cmd = exec.Command(setup.ctxBinary, append(args, "--force")...)
+	cmd.Dir = setup.tmpDir
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("ctx init --force failed: %v\nOutput: %s", err, output)
+	}
+
+	backups, err := filepath.Glob(configPath + ".bak.*")
+	if err != nil || len(backups) != 1 {
+		t.Errorf("Expected one config backup, got %v (%v)", backups, err)
+	}
+}
+
+func TestInitIntegration_NonInteractiveInvalidOutputFormat(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	configPath := filepath.Join(setup.tmpDir, "init", "config.json")
+
+	cmd := exec.Command(setup.ctxBinary, "init", "--non-interactive", "--config-file", configPath, "--output-formats", "claude=")
+	cmd.Dir = setup.tmpDir
+
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("Expected ctx init to fail for an empty output file, got: %s", output)
+	}
+
+	if !strings.Contains(string(output), "expected format=file") {
+		t.Errorf("Expected clear error for the output format, got: %s", output)
+	}
+
+	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
+		t.Error("Expected no config to be written")
+	}
+}
+
+func TestInitIntegration_AddGitignore(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	gitignorePath := filepath.Join(setup.tmpDir, ".gitignore")
+	if err := os.WriteFile(gitignorePath, []byte("node_modules/\nAGENTS.md\n"), 0o600); err != nil {
+		t.Fatalf("Failed to create .gitignore: %v", err)
+	}
+
+	configPath := filepath.Join(setup.tmpDir, "init", "config.json")
+	cmd := exec.Command(setup.ctxBinary, "init", "--non-interactive", "--config-file", configPath,
+		"--output-formats", "claude=CLAUDE.md", "--add-gitignore")
+	cmd.Dir = setup.tmpDir
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("ctx init failed: %v\nOutput: %s", err, output)
+	}
+
+	content, err := os.ReadFile(gitignorePath)
+	if err != nil {
+		t.Fatalf("Failed to read .gitignore: %v", err)
+	}
+
+	expected := "node_modules/\nAGENTS.md\nCLAUDE.md\nGEMINI.md\ncontext.yaml\nctx-output.json\n"
+	if string(content) != expected {
+		t.Errorf("Expected .gitignore %q, got %q", expected, content)
+	}
+
+	if !strings.Contains(string(output), "Added to .gitignore: CLAUDE.md, GEMINI.md") {
+		t.Errorf("Expected the added entries to be printed, got: %s", output)
+	}
+}
@@ -9,19 +9,72 @@ import (
 
 // Config represents the application configuration.
 type Config struct {
-	DefaultTags    []string               `json:"defaultTags"`
-	OutputFormats  map[string]string      `json:"outputFormats"`
-	FragmentsDir   string                 `json:"fragmentsDir,omitempty"`
-	CustomSettings map[string]interface{} `json:"customSettings,omitempty"`
+	Version        int                           `json:"version"`
+	DefaultTags    []string                      `json:"defaultTags"`
+	OutputFormats  map[string]OutputFormatConfig `json:"outputFormats"`
+	FragmentsDir   string                        `json:"fragmentsDir,omitempty"`
+	FragmentsDirs  []string                      `json:"fragmentsDirs,omitempty"`
+	CustomSettings map[string]interface{}        `json:"customSettings,omitempty"`
+	Aliases        map[string][]string           `json:"aliases,omitempty"`
+	// TagGroups maps a group name to the tags it selects; groups may reference other groups.
+	TagGroups map[string][]string `json:"tagGroups,omitempty"`
+	// Separator is written between fragments; nil uses the default blank line.
+	Separator *string                  `json:"separator,omitempty"`
+	Profiles  map[string]ProfileConfig `json:"profiles,omitempty"`
+	// NamespaceFromDir adds the subdirectories of a fragment as implicit tags.
+	NamespaceFromDir bool `json:"namespaceFromDir,omitempty"`
+	// PreBuildHook and PostBuildHook are shell commands run before and after a build.
+	PreBuildHook  string `json:"preBuildHook,omitempty"`
+	PostBuildHook string `json:"postBuildHook,omitempty"`
+	// Preprocessors are shell commands each fragment's content is piped through, in order,
+	// before splicing.
+	Preprocessors []string `json:"preprocessors,omitempty"`
+	// TrustLocalConfig lets the project configs merged by LoadMergedConfig set the build
+	// hooks and preprocessors; it is only read from the global config.
+	TrustLocalConfig bool `json:"trustLocalConfig,omitempty"`
+	// OutputDir is the directory relative output files are placed in when --output-dir is not given.
+	OutputDir string `json:"outputDir,omitempty"`
+	// OutputPermissions is the octal mode, e.g. "0644", output files are written with unless
+	// their output format sets permissions; empty keeps 0600.
+	OutputPermissions string `json:"outputPermissions,omitempty"`
+	// OutputHeader is written on its own line at the top of every output file, e.g. a
+	// DefaultOutputHeader comment warning against manual edits; empty writes no header.
+	OutputHeader string `json:"outputHeader,omitempty"`
+	// OverwritePolicy decides what build does with existing output files: ask, always or
+	// never; empty means ask.
+	OverwritePolicy string `json:"overwritePolicy,omitempty"`
+	// MaxFragments fails builds including more fragments than this; zero means no limit.
+	MaxFragments int `json:"maxFragments,omitempty"`
+	// NormalizeContent set to false keeps fragment content as written instead of normalizing
+	// line endings and trailing whitespace (see parser.NormalizeContent); nil means true.
+	NormalizeContent *bool `json:"normalizeContent,omitempty"`
+	// LintRules configures the rules checked by fragment lint, keyed by rule name.
+	LintRules map[string]interface{} `json:"lintRules,omitempty"`
+	// WalkUp set to false in a project's .ctx config stops build --walk-up from searching
+	// the directories above it for local fragments.
+	WalkUp *bool `json:"walkUp,omitempty"`
 }
 
+// ProfileConfig is a named combination of tags and output formats used by build --profile.
+type ProfileConfig struct {
+	Tags          []string `json:"tags,omitempty"`
+	OutputFormats []string `json:"outputFormats,omitempty"`
+}
+
+// DefaultOutputHeader is the outputHeader ctx init adds when asked to warn against manual
+// edits of the output files.
+const DefaultOutputHeader = "<!-- This file was generated by ctx. Do not edit manually. Run 'ctx build' to regenerate. -->"
+
 // DefaultConfig returns a default configuration.
 func DefaultConfig() *Config {
 	return &Config{
+		Version:     CurrentVersion,
 		DefaultTags: []string{},
-		OutputFormats: map[string]string{
-			"opencode": "AGENTS.md",
-			"gemini":   "GEMINI.md",
+		OutputFormats: map[string]OutputFormatConfig{
+			"opencode":         {Filename: "AGENTS.md"},
+			"gemini":           {Filename: "GEMINI.md"},
+			YAMLMultiDocFormat: {Filename: "context.yaml"},
+			JSONFormat:         {Filename: "ctx-output.json"},
 		},
 		FragmentsDir:   "",
 		CustomSettings: make(map[string]interface{}),
@@ -43,10 +96,47 @@ func GetConfigDir() (string, error) {
 	return filepath.Join(configDir, ".ctx"), nil
 }
 
-// GetFragmentsDir returns the fragments directory path.
+// GetFragmentsDir returns the primary fragments directory path, where new fragments are created.
+// This is the first directory returned by GetFragmentsDirs.
 func GetFragmentsDir(config *Config) (string, error) {
+	dirs, err := GetFragmentsDirs(config)
+	if err != nil {
+		return "", err
+	}
+
+	return dirs[0], nil
+}
+
+// GetFragmentsDirs returns all global fragments directory paths in scan order:
+// FragmentsDir (if set) followed by FragmentsDirs. When neither is configured the
+// default directory under the config directory is returned.
+func GetFragmentsDirs(config *Config) ([]string, error) {
+	var dirs []string
+
 	if config.FragmentsDir != "" {
-		return config.FragmentsDir, nil
+		dirs = append(dirs, config.FragmentsDir)
+	}
+
+	dirs = append(dirs, config.FragmentsDirs...)
+
+	if len(dirs) > 0 {
+		return dirs, nil
+	}
+
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{filepath.Join(configDir, "fragments")}, nil
+}
+
+// ResolveConfigPath returns configPath, or the default config file path when it is empty.
+// The default is the first of config.json, config.yaml, config.yml and config.toml that
+// exists in the config directory, falling back to config.json.
+func ResolveConfigPath(configPath string) (string, error) {
+	if configPath != "" {
+		return configPath, nil
 	}
 
 	configDir, err := GetConfigDir()
@@ -54,18 +144,19 @@ func GetFragmentsDir(config *Config) (string, error) {
 		return "", err
 	}
 
-	return filepath.Join(configDir, "fragments"), nil
+	return findConfigFile(configDir), nil
 }
 
 // LoadConfig loads configuration from the specified file path.
+// Files with a .yaml or .yml extension are parsed as YAML, files with a .toml extension
+// as TOML and all others as JSON.
+// Configs written with an older schema version are migrated transparently. CTX_
+// environment variables are not applied, so the result can be written back to the file;
+// LoadMergedConfig applies them.
 func LoadConfig(configPath string) (*Config, error) {
-	if configPath == "" {
-		configDir, err := GetConfigDir()
-		if err != nil {
-			return nil, err
-		}
-
-		configPath = filepath.Join(configDir, "config.json")
+	configPath, err := ResolveConfigPath(configPath)
+	if err != nil {
+		return nil, err
 	}
 
 	// If config file doesn't exist, return default config
@@ -78,23 +169,248 @@ func LoadConfig(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
+	switch FormatForPath(configPath) {
+	case FormatYAML:
+		data, err = yamlToJSON(data)
+	case FormatTOML:
+		data, err = tomlToJSON(data)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	config, err := decodeConfig(data)
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
-	return &config, nil
+	return config, nil
 }
 
-// SaveConfig saves the configuration to the specified file path.
-func SaveConfig(config *Config, configPath string) error {
-	if configPath == "" {
-		configDir, err := GetConfigDir()
+// LocalConfigPath returns the path of the local config file in the .ctx directory of the
+// current working directory, searched like the global config file.
+func LocalConfigPath() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	return DirConfigPath(cwd), nil
+}
+
+// DirConfigPath returns the path of the config file in the .ctx directory of dir,
+// searched like the global config file.
+func DirConfigPath(dir string) string {
+	return findConfigFile(filepath.Join(dir, ".ctx"))
+}
+
+// ProjectConfigFileName is the name of the project config file looked up in the current
+// working directory, meant to be tracked in version control next to the code.
+const ProjectConfigFileName = "ctx.json"
+
+// ProjectConfigPath returns the path of the ctx.json project config file in the current
+// working directory.
+func ProjectConfigPath() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	return filepath.Join(cwd, ProjectConfigFileName), nil
+}
+
+// OverrideConfigPaths returns the existing config files that LoadMergedConfig merges over
+// the global config at globalPath, lowest priority first: the local .ctx config, then the
+// ctx.json project config. A file that is the global config itself is skipped.
+func OverrideConfigPaths(globalPath string) ([]string, error) {
+	localPath, err := LocalConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	projectPath, err := ProjectConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+
+	for _, path := range []string{localPath, projectPath} {
+		if path == globalPath {
+			continue
+		}
+
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+
+	return paths, nil
+}
+
+// LoadGlobalConfig loads the config at configPath, or the global config when it is empty,
+// with the CTX_ environment variables applied but without merging any project config.
+func LoadGlobalConfig(configPath string) (*Config, error) {
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return ApplyEnvOverrides(cfg), nil
+}
+
+// LoadMergedConfig loads the effective configuration. When configPath is empty the
+// global config is loaded and the local .ctx/config.json and the ctx.json project config
+// in the current working directory are merged over it if present, in that order, so
+// settings are taken from --config-file, then ctx.json, then .ctx/config.json, then the
+// global config. An explicit configPath is loaded as is.
+func LoadMergedConfig(configPath string) (*Config, error) {
+	if configPath != "" {
+		return LoadGlobalConfig(configPath)
+	}
+
+	base, err := LoadConfig("")
+	if err != nil {
+		return nil, err
+	}
+
+	globalPath, err := ResolveConfigPath("")
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := OverrideConfigPaths(globalPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		override, err := LoadConfig(path)
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("failed to load local config %s: %w", path, err)
 		}
 
-		configPath = filepath.Join(configDir, "config.json")
+		dropUntrustedCommands(base, override)
+		base = MergeConfigs(base, override)
+	}
+
+	return ApplyEnvOverrides(base), nil
+}
+
+// dropUntrustedCommands clears the shell commands set by a project config that is merged
+// over base unless the global config sets trustLocalConfig, so that building in a cloned
+// repository does not run commands from its config.
+func dropUntrustedCommands(base, override *Config) {
+	if base.TrustLocalConfig {
+		return
+	}
+
+	override.PreBuildHook = ""
+	override.PostBuildHook = ""
+	override.Preprocessors = nil
+}
+
+// MergeConfigs returns a copy of base with every field that is set in override replaced.
+// Fields are replaced as a whole, so an override's outputFormats replaces all of the
+// base's output formats rather than being merged key by key.
+func MergeConfigs(base, override *Config) *Config {
+	merged := *base
+
+	if override.DefaultTags != nil {
+		merged.DefaultTags = override.DefaultTags
+	}
+
+	if override.OutputFormats != nil {
+		merged.OutputFormats = override.OutputFormats
+	}
+
+	if override.FragmentsDir != "" {
+		merged.FragmentsDir = override.FragmentsDir
+	}
+
+	if override.FragmentsDirs != nil {
+		merged.FragmentsDirs = override.FragmentsDirs
+	}
+
+	if override.OutputDir != "" {
+		merged.OutputDir = override.OutputDir
+	}
+
+	if override.OutputPermissions != "" {
+		merged.OutputPermissions = override.OutputPermissions
+	}
+
+	if override.OutputHeader != "" {
+		merged.OutputHeader = override.OutputHeader
+	}
+
+	if override.OverwritePolicy != "" {
+		merged.OverwritePolicy = override.OverwritePolicy
+	}
+
+	if override.MaxFragments != 0 {
+		merged.MaxFragments = override.MaxFragments
+	}
+
+	if override.NormalizeContent != nil {
+		merged.NormalizeContent = override.NormalizeContent
+	}
+
+	if override.CustomSettings != nil {
+		merged.CustomSettings = override.CustomSettings
+	}
+
+	if override.Aliases != nil {
+		merged.Aliases = override.Aliases
+	}
+
+	if override.TagGroups != nil {
+		merged.TagGroups = override.TagGroups
+	}
+
+	if override.Separator != nil {
+		merged.Separator = override.Separator
+	}
+
+	if override.Profiles != nil {
+		merged.Profiles = override.Profiles
+	}
+
+	if override.PreBuildHook != "" {
+		merged.PreBuildHook = override.PreBuildHook
+	}
+
+	if override.PostBuildHook != "" {
+		merged.PostBuildHook = override.PostBuildHook
+	}
+
+	if override.Preprocessors != nil {
+		merged.Preprocessors = override.Preprocessors
+	}
+
+	if override.NamespaceFromDir {
+		merged.NamespaceFromDir = true
+	}
+
+	if override.LintRules != nil {
+		merged.LintRules = override.LintRules
+	}
+
+	if override.WalkUp != nil {
+		merged.WalkUp = override.WalkUp
+	}
+
+	return &merged
+}
+
+// SaveConfig saves the configuration to the specified file path.
+// The file is written as YAML when the path has a .yaml or .yml extension, as TOML when it
+// has a .toml extension and otherwise as JSON.
+func SaveConfig(config *Config, configPath string) error {
+	configPath, err := ResolveConfigPath(configPath)
+	if err != nil {
+		return err
 	}
 
 	// Ensure config directory exists
@@ -102,9 +418,9 @@ func SaveConfig(config *Config, configPath string) error {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	data, err := json.MarshalIndent(config, "", "  ")
+	data, err := MarshalConfigAs(config, FormatForPath(configPath))
 	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
+		return err
 	}
 
 	if err := os.WriteFile(configPath, data, 0o600); err != nil {
@@ -113,3 +429,13 @@ func SaveConfig(config *Config, configPath string) error {
 
 	return nil
 }
+
+// MarshalConfig returns the JSON document SaveConfig writes for the configuration.
+func MarshalConfig(config *Config) ([]byte, error) {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	return data, nil
+}
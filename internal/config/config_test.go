@@ -15,9 +15,11 @@ func TestDefaultConfig(t *testing.T) {
 		t.Errorf("Expected empty default tags, got %v", config.DefaultTags)
 	}
 
-	expectedFormats := map[string]string{
-		"opencode": "AGENTS.md",
-		"gemini":   "GEMINI.md",
+	expectedFormats := map[string]OutputFormatConfig{
+		"opencode":         {Filename: "AGENTS.md"},
+		"gemini":           {Filename: "GEMINI.md"},
+		YAMLMultiDocFormat: {Filename: "context.yaml"},
+		JSONFormat:         {Filename: "ctx-output.json"},
 	}
 
 	if !reflect.DeepEqual(config.OutputFormats, expectedFormats) {
@@ -42,8 +44,8 @@ func TestLoadConfig(t *testing.T) {
 			}`,
 			expectedConfig: &Config{
 				DefaultTags: []string{"typescript", "rust"},
-				OutputFormats: map[string]string{
-					"custom": "CUSTOM.md",
+				OutputFormats: map[string]OutputFormatConfig{
+					"custom": {Filename: "CUSTOM.md"},
 				},
 				CustomSettings: make(map[string]interface{}),
 			},
@@ -103,8 +105,8 @@ func TestSaveConfig(t *testing.T) {
 
 	config := &Config{
 		DefaultTags: []string{"test"},
-		OutputFormats: map[string]string{
-			"test": "TEST.md",
+		OutputFormats: map[string]OutputFormatConfig{
+			"test": {Filename: "TEST.md"},
 		},
 		CustomSettings: make(map[string]interface{}),
 	}
@@ -132,3 +134,258 @@ func TestSaveConfig(t *testing.T) {
 			config.DefaultTags, savedConfig.DefaultTags)
 	}
 }
+
+func TestLoadConfigWithAliases(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+
+	configContent := `{
+		"aliases": {
+			"ts": ["react-with-typescript-strict", "typescript"]
+		}
+	}`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0o600); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string][]string{
+		"ts": {"react-with-typescript-strict", "typescript"},
+	}
+
+	if !reflect.DeepEqual(config.Aliases, expected) {
+		t.Errorf("Expected aliases %v, got %v", expected, config.Aliases)
+	}
+}
+
+func TestLoadConfigSeparator(t *testing.T) {
+	tests := []struct {
+		name          string
+		configContent string
+		expected      *string
+	}{
+		{name: "omitted separator", configContent: `{}`, expected: nil},
+		{name: "empty separator", configContent: `{"separator": ""}`, expected: new(string)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(configPath, []byte(tt.configContent), 0o600); err != nil {
+				t.Fatalf("Failed to create test config file: %v", err)
+			}
+
+			config, err := LoadConfig(configPath)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(config.Separator, tt.expected) {
+				t.Errorf("Expected separator %v, got %v", tt.expected, config.Separator)
+			}
+		})
+	}
+}
+
+func TestGetFragmentsDirs(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *Config
+		expected []string
+	}{
+		{
+			name:     "single fragments dir",
+			config:   &Config{FragmentsDir: "/a"},
+			expected: []string{"/a"},
+		},
+		{
+			name:     "multiple fragments dirs",
+			config:   &Config{FragmentsDirs: []string{"/b", "/c"}},
+			expected: []string{"/b", "/c"},
+		},
+		{
+			name:     "fragments dir first",
+			config:   &Config{FragmentsDir: "/a", FragmentsDirs: []string{"/b", "/c"}},
+			expected: []string{"/a", "/b", "/c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dirs, err := GetFragmentsDirs(tt.config)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(dirs, tt.expected) {
+				t.Errorf("Expected dirs %v, got %v", tt.expected, dirs)
+			}
+
+			primary, err := GetFragmentsDir(tt.config)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if primary != tt.expected[0] {
+				t.Errorf("Expected primary dir %q, got %q", tt.expected[0], primary)
+			}
+		})
+	}
+}
+
+func TestMergeConfigs(t *testing.T) {
+	base := &Config{
+		DefaultTags:   []string{"global"},
+		OutputFormats: map[string]OutputFormatConfig{"opencode": {Filename: "AGENTS.md"}},
+		FragmentsDir:  "/global/fragments",
+	}
+
+	tests := []struct {
+		name     string
+		override *Config
+		expected *Config
+	}{
+		{
+			name:     "empty override keeps base",
+			override: &Config{},
+			expected: base,
+		},
+		{
+			name:     "override default tags only",
+			override: &Config{DefaultTags: []string{"local"}},
+			expected: &Config{
+				DefaultTags:   []string{"local"},
+				OutputFormats: map[string]OutputFormatConfig{"opencode": {Filename: "AGENTS.md"}},
+				FragmentsDir:  "/global/fragments",
+			},
+		},
+		{
+			name:     "override output formats only",
+			override: &Config{OutputFormats: map[string]OutputFormatConfig{"gemini": {Filename: "GEMINI.md"}}},
+			expected: &Config{
+				DefaultTags:   []string{"global"},
+				OutputFormats: map[string]OutputFormatConfig{"gemini": {Filename: "GEMINI.md"}},
+				FragmentsDir:  "/global/fragments",
+			},
+		},
+		{
+			name:     "override fragments dir only",
+			override: &Config{FragmentsDir: "/local/fragments"},
+			expected: &Config{
+				DefaultTags:   []string{"global"},
+				OutputFormats: map[string]OutputFormatConfig{"opencode": {Filename: "AGENTS.md"}},
+				FragmentsDir:  "/local/fragments",
+			},
+		},
+		{
+			name:     "explicit empty default tags",
+			override: &Config{DefaultTags: []string{}},
+			expected: &Config{
+				DefaultTags:   []string{},
+				OutputFormats: map[string]OutputFormatConfig{"opencode": {Filename: "AGENTS.md"}},
+				FragmentsDir:  "/global/fragments",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := MergeConfigs(base, tt.override)
+			if !reflect.DeepEqual(merged, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, merged)
+			}
+		})
+	}
+
+	if !reflect.DeepEqual(base.DefaultTags, []string{"global"}) {
+		t.Error("Expected MergeConfigs not to modify base")
+	}
+}
+
+func TestLoadMergedConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	oldXDG := os.Getenv("XDG_CONFIG_HOME")
+	defer func() { _ = os.Setenv("XDG_CONFIG_HOME", oldXDG) }()
+
+	_ = os.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "xdg"))
+
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+
+	defer func() { _ = os.Chdir(originalWd) }()
+
+	projectDir := filepath.Join(tmpDir, "project")
+
+	files := map[string]string{
+		filepath.Join(tmpDir, "xdg", ".ctx", "config.json"): `{"defaultTags": ["global"], "outputFormats": {"opencode": "AGENTS.md"}}`,
+		filepath.Join(projectDir, ".ctx", "config.json"):    `{"defaultTags": ["local"]}`,
+		filepath.Join(tmpDir, "explicit.json"):              `{"defaultTags": ["explicit"]}`,
+	}
+
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create config file: %v", err)
+		}
+	}
+
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+
+	cfg, err := LoadMergedConfig("")
+	if err != nil {
+		t.Fatalf("LoadMergedConfig failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg.DefaultTags, []string{"local"}) {
+		t.Errorf("Expected local default tags, got %v", cfg.DefaultTags)
+	}
+
+	if !reflect.DeepEqual(cfg.OutputFormats, map[string]OutputFormatConfig{"opencode": {Filename: "AGENTS.md"}}) {
+		t.Errorf("Expected global output formats, got %v", cfg.OutputFormats)
+	}
+
+	// ctx.json in the working directory takes precedence over .ctx/config.json
+	projectConfig := `{"defaultTags": ["project"], "separator": "\n---\n"}`
+	if err := os.WriteFile(filepath.Join(projectDir, ProjectConfigFileName), []byte(projectConfig), 0o600); err != nil {
+		t.Fatalf("Failed to create project config file: %v", err)
+	}
+
+	cfg, err = LoadMergedConfig("")
+	if err != nil {
+		t.Fatalf("LoadMergedConfig failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg.DefaultTags, []string{"project"}) {
+		t.Errorf("Expected project default tags, got %v", cfg.DefaultTags)
+	}
+
+	if cfg.Separator == nil || *cfg.Separator != "\n---\n" {
+		t.Errorf("Expected the project separator, got %v", cfg.Separator)
+	}
+
+	if !reflect.DeepEqual(cfg.OutputFormats, map[string]OutputFormatConfig{"opencode": {Filename: "AGENTS.md"}}) {
+		t.Errorf("Expected global output formats, got %v", cfg.OutputFormats)
+	}
+
+	cfg, err = LoadMergedConfig(filepath.Join(tmpDir, "explicit.json"))
+	if err != nil {
+		t.Fatalf("LoadMergedConfig failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg.DefaultTags, []string{"explicit"}) {
+		t.Errorf("Expected explicit config to be used as is, got %v", cfg.DefaultTags)
+	}
+}
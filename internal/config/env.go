@@ -0,0 +1,70 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// Environment variables that override the configuration.
+const (
+	// EnvConfigFile is the config file path used when --config-file is not given.
+	EnvConfigFile = "CTX_CONFIG_FILE"
+	// EnvFragmentsDir overrides FragmentsDir.
+	EnvFragmentsDir = "CTX_FRAGMENTS_DIR"
+	// EnvDefaultTags overrides DefaultTags with a comma-separated list of tags.
+	EnvDefaultTags = "CTX_DEFAULT_TAGS"
+	// EnvOutputDir overrides OutputDir.
+	EnvOutputDir = "CTX_OUTPUT_DIR"
+)
+
+// envFields maps the JSON names of the config fields that can be overridden to their
+// environment variable.
+var envFields = map[string]string{
+	"fragmentsDir": EnvFragmentsDir,
+	"defaultTags":  EnvDefaultTags,
+	"outputDir":    EnvOutputDir,
+}
+
+// ApplyEnvOverrides returns a copy of cfg with the fields whose CTX_ environment
+// variable is set to a non-empty value replaced by that value.
+func ApplyEnvOverrides(cfg *Config) *Config {
+	overridden := *cfg
+
+	if dir := os.Getenv(EnvFragmentsDir); dir != "" {
+		overridden.FragmentsDir = dir
+	}
+
+	if tags := os.Getenv(EnvDefaultTags); tags != "" {
+		overridden.DefaultTags = splitEnvList(tags)
+	}
+
+	if dir := os.Getenv(EnvOutputDir); dir != "" {
+		overridden.OutputDir = dir
+	}
+
+	return &overridden
+}
+
+// envSource returns the environment variable overriding the config field with the given
+// JSON name, or an empty string if it is not overridden.
+func envSource(name string) string {
+	variable, ok := envFields[name]
+	if !ok || os.Getenv(variable) == "" {
+		return ""
+	}
+
+	return variable
+}
+
+// splitEnvList splits a comma-separated value, dropping surrounding whitespace and empty entries.
+func splitEnvList(value string) []string {
+	items := []string{}
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
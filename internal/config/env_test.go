@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestApplyEnvOverrides(t *testing.T) {
+	base := &Config{
+		DefaultTags:  []string{"general"},
+		FragmentsDir: "/fragments",
+		OutputDir:    "out",
+	}
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected *Config
+	}{
+		{
+			name:     "no variables",
+			expected: base,
+		},
+		{
+			name:     "fragments dir",
+			env:      map[string]string{EnvFragmentsDir: "/env/fragments"},
+			expected: &Config{DefaultTags: []string{"general"}, FragmentsDir: "/env/fragments", OutputDir: "out"},
+		},
+		{
+			name:     "default tags",
+			env:      map[string]string{EnvDefaultTags: "go, rust,,typescript "},
+			expected: &Config{DefaultTags: []string{"go", "rust", "typescript"}, FragmentsDir: "/fragments", OutputDir: "out"},
+		},
+		{
+			name:     "output dir",
+			env:      map[string]string{EnvOutputDir: "/env/out"},
+			expected: &Config{DefaultTags: []string{"general"}, FragmentsDir: "/fragments", OutputDir: "/env/out"},
+		},
+		{
+			name:     "empty values are ignored",
+			env:      map[string]string{EnvFragmentsDir: "", EnvDefaultTags: "", EnvOutputDir: ""},
+			expected: base,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, variable := range []string{EnvFragmentsDir, EnvDefaultTags, EnvOutputDir} {
+				t.Setenv(variable, tt.env[variable])
+			}
+
+			overridden := ApplyEnvOverrides(base)
+			if !reflect.DeepEqual(overridden, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, overridden)
+			}
+
+			if base.FragmentsDir != "/fragments" || base.OutputDir != "out" {
+				t.Errorf("Expected ApplyEnvOverrides not to modify its input, got %+v", base)
+			}
+		})
+	}
+}
+
+func TestLoadMergedConfigAppliesEnvOverrides(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+
+	if err := os.WriteFile(configPath, []byte(`{"defaultTags": ["general"], "fragmentsDir": "/fragments"}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config file: %v", err)
+	}
+
+	t.Setenv(EnvFragmentsDir, "/env/fragments")
+	t.Setenv(EnvDefaultTags, "go")
+	t.Setenv(EnvOutputDir, "/env/out")
+
+	for _, path := range []string{configPath, filepath.Join(tmpDir, "missing.json")} {
+		cfg, err := LoadMergedConfig(path)
+		if err != nil {
+			t.Fatalf("LoadMergedConfig failed: %v", err)
+		}
+
+		if cfg.FragmentsDir != "/env/fragments" || !reflect.DeepEqual(cfg.DefaultTags, []string{"go"}) || cfg.OutputDir != "/env/out" {
+			t.Errorf("Expected environment overrides for %s, got %+v", path, cfg)
+		}
+	}
+
+	// LoadConfig returns the file as written, so that it can be migrated or converted
+	raw, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if raw.FragmentsDir != "/fragments" || raw.OutputDir != "" {
+		t.Errorf("Expected LoadConfig not to apply environment overrides, got %+v", raw)
+	}
+
+	_, fields, err := LoadMergedConfigWithSources(configPath)
+	if err != nil {
+		t.Fatalf("LoadMergedConfigWithSources failed: %v", err)
+	}
+
+	for _, field := range fields {
+		if field.Name == "fragmentsDir" && field.Source != SourceEnvPrefix+EnvFragmentsDir {
+			t.Errorf("Expected fragmentsDir to come from %s, got %s", EnvFragmentsDir, field.Source)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config file formats.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// configFileNames are the config file names searched in a config directory, in order of precedence.
+var configFileNames = []string{"config.json", "config.yaml", "config.yml", "config.toml"}
+
+// findConfigFile returns the first config file that exists in dir,
+// or the path of config.json in dir when there is none.
+func findConfigFile(dir string) string {
+	for _, name := range configFileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	return filepath.Join(dir, configFileNames[0])
+}
+
+// FormatForPath returns the config format implied by the extension of path.
+// Files ending in .yaml or .yml are YAML, files ending in .toml are TOML; everything else is JSON.
+func FormatForPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatJSON
+	}
+}
+
+// MarshalConfigAs returns the document for the configuration in the given format.
+func MarshalConfigAs(config *Config, format string) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return MarshalConfig(config)
+	case FormatYAML:
+		return marshalConfigYAML(config)
+	case FormatTOML:
+		return marshalConfigTOML(config)
+	default:
+		return nil, fmt.Errorf("unsupported config format %q (expected %s, %s or %s)", format, FormatJSON, FormatYAML, FormatTOML)
+	}
+}
+
+// marshalConfigYAML encodes the configuration as YAML using the same keys and field
+// order as the JSON document.
+func marshalConfigYAML(config *Config) ([]byte, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	// JSON is valid YAML, so decoding it into a node keeps the key order.
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	clearNodeStyle(&node)
+
+	var out bytes.Buffer
+
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(&node); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	return out.Bytes(), nil
+}
+
+// clearNodeStyle resets the flow and quoting styles inherited from JSON so the
+// node is written in block style with quotes only where required. Multi-line
+// strings stay double-quoted because block scalars cannot hold leading newlines.
+func clearNodeStyle(node *yaml.Node) {
+	node.Style = 0
+	if node.Kind == yaml.ScalarNode && strings.Contains(node.Value, "\n") {
+		node.Style = yaml.DoubleQuotedStyle
+	}
+
+	for _, child := range node.Content {
+		clearNodeStyle(child)
+	}
+}
+
+// yamlToJSON converts a YAML config document to the equivalent JSON document.
+func yamlToJSON(data []byte) ([]byte, error) {
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	if raw == nil {
+		raw = map[string]interface{}{}
+	}
+
+	return json.Marshal(raw)
+}
+
+// marshalConfigTOML encodes the configuration as TOML. Config fields and profile fields
+// use snake_case keys (e.g. default_tags), following TOML conventions; user-defined
+// keys such as output format or alias names are written unchanged.
+func marshalConfigTOML(config *Config) ([]byte, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var raw map[string]interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	document, _ := tomlValue(raw).(map[string]interface{})
+	renameConfigKeys(document, camelToSnake)
+
+	var out bytes.Buffer
+
+	encoder := toml.NewEncoder(&out)
+	encoder.Indent = ""
+
+	if err := encoder.Encode(document); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	return out.Bytes(), nil
+}
+
+// tomlValue converts a value decoded from JSON into one TOML can represent: numbers
+// become integers where possible and null values are dropped, as TOML has no null.
+func tomlValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if number, err := v.Int64(); err == nil {
+			return number
+		}
+
+		number, _ := v.Float64()
+
+		return number
+	case map[string]interface{}:
+		for key, item := range v {
+			if item == nil {
+				delete(v, key)
+				continue
+			}
+
+			v[key] = tomlValue(item)
+		}
+
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = tomlValue(item)
+		}
+
+		return v
+	default:
+		return v
+	}
+}
+
+// tomlToJSON converts a TOML config document to the equivalent JSON document,
+// mapping the snake_case config and profile keys to their JSON names.
+func tomlToJSON(data []byte) ([]byte, error) {
+	raw := map[string]interface{}{}
+	if err := toml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	renameConfigKeys(raw, snakeToCamel)
+
+	return json.Marshal(raw)
+}
+
+// renameConfigKeys renames the top-level keys of a raw config document and the keys of
+// each profile with rename. Keys that are names chosen by the user are left alone.
+func renameConfigKeys(raw map[string]interface{}, rename func(string) string) {
+	renameKeys(raw, rename)
+
+	profiles, ok := raw["profiles"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	for _, profile := range profiles {
+		if fields, ok := profile.(map[string]interface{}); ok {
+			renameKeys(fields, rename)
+		}
+	}
+}
+
+// renameKeys renames the keys of raw with rename. When a key exists in both spellings
+// the one already spelled as the target wins.
+func renameKeys(raw map[string]interface{}, rename func(string) string) {
+	for key, value := range raw {
+		renamed := rename(key)
+		if renamed == key {
+			continue
+		}
+
+		if _, exists := raw[renamed]; !exists {
+			raw[renamed] = value
+		}
+
+		delete(raw, key)
+	}
+}
+
+// camelToSnake converts a camelCase identifier to snake_case.
+func camelToSnake(key string) string {
+	var result strings.Builder
+
+	for i, r := range key {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				result.WriteByte('_')
+			}
+
+			r += 'a' - 'A'
+		}
+
+		result.WriteRune(r)
+	}
+
+	return result.String()
+}
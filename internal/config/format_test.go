@@ -0,0 +1,239 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestYAMLConfigRoundTrip(t *testing.T) {
+	separator := "\n\n---\n\n"
+	original := &Config{
+		Version:       CurrentVersion,
+		DefaultTags:   []string{"typescript", "1"},
+		OutputFormats: map[string]OutputFormatConfig{"opencode": {Filename: "AGENTS.md"}, "gemini": {Filename: "GEMINI.md"}},
+		FragmentsDir:  "/tmp/fragments",
+		Aliases:       map[string][]string{"ts": {"typescript"}},
+		Separator:     &separator,
+		Profiles: map[string]ProfileConfig{
+			"frontend": {Tags: []string{"typescript"}, OutputFormats: []string{"opencode"}},
+		},
+		NamespaceFromDir: true,
+	}
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+
+	if err := SaveConfig(original, configPath); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read config file: %v", err)
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		t.Errorf("Expected YAML block style, got:\n%s", data)
+	}
+
+	loaded, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(loaded, original) {
+		t.Errorf("Round trip mismatch:\nexpected %+v\ngot      %+v\nfile:\n%s", original, loaded, data)
+	}
+}
+
+func TestLoadConfigYAML(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yml")
+	content := `# hand-edited config
+default_tags:
+  - general
+outputFormats:
+  opencode: AGENTS.md
+`
+
+	if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to create config file: %v", err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg.DefaultTags, []string{"general"}) {
+		t.Errorf("Expected migrated default tags [general], got %v", cfg.DefaultTags)
+	}
+
+	if cfg.OutputFormats["opencode"].Filename != "AGENTS.md" {
+		t.Errorf("Expected opencode output format, got %v", cfg.OutputFormats)
+	}
+}
+
+func TestResolveConfigPathSearchOrder(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	oldXDG := os.Getenv("XDG_CONFIG_HOME")
+	defer func() { _ = os.Setenv("XDG_CONFIG_HOME", oldXDG) }()
+
+	_ = os.Setenv("XDG_CONFIG_HOME", tmpDir)
+
+	configDir := filepath.Join(tmpDir, ".ctx")
+	if err := os.MkdirAll(configDir, 0o750); err != nil {
+		t.Fatalf("Failed to create config directory: %v", err)
+	}
+
+	steps := []struct {
+		create   string
+		expected string
+	}{
+		{create: "", expected: "config.json"},
+		{create: "config.toml", expected: "config.toml"},
+		{create: "config.yml", expected: "config.yml"},
+		{create: "config.yaml", expected: "config.yaml"},
+		{create: "config.json", expected: "config.json"},
+	}
+
+	for _, step := range steps {
+		if step.create != "" {
+			if err := os.WriteFile(filepath.Join(configDir, step.create), []byte("{}"), 0o600); err != nil {
+				t.Fatalf("Failed to create %s: %v", step.create, err)
+			}
+		}
+
+		path, err := ResolveConfigPath("")
+		if err != nil {
+			t.Fatalf("ResolveConfigPath failed: %v", err)
+		}
+
+		if path != filepath.Join(configDir, step.expected) {
+			t.Errorf("After creating %q expected %s, got %s", step.create, step.expected, path)
+		}
+	}
+}
+
+func TestTOMLConfigRoundTrip(t *testing.T) {
+	separator := "\n\n---\n\n"
+	original := &Config{
+		Version:     CurrentVersion,
+		DefaultTags: []string{"typescript", "1"},
+		OutputFormats: map[string]OutputFormatConfig{
+			"opencode":      {Filename: "AGENTS.md", Template: "wrap.tmpl", Permissions: 0o644, AppendMode: true},
+			"custom_format": {Filename: "CUSTOM.md"},
+		},
+		FragmentsDir:   "/tmp/fragments",
+		FragmentsDirs:  []string{"/tmp/shared"},
+		CustomSettings: map[string]interface{}{"theme": "dark", "width": float64(80), "ratio": 0.5, "nested": map[string]interface{}{"enabled": true}},
+		Aliases:        map[string][]string{"ts": {"typescript"}},
+		TagGroups:      map[string][]string{"web_stack": {"ts", "css"}},
+		Separator:      &separator,
+		Profiles: map[string]ProfileConfig{
+			"frontend": {Tags: []string{"typescript"}, OutputFormats: []string{"opencode"}},
+		},
+		NamespaceFromDir: true,
+		PreBuildHook:     "make fragments",
+		PostBuildHook:    "git add AGENTS.md",
+		OutputDir:        "out",
+	}
+
+	tmpDir := t.TempDir()
+	jsonPath := filepath.Join(tmpDir, "config.json")
+	tomlPath := filepath.Join(tmpDir, "config.toml")
+
+	if err := SaveConfig(original, jsonPath); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+
+	fromJSON, err := LoadConfig(jsonPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if err := SaveConfig(fromJSON, tomlPath); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+
+	data, err := os.ReadFile(tomlPath)
+	if err != nil {
+		t.Fatalf("Failed to read config file: %v", err)
+	}
+
+	for _, key := range []string{"default_tags = ", "[output_formats.custom_format]", "[tag_groups]", "web_stack = ", "output_formats = [\"opencode\"]"} {
+		if !strings.Contains(string(data), key) {
+			t.Errorf("Expected TOML to contain %q, got:\n%s", key, data)
+		}
+	}
+
+	fromTOML, err := LoadConfig(tomlPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v\nfile:\n%s", err, data)
+	}
+
+	if !reflect.DeepEqual(fromTOML, original) {
+		t.Errorf("Round trip mismatch:\nexpected %+v\ngot      %+v\nfile:\n%s", original, fromTOML, data)
+	}
+
+	before, err := MarshalConfig(fromJSON)
+	if err != nil {
+		t.Fatalf("MarshalConfig failed: %v", err)
+	}
+
+	after, err := MarshalConfig(fromTOML)
+	if err != nil {
+		t.Fatalf("MarshalConfig failed: %v", err)
+	}
+
+	if string(before) != string(after) {
+		t.Errorf("Expected identical JSON after the round trip:\n%s\n%s", before, after)
+	}
+}
+
+func TestLoadConfigTOML(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	content := `# hand-edited config
+version = 2
+default_tags = ["general"]
+fragments_dir = "/fragments"
+
+[output_formats]
+opencode = "AGENTS.md"
+
+[output_formats.gemini]
+file = "GEMINI.md"
+permissions = "0644"
+
+[profiles.frontend]
+tags = ["typescript"]
+output_formats = ["gemini"]
+`
+
+	if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to create config file: %v", err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg.DefaultTags, []string{"general"}) || cfg.FragmentsDir != "/fragments" {
+		t.Errorf("Expected default tags [general] and fragments dir /fragments, got %v and %q", cfg.DefaultTags, cfg.FragmentsDir)
+	}
+
+	expectedFormats := map[string]OutputFormatConfig{
+		"opencode": {Filename: "AGENTS.md"},
+		"gemini":   {Filename: "GEMINI.md", Permissions: 0o644},
+	}
+	if !reflect.DeepEqual(cfg.OutputFormats, expectedFormats) {
+		t.Errorf("Expected output formats %v, got %v", expectedFormats, cfg.OutputFormats)
+	}
+
+	if !reflect.DeepEqual(cfg.Profiles["frontend"].OutputFormats, []string{"gemini"}) {
+		t.Errorf("Expected profile output formats [gemini], got %v", cfg.Profiles["frontend"].OutputFormats)
+	}
+}
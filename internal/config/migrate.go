@@ -0,0 +1,121 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// CurrentVersion is the config schema version written by this version of ctx.
+const CurrentVersion = 2
+
+// migrations upgrade a raw config document one schema version at a time.
+// migrations[i] upgrades a document from version i to version i+1.
+var migrations = []func(raw map[string]interface{}){
+	migrateSnakeCaseKeys,
+	migrateOutputFormatObjects,
+}
+
+// MigrateRaw upgrades a raw config document to CurrentVersion in place.
+// Documents without a version field are treated as version 0.
+// It reports whether any migration was applied.
+func MigrateRaw(raw map[string]interface{}) (bool, error) {
+	version := 0
+
+	if value, exists := raw["version"]; exists {
+		number, ok := value.(float64)
+		if !ok || number != float64(int(number)) {
+			return false, fmt.Errorf("invalid config version: %v", value)
+		}
+
+		version = int(number)
+	}
+
+	if version > CurrentVersion {
+		return false, fmt.Errorf("config version %d is newer than the supported version %d", version, CurrentVersion)
+	}
+
+	changed := version < CurrentVersion
+
+	for ; version < CurrentVersion; version++ {
+		migrations[version](raw)
+	}
+
+	raw["version"] = float64(CurrentVersion)
+
+	return changed, nil
+}
+
+// decodeConfig parses a config document, applying any pending migrations.
+func decodeConfig(data []byte) (*Config, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	if _, err := MigrateRaw(raw); err != nil {
+		return nil, err
+	}
+
+	migrated, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := json.Unmarshal(migrated, &config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// migrateSnakeCaseKeys renames snake_case top-level keys (e.g. default_tags) to camelCase.
+// When both spellings are present the camelCase value wins.
+func migrateSnakeCaseKeys(raw map[string]interface{}) {
+	for key, value := range raw {
+		if !strings.Contains(key, "_") {
+			continue
+		}
+
+		camel := snakeToCamel(key)
+		if _, exists := raw[camel]; !exists {
+			raw[camel] = value
+		}
+
+		delete(raw, key)
+	}
+}
+
+// migrateOutputFormatObjects converts plain filename values of outputFormats to the object form.
+func migrateOutputFormatObjects(raw map[string]interface{}) {
+	formats, ok := raw["outputFormats"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	for format, value := range formats {
+		if filename, ok := value.(string); ok {
+			formats[format] = map[string]interface{}{"file": filename}
+		}
+	}
+}
+
+// snakeToCamel converts a snake_case identifier to camelCase.
+func snakeToCamel(key string) string {
+	parts := strings.Split(key, "_")
+
+	var result strings.Builder
+
+	result.WriteString(parts[0])
+
+	for _, part := range parts[1:] {
+		if part == "" {
+			continue
+		}
+
+		result.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+
+	return result.String()
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMigrateRaw(t *testing.T) {
+	tests := []struct {
+		name            string
+		raw             map[string]interface{}
+		expected        map[string]interface{}
+		expectedChanged bool
+		expectError     bool
+	}{
+		{
+			name: "snake_case keys are renamed",
+			raw: map[string]interface{}{
+				"default_tags":  []interface{}{"go"},
+				"fragments_dir": "/fragments",
+			},
+			expected: map[string]interface{}{
+				"version":      float64(2),
+				"defaultTags":  []interface{}{"go"},
+				"fragmentsDir": "/fragments",
+			},
+			expectedChanged: true,
+		},
+		{
+			name: "camelCase wins over snake_case",
+			raw: map[string]interface{}{
+				"default_tags": []interface{}{"old"},
+				"defaultTags":  []interface{}{"new"},
+			},
+			expected: map[string]interface{}{
+				"version":     float64(2),
+				"defaultTags": []interface{}{"new"},
+			},
+			expectedChanged: true,
+		},
+		{
+			name: "current version is untouched",
+			raw: map[string]interface{}{
+				"version":     float64(2),
+				"defaultTags": []interface{}{"go"},
+			},
+			expected: map[string]interface{}{
+				"version":     float64(2),
+				"defaultTags": []interface{}{"go"},
+			},
+			expectedChanged: false,
+		},
+		{
+			name: "version 1 output formats are converted to objects",
+			raw: map[string]interface{}{
+				"version": float64(1),
+				"outputFormats": map[string]interface{}{
+					"opencode": "AGENTS.md",
+					"gemini":   map[string]interface{}{"file": "GEMINI.md"},
+				},
+			},
+			expected: map[string]interface{}{
+				"version": float64(2),
+				"outputFormats": map[string]interface{}{
+					"opencode": map[string]interface{}{"file": "AGENTS.md"},
+					"gemini":   map[string]interface{}{"file": "GEMINI.md"},
+				},
+			},
+			expectedChanged: true,
+		},
+		{
+			name: "newer version is rejected",
+			raw: map[string]interface{}{
+				"version": float64(CurrentVersion + 1),
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid version is rejected",
+			raw: map[string]interface{}{
+				"version": "one",
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed, err := MigrateRaw(tt.raw)
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if changed != tt.expectedChanged {
+				t.Errorf("Expected changed %v, got %v", tt.expectedChanged, changed)
+			}
+
+			if !reflect.DeepEqual(tt.raw, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, tt.raw)
+			}
+		})
+	}
+}
+
+func TestLoadConfigMigratesSnakeCase(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+
+	configContent := `{
+		"default_tags": ["typescript"],
+		"output_formats": {"custom_format": "CUSTOM.md"}
+	}`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0o600); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if config.Version != CurrentVersion {
+		t.Errorf("Expected version %d, got %d", CurrentVersion, config.Version)
+	}
+
+	if !reflect.DeepEqual(config.DefaultTags, []string{"typescript"}) {
+		t.Errorf("Expected default tags [typescript], got %v", config.DefaultTags)
+	}
+
+	// Nested keys such as output format names must not be renamed
+	expectedFormats := map[string]OutputFormatConfig{"custom_format": {Filename: "CUSTOM.md"}}
+	if !reflect.DeepEqual(config.OutputFormats, expectedFormats) {
+		t.Errorf("Expected output formats %v, got %v", expectedFormats, config.OutputFormats)
+	}
+}
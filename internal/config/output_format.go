@@ -0,0 +1,125 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"strconv"
+)
+
+// OutputFormatConfig describes how the output of a format is written.
+// In a config file it is either a plain filename or an object with the fields below.
+type OutputFormatConfig struct {
+	// Filename is the path the output is written to.
+	Filename string
+	// Template is a text/template file the output of this format is wrapped in.
+	Template string
+	// Permissions is the mode of the written file; zero keeps the default.
+	Permissions os.FileMode
+	// AppendMode appends the output to the file instead of replacing it.
+	AppendMode bool
+}
+
+// ParseFileMode parses an octal file permission mode such as "0644" or "600".
+func ParseFileMode(value string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("%q is not an octal mode such as \"0644\"", value)
+	}
+
+	return os.FileMode(mode), nil
+}
+
+// Built-in output formats that serialize the fragments instead of writing the spliced Markdown.
+const (
+	// YAMLMultiDocFormat writes one YAML document per fragment.
+	YAMLMultiDocFormat = "yaml-multi-doc"
+	// JSONFormat writes a JSON array of the fragments.
+	JSONFormat = "json"
+)
+
+// BuiltInOutputFormats returns the output formats that can be used without configuring
+// them, those of DefaultConfig.
+func BuiltInOutputFormats() map[string]OutputFormatConfig {
+	return DefaultConfig().OutputFormats
+}
+
+// AvailableOutputFormats returns the output formats a build can use: the built-in formats,
+// overridden by the formats configured in cfg.
+func AvailableOutputFormats(cfg *Config) map[string]OutputFormatConfig {
+	formats := BuiltInOutputFormats()
+	maps.Copy(formats, cfg.OutputFormats)
+
+	return formats
+}
+
+// OutputFormat returns the output format called name: the one configured in c, or else the
+// built-in format of that name. Builds resolve every per-format setting through it so that
+// built-in formats behave the same whether or not the config lists them.
+func (c *Config) OutputFormat(name string) (OutputFormatConfig, bool) {
+	if format, exists := c.OutputFormats[name]; exists {
+		return format, true
+	}
+
+	format, exists := BuiltInOutputFormats()[name]
+
+	return format, exists
+}
+
+// outputFormatJSON is the object form of OutputFormatConfig, with the permissions as an octal string.
+type outputFormatJSON struct {
+	File        string `json:"file"`
+	Template    string `json:"template,omitempty"`
+	Permissions string `json:"permissions,omitempty"`
+	Append      bool   `json:"append,omitempty"`
+}
+
+// UnmarshalJSON accepts either a plain filename string or an object.
+func (f *OutputFormatConfig) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '"' {
+		*f = OutputFormatConfig{}
+		return json.Unmarshal(trimmed, &f.Filename)
+	}
+
+	var raw outputFormatJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	var permissions os.FileMode
+
+	if raw.Permissions != "" {
+		mode, err := ParseFileMode(raw.Permissions)
+		if err != nil {
+			return fmt.Errorf("invalid output format permissions: %w", err)
+		}
+
+		permissions = mode
+	}
+
+	*f = OutputFormatConfig{
+		Filename:    raw.File,
+		Template:    raw.Template,
+		Permissions: permissions,
+		AppendMode:  raw.Append,
+	}
+
+	return nil
+}
+
+// MarshalJSON always writes the object form.
+func (f OutputFormatConfig) MarshalJSON() ([]byte, error) {
+	raw := outputFormatJSON{
+		File:     f.Filename,
+		Template: f.Template,
+		Append:   f.AppendMode,
+	}
+
+	if f.Permissions != 0 {
+		raw.Permissions = fmt.Sprintf("%04o", uint32(f.Permissions.Perm()))
+	}
+
+	return json.Marshal(raw)
+}
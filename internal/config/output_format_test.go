@@ -0,0 +1,171 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestOutputFormatConfigUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    OutputFormatConfig
+		expectError bool
+	}{
+		{
+			name:     "plain filename",
+			input:    `"AGENTS.md"`,
+			expected: OutputFormatConfig{Filename: "AGENTS.md"},
+		},
+		{
+			name:     "object",
+			input:    `{"file": "AGENTS.md", "template": "templates/opencode.tmpl", "permissions": "0644", "append": true}`,
+			expected: OutputFormatConfig{Filename: "AGENTS.md", Template: "templates/opencode.tmpl", Permissions: 0o644, AppendMode: true},
+		},
+		{
+			name:     "object with filename only",
+			input:    `{"file": "GEMINI.md"}`,
+			expected: OutputFormatConfig{Filename: "GEMINI.md"},
+		},
+		{
+			name:        "invalid permissions",
+			input:       `{"file": "AGENTS.md", "permissions": "rw-r--r--"}`,
+			expectError: true,
+		},
+		{
+			name:        "permissions out of range",
+			input:       `{"file": "AGENTS.md", "permissions": "7777"}`,
+			expectError: true,
+		},
+		{
+			name:        "invalid type",
+			input:       `42`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var format OutputFormatConfig
+
+			err := json.Unmarshal([]byte(tt.input), &format)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got %+v", format)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if format != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, format)
+			}
+		})
+	}
+}
+
+func TestParseFileMode(t *testing.T) {
+	for value, expected := range map[string]os.FileMode{"0644": 0o644, "600": 0o600, "0755": 0o755} {
+		mode, err := ParseFileMode(value)
+		if err != nil {
+			t.Errorf("ParseFileMode(%q) failed: %v", value, err)
+		}
+
+		if mode != expected {
+			t.Errorf("ParseFileMode(%q) = %04o, expected %04o", value, mode, expected)
+		}
+	}
+
+	for _, value := range []string{"", "0999", "rw-r--r--", "01777"} {
+		if _, err := ParseFileMode(value); err == nil {
+			t.Errorf("Expected ParseFileMode(%q) to fail", value)
+		}
+	}
+}
+
+func TestOutputFormatConfigRoundTrip(t *testing.T) {
+	original := OutputFormatConfig{Filename: "AGENTS.md", Template: "t.tmpl", Permissions: 0o640, AppendMode: true}
+
+	data, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedJSON := `{"file":"AGENTS.md","template":"t.tmpl","permissions":"0640","append":true}`
+	if string(data) != expectedJSON {
+		t.Errorf("Expected %s, got %s", expectedJSON, data)
+	}
+
+	var decoded OutputFormatConfig
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if decoded != original {
+		t.Errorf("Expected %+v after round trip, got %+v", original, decoded)
+	}
+}
+
+func TestConfigOutputFormat(t *testing.T) {
+	cfg := &Config{OutputFormats: map[string]OutputFormatConfig{
+		"opencode": {Filename: "CUSTOM.md", Permissions: 0o600},
+	}}
+
+	if format, ok := cfg.OutputFormat("opencode"); !ok || format.Filename != "CUSTOM.md" || format.Permissions != 0o600 {
+		t.Errorf("Expected the configured opencode format, got %+v (%v)", format, ok)
+	}
+
+	if format, ok := cfg.OutputFormat(JSONFormat); !ok || format.Filename != "ctx-output.json" {
+		t.Errorf("Expected the built-in json format, got %+v (%v)", format, ok)
+	}
+
+	if _, ok := cfg.OutputFormat("unknown"); ok {
+		t.Error("Expected no format for an unknown name")
+	}
+}
+
+func TestLoadConfigStringOutputFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "unversioned config",
+			content: `{"outputFormats": {"opencode": "AGENTS.md", "gemini": {"file": "GEMINI.md", "append": true}}}`,
+		},
+		{
+			name:    "current version with string values",
+			content: `{"version": 2, "outputFormats": {"opencode": "AGENTS.md", "gemini": {"file": "GEMINI.md", "append": true}}}`,
+		},
+	}
+
+	expected := map[string]OutputFormatConfig{
+		"opencode": {Filename: "AGENTS.md"},
+		"gemini":   {Filename: "GEMINI.md", AppendMode: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(configPath, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			cfg, err := LoadConfig(configPath)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(cfg.OutputFormats, expected) {
+				t.Errorf("Expected output formats %+v, got %+v", expected, cfg.OutputFormats)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// PresetFull is the preset that also creates a sample fragment.
+const PresetFull = "full"
+
+// Presets returns the built-in configurations ctx init can start from, keyed by name.
+// Every call returns new configs, so callers may modify them.
+func Presets() map[string]*Config {
+	withFormats := func(formats map[string]OutputFormatConfig) *Config {
+		cfg := DefaultConfig()
+		cfg.OutputFormats = formats
+
+		return cfg
+	}
+
+	return map[string]*Config{
+		"minimal":      withFormats(map[string]OutputFormatConfig{}),
+		"openai-codex": withFormats(map[string]OutputFormatConfig{"codex": {Filename: "AGENTS.md"}}),
+		"gemini": withFormats(map[string]OutputFormatConfig{
+			"gemini":   {Filename: "GEMINI.md"},
+			"opencode": {Filename: "AGENTS.md"},
+		}),
+		PresetFull: DefaultConfig(),
+	}
+}
+
+// PresetNames returns the names of the built-in presets in sorted order.
+func PresetNames() []string {
+	return slices.Sorted(maps.Keys(Presets()))
+}
+
+// Preset returns the built-in preset with the given name.
+func Preset(name string) (*Config, error) {
+	cfg, exists := Presets()[name]
+	if !exists {
+		return nil, fmt.Errorf("unknown preset %q; valid presets: %s", name, strings.Join(PresetNames(), ", "))
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPreset(t *testing.T) {
+	tests := []struct {
+		name            string
+		expectedFormats map[string]OutputFormatConfig
+	}{
+		{name: "minimal", expectedFormats: map[string]OutputFormatConfig{}},
+		{name: "openai-codex", expectedFormats: map[string]OutputFormatConfig{"codex": {Filename: "AGENTS.md"}}},
+		{name: "gemini", expectedFormats: map[string]OutputFormatConfig{"gemini": {Filename: "GEMINI.md"}, "opencode": {Filename: "AGENTS.md"}}},
+		{name: "full", expectedFormats: DefaultConfig().OutputFormats},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Preset(tt.name)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if cfg.Version != CurrentVersion {
+				t.Errorf("Expected version %d, got %d", CurrentVersion, cfg.Version)
+			}
+
+			if !reflect.DeepEqual(cfg.OutputFormats, tt.expectedFormats) {
+				t.Errorf("Expected output formats %v, got %v", tt.expectedFormats, cfg.OutputFormats)
+			}
+		})
+	}
+}
+
+func TestPresetUnknown(t *testing.T) {
+	_, err := Preset("nope")
+	if err == nil {
+		t.Fatal("Expected error for unknown preset")
+	}
+
+	if !strings.Contains(err.Error(), "full, gemini, minimal, openai-codex") {
+		t.Errorf("Expected error to list valid presets, got: %v", err)
+	}
+}
+
+func TestPresetsAreIndependent(t *testing.T) {
+	first, _ := Preset("gemini")
+	first.OutputFormats["extra"] = OutputFormatConfig{Filename: "EXTRA.md"}
+
+	second, _ := Preset("gemini")
+	if _, exists := second.OutputFormats["extra"]; exists {
+		t.Error("Expected each call to return a new preset config")
+	}
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// SourceDefault is the source of config fields taken from the built-in defaults.
+const SourceDefault = "default"
+
+// SourceEnvPrefix prefixes the environment variable named as the source of config fields
+// overridden by the environment.
+const SourceEnvPrefix = "env:"
+
+// ConfigField is a field of the effective configuration together with the file that set it.
+type ConfigField struct {
+	Name   string          `json:"name"`
+	Value  json.RawMessage `json:"value"`
+	Source string          `json:"source"`
+}
+
+// LoadMergedConfigWithSources loads the effective configuration like LoadMergedConfig and
+// also returns every field that has a value, in declaration order, with its source:
+// the path of the config file that set it, SourceEnvPrefix followed by the environment
+// variable that overrides it, or SourceDefault.
+func LoadMergedConfigWithSources(configPath string) (*Config, []ConfigField, error) {
+	merged, err := LoadMergedConfig(configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	basePath, err := ResolveConfigPath(configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	baseSource := SourceDefault
+	if _, err := os.Stat(basePath); err == nil {
+		baseSource = basePath
+	}
+
+	overrideSources, err := overrideConfigSources(configPath, basePath, merged)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values, err := configValues(merged)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var fields []ConfigField
+
+	for _, name := range configFieldNames() {
+		value, ok := values[name]
+		if !ok || string(value) == "null" {
+			continue
+		}
+
+		source := baseSource
+		if path, ok := overrideSources[name]; ok {
+			source = path
+		}
+
+		if variable := envSource(name); variable != "" {
+			source = SourceEnvPrefix + variable
+		}
+
+		fields = append(fields, ConfigField{Name: name, Value: value, Source: source})
+	}
+
+	return merged, fields, nil
+}
+
+// overrideConfigSources returns, for each field set by a config merged over the global
+// config by LoadMergedConfig, the path of the highest priority config that sets it.
+// No fields are returned when configPath is given, since nothing is merged then.
+func overrideConfigSources(configPath, basePath string, merged *Config) (map[string]string, error) {
+	if configPath != "" {
+		return nil, nil
+	}
+
+	paths, err := OverrideConfigPaths(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make(map[string]string)
+
+	for _, path := range paths {
+		override, err := LoadConfig(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load local config %s: %w", path, err)
+		}
+
+		dropUntrustedCommands(merged, override)
+
+		values, err := configValues(override)
+		if err != nil {
+			return nil, err
+		}
+
+		for name, value := range values {
+			// The version is never taken from a merged config, see MergeConfigs
+			if name != "version" && string(value) != "null" {
+				sources[name] = path
+			}
+		}
+	}
+
+	return sources, nil
+}
+
+// configValues returns the JSON encoding of each field of config by its JSON name.
+func configValues(config *Config) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	return values, nil
+}
+
+// configFieldNames returns the JSON names of the Config fields in declaration order.
+func configFieldNames() []string {
+	configType := reflect.TypeOf(Config{})
+	names := make([]string, 0, configType.NumField())
+
+	for i := range configType.NumField() {
+		name, _, _ := strings.Cut(configType.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
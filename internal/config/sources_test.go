@@ -0,0 +1,92 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadMergedConfigWithSources(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	oldXDG := os.Getenv("XDG_CONFIG_HOME")
+	defer func() { _ = os.Setenv("XDG_CONFIG_HOME", oldXDG) }()
+
+	_ = os.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "xdg"))
+
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+
+	defer func() { _ = os.Chdir(originalWd) }()
+
+	projectDir := filepath.Join(tmpDir, "project")
+	globalPath := filepath.Join(tmpDir, "xdg", ".ctx", "config.json")
+	localPath := filepath.Join(projectDir, ".ctx", "config.yaml")
+	projectPath := filepath.Join(projectDir, ProjectConfigFileName)
+
+	files := map[string]string{
+		globalPath:  `{"defaultTags": ["global"], "outputFormats": {"opencode": "AGENTS.md"}}`,
+		localPath:   "defaultTags: [local]\nnamespaceFromDir: true\n",
+		projectPath: `{"outputDir": "out"}`,
+	}
+
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create config file: %v", err)
+		}
+	}
+
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+
+	// Resolve symlinks in the temp dir (e.g. /var -> /private/var on macOS)
+	if wd, err := os.Getwd(); err == nil {
+		localPath = filepath.Join(wd, ".ctx", "config.yaml")
+		projectPath = filepath.Join(wd, ProjectConfigFileName)
+	}
+
+	cfg, fields, err := LoadMergedConfigWithSources("")
+	if err != nil {
+		t.Fatalf("LoadMergedConfigWithSources failed: %v", err)
+	}
+
+	if len(cfg.DefaultTags) != 1 || cfg.DefaultTags[0] != "local" {
+		t.Errorf("Expected merged default tags [local], got %v", cfg.DefaultTags)
+	}
+
+	expected := map[string]string{
+		"version":          globalPath,
+		"defaultTags":      localPath,
+		"outputFormats":    globalPath,
+		"namespaceFromDir": localPath,
+		"outputDir":        projectPath,
+	}
+
+	if len(fields) != len(expected) {
+		t.Errorf("Expected %d fields, got %+v", len(expected), fields)
+	}
+
+	for _, field := range fields {
+		if source := expected[field.Name]; field.Source != source {
+			t.Errorf("Expected %s to come from %s, got %s", field.Name, source, field.Source)
+		}
+	}
+
+	_, fields, err = LoadMergedConfigWithSources(filepath.Join(tmpDir, "missing.json"))
+	if err != nil {
+		t.Fatalf("LoadMergedConfigWithSources failed: %v", err)
+	}
+
+	for _, field := range fields {
+		if field.Source != SourceDefault {
+			t.Errorf("Expected %s to come from the defaults, got %s", field.Name, field.Source)
+		}
+	}
+}
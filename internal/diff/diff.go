@@ -0,0 +1,265 @@
+// Package diff computes line-based unified diffs between two texts.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change.
+const contextLines = 3
+
+const (
+	ansiReset = "\033[0m"
+	ansiRed   = "\033[31m"
+	ansiGreen = "\033[32m"
+	ansiCyan  = "\033[36m"
+)
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type edit struct {
+	kind opKind
+	line string
+}
+
+// Unified returns a unified diff transforming from into to, or an empty string if they are equal.
+func Unified(fromName, toName, from, to string) string {
+	if from == to {
+		return ""
+	}
+
+	a := splitLines(from)
+	b := splitLines(to)
+	edits := computeEdits(a, b)
+
+	// Track how many lines of each side were consumed before every edit
+	aPos := make([]int, len(edits)+1)
+	bPos := make([]int, len(edits)+1)
+
+	for i, e := range edits {
+		aPos[i+1] = aPos[i]
+		bPos[i+1] = bPos[i]
+
+		if e.kind != opInsert {
+			aPos[i+1]++
+		}
+
+		if e.kind != opDelete {
+			bPos[i+1]++
+		}
+	}
+
+	var result strings.Builder
+
+	result.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", fromName, toName))
+
+	for _, h := range hunks(edits) {
+		start, end := h[0], h[1]
+		aLen := aPos[end] - aPos[start]
+		bLen := bPos[end] - bPos[start]
+
+		result.WriteString(fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(aPos[start], aLen), hunkRange(bPos[start], bLen)))
+
+		for _, e := range edits[start:end] {
+			switch e.kind {
+			case opEqual:
+				result.WriteString(" ")
+			case opDelete:
+				result.WriteString("-")
+			case opInsert:
+				result.WriteString("+")
+			}
+
+			result.WriteString(e.line)
+
+			// Like diff(1), mark a last line that has no trailing newline
+			if !strings.HasSuffix(e.line, "\n") {
+				result.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+
+	return result.String()
+}
+
+// Colorize adds ANSI colors to a unified diff: deletions red, additions green and hunk headers cyan.
+func Colorize(unified string) string {
+	if unified == "" {
+		return ""
+	}
+
+	lines := strings.SplitAfter(unified, "\n")
+
+	var result strings.Builder
+
+	for _, line := range lines {
+		text := strings.TrimSuffix(line, "\n")
+		newline := line[len(text):]
+
+		switch {
+		case strings.HasPrefix(text, "+++"), strings.HasPrefix(text, "---"):
+			result.WriteString(line)
+		case strings.HasPrefix(text, "@@"):
+			result.WriteString(ansiCyan + text + ansiReset + newline)
+		case strings.HasPrefix(text, "+"):
+			result.WriteString(ansiGreen + text + ansiReset + newline)
+		case strings.HasPrefix(text, "-"):
+			result.WriteString(ansiRed + text + ansiReset + newline)
+		default:
+			result.WriteString(line)
+		}
+	}
+
+	return result.String()
+}
+
+// splitLines splits text into lines that keep their trailing newline, so that a last line
+// without one differs from the same line with one.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// hunkRange formats a hunk header range in unified diff notation.
+func hunkRange(consumed, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", consumed)
+	}
+
+	return fmt.Sprintf("%d,%d", consumed+1, length)
+}
+
+// hunks groups edits into [start, end) ranges containing changes plus surrounding context.
+func hunks(edits []edit) [][2]int {
+	var result [][2]int
+
+	for i := 0; i < len(edits); {
+		if edits[i].kind == opEqual {
+			i++
+			continue
+		}
+
+		start := max(0, i-contextLines)
+		end := i
+
+		for end < len(edits) {
+			if edits[end].kind != opEqual {
+				end++
+				continue
+			}
+
+			run := end
+			for run < len(edits) && edits[run].kind == opEqual {
+				run++
+			}
+
+			// Split into a new hunk when the unchanged run is too long to bridge
+			if run == len(edits) || run-end > 2*contextLines {
+				end = min(end+contextLines, len(edits))
+				break
+			}
+
+			end = run
+		}
+
+		result = append(result, [2]int{start, end})
+		i = end
+	}
+
+	return result
+}
+
+// computeEdits returns the shortest edit script turning a into b using Myers' algorithm.
+func computeEdits(a, b []string) []edit {
+	n, m := len(a), len(b)
+	offset := n + m
+	v := make([]int, 2*offset+2)
+
+	var trace [][]int
+
+	for d := 0; d <= offset; d++ {
+		trace = append(trace, append([]int(nil), v...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(trace, a, b, offset)
+			}
+		}
+	}
+
+	return nil
+}
+
+// backtrack walks the Myers trace backwards to recover the edit script.
+func backtrack(trace [][]int, a, b []string, offset int) []edit {
+	x, y := len(a), len(b)
+
+	var edits []edit
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, edit{kind: opEqual, line: a[x-1]})
+			x--
+			y--
+		}
+
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, edit{kind: opInsert, line: b[y-1]})
+				y--
+			} else {
+				edits = append(edits, edit{kind: opDelete, line: a[x-1]})
+				x--
+			}
+		}
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+
+	return edits
+}
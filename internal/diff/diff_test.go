@@ -0,0 +1,83 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		expected string
+	}{
+		{
+			name:     "identical",
+			from:     "a\nb\n",
+			to:       "a\nb\n",
+			expected: "",
+		},
+		{
+			name:     "single line changed",
+			from:     "a\nb\nc\n",
+			to:       "a\nB\nc\n",
+			expected: "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:     "new file",
+			from:     "",
+			to:       "a\nb\n",
+			expected: "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:     "removed file",
+			from:     "a\n",
+			to:       "",
+			expected: "--- old\n+++ new\n@@ -1,1 +0,0 @@\n-a\n",
+		},
+		{
+			name:     "separate hunks",
+			from:     "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			to:       "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n",
+			expected: "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+twelve\n",
+		},
+		{
+			name:     "trailing newline added",
+			from:     "a\nb",
+			to:       "a\nb\n",
+			expected: "--- old\n+++ new\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+		{
+			name:     "trailing newline removed",
+			from:     "a\n",
+			to:       "a",
+			expected: "--- old\n+++ new\n@@ -1,1 +1,1 @@\n-a\n+a\n\\ No newline at end of file\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Unified("old", "new", tt.from, tt.to)
+			if result != tt.expected {
+				t.Errorf("Expected diff:\n%s\ngot:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestColorize(t *testing.T) {
+	result := Colorize("--- old\n+++ new\n@@ -1,1 +1,1 @@\n-a\n+b\n")
+
+	if !strings.Contains(result, ansiRed+"-a"+ansiReset) {
+		t.Errorf("Expected deletion to be red, got %q", result)
+	}
+
+	if !strings.Contains(result, ansiGreen+"+b"+ansiReset) {
+		t.Errorf("Expected addition to be green, got %q", result)
+	}
+
+	if !strings.HasPrefix(result, "--- old\n+++ new\n") {
+		t.Errorf("Expected file headers to be uncolored, got %q", result)
+	}
+}
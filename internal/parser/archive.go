@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ArchiveDirName is the directory, relative to a fragments directory, that archived fragments are moved to.
+const ArchiveDirName = ".ctx/archive"
+
+// ArchiveDir returns the archive directory of a fragments directory.
+func ArchiveDir(fragmentsDir string) string {
+	return filepath.Join(fragmentsDir, filepath.FromSlash(ArchiveDirName))
+}
+
+// ArchiveFragment moves the fragment called name from fragmentsDir to its archive directory,
+// keeping its path relative to the fragments directory, and returns the archived path.
+// The name is the filename or relative path, with or without extension.
+func ArchiveFragment(fragmentsDir, name string) (string, error) {
+	return moveFragment(fragmentsDir, ArchiveDir(fragmentsDir), name)
+}
+
+// RestoreFragment moves the archived fragment called name back from the archive directory
+// of fragmentsDir and returns the restored path.
+func RestoreFragment(fragmentsDir, name string) (string, error) {
+	return moveFragment(ArchiveDir(fragmentsDir), fragmentsDir, name)
+}
+
+// moveFragment moves the fragment called name from the directory from to the same relative
+// path in the directory to. It fails if the destination already exists.
+func moveFragment(from, to, name string) (string, error) {
+	source, err := resolveFragmentFile(from, name)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(from, source)
+	if err != nil {
+		return "", err
+	}
+
+	destination := filepath.Join(to, rel)
+	if _, err := os.Stat(destination); err == nil {
+		return "", fmt.Errorf("%s already exists", destination)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destination), 0o750); err != nil {
+		return "", fmt.Errorf("failed to create directory %s: %w", filepath.Dir(destination), err)
+	}
+
+	if err := os.Rename(source, destination); err != nil {
+		return "", fmt.Errorf("failed to move %s: %w", source, err)
+	}
+
+	return destination, nil
+}
+
+// resolveFragmentFile returns the markdown file in dir called name. The name is the path
+// relative to dir, with or without the .md or .markdown extension.
+func resolveFragmentFile(dir, name string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid fragment name %q", name)
+	}
+
+	candidates := []string{clean + ".md", clean + ".markdown"}
+	if ext := filepath.Ext(clean); ext == ".md" || ext == ".markdown" {
+		candidates = []string{clean}
+	}
+
+	for _, candidate := range candidates {
+		path := filepath.Join(dir, candidate)
+
+		info, err := os.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		if !info.IsDir() {
+			return path, nil
+		}
+	}
+
+	return "", fmt.Errorf("fragment %q not found in %s: %w", name, dir, fs.ErrNotExist)
+}
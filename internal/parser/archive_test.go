@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeArchiveTestFragment(t *testing.T, path string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("---\nctx-tags: go\n---\nContent"), 0o600); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+}
+
+func TestArchiveAndRestoreFragment(t *testing.T) {
+	fragmentsDir := t.TempDir()
+	original := filepath.Join(fragmentsDir, "react", "hooks.md")
+	writeArchiveTestFragment(t, original)
+	writeArchiveTestFragment(t, filepath.Join(fragmentsDir, "kept.md"))
+
+	archived, err := ArchiveFragment(fragmentsDir, "react/hooks")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedArchived := filepath.Join(fragmentsDir, ".ctx", "archive", "react", "hooks.md")
+	if archived != expectedArchived {
+		t.Errorf("Expected archived path %s, got %s", expectedArchived, archived)
+	}
+
+	if _, err := os.Stat(original); !os.IsNotExist(err) {
+		t.Error("Expected the fragment to be moved out of the fragments directory")
+	}
+
+	fragments, err := ScanFragments(fragmentsDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(fragments) != 1 || !strings.HasSuffix(fragments[0].Path, "kept.md") {
+		t.Errorf("Expected archived fragments to be excluded from the scan, got %v", fragments)
+	}
+
+	restored, err := RestoreFragment(fragmentsDir, "react/hooks.md")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if restored != original {
+		t.Errorf("Expected restored path %s, got %s", original, restored)
+	}
+
+	if _, err := os.Stat(archived); !os.IsNotExist(err) {
+		t.Error("Expected the fragment to be moved out of the archive")
+	}
+}
+
+func TestArchiveFragmentErrors(t *testing.T) {
+	fragmentsDir := t.TempDir()
+	writeArchiveTestFragment(t, filepath.Join(fragmentsDir, "go.md"))
+	writeArchiveTestFragment(t, filepath.Join(ArchiveDir(fragmentsDir), "go.md"))
+
+	if _, err := ArchiveFragment(fragmentsDir, "go"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expected error for an existing archived fragment, got %v", err)
+	}
+
+	if _, err := ArchiveFragment(fragmentsDir, "missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+
+	if _, err := ArchiveFragment(fragmentsDir, "../outside"); err == nil || !strings.Contains(err.Error(), "invalid fragment name") {
+		t.Errorf("Expected invalid name error, got %v", err)
+	}
+}
+
+func TestScanFragmentsExcludeDirs(t *testing.T) {
+	fragmentsDir := t.TempDir()
+	writeArchiveTestFragment(t, filepath.Join(fragmentsDir, "a.md"))
+	writeArchiveTestFragment(t, filepath.Join(fragmentsDir, "drafts", "b.md"))
+	writeArchiveTestFragment(t, filepath.Join(fragmentsDir, "nested", "drafts", "c.md"))
+
+	fragments, err := ScanFragmentsWithOptions(fragmentsDir, ScanOptions{ExcludeDirs: []string{"drafts"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var names []string
+	for _, fragment := range fragments {
+		names = append(names, filepath.Base(fragment.Path))
+	}
+
+	if strings.Join(names, ",") != "a.md,c.md" {
+		t.Errorf("Expected only the top-level drafts directory to be excluded, got %v", names)
+	}
+}
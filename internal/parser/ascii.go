@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// asciiReplacements are the ASCII equivalents of common typographic characters that
+// have no decomposition to ASCII.
+var asciiReplacements = map[rune]string{
+	'‐': "-",  // hyphen
+	'‒': "-",  // figure dash
+	'–': "-",  // en dash
+	'—': "--", // em dash
+	'―': "--", // horizontal bar
+	'‘': "'",  // left single quotation mark
+	'’': "'",  // right single quotation mark
+	'‚': "'",  // single low-9 quotation mark
+	'“': "\"", // left double quotation mark
+	'”': "\"", // right double quotation mark
+	'„': "\"", // double low-9 quotation mark
+	'•': "*",  // bullet
+	'′': "'",  // prime
+	'→': "->", // rightwards arrow
+	'←': "<-", // leftwards arrow
+	'«': "<<", // left-pointing double angle quotation mark
+	'»': ">>", // right-pointing double angle quotation mark
+	'×': "x",  // multiplication sign
+	'ß': "ss", // sharp s
+	'æ': "ae", // ae
+	'Æ': "AE", // AE
+	'ø': "o",  // o with stroke
+	'Ø': "O",  // O with stroke
+	'œ': "oe", // oe
+	'Œ': "OE", // OE
+	'ł': "l",  // l with stroke
+	'Ł': "L",  // L with stroke
+}
+
+// ToASCII transliterates s to ASCII. Accented letters lose their accents (é becomes e),
+// common typographic characters are replaced with their ASCII equivalents (— becomes --)
+// and all other non-ASCII characters are replaced with '?'.
+func ToASCII(s string) string {
+	var result strings.Builder
+
+	for _, r := range norm.NFKD.String(s) {
+		switch {
+		case r <= unicode.MaxASCII:
+			result.WriteRune(r)
+		case unicode.Is(unicode.Mn, r):
+			// Combining marks split off by the decomposition, such as accents.
+		default:
+			if replacement, ok := asciiReplacements[r]; ok {
+				result.WriteString(replacement)
+			} else {
+				result.WriteByte('?')
+			}
+		}
+	}
+
+	return result.String()
+}
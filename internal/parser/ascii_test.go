@@ -0,0 +1,23 @@
+package parser
+
+import "testing"
+
+func TestToASCII(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "plain ASCII\n", expected: "plain ASCII\n"},
+		{input: "café — naïve", expected: "cafe -- naive"},
+		{input: "“quoted” ‘text’…", expected: "\"quoted\" 'text'..."},
+		{input: "Straße Œuvre", expected: "Strasse OEuvre"},
+		{input: "ﬁle", expected: "file"},
+		{input: "日本 ✓", expected: "?? ?"},
+	}
+
+	for _, tt := range tests {
+		if got := ToASCII(tt.input); got != tt.expected {
+			t.Errorf("ToASCII(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
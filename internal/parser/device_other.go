@@ -0,0 +1,9 @@
+//go:build !unix
+
+package parser
+
+// deviceID reports that the device of path is unknown on platforms without device IDs,
+// so directory walks do not stop at filesystem boundaries there.
+func deviceID(string) (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ctxDescriptionLineRegex matches a ctx-description key line.
+var ctxDescriptionLineRegex = regexp.MustCompile(`^ctx-description:`)
+
+// DuplicateFragment copies the fragment file at srcPath to dstPath and sets its
+// ctx-description to "Copy of <name>", where name is the source filename without
+// extension. An existing description is replaced and a frontmatter block is added if
+// the source has none; everything else is copied unchanged. dstPath is overwritten if
+// it exists.
+func DuplicateFragment(srcPath, dstPath string) error {
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", srcPath, err)
+	}
+
+	data, err := os.ReadFile(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", srcPath, err)
+	}
+
+	lines := strings.Split(string(data), "\n")
+	if _, delimiter := frontmatterBlock(lines); delimiter == tomlDelimiter {
+		return errTOMLFrontmatter(srcPath)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(srcPath), filepath.Ext(srcPath))
+	lines = setDescription(lines, "Copy of "+name)
+
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0o750); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", dstPath, err)
+	}
+
+	if err := os.WriteFile(dstPath, []byte(strings.Join(lines, "\n")), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dstPath, err)
+	}
+
+	return nil
+}
+
+// setDescription returns lines with the ctx-description in the frontmatter set to
+// description. Indented continuation lines of a replaced description are removed.
+func setDescription(lines []string, description string) []string {
+	descriptionLine := "ctx-description: " + quoteYAMLValue(description)
+	mask := frontmatterMask(lines)
+
+	start, end := -1, -1
+
+	for i, line := range lines {
+		if mask[i] && strings.TrimSpace(line) == "---" {
+			if start < 0 {
+				start = i
+				continue
+			}
+
+			end = i
+
+			break
+		}
+	}
+
+	if start < 0 || end < 0 {
+		return append([]string{"---", descriptionLine, "---"}, lines...)
+	}
+
+	result := make([]string, 0, len(lines)+1)
+	result = append(result, lines[:start+1]...)
+
+	for i := start + 1; i < end; i++ {
+		if !ctxDescriptionLineRegex.MatchString(lines[i]) {
+			result = append(result, lines[i])
+			continue
+		}
+
+		// Skip the continuation lines of a multi-line value
+		for i+1 < end && (strings.HasPrefix(lines[i+1], " ") || strings.HasPrefix(lines[i+1], "\t")) {
+			i++
+		}
+	}
+
+	result = append(result, descriptionLine)
+
+	return append(result, lines[end:]...)
+}
+
+// quoteYAMLValue returns value as a YAML scalar, double-quoted when it contains
+// characters that would otherwise change its meaning.
+func quoteYAMLValue(value string) string {
+	if strings.ContainsAny(value, ":#'\"[]{},&*!|>%@`") || strings.TrimSpace(value) != value {
+		return fmt.Sprintf("%q", value)
+	}
+
+	return value
+}
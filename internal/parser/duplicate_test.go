@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDuplicateFragment(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "description added",
+			content:  "---\nctx-tags: go\nctx-priority: 2\n---\n# Style\nBody\n",
+			expected: "---\nctx-tags: go\nctx-priority: 2\nctx-description: Copy of style\n---\n# Style\nBody\n",
+		},
+		{
+			name:     "description replaced",
+			content:  "---\nctx-description: >\n  A long\n  description\nctx-tags: go\n---\nBody",
+			expected: "---\nctx-tags: go\nctx-description: Copy of style\n---\nBody",
+		},
+		{
+			name:     "frontmatter added",
+			content:  "Body only\n",
+			expected: "---\nctx-description: Copy of style\n---\nBody only\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			srcPath := filepath.Join(dir, "style.md")
+			dstPath := filepath.Join(dir, "style-copy.md")
+
+			if err := os.WriteFile(srcPath, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("Failed to create fragment: %v", err)
+			}
+
+			if err := DuplicateFragment(srcPath, dstPath); err != nil {
+				t.Fatalf("DuplicateFragment failed: %v", err)
+			}
+
+			data, err := os.ReadFile(dstPath)
+			if err != nil {
+				t.Fatalf("Failed to read duplicate: %v", err)
+			}
+
+			if string(data) != tt.expected {
+				t.Errorf("Expected:\n%q\ngot:\n%q", tt.expected, data)
+			}
+
+			source, err := os.ReadFile(srcPath)
+			if err != nil || string(source) != tt.content {
+				t.Errorf("Expected the source to be unchanged, got %q, %v", source, err)
+			}
+
+			frontmatter, err := ReadFrontmatter(dstPath)
+			if err != nil {
+				t.Fatalf("ReadFrontmatter failed: %v", err)
+			}
+
+			if frontmatter["ctx-description"] != "Copy of style" {
+				t.Errorf("Expected description %q, got %v", "Copy of style", frontmatter["ctx-description"])
+			}
+		})
+	}
+}
+
+func TestDuplicateFragmentQuotesDescription(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "a: b.md")
+
+	if err := os.WriteFile(srcPath, []byte("---\nctx-tags: go\n---\nBody"), 0o600); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+
+	dstPath := filepath.Join(dir, "copy.md")
+	if err := DuplicateFragment(srcPath, dstPath); err != nil {
+		t.Fatalf("DuplicateFragment failed: %v", err)
+	}
+
+	fragment, err := ParseFragment(dstPath)
+	if err != nil {
+		t.Fatalf("ParseFragment failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(fragment.Tags, []string{"go"}) {
+		t.Errorf("Expected tags [go], got %v", fragment.Tags)
+	}
+
+	frontmatter, err := ReadFrontmatter(dstPath)
+	if err != nil {
+		t.Fatalf("ReadFrontmatter failed: %v", err)
+	}
+
+	if frontmatter["ctx-description"] != "Copy of a: b" {
+		t.Errorf("Expected description %q, got %v", "Copy of a: b", frontmatter["ctx-description"])
+	}
+}
@@ -2,59 +2,279 @@ package parser
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 )
 
 // Fragment represents a markdown fragment with its metadata.
 type Fragment struct {
-	Path    string   `json:"path"`
-	Tags    []string `json:"tags"`
-	Content string   `json:"content"`
+	Path     string   `json:"path"`
+	Tags     []string `json:"tags"`
+	Content  string   `json:"content"`
+	Includes []string `json:"includes,omitempty"`
+	Priority int      `json:"priority,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+	// Checksum is the hex SHA-256 of Content, set when the fragment is parsed.
+	Checksum string `json:"checksum,omitempty"`
+	// ModTime is the latest modification time of the fragment file and the files it includes.
+	ModTime time.Time `json:"modTime,omitzero"`
+	// Vars are the variables declared with ctx-var-* frontmatter keys, by name without the prefix.
+	Vars map[string]string `json:"vars,omitempty"`
+	// Description is the ctx-description of the fragment.
+	Description string `json:"description,omitempty"`
+	// RawFrontmatter is the unparsed text between the frontmatter delimiters, for tools
+	// that read keys ctx does not know about.
+	RawFrontmatter string `json:"rawFrontmatter,omitempty"`
+	// FrontmatterDelimiter is the line enclosing RawFrontmatter: "---" for YAML and "+++"
+	// for TOML frontmatter.
+	FrontmatterDelimiter string `json:"frontmatterDelimiter,omitempty"`
 }
 
-// ScanFragments scans the fragments directory and returns all found fragments.
+// ScanOptions controls how fragments directories are scanned.
+type ScanOptions struct {
+	// NamespaceFromDir adds the subdirectories a fragment lives in as implicit tags.
+	NamespaceFromDir bool
+	// ExcludeDirs are directories, relative to the fragments directory, that are not scanned
+	// in addition to DefaultExcludeDirs.
+	ExcludeDirs []string
+	// NoNormalize keeps the content of the fragments as written, see ParseOptions.
+	NoNormalize bool
+	// IncludeRoot confines the includes of the fragments, see ParseOptions.
+	IncludeRoot string
+}
+
+// ParseOptions controls how a fragment file is parsed.
+type ParseOptions struct {
+	// NoNormalize keeps the content as written instead of applying NormalizeContent.
+	NoNormalize bool
+	// IncludeRoot, if set, is the directory all ctx-include files must be inside; includes
+	// resolving outside of it are an error. Fragments from untrusted sources set it so they
+	// cannot pull arbitrary local files into the output.
+	IncludeRoot string
+}
+
+// ParseOptions returns the options each fragment file of the scan is parsed with.
+func (o ScanOptions) ParseOptions() ParseOptions {
+	return ParseOptions{NoNormalize: o.NoNormalize, IncludeRoot: o.IncludeRoot}
+}
+
+// DefaultExcludeDirs are never scanned for fragments; archived fragments live there.
+var DefaultExcludeDirs = []string{ArchiveDirName}
+
+// ScanFragments scans the fragments directory and returns all found fragments sorted by path.
 func ScanFragments(fragmentsDir string) ([]Fragment, error) {
+	return ScanFragmentsWithOptions(fragmentsDir, ScanOptions{})
+}
+
+// ScanFragmentsWithOptions scans the fragments directory like ScanFragments using the given options.
+// A fragment whose frontmatter (or that of a file it includes) cannot be decoded is skipped
+// with a warning on stderr, so one broken file does not hide all others; other parse errors
+// fail the scan.
+func ScanFragmentsWithOptions(fragmentsDir string, opts ScanOptions) ([]Fragment, error) {
+	paths, err := findFragmentFiles(fragmentsDir, append(slices.Clone(DefaultExcludeDirs), opts.ExcludeDirs...))
+	if err != nil {
+		return nil, err
+	}
+
 	var fragments []Fragment
 
+	for _, path := range paths {
+		fragment, err := ParseFragmentWithOptions(path, opts.ParseOptions())
+		if errors.Is(err, ErrInvalidFrontmatter) {
+			fmt.Fprintf(os.Stderr, "Warning: skipped fragment %s: %v\n", path, err)
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse fragment %s: %w", path, err)
+		}
+
+		if opts.NamespaceFromDir {
+			fragment.Tags = addTags(fragment.Tags, NamespaceTags(fragmentsDir, path))
+		}
+
+		fragments = append(fragments, *fragment)
+	}
+
+	return fragments, nil
+}
+
+// NamespaceTags returns the implicit tags of a fragment from the directories between the
+// fragments directory and the fragment file, e.g. fragments/react/hooks.md yields "react".
+func NamespaceTags(fragmentsDir, path string) []string {
+	rel, err := filepath.Rel(fragmentsDir, filepath.Dir(path))
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return nil
+	}
+
+	return strings.Split(filepath.ToSlash(rel), "/")
+}
+
+// addTags appends the extra tags that are not already present in tags.
+func addTags(tags, extra []string) []string {
+	for _, tag := range extra {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// FindFragmentFiles returns the paths of all markdown files in the fragments directory, sorted by path.
+// Files and directories matching the patterns in the directory's .ctxignore file are skipped,
+// as are the DefaultExcludeDirs.
+func FindFragmentFiles(fragmentsDir string) ([]string, error) {
+	return findFragmentFiles(fragmentsDir, DefaultExcludeDirs)
+}
+
+// findFragmentFiles is FindFragmentFiles skipping the given directories relative to fragmentsDir.
+func findFragmentFiles(fragmentsDir string, excludeDirs []string) ([]string, error) {
+	var paths []string
+
 	if _, err := os.Stat(fragmentsDir); os.IsNotExist(err) {
-		return fragments, nil // Return empty slice if directory doesn't exist
+		return paths, nil // Return empty slice if directory doesn't exist
 	}
 
-	err := filepath.Walk(fragmentsDir, func(path string, info os.FileInfo, err error) error {
+	ignore, err := loadIgnoreFile(fragmentsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", IgnoreFileName, err)
+	}
+
+	err = filepath.Walk(fragmentsDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
-		// Only process markdown files
-		if !info.IsDir() && (strings.HasSuffix(path, ".md") || strings.HasSuffix(path, ".markdown")) {
-			fragment, err := ParseFragment(path)
-			if err != nil {
-				return fmt.Errorf("failed to parse fragment %s: %w", path, err)
+		if rel, relErr := filepath.Rel(fragmentsDir, path); relErr == nil && rel != "." {
+			rel = filepath.ToSlash(rel)
+
+			if info.IsDir() && slices.Contains(excludeDirs, rel) {
+				return filepath.SkipDir
 			}
 
-			fragments = append(fragments, *fragment)
+			if ignore.Match(rel, info.IsDir()) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+		}
+
+		// Only process markdown files
+		if !info.IsDir() && (strings.HasSuffix(path, ".md") || strings.HasSuffix(path, ".markdown")) {
+			paths = append(paths, path)
 		}
 
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	return fragments, err
+	// Sort by path so output is reproducible regardless of the walk order
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+// SortFragmentsByPriority returns the fragments ordered by ascending priority.
+// Fragments with equal priority keep their relative order.
+func SortFragmentsByPriority(fragments []Fragment) []Fragment {
+	sorted := slices.Clone(fragments)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority < sorted[j].Priority
+	})
+
+	return sorted
+}
+
+// Sort strategies accepted by SortFragments.
+const (
+	SortAlpha    = "alpha"
+	SortPriority = "priority"
+	SortMtime    = "mtime"
+)
+
+// SortStrategies lists the strategies accepted by SortFragments.
+var SortStrategies = []string{SortAlpha, SortPriority, SortMtime}
+
+// SortFragments returns the fragments ordered by strategy: SortAlpha orders by path,
+// SortPriority by ascending priority then path, and SortMtime by descending ModTime
+// then path. Unknown strategies sort like SortPriority.
+func SortFragments(fragments []Fragment, strategy string) []Fragment {
+	sorted := slices.Clone(fragments)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+
+		switch strategy {
+		case SortAlpha:
+		case SortMtime:
+			if !a.ModTime.Equal(b.ModTime) {
+				return a.ModTime.After(b.ModTime)
+			}
+		default:
+			if a.Priority != b.Priority {
+				return a.Priority < b.Priority
+			}
+		}
+
+		return a.Path < b.Path
+	})
+
+	return sorted
+}
+
+// LocalFragmentsDir returns the path of the local .ctx/fragments directory in the current working directory.
+func LocalFragmentsDir() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	return filepath.Join(cwd, ".ctx", "fragments"), nil
 }
 
 // ScanLocalFragments scans the local .ctx/fragments directory in the current working directory.
 func ScanLocalFragments() ([]Fragment, error) {
-	cwd, err := os.Getwd()
+	return ScanLocalFragmentsWithOptions(ScanOptions{})
+}
+
+// ScanLocalFragmentsWithOptions scans the local .ctx/fragments directory using the given options.
+func ScanLocalFragmentsWithOptions(opts ScanOptions) ([]Fragment, error) {
+	localFragmentsDir, err := LocalFragmentsDir()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get current working directory: %w", err)
+		return nil, err
 	}
 
-	localFragmentsDir := filepath.Join(cwd, ".ctx", "fragments")
+	return ScanFragmentsWithOptions(localFragmentsDir, opts)
+}
+
+// ScanFragmentsDirs scans several fragments directories in order. Fragments in later
+// directories take precedence over fragments with the same filename in earlier ones,
+// unless noOverride is set, in which case all fragments are included.
+func ScanFragmentsDirs(dirs []string, noOverride bool, opts ScanOptions) ([]Fragment, error) {
+	var fragments []Fragment
+
+	for _, dir := range dirs {
+		dirFragments, err := ScanFragmentsWithOptions(dir, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		fragments = CombineFragments(fragments, dirFragments, noOverride)
+	}
 
-	return ScanFragments(localFragmentsDir)
+	return fragments, nil
 }
 
 // CombineFragments combines global and local fragments, with optional override logic.
@@ -94,7 +314,145 @@ func CombineFragments(globalFragments, localFragments []Fragment, noLocalOverrid
 }
 
 // ParseFragment parses a single markdown file and extracts ctx-tags and content.
+// Files listed in ctx-include are resolved relative to the fragment's directory
+// and their content is prepended to the fragment's content. The content is normalized
+// with NormalizeContent.
 func ParseFragment(filePath string) (*Fragment, error) {
+	return ParseFragmentWithOptions(filePath, ParseOptions{})
+}
+
+// ParseFragmentWithOptions parses a fragment like ParseFragment using the given options.
+func ParseFragmentWithOptions(filePath string, opts ParseOptions) (*Fragment, error) {
+	return parseFragment(filePath, nil, opts)
+}
+
+// parseFragment parses a fragment, where chain holds the paths of the fragments
+// currently being included and is used to detect circular includes.
+func parseFragment(filePath string, chain []string, opts ParseOptions) (*Fragment, error) {
+	lines, err := readLines(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	var contentLines []string
+
+	mask := frontmatterMask(lines)
+
+	for i, line := range lines {
+		if !mask[i] {
+			// After frontmatter or no frontmatter detected, collect all content
+			contentLines = append(contentLines, line)
+		}
+	}
+
+	frontmatterLines, delimiter := frontmatterBlock(lines)
+
+	fm, err := parseFrontmatter(frontmatterLines, delimiter)
+	if err != nil {
+		return nil, err
+	}
+
+	includes, included, includedModTime, err := resolveIncludes(filePath, fm.Includes, append(slices.Clone(chain), filepath.Clean(filePath)), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	modTime := info.ModTime()
+	if includedModTime.After(modTime) {
+		modTime = includedModTime
+	}
+
+	content := strings.Join(append(included, contentLines...), "\n")
+	if !opts.NoNormalize {
+		content = NormalizeContent(content)
+	}
+
+	priority, warnings := fm.priority()
+	vars, varWarnings := fm.vars()
+
+	fragment := &Fragment{
+		Path:     filePath,
+		Tags:     fm.Tags,
+		Content:  content,
+		Includes: includes,
+		Priority: priority,
+		Warnings: append(warnings, varWarnings...),
+		ModTime:  modTime,
+		Vars:     vars,
+	}
+	fragment.Description = fm.Description
+	fragment.RawFrontmatter = strings.Join(frontmatterLines, "\n")
+	fragment.FrontmatterDelimiter = delimiter
+	fragment.Checksum = ComputeFragmentHash(*fragment)
+
+	return fragment, nil
+}
+
+// resolveIncludes parses the included files of the fragment at filePath and returns
+// their resolved paths and contents, and the latest modification time among them.
+func resolveIncludes(filePath string, names, chain []string, opts ParseOptions) ([]string, []string, time.Time, error) {
+	var paths []string
+
+	var contents []string
+
+	var latest time.Time
+
+	for _, name := range names {
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(filePath), name)
+		}
+
+		path = filepath.Clean(path)
+
+		if opts.IncludeRoot != "" && !withinDir(opts.IncludeRoot, path) {
+			return nil, nil, time.Time{}, fmt.Errorf("include %s is outside of %s", name, opts.IncludeRoot)
+		}
+
+		if slices.Contains(chain, path) {
+			return nil, nil, time.Time{}, fmt.Errorf("circular include: %s", strings.Join(append(chain, path), " -> "))
+		}
+
+		fragment, err := parseFragment(path, chain, opts)
+		if err != nil {
+			return nil, nil, time.Time{}, fmt.Errorf("failed to include %s: %w", name, err)
+		}
+
+		paths = append(paths, path)
+		contents = append(contents, fragment.Content)
+
+		if fragment.ModTime.After(latest) {
+			latest = fragment.ModTime
+		}
+	}
+
+	return paths, contents, latest, nil
+}
+
+// withinDir reports whether path is dir or lies below it.
+func withinDir(dir, path string) bool {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(absDir, absPath)
+
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// readLines reads all lines of a file.
+func readLines(filePath string) ([]string, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %w", err)
@@ -108,81 +466,244 @@ func ParseFragment(filePath string) (*Fragment, error) {
 
 	scanner := bufio.NewScanner(file)
 
-	var tags []string
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
 
-	var contentLines []string
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
 
-	var inFrontmatter bool
+	return lines, nil
+}
 
-	var frontmatterProcessed bool
+// Frontmatter delimiters: YAML frontmatter is enclosed in "---" lines and TOML
+// frontmatter in "+++" lines.
+const (
+	yamlDelimiter = "---"
+	tomlDelimiter = "+++"
+)
 
-	// Regex to match ctx-tags line
-	ctxTagsRegex := regexp.MustCompile(`^ctx-tags:\s*(.+)$`)
+// frontmatterMask reports for each line whether it belongs to the frontmatter block,
+// including its delimiters. Frontmatter is only recognized when the first line is a
+// delimiter, so that a later --- or +++ line, such as a Markdown horizontal rule, stays
+// content; the block is closed by the same delimiter that opened it.
+func frontmatterMask(lines []string) []bool {
+	mask := make([]bool, len(lines))
+	if len(lines) == 0 {
+		return mask
+	}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		// Check for frontmatter boundaries
-		if strings.TrimSpace(line) == "---" {
-			if !frontmatterProcessed {
-				inFrontmatter = !inFrontmatter
-				if !inFrontmatter {
-					frontmatterProcessed = true
-				}
+	delimiter := strings.TrimSpace(lines[0])
+	if delimiter != yamlDelimiter && delimiter != tomlDelimiter {
+		return mask
+	}
 
-				continue
-			}
+	mask[0] = true
+
+	for i := 1; i < len(lines); i++ {
+		mask[i] = true
+
+		if strings.TrimSpace(lines[i]) == delimiter {
+			break
 		}
+	}
 
-		// If we're in frontmatter, look for ctx-tags
-		if inFrontmatter {
-			if matches := ctxTagsRegex.FindStringSubmatch(line); matches != nil {
-				tagsStr := strings.TrimSpace(matches[1])
-				// Split by comma and clean up each tag
-				for _, tag := range strings.Split(tagsStr, ",") {
-					tag = strings.TrimSpace(tag)
-					if tag != "" {
-						tags = append(tags, tag)
-					}
-				}
-			}
-		} else {
-			// After frontmatter or no frontmatter detected, collect all content
-			contentLines = append(contentLines, line)
+	return mask
+}
+
+// frontmatterBlock returns the lines of the frontmatter block without its delimiters and
+// the delimiter, or nil and "" when there is no frontmatter.
+func frontmatterBlock(lines []string) ([]string, string) {
+	mask := frontmatterMask(lines)
+
+	var block []string
+
+	var delimiter string
+
+	for i, line := range lines {
+		switch {
+		case !mask[i]:
+			continue
+		case delimiter == "":
+			delimiter = strings.TrimSpace(line)
+		case strings.TrimSpace(line) == delimiter:
+			return block, delimiter
+		default:
+			block = append(block, line)
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading file: %w", err)
+	return block, delimiter
+}
+
+// errTOMLFrontmatter returns the error of the functions editing frontmatter, which only
+// support YAML frontmatter, for a fragment with TOML frontmatter.
+func errTOMLFrontmatter(path string) error {
+	return fmt.Errorf("%s: editing TOML frontmatter is not supported", path)
+}
+
+// SearchMatch is a single line of a fragment file matching a search.
+type SearchMatch struct {
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// SearchFragment returns the lines of a fragment file for which match returns true.
+// Line numbers refer to the file on disk. Frontmatter lines are only searched when
+// includeFrontmatter is set.
+func SearchFragment(fragment Fragment, match func(line string) bool, includeFrontmatter bool) ([]SearchMatch, error) {
+	lines, err := readLines(fragment.Path)
+	if err != nil {
+		return nil, err
 	}
 
-	content := strings.Join(contentLines, "\n")
+	mask := frontmatterMask(lines)
+
+	var matches []SearchMatch
+
+	for i, line := range lines {
+		if mask[i] && !includeFrontmatter {
+			continue
+		}
 
-	return &Fragment{
-		Path:    filePath,
-		Tags:    tags,
-		Content: content,
-	}, nil
+		if match(line) {
+			matches = append(matches, SearchMatch{Line: i + 1, Text: line})
+		}
+	}
+
+	return matches, nil
 }
 
-// GetAllTags extracts all unique tags from a slice of fragments.
-func GetAllTags(fragments []Fragment) []string {
-	tagSet := make(map[string]bool)
+// TagInfo describes a tag and the fragments using it.
+type TagInfo struct {
+	Tag string `json:"tag"`
+	// Count is the number of fragments using the tag.
+	Count int `json:"count"`
+	// Fragments are the paths of the fragments using the tag, in the order given.
+	Fragments []string `json:"fragments"`
+}
+
+// GetAllTagInfo returns every unique tag of the fragments, sorted by tag, with the
+// fragments using it. A fragment listing a tag more than once is counted once.
+func GetAllTagInfo(fragments []Fragment) []TagInfo {
+	byTag := make(map[string]*TagInfo)
 
 	for _, fragment := range fragments {
+		seen := make(map[string]bool, len(fragment.Tags))
+
 		for _, tag := range fragment.Tags {
-			tagSet[tag] = true
+			if seen[tag] {
+				continue
+			}
+
+			seen[tag] = true
+
+			info, ok := byTag[tag]
+			if !ok {
+				info = &TagInfo{Tag: tag}
+				byTag[tag] = info
+			}
+
+			info.Count++
+			info.Fragments = append(info.Fragments, fragment.Path)
 		}
 	}
 
+	infos := make([]TagInfo, 0, len(byTag))
+	for _, info := range byTag {
+		infos = append(infos, *info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Tag < infos[j].Tag
+	})
+
+	return infos
+}
+
+// GetAllTags extracts all unique tags from a slice of fragments in sorted order.
+func GetAllTags(fragments []Fragment) []string {
+	var tags []string
+	for _, info := range GetAllTagInfo(fragments) {
+		tags = append(tags, info.Tag)
+	}
+
+	return tags
+}
+
+// TagsWithPrefix returns the tags of the fragments that start with prefix, in sorted order.
+func TagsWithPrefix(prefix string, fragments []Fragment) []string {
 	var tags []string
-	for tag := range tagSet {
-		tags = append(tags, tag)
+
+	for _, tag := range GetAllTags(fragments) {
+		if strings.HasPrefix(tag, prefix) {
+			tags = append(tags, tag)
+		}
 	}
 
 	return tags
 }
 
+// globChars are the characters that make a tag a glob pattern for ExpandGlobTags.
+const globChars = "*?["
+
+// ExpandGlobTags returns the tags in allTags matching pattern with filepath.Match
+// semantics, e.g. lang-* matches lang-typescript and lang-rust, in the order of allTags.
+// A pattern without wildcards, or a malformed one, is returned as a literal tag.
+func ExpandGlobTags(pattern string, allTags []string) []string {
+	if !strings.ContainsAny(pattern, globChars) {
+		return []string{pattern}
+	}
+
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return []string{pattern}
+	}
+
+	var matches []string
+
+	for _, tag := range allTags {
+		if matched, _ := filepath.Match(pattern, tag); matched {
+			matches = append(matches, tag)
+		}
+	}
+
+	return matches
+}
+
+// ExpandGlobTagTerms expands the glob patterns in tag terms as accepted by
+// FilterFragmentsByTagExpression with ExpandGlobTags. A term joined with "+" becomes one
+// term per combination of matching tags, so lang-*+strict selects fragments tagged strict
+// and any lang- tag. Terms with a pattern that matches no tag are dropped.
+func ExpandGlobTagTerms(terms, allTags []string) []string {
+	var expanded []string
+
+	for _, term := range terms {
+		combinations := [][]string{{}}
+
+		for _, tag := range parseTagTerm(term) {
+			var next [][]string
+
+			for _, combination := range combinations {
+				for _, match := range ExpandGlobTags(tag, allTags) {
+					next = append(next, append(slices.Clone(combination), match))
+				}
+			}
+
+			combinations = next
+		}
+
+		for _, combination := range combinations {
+			if joined := strings.Join(combination, "+"); !slices.Contains(expanded, joined) {
+				expanded = append(expanded, joined)
+			}
+		}
+	}
+
+	return expanded
+}
+
 // FilterFragmentsByTags returns fragments that contain any of the specified tags.
 func FilterFragmentsByTags(fragments []Fragment, selectedTags []string) []Fragment {
 	if len(selectedTags) == 0 {
@@ -207,3 +728,173 @@ func FilterFragmentsByTags(fragments []Fragment, selectedTags []string) []Fragme
 
 	return filtered
 }
+
+// ExpandAliases replaces every tag that names an alias with the tags it maps to.
+// Aliases may reference other aliases; circular references return an error.
+// Tags that are not aliases are kept as-is and duplicates are removed, preserving order.
+func ExpandAliases(tags []string, aliases map[string][]string) ([]string, error) {
+	return expandNames(tags, aliases, "alias")
+}
+
+// ExpandTagGroups replaces every tag that names a tag group with the tags of the group.
+// Groups may reference other groups; circular references return an error.
+// Tags that are not groups are kept as-is and duplicates are removed, preserving order.
+func ExpandTagGroups(tags []string, groups map[string][]string) ([]string, error) {
+	return expandNames(tags, groups, "tag group")
+}
+
+// expandNames expands tags naming an entry of definitions; kind is used in error messages.
+func expandNames(tags []string, definitions map[string][]string, kind string) ([]string, error) {
+	var expanded []string
+
+	seen := make(map[string]bool)
+
+	for _, tag := range tags {
+		if err := expandName(tag, definitions, kind, nil, seen, &expanded); err != nil {
+			return nil, err
+		}
+	}
+
+	return expanded, nil
+}
+
+// expandName recursively expands a single tag, tracking the definition chain to detect cycles.
+func expandName(tag string, definitions map[string][]string, kind string, chain []string, seen map[string]bool, expanded *[]string) error {
+	targets, isDefined := definitions[tag]
+	if !isDefined {
+		if !seen[tag] {
+			seen[tag] = true
+			*expanded = append(*expanded, tag)
+		}
+
+		return nil
+	}
+
+	for _, name := range chain {
+		if name == tag {
+			return fmt.Errorf("circular %s reference: %s", kind, strings.Join(append(chain, tag), " -> "))
+		}
+	}
+
+	chain = append(chain, tag)
+	for _, target := range targets {
+		if err := expandName(target, definitions, kind, chain, seen, expanded); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FilterFragmentsByTagExpression returns fragments matching any of the given tag terms.
+// Each term is either a single tag or several tags joined with "+", in which case a
+// fragment must carry all of them. For example the terms ["typescript", "rust+strict"]
+// select fragments tagged typescript OR (rust AND strict). Terms with empty parts
+// around "+" (such as "c++") are treated as literal tags.
+func FilterFragmentsByTagExpression(fragments []Fragment, terms []string) []Fragment {
+	if len(terms) == 0 {
+		return fragments
+	}
+
+	groups := make([][]string, 0, len(terms))
+	for _, term := range terms {
+		groups = append(groups, parseTagTerm(term))
+	}
+
+	var filtered []Fragment
+
+	for _, fragment := range fragments {
+		fragmentTags := make(map[string]bool, len(fragment.Tags))
+		for _, tag := range fragment.Tags {
+			fragmentTags[tag] = true
+		}
+
+		for _, group := range groups {
+			if hasAllTags(fragmentTags, group) {
+				filtered = append(filtered, fragment)
+				break
+			}
+		}
+	}
+
+	return filtered
+}
+
+// ValidateTags returns the tags that no fragment carries, in the order given and without
+// duplicates. Tags are tag terms as accepted by FilterFragmentsByTagExpression, so every
+// tag of a term joined with "+" is checked on its own.
+func ValidateTags(tags []string, fragments []Fragment) []string {
+	known := make(map[string]bool)
+
+	for _, fragment := range fragments {
+		for _, tag := range fragment.Tags {
+			known[tag] = true
+		}
+	}
+
+	var unknown []string
+
+	for _, term := range tags {
+		for _, tag := range parseTagTerm(term) {
+			if !known[tag] && !slices.Contains(unknown, tag) {
+				unknown = append(unknown, tag)
+			}
+		}
+	}
+
+	return unknown
+}
+
+// FilterFragmentsModifiedSince returns the fragments whose ModTime is after since.
+func FilterFragmentsModifiedSince(fragments []Fragment, since time.Time) []Fragment {
+	var filtered []Fragment
+
+	for _, fragment := range fragments {
+		if fragment.ModTime.After(since) {
+			filtered = append(filtered, fragment)
+		}
+	}
+
+	return filtered
+}
+
+// ExcludeFragmentsByTags returns the fragments that carry none of the ignored tags.
+func ExcludeFragmentsByTags(fragments []Fragment, ignoreTags []string) []Fragment {
+	if len(ignoreTags) == 0 {
+		return fragments
+	}
+
+	var filtered []Fragment
+
+	for _, fragment := range fragments {
+		if !slices.ContainsFunc(fragment.Tags, func(tag string) bool { return slices.Contains(ignoreTags, tag) }) {
+			filtered = append(filtered, fragment)
+		}
+	}
+
+	return filtered
+}
+
+// parseTagTerm splits an AND term into its tags.
+func parseTagTerm(term string) []string {
+	parts := strings.Split(term, "+")
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+		if parts[i] == "" {
+			return []string{term}
+		}
+	}
+
+	return parts
+}
+
+// hasAllTags reports whether every tag in required is present in tags.
+func hasAllTags(tags map[string]bool, required []string) bool {
+	for _, tag := range required {
+		if !tags[tag] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -1,9 +1,16 @@
 package parser
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
 )
 
 func TestParseFragment(t *testing.T) {
@@ -48,6 +55,31 @@ ctx-tags: rust
 				Content: "\n# Rust Guidelines",
 			},
 		},
+		{
+			name: "fragment with flow sequence tags",
+			content: `---
+ctx-tags: [typescript, rust]
+---
+# Flow`,
+			expected: Fragment{
+				Tags:    []string{"typescript", "rust"},
+				Content: "# Flow",
+			},
+		},
+		{
+			name: "fragment with block sequence tags",
+			content: `---
+ctx-tags:
+  - typescript
+  - rust
+ctx-description: other keys are ignored
+---
+# Block`,
+			expected: Fragment{
+				Tags:    []string{"typescript", "rust"},
+				Content: "# Block",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -100,25 +132,184 @@ func TestGetAllTags(t *testing.T) {
 
 	tags := GetAllTags(fragments)
 
-	expected := map[string]bool{
-		"typescript": true,
-		"frontend":   true,
-		"rust":       true,
-		"systems":    true,
-		"web":        true,
+	expected := []string{"frontend", "rust", "systems", "typescript", "web"}
+
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("Expected sorted tags %v, got %v", expected, tags)
+	}
+}
+
+func TestTagsWithPrefix(t *testing.T) {
+	fragments := []Fragment{
+		{Tags: []string{"lang-typescript", "env-production"}},
+		{Tags: []string{"lang-rust", "language"}},
+	}
+
+	expected := []string{"lang-rust", "lang-typescript"}
+	if tags := TagsWithPrefix("lang-", fragments); !reflect.DeepEqual(tags, expected) {
+		t.Errorf("Expected tags %v, got %v", expected, tags)
 	}
 
-	if len(tags) != len(expected) {
-		t.Errorf("Expected %d unique tags, got %d", len(expected), len(tags))
+	if tags := TagsWithPrefix("os-", fragments); len(tags) != 0 {
+		t.Errorf("Expected no tags for an unmatched prefix, got %v", tags)
+	}
+}
+
+func TestExpandGlobTags(t *testing.T) {
+	allTags := []string{"env-production", "lang-rust", "lang-typescript"}
+
+	tests := []struct {
+		pattern  string
+		expected []string
+	}{
+		{pattern: "lang-*", expected: []string{"lang-rust", "lang-typescript"}},
+		{pattern: "*-production", expected: []string{"env-production"}},
+		{pattern: "lang-?ust", expected: []string{"lang-rust"}},
+		{pattern: "os-*", expected: nil},
+		{pattern: "typescript", expected: []string{"typescript"}},
+		{pattern: "lang-[", expected: []string{"lang-["}},
 	}
 
-	for _, tag := range tags {
-		if !expected[tag] {
-			t.Errorf("Unexpected tag: %s", tag)
+	for _, tt := range tests {
+		if got := ExpandGlobTags(tt.pattern, allTags); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("ExpandGlobTags(%q) = %v, expected %v", tt.pattern, got, tt.expected)
 		}
 	}
 }
 
+func TestExpandGlobTagTerms(t *testing.T) {
+	allTags := []string{"env-production", "lang-rust", "lang-typescript", "strict"}
+
+	terms := []string{"lang-*", "lang-*+strict", "c++", "os-*", "lang-rust"}
+	expected := []string{"lang-rust", "lang-typescript", "lang-rust+strict", "lang-typescript+strict", "c++"}
+
+	if got := ExpandGlobTagTerms(terms, allTags); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected terms %v, got %v", expected, got)
+	}
+}
+
+func TestGetAllTagInfo(t *testing.T) {
+	fragments := []Fragment{
+		{Path: "a.md", Tags: []string{"typescript", "frontend"}},
+		{Path: "b.md", Tags: []string{"rust", "rust"}},
+		{Path: "c.md", Tags: []string{"typescript", "web"}},
+		{Path: "d.md", Tags: []string{"typescript"}},
+	}
+
+	expected := []TagInfo{
+		{Tag: "frontend", Count: 1, Fragments: []string{"a.md"}},
+		{Tag: "rust", Count: 1, Fragments: []string{"b.md"}},
+		{Tag: "typescript", Count: 3, Fragments: []string{"a.md", "c.md", "d.md"}},
+		{Tag: "web", Count: 1, Fragments: []string{"c.md"}},
+	}
+
+	if infos := GetAllTagInfo(fragments); !reflect.DeepEqual(infos, expected) {
+		t.Errorf("Expected tag info %v, got %v", expected, infos)
+	}
+
+	if infos := GetAllTagInfo(nil); len(infos) != 0 {
+		t.Errorf("Expected no tag info without fragments, got %v", infos)
+	}
+}
+
+func TestValidateTags(t *testing.T) {
+	fragments := []Fragment{
+		{Path: "a.md", Tags: []string{"typescript", "c++"}},
+		{Path: "b.md", Tags: []string{"rust", "strict"}},
+	}
+
+	tags := []string{"typescript", "typscript", "rust+strict", "rust+unsafe", "c++", "typscript"}
+	expected := []string{"typscript", "unsafe"}
+
+	if unknown := ValidateTags(tags, fragments); !reflect.DeepEqual(unknown, expected) {
+		t.Errorf("Expected unknown tags %v, got %v", expected, unknown)
+	}
+
+	if unknown := ValidateTags([]string{"rust", "typescript"}, fragments); len(unknown) != 0 {
+		t.Errorf("Expected no unknown tags, got %v", unknown)
+	}
+}
+
+func TestScanFragmentsSortedByPath(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Create fragments in reverse-alphabetical order
+	names := []string{"zeta.md", "mu.md", "alpha.md"}
+	for _, name := range names {
+		content := "---\nctx-tags: test\n---\n# " + name
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create fragment %s: %v", name, err)
+		}
+	}
+
+	fragments, err := ScanFragments(tmpDir)
+	if err != nil {
+		t.Fatalf("ScanFragments failed: %v", err)
+	}
+
+	paths := make([]string, 0, len(fragments))
+	for _, fragment := range fragments {
+		paths = append(paths, filepath.Base(fragment.Path))
+	}
+
+	expected := []string{"alpha.md", "mu.md", "zeta.md"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected fragments in order %v, got %v", expected, paths)
+	}
+}
+
+func TestScanFragmentsSkipsInvalidFrontmatter(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"broken.md":   "---\nctx-tags: [go\n---\n# Broken",
+		"includer.md": "---\nctx-tags: go\nctx-include: broken.md\n---\n# Includer",
+		"valid.md":    "---\nctx-tags: go\n---\n# Valid",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create fragment %s: %v", name, err)
+		}
+	}
+
+	fragments, err := ScanFragments(tmpDir)
+	if err != nil {
+		t.Fatalf("ScanFragments failed: %v", err)
+	}
+
+	if len(fragments) != 1 || filepath.Base(fragments[0].Path) != "valid.md" {
+		t.Errorf("Expected only valid.md to be scanned, got %v", fragments)
+	}
+
+	if _, err := ParseFragment(filepath.Join(tmpDir, "broken.md")); !errors.Is(err, ErrInvalidFrontmatter) {
+		t.Errorf("Expected ParseFragment to report ErrInvalidFrontmatter, got %v", err)
+	}
+}
+
+func TestScanFragmentsHorizontalRuleWithoutFrontmatter(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Only a delimiter on the first line opens frontmatter, so these rules stay content
+	content := "# Rules\n\n---\nkey: [not frontmatter\n---\n\nMore rules"
+	if err := os.WriteFile(filepath.Join(tmpDir, "rules.md"), []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+
+	fragments, err := ScanFragments(tmpDir)
+	if err != nil {
+		t.Fatalf("ScanFragments failed: %v", err)
+	}
+
+	if len(fragments) != 1 {
+		t.Fatalf("Expected the fragment to be scanned, got %v", fragments)
+	}
+
+	if fragments[0].Content != content {
+		t.Errorf("Expected the horizontal rules to stay content, got %q", fragments[0].Content)
+	}
+}
+
 func TestFilterFragmentsByTags(t *testing.T) {
 	fragments := []Fragment{
 		{Path: "typescript.md", Tags: []string{"typescript", "frontend"}},
@@ -170,3 +361,689 @@ func TestFilterFragmentsByTags(t *testing.T) {
 		})
 	}
 }
+
+func TestExpandAliases(t *testing.T) {
+	aliases := map[string][]string{
+		"ts":    {"react-with-typescript-strict"},
+		"web":   {"ts", "css"},
+		"loopA": {"loopB"},
+		"loopB": {"loopA"},
+	}
+
+	tests := []struct {
+		name        string
+		tags        []string
+		expected    []string
+		expectError bool
+	}{
+		{
+			name:     "no aliases used",
+			tags:     []string{"rust", "go"},
+			expected: []string{"rust", "go"},
+		},
+		{
+			name:     "single alias",
+			tags:     []string{"ts"},
+			expected: []string{"react-with-typescript-strict"},
+		},
+		{
+			name:     "nested alias with duplicates",
+			tags:     []string{"web", "css", "ts"},
+			expected: []string{"react-with-typescript-strict", "css"},
+		},
+		{
+			name:        "circular alias",
+			tags:        []string{"loopA"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ExpandAliases(tt.tags, aliases)
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected tags %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestFilterFragmentsByTagExpression(t *testing.T) {
+	fragments := []Fragment{
+		{Path: "typescript.md", Tags: []string{"typescript", "frontend"}},
+		{Path: "typescript-strict.md", Tags: []string{"typescript", "strict-mode"}},
+		{Path: "rust.md", Tags: []string{"rust"}},
+		{Path: "rust-strict.md", Tags: []string{"rust", "strict"}},
+		{Path: "cpp.md", Tags: []string{"c++"}},
+	}
+
+	tests := []struct {
+		name          string
+		terms         []string
+		expectedPaths []string
+	}{
+		{
+			name:          "OR semantics",
+			terms:         []string{"frontend", "rust"},
+			expectedPaths: []string{"typescript.md", "rust.md", "rust-strict.md"},
+		},
+		{
+			name:          "AND semantics",
+			terms:         []string{"typescript+strict-mode"},
+			expectedPaths: []string{"typescript-strict.md"},
+		},
+		{
+			name:          "mixed OR and AND",
+			terms:         []string{"frontend", "rust+strict"},
+			expectedPaths: []string{"typescript.md", "rust-strict.md"},
+		},
+		{
+			name:          "AND with no match",
+			terms:         []string{"typescript+rust"},
+			expectedPaths: []string{},
+		},
+		{
+			name:          "literal plus in tag",
+			terms:         []string{"c++"},
+			expectedPaths: []string{"cpp.md"},
+		},
+		{
+			name:          "empty terms",
+			terms:         []string{},
+			expectedPaths: []string{"typescript.md", "typescript-strict.md", "rust.md", "rust-strict.md", "cpp.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered := FilterFragmentsByTagExpression(fragments, tt.terms)
+
+			paths := make([]string, 0, len(filtered))
+			for _, fragment := range filtered {
+				paths = append(paths, fragment.Path)
+			}
+
+			if !reflect.DeepEqual(paths, tt.expectedPaths) {
+				t.Errorf("Expected paths %v, got %v", tt.expectedPaths, paths)
+			}
+		})
+	}
+}
+
+func TestSearchFragment(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "search.md")
+
+	content := "---\nctx-tags: typescript\n---\n\n# TypeScript\nUse strict mode.\nNo typescript any."
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	match := func(line string) bool {
+		return strings.Contains(strings.ToLower(line), "typescript")
+	}
+
+	matches, err := SearchFragment(Fragment{Path: path}, match, false)
+	if err != nil {
+		t.Fatalf("SearchFragment failed: %v", err)
+	}
+
+	expected := []SearchMatch{
+		{Line: 5, Text: "# TypeScript"},
+		{Line: 7, Text: "No typescript any."},
+	}
+	if !reflect.DeepEqual(matches, expected) {
+		t.Errorf("Expected matches %v, got %v", expected, matches)
+	}
+
+	matches, err = SearchFragment(Fragment{Path: path}, match, true)
+	if err != nil {
+		t.Fatalf("SearchFragment failed: %v", err)
+	}
+
+	if len(matches) != 3 || matches[0].Line != 2 {
+		t.Errorf("Expected frontmatter match on line 2 first, got %v", matches)
+	}
+}
+
+func TestScanFragmentsDirs(t *testing.T) {
+	dirs := []string{t.TempDir(), t.TempDir(), t.TempDir()}
+	files := []map[string]string{
+		{"shared.md": "first", "first.md": "first only"},
+		{"shared.md": "second", "second.md": "second only"},
+		{"shared.md": "third"},
+	}
+
+	for i, dir := range dirs {
+		for name, body := range files[i] {
+			content := "---\nctx-tags: test\n---\n" + body
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+				t.Fatalf("Failed to create fragment %s: %v", name, err)
+			}
+		}
+	}
+
+	fragments, err := ScanFragmentsDirs(dirs, false, ScanOptions{})
+	if err != nil {
+		t.Fatalf("ScanFragmentsDirs failed: %v", err)
+	}
+
+	contents := make(map[string]string, len(fragments))
+	for _, fragment := range fragments {
+		contents[filepath.Base(fragment.Path)] = fragment.Content
+	}
+
+	expected := map[string]string{
+		"shared.md": "third",
+		"first.md":  "first only",
+		"second.md": "second only",
+	}
+	if !reflect.DeepEqual(contents, expected) {
+		t.Errorf("Expected fragments %v, got %v", expected, contents)
+	}
+
+	fragments, err = ScanFragmentsDirs(dirs, true, ScanOptions{})
+	if err != nil {
+		t.Fatalf("ScanFragmentsDirs failed: %v", err)
+	}
+
+	if len(fragments) != 5 {
+		t.Errorf("Expected 5 fragments without override, got %d", len(fragments))
+	}
+}
+
+func TestParseFragmentInvalidFrontmatter(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "invalid.md")
+
+	content := "---\nctx-tags:\n  key: value\n---\n# Invalid"
+	if err := os.WriteFile(tmpFile, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	if _, err := ParseFragment(tmpFile); err == nil {
+		t.Error("Expected error for mapping ctx-tags, got nil")
+	}
+}
+
+func TestParseFragmentIncludes(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"main.md":          "---\nctx-tags: main\nctx-include: shared/header.md\n---\n# Main",
+		"shared/header.md": "---\nctx-include: [footer.md]\n---\nHeader",
+		"shared/footer.md": "Footer",
+		"loop-a.md":        "---\nctx-include: loop-b.md\n---\nA",
+		"loop-b.md":        "---\nctx-include: loop-a.md\n---\nB",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create fragment %s: %v", name, err)
+		}
+	}
+
+	fragment, err := ParseFragment(filepath.Join(tmpDir, "main.md"))
+	if err != nil {
+		t.Fatalf("ParseFragment failed: %v", err)
+	}
+
+	if fragment.Content != "Footer\nHeader\n# Main" {
+		t.Errorf("Expected included content to be prepended, got %q", fragment.Content)
+	}
+
+	expectedIncludes := []string{filepath.Join(tmpDir, "shared", "header.md")}
+	if !reflect.DeepEqual(fragment.Includes, expectedIncludes) {
+		t.Errorf("Expected includes %v, got %v", expectedIncludes, fragment.Includes)
+	}
+
+	// The checksum covers the included content, so changing an include changes it
+	if expected := ComputeContentHash("Footer\nHeader\n# Main"); fragment.Checksum != expected {
+		t.Errorf("Expected checksum %s, got %s", expected, fragment.Checksum)
+	}
+
+	_, err = ParseFragment(filepath.Join(tmpDir, "loop-a.md"))
+	if err == nil || !strings.Contains(err.Error(), "circular include") {
+		t.Errorf("Expected circular include error, got %v", err)
+	}
+}
+
+func TestParseFragmentPriority(t *testing.T) {
+	tests := []struct {
+		name             string
+		frontmatter      string
+		expectedPriority int
+		expectWarning    bool
+	}{
+		{name: "no priority", frontmatter: "ctx-tags: a", expectedPriority: 0},
+		{name: "ctx-priority", frontmatter: "ctx-priority: 5", expectedPriority: 5},
+		{name: "ctx-order", frontmatter: "ctx-order: 3", expectedPriority: 3, expectWarning: true},
+		{name: "ctx-priority wins", frontmatter: "ctx-order: 3\nctx-priority: 7", expectedPriority: 7, expectWarning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := filepath.Join(t.TempDir(), "test.md")
+			if err := os.WriteFile(tmpFile, []byte("---\n"+tt.frontmatter+"\n---\n# Body"), 0o600); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+
+			fragment, err := ParseFragment(tmpFile)
+			if err != nil {
+				t.Fatalf("ParseFragment failed: %v", err)
+			}
+
+			if fragment.Priority != tt.expectedPriority {
+				t.Errorf("Expected priority %d, got %d", tt.expectedPriority, fragment.Priority)
+			}
+
+			if (len(fragment.Warnings) > 0) != tt.expectWarning {
+				t.Errorf("Expected warning %v, got %v", tt.expectWarning, fragment.Warnings)
+			}
+		})
+	}
+}
+
+func TestParseFragmentVars(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "test.md")
+	content := "---\nctx-tags: a\nctx-var-language: TypeScript\nctx-var-version: 5\nctx-var-list: [a, b]\nauthor: me\n---\n# Body"
+
+	if err := os.WriteFile(tmpFile, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	fragment, err := ParseFragment(tmpFile)
+	if err != nil {
+		t.Fatalf("ParseFragment failed: %v", err)
+	}
+
+	expected := map[string]string{"language": "TypeScript", "version": "5"}
+	if !reflect.DeepEqual(fragment.Vars, expected) {
+		t.Errorf("Expected vars %v, got %v", expected, fragment.Vars)
+	}
+
+	if !reflect.DeepEqual(fragment.Warnings, []string{"ctx-var-list must be a single value"}) {
+		t.Errorf("Expected a warning for the list variable, got %v", fragment.Warnings)
+	}
+
+	merged := MergeFragmentVars([]Fragment{
+		{Vars: map[string]string{"language": "Go", "strictness": "strict"}},
+		{},
+		*fragment,
+	})
+
+	expected = map[string]string{"language": "TypeScript", "version": "5", "strictness": "strict"}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("Expected merged vars %v, got %v", expected, merged)
+	}
+}
+
+func TestParseFragmentRawFrontmatter(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "test.md")
+	raw := "ctx-tags: [go, lint]\nowner: platform-team\nreview:\n  interval: 30\n  # checked quarterly\n  strict: true"
+
+	if err := os.WriteFile(tmpFile, []byte("---\n"+raw+"\n---\n# Body\n---\nNot frontmatter"), 0o600); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	fragment, err := ParseFragment(tmpFile)
+	if err != nil {
+		t.Fatalf("ParseFragment failed: %v", err)
+	}
+
+	if fragment.RawFrontmatter != raw {
+		t.Errorf("Expected raw frontmatter %q, got %q", raw, fragment.RawFrontmatter)
+	}
+
+	var custom struct {
+		Owner  string `yaml:"owner"`
+		Review struct {
+			Interval int  `yaml:"interval"`
+			Strict   bool `yaml:"strict"`
+		} `yaml:"review"`
+	}
+
+	if err := yaml.Unmarshal([]byte(fragment.RawFrontmatter), &custom); err != nil {
+		t.Fatalf("Failed to re-parse raw frontmatter: %v", err)
+	}
+
+	if custom.Owner != "platform-team" || custom.Review.Interval != 30 || !custom.Review.Strict {
+		t.Errorf("Unexpected custom keys: %+v", custom)
+	}
+
+	if err := os.WriteFile(tmpFile, []byte("# No frontmatter"), 0o600); err != nil {
+		t.Fatalf("Failed to update test file: %v", err)
+	}
+
+	fragment, err = ParseFragment(tmpFile)
+	if err != nil {
+		t.Fatalf("ParseFragment failed: %v", err)
+	}
+
+	if fragment.RawFrontmatter != "" {
+		t.Errorf("Expected no raw frontmatter, got %q", fragment.RawFrontmatter)
+	}
+}
+
+func TestParseFragmentTOMLFrontmatter(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "test.md")
+	raw := "ctx-tags = [\"go\", \"lint\"]\nctx-priority = 2\nctx-description = \"Go linting\"\nctx-var-language = \"Go\"\nowner = \"platform-team\"\n\n[review]\ninterval = 30\nstrict = true"
+
+	if err := os.WriteFile(tmpFile, []byte("+++\n"+raw+"\n+++\n# Body\n---\nNot frontmatter"), 0o600); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	fragment, err := ParseFragment(tmpFile)
+	if err != nil {
+		t.Fatalf("ParseFragment failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(fragment.Tags, []string{"go", "lint"}) {
+		t.Errorf("Expected tags [go lint], got %v", fragment.Tags)
+	}
+
+	if fragment.Priority != 2 || fragment.Description != "Go linting" {
+		t.Errorf("Expected priority 2 and description %q, got %d and %q", "Go linting", fragment.Priority, fragment.Description)
+	}
+
+	if fragment.Vars["language"] != "Go" {
+		t.Errorf("Expected variable language=Go, got %v", fragment.Vars)
+	}
+
+	if fragment.Content != "# Body\n---\nNot frontmatter" {
+		t.Errorf("Unexpected content %q", fragment.Content)
+	}
+
+	if fragment.RawFrontmatter != raw || fragment.FrontmatterDelimiter != "+++" {
+		t.Errorf("Expected raw TOML frontmatter %q, got %q (delimiter %q)", raw, fragment.RawFrontmatter, fragment.FrontmatterDelimiter)
+	}
+
+	var custom struct {
+		Owner  string `toml:"owner"`
+		Review struct {
+			Interval int  `toml:"interval"`
+			Strict   bool `toml:"strict"`
+		} `toml:"review"`
+	}
+
+	if _, err := toml.Decode(fragment.RawFrontmatter, &custom); err != nil {
+		t.Fatalf("Failed to re-parse raw frontmatter: %v", err)
+	}
+
+	if custom.Owner != "platform-team" || custom.Review.Interval != 30 || !custom.Review.Strict {
+		t.Errorf("Unexpected custom keys: %+v", custom)
+	}
+
+	fields, err := ReadFrontmatter(tmpFile)
+	if err != nil {
+		t.Fatalf("ReadFrontmatter failed: %v", err)
+	}
+
+	if fields["owner"] != "platform-team" {
+		t.Errorf("Expected owner in frontmatter fields, got %v", fields)
+	}
+}
+
+func TestParseFragmentTOMLFrontmatterCommaSeparatedTags(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "test.md")
+	if err := os.WriteFile(tmpFile, []byte("+++\nctx-tags = \"go, lint\"\n+++\n# Body"), 0o600); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	fragment, err := ParseFragment(tmpFile)
+	if err != nil {
+		t.Fatalf("ParseFragment failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(fragment.Tags, []string{"go", "lint"}) {
+		t.Errorf("Expected tags [go lint], got %v", fragment.Tags)
+	}
+
+	if err := os.WriteFile(tmpFile, []byte("+++\nctx-tags = [go\n+++\n# Body"), 0o600); err != nil {
+		t.Fatalf("Failed to update test file: %v", err)
+	}
+
+	if _, err := ParseFragment(tmpFile); err == nil || !strings.Contains(err.Error(), "invalid frontmatter") {
+		t.Errorf("Expected an invalid frontmatter error, got %v", err)
+	}
+}
+
+func TestSortFragmentsByPriority(t *testing.T) {
+	fragments := []Fragment{
+		{Path: "b.md", Priority: 2},
+		{Path: "a.md"},
+		{Path: "c.md", Priority: -1},
+		{Path: "d.md"},
+	}
+
+	sorted := SortFragmentsByPriority(fragments)
+
+	paths := make([]string, 0, len(sorted))
+	for _, fragment := range sorted {
+		paths = append(paths, fragment.Path)
+	}
+
+	expected := []string{"c.md", "a.md", "d.md", "b.md"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected order %v, got %v", expected, paths)
+	}
+}
+
+func TestSortFragments(t *testing.T) {
+	now := time.Now()
+	fragments := []Fragment{
+		{Path: "/l/b.md", Priority: 1, ModTime: now.Add(-time.Hour)},
+		{Path: "/g/c.md", ModTime: now},
+		{Path: "/g/a.md", Priority: 1, ModTime: now.Add(-2 * time.Hour)},
+		{Path: "/l/d.md", ModTime: now},
+	}
+
+	tests := []struct {
+		strategy string
+		expected []string
+	}{
+		{strategy: SortAlpha, expected: []string{"/g/a.md", "/g/c.md", "/l/b.md", "/l/d.md"}},
+		{strategy: SortPriority, expected: []string{"/g/c.md", "/l/d.md", "/g/a.md", "/l/b.md"}},
+		{strategy: SortMtime, expected: []string{"/g/c.md", "/l/d.md", "/l/b.md", "/g/a.md"}},
+		{strategy: "", expected: []string{"/g/c.md", "/l/d.md", "/g/a.md", "/l/b.md"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			sorted := SortFragments(fragments, tt.strategy)
+
+			paths := make([]string, 0, len(sorted))
+			for _, fragment := range sorted {
+				paths = append(paths, fragment.Path)
+			}
+
+			if !reflect.DeepEqual(paths, tt.expected) {
+				t.Errorf("Expected order %v, got %v", tt.expected, paths)
+			}
+		})
+	}
+
+	if fragments[0].Path != "/l/b.md" {
+		t.Error("Expected SortFragments not to modify its input")
+	}
+}
+
+func TestScanFragmentsNamespaceFromDir(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"root.md":                "---\nctx-tags: general\n---\nRoot",
+		"react/hooks.md":         "---\nctx-tags: hooks\n---\nHooks",
+		"react/state/reducer.md": "---\nctx-tags: react\n---\nReducer",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create fragment %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		opts     ScanOptions
+		expected map[string][]string
+	}{
+		{
+			name: "disabled",
+			opts: ScanOptions{},
+			expected: map[string][]string{
+				"root.md":    {"general"},
+				"hooks.md":   {"hooks"},
+				"reducer.md": {"react"},
+			},
+		},
+		{
+			name: "enabled",
+			opts: ScanOptions{NamespaceFromDir: true},
+			expected: map[string][]string{
+				"root.md":    {"general"},
+				"hooks.md":   {"hooks", "react"},
+				"reducer.md": {"react", "state"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fragments, err := ScanFragmentsWithOptions(tmpDir, tt.opts)
+			if err != nil {
+				t.Fatalf("ScanFragmentsWithOptions failed: %v", err)
+			}
+
+			tags := make(map[string][]string, len(fragments))
+			for _, fragment := range fragments {
+				tags[filepath.Base(fragment.Path)] = fragment.Tags
+			}
+
+			if !reflect.DeepEqual(tags, tt.expected) {
+				t.Errorf("Expected tags %v, got %v", tt.expected, tags)
+			}
+		})
+	}
+}
+
+func TestExcludeFragmentsByTags(t *testing.T) {
+	fragments := []Fragment{
+		{Path: "typescript.md", Tags: []string{"typescript"}},
+		{Path: "typescript-experimental.md", Tags: []string{"typescript", "experimental"}},
+		{Path: "rust.md", Tags: []string{"rust", "deprecated"}},
+	}
+
+	tests := []struct {
+		name          string
+		include       []string
+		ignore        []string
+		expectedPaths []string
+	}{
+		{
+			name:          "no ignore tags",
+			include:       []string{"typescript"},
+			expectedPaths: []string{"typescript.md", "typescript-experimental.md"},
+		},
+		{
+			name:          "ignore wins over include",
+			include:       []string{"typescript"},
+			ignore:        []string{"experimental"},
+			expectedPaths: []string{"typescript.md"},
+		},
+		{
+			name:          "multiple ignore tags",
+			include:       []string{"typescript", "rust"},
+			ignore:        []string{"experimental", "deprecated"},
+			expectedPaths: []string{"typescript.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered := ExcludeFragmentsByTags(FilterFragmentsByTagExpression(fragments, tt.include), tt.ignore)
+
+			paths := make([]string, 0, len(filtered))
+			for _, fragment := range filtered {
+				paths = append(paths, fragment.Path)
+			}
+
+			if !reflect.DeepEqual(paths, tt.expectedPaths) {
+				t.Errorf("Expected paths %v, got %v", tt.expectedPaths, paths)
+			}
+		})
+	}
+}
+
+func TestFilterFragmentsModifiedSince(t *testing.T) {
+	fragmentsDir := t.TempDir()
+	since := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	files := map[string]time.Time{
+		"old.md":      since.Add(-time.Hour),
+		"new.md":      since.Add(time.Hour),
+		"exact.md":    since,
+		"includer.md": since.Add(-time.Hour),
+		"partial.md":  since.Add(2 * time.Hour),
+	}
+
+	for name, modTime := range files {
+		content := "---\nctx-tags: go\n---\n" + name
+		if name == "includer.md" {
+			content = "---\nctx-tags: go\nctx-include: partial.md\n---\n" + name
+		}
+
+		path := filepath.Join(fragmentsDir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create fragment: %v", err)
+		}
+
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set modification time: %v", err)
+		}
+	}
+
+	fragments, err := ScanFragments(fragmentsDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, fragment := range fragments {
+		if fragment.ModTime.IsZero() {
+			t.Errorf("Expected ModTime to be set for %s", fragment.Path)
+		}
+	}
+
+	var names []string
+	for _, fragment := range FilterFragmentsModifiedSince(fragments, since) {
+		names = append(names, filepath.Base(fragment.Path))
+	}
+
+	// includer.md is older than since, but includes the newer partial.md
+	expected := []string{"includer.md", "new.md", "partial.md"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+}
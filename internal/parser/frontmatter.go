@@ -0,0 +1,199 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// frontmatter holds the ctx-specific keys of a fragment's YAML or TOML frontmatter block.
+type frontmatter struct {
+	Tags     stringList `yaml:"ctx-tags"`
+	Includes stringList `yaml:"ctx-include"`
+	Priority *int       `yaml:"ctx-priority"`
+	Order    *int       `yaml:"ctx-order"`
+	// Description is the human-readable summary of the fragment.
+	Description string `yaml:"ctx-description"`
+	// Other holds the keys not listed above, including the ctx-var-* variables.
+	Other map[string]interface{} `yaml:",inline"`
+}
+
+// ErrInvalidFrontmatter is wrapped by the errors for frontmatter that cannot be decoded.
+var ErrInvalidFrontmatter = errors.New("invalid frontmatter")
+
+// VarPrefix is the prefix of frontmatter keys declaring fragment variables,
+// e.g. ctx-var-language declares the variable language.
+const VarPrefix = "ctx-var-"
+
+// vars returns the variables declared with ctx-var-* keys and a warning for each
+// variable whose value is not a scalar.
+func (fm *frontmatter) vars() (map[string]string, []string) {
+	var vars map[string]string
+
+	var warnings []string
+
+	for key, value := range fm.Other {
+		name, ok := strings.CutPrefix(key, VarPrefix)
+		if !ok || name == "" {
+			continue
+		}
+
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			warnings = append(warnings, fmt.Sprintf("%s must be a single value", key))
+			continue
+		case nil:
+			value = ""
+		}
+
+		if vars == nil {
+			vars = make(map[string]string)
+		}
+
+		vars[name] = fmt.Sprint(value)
+	}
+
+	sort.Strings(warnings)
+
+	return vars, warnings
+}
+
+// priority returns the fragment priority from ctx-priority or its older alias ctx-order,
+// with ctx-priority taking precedence, and a deprecation warning if ctx-order is used.
+func (fm *frontmatter) priority() (int, []string) {
+	var warnings []string
+
+	if fm.Order != nil {
+		warnings = append(warnings, "ctx-order is deprecated, use ctx-priority instead")
+	}
+
+	switch {
+	case fm.Priority != nil:
+		return *fm.Priority, warnings
+	case fm.Order != nil:
+		return *fm.Order, warnings
+	default:
+		return 0, warnings
+	}
+}
+
+// stringList is a list of values that can be written as a comma-separated string,
+// a flow sequence ([a, b]) or a block sequence.
+type stringList []string
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (t *stringList) UnmarshalYAML(node *yaml.Node) error {
+	var raw []string
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		raw = strings.Split(node.Value, ",")
+	case yaml.SequenceNode:
+		if err := node.Decode(&raw); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("line %d: expected a string or a list", node.Line)
+	}
+
+	var values []string
+
+	for _, value := range raw {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+
+	*t = values
+
+	return nil
+}
+
+// parseFrontmatter decodes the lines of a frontmatter block, without its delimiters.
+// TOML frontmatter ("+++") is decoded into the same keys as YAML frontmatter.
+func parseFrontmatter(lines []string, delimiter string) (*frontmatter, error) {
+	var fm frontmatter
+
+	if delimiter != tomlDelimiter {
+		if err := yaml.Unmarshal([]byte(strings.Join(lines, "\n")), &fm); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidFrontmatter, err)
+		}
+
+		return &fm, nil
+	}
+
+	fields, err := decodeTOMLFrontmatter(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	// Re-encode the TOML values as a YAML node so both formats share the decoding of
+	// the ctx keys, such as comma-separated or list values for ctx-tags.
+	var node yaml.Node
+	if err := node.Encode(fields); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFrontmatter, err)
+	}
+
+	if err := node.Decode(&fm); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFrontmatter, err)
+	}
+
+	return &fm, nil
+}
+
+// decodeTOMLFrontmatter decodes the lines of a TOML frontmatter block.
+func decodeTOMLFrontmatter(lines []string) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+
+	if _, err := toml.Decode(strings.Join(lines, "\n"), &fields); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFrontmatter, err)
+	}
+
+	return fields, nil
+}
+
+// ReadFrontmatter returns all fields of the frontmatter block of the fragment at filePath,
+// including keys ctx does not use. It returns an empty map when there is no frontmatter.
+func ReadFrontmatter(filePath string) (map[string]interface{}, error) {
+	lines, err := readLines(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	frontmatterLines, delimiter := frontmatterBlock(lines)
+	if delimiter == tomlDelimiter {
+		return decodeTOMLFrontmatter(frontmatterLines)
+	}
+
+	fields := make(map[string]interface{})
+
+	if err := yaml.Unmarshal([]byte(strings.Join(frontmatterLines, "\n")), &fields); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFrontmatter, err)
+	}
+
+	return fields, nil
+}
+
+// MergeFragmentVars merges the variables declared by the fragments. When several fragments
+// declare the same variable, the one later in the list wins. It returns nil when no
+// fragment declares a variable.
+func MergeFragmentVars(fragments []Fragment) map[string]string {
+	var merged map[string]string
+
+	for _, fragment := range fragments {
+		for name, value := range fragment.Vars {
+			if merged == nil {
+				merged = make(map[string]string)
+			}
+
+			merged[name] = value
+		}
+	}
+
+	return merged
+}
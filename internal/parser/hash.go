@@ -0,0 +1,18 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// ComputeContentHash returns the hex-encoded SHA-256 hash of content.
+func ComputeContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// ComputeFragmentHash returns the hex SHA-256 of the fragment's content, including the
+// content of its included files.
+func ComputeFragmentHash(f Fragment) string {
+	return ComputeContentHash(f.Content)
+}
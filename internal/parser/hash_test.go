@@ -0,0 +1,17 @@
+package parser
+
+import "testing"
+
+func TestComputeContentHash(t *testing.T) {
+	// SHA-256 of the empty string and of "hello"
+	tests := map[string]string{
+		"":      "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		"hello": "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+	}
+
+	for content, expected := range tests {
+		if hash := ComputeContentHash(content); hash != expected {
+			t.Errorf("Expected hash %s for %q, got %s", expected, content, hash)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFileName is the name of the file in a fragments directory root listing
+// gitignore-style patterns of files to exclude from scanning.
+const IgnoreFileName = ".ctxignore"
+
+// ignoreRule is a single pattern line of an ignore file.
+type ignoreRule struct {
+	segments []string
+	negate   bool
+	dirOnly  bool
+}
+
+// ignoreMatcher matches slash-separated paths relative to a fragments directory
+// against the rules of an ignore file. The last matching rule wins.
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+// loadIgnoreFile reads the ignore file of a fragments directory. A missing file yields
+// a matcher that ignores nothing.
+func loadIgnoreFile(fragmentsDir string) (*ignoreMatcher, error) {
+	file, err := os.Open(filepath.Join(fragmentsDir, IgnoreFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return &ignoreMatcher{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = file.Close() }()
+
+	return parseIgnoreRules(file)
+}
+
+// parseIgnoreRules parses gitignore-style patterns. Blank lines and lines starting with
+// # are skipped, a leading ! negates a pattern, a trailing / matches directories only,
+// and patterns without a / match at any depth. ** matches any number of directories.
+func parseIgnoreRules(r io.Reader) (*ignoreMatcher, error) {
+	matcher := &ignoreMatcher{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+
+		if !strings.Contains(line, "/") {
+			line = "**/" + line
+		}
+
+		rule.segments = strings.Split(strings.TrimPrefix(line, "/"), "/")
+		matcher.rules = append(matcher.rules, rule)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return matcher, nil
+}
+
+// Match reports whether the slash-separated relative path should be ignored.
+func (m *ignoreMatcher) Match(relPath string, isDir bool) bool {
+	ignored := false
+	parts := strings.Split(relPath, "/")
+
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+
+		if matchSegments(rule.segments, parts) {
+			ignored = !rule.negate
+		}
+	}
+
+	return ignored
+}
+
+// matchSegments matches path segments against pattern segments, where "**" matches
+// zero or more segments and other segments use path.Match syntax.
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(parts) == 0 {
+		return false
+	}
+
+	matched, err := path.Match(pattern[0], parts[0])
+
+	return err == nil && matched && matchSegments(pattern[1:], parts[1:])
+}
@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	matcher, err := parseIgnoreRules(strings.NewReader(`# drafts
+draft-*.md
+/top.md
+archive/
+docs/**/old.md
+!draft-keep.md
+`))
+	if err != nil {
+		t.Fatalf("parseIgnoreRules failed: %v", err)
+	}
+
+	tests := []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{path: "draft-api.md", expected: true},
+		{path: "nested/draft-api.md", expected: true},
+		{path: "draft-keep.md", expected: false},
+		{path: "top.md", expected: true},
+		{path: "nested/top.md", expected: false},
+		{path: "archive", isDir: true, expected: true},
+		{path: "archive", isDir: false, expected: false},
+		{path: "docs/old.md", expected: true},
+		{path: "docs/a/b/old.md", expected: true},
+		{path: "api.md", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if matched := matcher.Match(tt.path, tt.isDir); matched != tt.expected {
+				t.Errorf("Expected Match(%q, %v) = %v, got %v", tt.path, tt.isDir, tt.expected, matched)
+			}
+		})
+	}
+}
+
+func TestScanFragmentsCtxignore(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		IgnoreFileName:        "draft-*.md\narchive/\n",
+		"api.md":              "API",
+		"draft-api.md":        "Draft",
+		"archive/old.md":      "Old",
+		"nested/guide.md":     "Guide",
+		"nested/draft-web.md": "Draft",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create file %s: %v", name, err)
+		}
+	}
+
+	fragments, err := ScanFragments(tmpDir)
+	if err != nil {
+		t.Fatalf("ScanFragments failed: %v", err)
+	}
+
+	paths := make([]string, 0, len(fragments))
+	for _, fragment := range fragments {
+		rel, _ := filepath.Rel(tmpDir, fragment.Path)
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+
+	expected := []string{"api.md", "nested/guide.md"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected fragments %v, got %v", expected, paths)
+	}
+}
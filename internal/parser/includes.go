@@ -0,0 +1,173 @@
+package parser
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// maxIncludeSuggestions is the number of similar filenames an IncludeError suggests at most.
+const maxIncludeSuggestions = 3
+
+// IncludeError is a ctx-include reference that does not resolve to a file.
+type IncludeError struct {
+	// Fragment is the path of the fragment with the reference.
+	Fragment string `json:"fragment"`
+	// Include is the name as listed in ctx-include.
+	Include string `json:"include"`
+	// Suggestions are existing files with a similar name, relative to the fragment's
+	// directory as they would be written in ctx-include.
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+func (e IncludeError) Error() string {
+	return e.Fragment + ": " + e.Message()
+}
+
+// Message describes the unresolvable include without the fragment path.
+func (e IncludeError) Message() string {
+	message := fmt.Sprintf("included file %q not found", e.Include)
+	if len(e.Suggestions) > 0 {
+		message += fmt.Sprintf(" (did you mean %s?)", strings.Join(e.Suggestions, ", "))
+	}
+
+	return message
+}
+
+// ReadIncludeNames returns the files listed in the ctx-include frontmatter of the fragment
+// at filePath as written, without resolving or reading them.
+func ReadIncludeNames(filePath string) ([]string, error) {
+	lines, err := readLines(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	fm, err := parseFrontmatter(frontmatterBlock(lines))
+	if err != nil {
+		return nil, err
+	}
+
+	return fm.Includes, nil
+}
+
+// VerifyIncludes checks that the includes of the fragments resolve to existing files.
+// Includes are the names as listed in ctx-include (see ReadIncludeNames), resolved like
+// ParseFragment does: relative to the directory of the fragment unless absolute. Files in
+// dirs and in the fragments' own directories are suggested for names that do not resolve.
+func VerifyIncludes(fragments []Fragment, dirs []string) []IncludeError {
+	var errs []IncludeError
+
+	var candidates []string
+
+	for _, fragment := range fragments {
+		for _, name := range fragment.Includes {
+			path := name
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(filepath.Dir(fragment.Path), name)
+			}
+
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				continue
+			}
+
+			if candidates == nil {
+				candidates = includeCandidates(fragments, dirs)
+			}
+
+			errs = append(errs, IncludeError{
+				Fragment:    fragment.Path,
+				Include:     name,
+				Suggestions: similarIncludes(fragment.Path, name, candidates),
+			})
+		}
+	}
+
+	return errs
+}
+
+// includeCandidates returns the files in dirs and in the directories of the fragments.
+func includeCandidates(fragments []Fragment, dirs []string) []string {
+	roots := slices.Clone(dirs)
+	for _, fragment := range fragments {
+		roots = append(roots, filepath.Dir(fragment.Path))
+	}
+
+	candidates := []string{}
+
+	for _, root := range roots {
+		// Unreadable directories only mean fewer suggestions
+		_ = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return filepath.SkipDir
+			}
+
+			if !entry.IsDir() && !slices.Contains(candidates, path) {
+				candidates = append(candidates, path)
+			}
+
+			return nil
+		})
+	}
+
+	return candidates
+}
+
+// similarIncludes returns the candidates whose filename is close to that of name, relative
+// to the directory of the fragment and in sorted order.
+func similarIncludes(fragmentPath, name string, candidates []string) []string {
+	target := strings.ToLower(filepath.Base(name))
+
+	var similar []string
+
+	for _, candidate := range candidates {
+		if levenshtein(target, strings.ToLower(filepath.Base(candidate))) > 2 {
+			continue
+		}
+
+		suggestion := candidate
+		if rel, err := filepath.Rel(filepath.Dir(fragmentPath), candidate); err == nil {
+			suggestion = filepath.ToSlash(rel)
+		}
+
+		if !slices.Contains(similar, suggestion) {
+			similar = append(similar, suggestion)
+		}
+	}
+
+	slices.Sort(similar)
+
+	if len(similar) > maxIncludeSuggestions {
+		similar = similar[:maxIncludeSuggestions]
+	}
+
+	return similar
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous = current
+	}
+
+	return previous[len(b)]
+}
@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadIncludeNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.md")
+	if err := os.WriteFile(path, []byte("---\nctx-tags: a\nctx-include: partials/header.md, missing.md\n---\nBody"), 0o600); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+
+	includes, err := ReadIncludeNames(path)
+	if err != nil {
+		t.Fatalf("ReadIncludeNames failed: %v", err)
+	}
+
+	if expected := []string{"partials/header.md", "missing.md"}; !reflect.DeepEqual(includes, expected) {
+		t.Errorf("Expected includes %v, got %v", expected, includes)
+	}
+}
+
+func TestVerifyIncludes(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"main.md", filepath.Join("partials", "header.md"), filepath.Join("partials", "license.md")} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte("Body"), 0o600); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	fragments := []Fragment{
+		{Path: filepath.Join(dir, "main.md"), Includes: []string{"partials/header.md", "partials/headr.md", "partials"}},
+		{Path: filepath.Join(dir, "partials", "license.md"), Includes: []string{"header.md"}},
+	}
+
+	errs := VerifyIncludes(fragments, []string{dir})
+	if len(errs) != 2 {
+		t.Fatalf("Expected 2 include errors, got %d: %v", len(errs), errs)
+	}
+
+	if errs[0].Include != "partials/headr.md" || !reflect.DeepEqual(errs[0].Suggestions, []string{"partials/header.md"}) {
+		t.Errorf("Expected partials/header.md to be suggested for the typo, got %+v", errs[0])
+	}
+
+	if errs[1].Include != "partials" || errs[1].Fragment != fragments[0].Path {
+		t.Errorf("Expected a directory not to resolve as an include, got %+v", errs[1])
+	}
+
+	if message := errs[0].Error(); !strings.Contains(message, "main.md: included file \"partials/headr.md\" not found (did you mean partials/header.md?)") {
+		t.Errorf("Unexpected error message: %s", message)
+	}
+}
@@ -0,0 +1,188 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Lint rule names, as used in the lintRules config.
+const (
+	LintRequireDescription = "require-description"
+	LintMaxTags            = "max-tags"
+	LintMinTags            = "min-tags"
+	LintNoWhitespaceInTags = "no-whitespace-in-tags"
+	LintUniqueOrderValues  = "unique-order-values"
+)
+
+// LintConfig selects the rules applied by LintFragments. Zero values disable a rule.
+type LintConfig struct {
+	// RequireDescription requires every fragment to have a ctx-description.
+	RequireDescription bool
+	// MaxTags is the largest number of tags a fragment may have.
+	MaxTags int
+	// MinTags is the smallest number of tags a fragment must have.
+	MinTags int
+	// NoWhitespaceInTags requires tag names to be slugs without whitespace.
+	NoWhitespaceInTags bool
+	// UniqueOrderValues forbids two fragments from sharing a ctx-priority (or ctx-order)
+	// value. Fragments at the default priority 0 are not compared.
+	UniqueOrderValues bool
+}
+
+// LintIssue is a rule violation found in a fragment.
+type LintIssue struct {
+	Path    string `json:"path"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ParseLintConfig reads the lint rules from the lintRules config map. Boolean rules take
+// true or false, max-tags and min-tags take a non-negative number.
+func ParseLintConfig(rules map[string]interface{}) (LintConfig, error) {
+	var cfg LintConfig
+
+	for name, value := range rules {
+		var err error
+
+		switch name {
+		case LintRequireDescription:
+			cfg.RequireDescription, err = lintBool(value)
+		case LintNoWhitespaceInTags:
+			cfg.NoWhitespaceInTags, err = lintBool(value)
+		case LintUniqueOrderValues:
+			cfg.UniqueOrderValues, err = lintBool(value)
+		case LintMaxTags:
+			cfg.MaxTags, err = lintCount(value)
+		case LintMinTags:
+			cfg.MinTags, err = lintCount(value)
+		default:
+			return LintConfig{}, fmt.Errorf("unknown lint rule %q", name)
+		}
+
+		if err != nil {
+			return LintConfig{}, fmt.Errorf("lint rule %s: %w", name, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// lintBool returns the value of a boolean lint rule.
+func lintBool(value interface{}) (bool, error) {
+	enabled, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expected true or false, got %v", value)
+	}
+
+	return enabled, nil
+}
+
+// lintCount returns the value of a numeric lint rule. YAML and TOML configs are converted
+// to JSON when loaded, so numbers always arrive as float64.
+func lintCount(value interface{}) (int, error) {
+	var count int
+
+	switch v := value.(type) {
+	case float64:
+		if v != float64(int(v)) {
+			return 0, fmt.Errorf("expected a whole number, got %v", v)
+		}
+
+		count = int(v)
+	default:
+		return 0, fmt.Errorf("expected a number, got %v", value)
+	}
+
+	if count < 0 {
+		return 0, fmt.Errorf("expected a non-negative number, got %d", count)
+	}
+
+	return count, nil
+}
+
+// LintFragments checks the fragments against the rules and returns the issues found,
+// ordered by path and then rule.
+func LintFragments(fragments []Fragment, rules LintConfig) []LintIssue {
+	var issues []LintIssue
+
+	for _, fragment := range fragments {
+		issues = append(issues, lintFragment(fragment, rules)...)
+	}
+
+	if rules.UniqueOrderValues {
+		issues = append(issues, lintOrderValues(fragments)...)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Path != issues[j].Path {
+			return issues[i].Path < issues[j].Path
+		}
+
+		return issues[i].Rule < issues[j].Rule
+	})
+
+	return issues
+}
+
+// lintFragment applies the rules concerning a single fragment.
+func lintFragment(fragment Fragment, rules LintConfig) []LintIssue {
+	var issues []LintIssue
+
+	issue := func(rule, format string, args ...interface{}) {
+		issues = append(issues, LintIssue{Path: fragment.Path, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if rules.RequireDescription && strings.TrimSpace(fragment.Description) == "" {
+		issue(LintRequireDescription, "missing ctx-description")
+	}
+
+	if rules.MaxTags > 0 && len(fragment.Tags) > rules.MaxTags {
+		issue(LintMaxTags, "has %d tags, at most %d allowed", len(fragment.Tags), rules.MaxTags)
+	}
+
+	if rules.MinTags > 0 && len(fragment.Tags) < rules.MinTags {
+		issue(LintMinTags, "has %d tags, at least %d required", len(fragment.Tags), rules.MinTags)
+	}
+
+	if rules.NoWhitespaceInTags {
+		for _, tag := range fragment.Tags {
+			if strings.ContainsFunc(tag, unicode.IsSpace) {
+				issue(LintNoWhitespaceInTags, "tag %q contains whitespace", tag)
+			}
+		}
+	}
+
+	return issues
+}
+
+// lintOrderValues reports every fragment sharing a non-default priority with another fragment.
+func lintOrderValues(fragments []Fragment) []LintIssue {
+	byPriority := make(map[int][]string)
+
+	for _, fragment := range fragments {
+		if fragment.Priority != 0 {
+			byPriority[fragment.Priority] = append(byPriority[fragment.Priority], fragment.Path)
+		}
+	}
+
+	var issues []LintIssue
+
+	for priority, paths := range byPriority {
+		if len(paths) < 2 {
+			continue
+		}
+
+		for i, path := range paths {
+			others := append(append([]string{}, paths[:i]...), paths[i+1:]...)
+			issues = append(issues, LintIssue{
+				Path:    path,
+				Rule:    LintUniqueOrderValues,
+				Message: fmt.Sprintf("order value %d is also used by %s", priority, strings.Join(others, ", ")),
+			})
+		}
+	}
+
+	return issues
+}
@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLintConfig(t *testing.T) {
+	rules := map[string]interface{}{
+		"require-description":   true,
+		"max-tags":              float64(3),
+		"min-tags":              float64(1),
+		"no-whitespace-in-tags": false,
+		"unique-order-values":   true,
+	}
+
+	cfg, err := ParseLintConfig(rules)
+	if err != nil {
+		t.Fatalf("ParseLintConfig failed: %v", err)
+	}
+
+	expected := LintConfig{RequireDescription: true, MaxTags: 3, MinTags: 1, UniqueOrderValues: true}
+	if cfg != expected {
+		t.Errorf("Expected %+v, got %+v", expected, cfg)
+	}
+
+	invalid := []map[string]interface{}{
+		{"no-todos": true},
+		{"max-tags": "3"},
+		{"max-tags": 2.5},
+		{"min-tags": float64(-1)},
+		{"require-description": "yes"},
+	}
+
+	for _, rules := range invalid {
+		if _, err := ParseLintConfig(rules); err == nil {
+			t.Errorf("Expected an error for %v", rules)
+		}
+	}
+}
+
+func TestLintFragments(t *testing.T) {
+	fragments := []Fragment{
+		{Path: "a.md", Tags: []string{"go", "backend", "api"}, Description: "API rules", Priority: 1},
+		{Path: "b.md", Tags: []string{"go"}, Priority: 1},
+		{Path: "c.md", Tags: []string{"code style"}, Description: "Style"},
+		{Path: "d.md", Description: "Untagged"},
+	}
+
+	rules := LintConfig{
+		RequireDescription: true,
+		MaxTags:            2,
+		MinTags:            1,
+		NoWhitespaceInTags: true,
+		UniqueOrderValues:  true,
+	}
+
+	var got []string
+	for _, issue := range LintFragments(fragments, rules) {
+		got = append(got, issue.Path+":"+issue.Rule)
+	}
+
+	expected := []string{
+		"a.md:max-tags",
+		"a.md:unique-order-values",
+		"b.md:require-description",
+		"b.md:unique-order-values",
+		"c.md:no-whitespace-in-tags",
+		"d.md:min-tags",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected issues %v, got %v", expected, got)
+	}
+
+	issues := LintFragments(fragments, LintConfig{UniqueOrderValues: true})
+	if len(issues) != 2 || !strings.Contains(issues[0].Message, "b.md") {
+		t.Errorf("Expected the duplicate order value to name the other fragment, got %+v", issues)
+	}
+
+	if issues := LintFragments(fragments, LintConfig{}); len(issues) != 0 {
+		t.Errorf("Expected no issues without rules, got %+v", issues)
+	}
+}
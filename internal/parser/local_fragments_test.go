@@ -3,6 +3,7 @@ package parser
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -199,3 +200,61 @@ func TestCombineFragments_EmptyFragments(t *testing.T) {
 		t.Errorf("Expected 0 fragments with both empty, got %d", len(combined))
 	}
 }
+
+func TestWalkUpLocalFragmentsDirs(t *testing.T) {
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+
+	defer func() {
+		_ = os.Chdir(originalWd)
+	}()
+
+	tmpDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to resolve temp directory: %v", err)
+	}
+
+	root := filepath.Join(tmpDir, ".ctx", "fragments")
+	service := filepath.Join(tmpDir, "services", "api", ".ctx", "fragments")
+
+	for _, dir := range []string{root, service, filepath.Join(tmpDir, "services", "api", "src")} {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+
+	if err := os.Chdir(filepath.Join(tmpDir, "services", "api", "src")); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+
+	var visited []string
+
+	dirs, err := WalkUpLocalFragmentsDirs(func(dir string) (bool, error) {
+		visited = append(visited, dir)
+		return dir == tmpDir, nil
+	})
+	if err != nil {
+		t.Fatalf("WalkUpLocalFragmentsDirs failed: %v", err)
+	}
+
+	if expected := []string{root, service}; !reflect.DeepEqual(dirs, expected) {
+		t.Errorf("Expected directories %v, got %v", expected, dirs)
+	}
+
+	if len(visited) != 4 || visited[len(visited)-1] != tmpDir {
+		t.Errorf("Expected the walk to stop at %s, visited %v", tmpDir, visited)
+	}
+
+	dirs, err = WalkUpLocalFragmentsDirs(func(dir string) (bool, error) {
+		return dir == filepath.Join(tmpDir, "services"), nil
+	})
+	if err != nil {
+		t.Fatalf("WalkUpLocalFragmentsDirs failed: %v", err)
+	}
+
+	if expected := []string{service}; !reflect.DeepEqual(dirs, expected) {
+		t.Errorf("Expected directories %v, got %v", expected, dirs)
+	}
+}
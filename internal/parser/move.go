@@ -0,0 +1,40 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// MoveFragmentFile moves the fragment file at srcPath to dstPath by copying its content
+// and mode and then deleting the source, so it also works across filesystems. An existing
+// destination is only replaced when overwrite is set.
+func MoveFragmentFile(srcPath, dstPath string, overwrite bool) error {
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", srcPath, err)
+	}
+
+	if _, err := os.Stat(dstPath); err == nil && !overwrite {
+		return fmt.Errorf("%s already exists", dstPath)
+	}
+
+	data, err := os.ReadFile(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", srcPath, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0o750); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", dstPath, err)
+	}
+
+	if err := os.WriteFile(dstPath, data, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dstPath, err)
+	}
+
+	if err := os.Remove(srcPath); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", srcPath, err)
+	}
+
+	return nil
+}
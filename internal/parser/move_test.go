@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMoveFragmentFile(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "local", "style.md")
+	dstPath := filepath.Join(dir, "global", "nested", "style.md")
+	content := "---\nctx-tags: go\n---\nBody"
+
+	if err := os.MkdirAll(filepath.Dir(srcPath), 0o750); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	if err := os.WriteFile(srcPath, []byte(content), 0o640); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+
+	if err := MoveFragmentFile(srcPath, dstPath, false); err != nil {
+		t.Fatalf("MoveFragmentFile failed: %v", err)
+	}
+
+	if _, err := os.Stat(srcPath); !os.IsNotExist(err) {
+		t.Errorf("Expected source to be removed, got %v", err)
+	}
+
+	info, err := os.Stat(dstPath)
+	if err != nil {
+		t.Fatalf("Expected destination to exist: %v", err)
+	}
+
+	if info.Mode().Perm() != 0o640 {
+		t.Errorf("Expected mode 0640, got %o", info.Mode().Perm())
+	}
+
+	data, _ := os.ReadFile(dstPath)
+	if string(data) != content {
+		t.Errorf("Expected content %q, got %q", content, data)
+	}
+
+	// An existing destination is only replaced with overwrite
+	if err := os.WriteFile(srcPath, []byte("New body"), 0o600); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+
+	if err := MoveFragmentFile(srcPath, dstPath, false); err == nil {
+		t.Error("Expected an error when the destination exists")
+	}
+
+	if err := MoveFragmentFile(srcPath, dstPath, true); err != nil {
+		t.Fatalf("MoveFragmentFile with overwrite failed: %v", err)
+	}
+
+	data, _ = os.ReadFile(dstPath)
+	if string(data) != "New body" {
+		t.Errorf("Expected overwritten content, got %q", data)
+	}
+}
@@ -0,0 +1,27 @@
+package parser
+
+import "strings"
+
+// hardBreak is the trailing whitespace of a Markdown hard line break.
+const hardBreak = "  "
+
+// NormalizeContent makes fragment content consistent regardless of the platform it was
+// written on: all line endings become "\n", trailing whitespace is stripped from every line
+// and trailing newlines are removed from the end. A line ending in exactly two spaces keeps
+// them, since that is a Markdown hard line break.
+func NormalizeContent(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.ReplaceAll(content, "\r", "\n")
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimRight(line, " \t")
+		if trimmed != "" && line == trimmed+hardBreak {
+			continue
+		}
+
+		lines[i] = trimmed
+	}
+
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeContent(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "plain\ntext", expected: "plain\ntext"},
+		{input: "windows\r\nline\r\nendings\r\n", expected: "windows\nline\nendings"},
+		{input: "classic\rmac", expected: "classic\nmac"},
+		{input: "trailing \nwhitespace\t\n\n", expected: "trailing\nwhitespace"},
+		{input: "three   \nmixed \t \n", expected: "three\nmixed"},
+		{input: "hard  \r\nbreak\r\n", expected: "hard  \nbreak"},
+		{input: "blank\n  \nline", expected: "blank\n\nline"},
+		{input: "  indented\n\n\nkept", expected: "  indented\n\n\nkept"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeContent(tt.input); got != tt.expected {
+			t.Errorf("NormalizeContent(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestParseFragmentNormalizesCRLF(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "windows.md")
+	included := filepath.Join(dir, "included.md")
+
+	if err := os.WriteFile(included, []byte("Included line \r\n\r\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write include: %v", err)
+	}
+
+	content := "---\r\nctx-tags: windows\r\nctx-include: included.md\r\n---\r\nFirst line  \r\nSecond\rline\r\n\r\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write fragment: %v", err)
+	}
+
+	fragment, err := ParseFragment(path)
+	if err != nil {
+		t.Fatalf("ParseFragment failed: %v", err)
+	}
+
+	if strings.Contains(fragment.Content, "\r") {
+		t.Errorf("Expected only \\n line endings, got %q", fragment.Content)
+	}
+
+	if expected := "Included line\nFirst line  \nSecond\nline"; fragment.Content != expected {
+		t.Errorf("Expected content %q, got %q", expected, fragment.Content)
+	}
+
+	raw, err := ParseFragmentWithOptions(path, ParseOptions{NoNormalize: true})
+	if err != nil {
+		t.Fatalf("ParseFragmentWithOptions failed: %v", err)
+	}
+
+	if !strings.HasSuffix(raw.Content, "\n") || !strings.Contains(raw.Content, "Second\rline") {
+		t.Errorf("Expected the content as written with NoNormalize, got %q", raw.Content)
+	}
+}
@@ -0,0 +1,187 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ctxTagsLineRegex matches a ctx-tags key line and captures its inline value.
+var ctxTagsLineRegex = regexp.MustCompile(`^(ctx-tags:\s*)(.*)$`)
+
+// blockItemRegex matches a YAML block sequence item and captures its value.
+var blockItemRegex = regexp.MustCompile(`^(\s*-\s*)(.*)$`)
+
+// RenameTag replaces oldTag with newTag in the ctx-tags frontmatter of every fragment in
+// fragmentsDir and returns the paths of the modified files. Comma-separated, flow
+// sequence and block sequence tag lists are supported; other formatting is preserved.
+func RenameTag(fragmentsDir, oldTag, newTag string) ([]string, error) {
+	return renameTag(fragmentsDir, oldTag, newTag, true)
+}
+
+// PreviewRenameTag returns the paths of the files RenameTag would modify without writing them.
+func PreviewRenameTag(fragmentsDir, oldTag, newTag string) ([]string, error) {
+	return renameTag(fragmentsDir, oldTag, newTag, false)
+}
+
+func renameTag(fragmentsDir, oldTag, newTag string, write bool) ([]string, error) {
+	if oldTag == "" || newTag == "" {
+		return nil, fmt.Errorf("tag names cannot be empty")
+	}
+
+	paths, err := FindFragmentFiles(fragmentsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var modified []string
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		lines := strings.Split(string(data), "\n")
+
+		if block, delimiter := frontmatterBlock(lines); delimiter == tomlDelimiter {
+			if fm, err := parseFrontmatter(block, delimiter); err == nil && slices.Contains(fm.Tags, oldTag) {
+				return nil, errTOMLFrontmatter(path)
+			}
+
+			continue
+		}
+
+		if !renameTagInLines(lines, oldTag, newTag) {
+			continue
+		}
+
+		if write {
+			if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), info.Mode().Perm()); err != nil {
+				return nil, fmt.Errorf("failed to write %s: %w", path, err)
+			}
+		}
+
+		modified = append(modified, path)
+	}
+
+	return modified, nil
+}
+
+// renameTagInLines rewrites the ctx-tags entry in the frontmatter of lines in place
+// and reports whether anything changed.
+func renameTagInLines(lines []string, oldTag, newTag string) bool {
+	mask := frontmatterMask(lines)
+
+	for i, line := range lines {
+		if !mask[i] {
+			continue
+		}
+
+		matches := ctxTagsLineRegex.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+
+		if strings.TrimSpace(matches[2]) != "" {
+			value, changed := renameInlineTags(matches[2], oldTag, newTag)
+			lines[i] = matches[1] + value
+
+			return changed
+		}
+
+		return renameBlockTags(lines, mask, i+1, oldTag, newTag)
+	}
+
+	return false
+}
+
+// renameInlineTags renames a tag in a comma-separated or flow sequence value.
+func renameInlineTags(value, oldTag, newTag string) (string, bool) {
+	trimmed := strings.TrimSpace(value)
+	flow := strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]")
+
+	if flow {
+		trimmed = trimmed[1 : len(trimmed)-1]
+	}
+
+	var tags []string
+
+	changed := false
+	seen := make(map[string]bool)
+
+	for _, token := range strings.Split(trimmed, ",") {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+
+		if unquoteTag(token) == oldTag {
+			token = requoteTag(token, newTag)
+			changed = true
+		}
+
+		if seen[unquoteTag(token)] {
+			continue
+		}
+
+		seen[unquoteTag(token)] = true
+
+		tags = append(tags, token)
+	}
+
+	if !changed {
+		return value, false
+	}
+
+	result := strings.Join(tags, ", ")
+	if flow {
+		result = "[" + result + "]"
+	}
+
+	return result, true
+}
+
+// renameBlockTags renames a tag in the block sequence items following line start.
+func renameBlockTags(lines []string, mask []bool, start int, oldTag, newTag string) bool {
+	changed := false
+
+	for i := start; i < len(lines) && mask[i]; i++ {
+		matches := blockItemRegex.FindStringSubmatch(lines[i])
+		if matches == nil {
+			break
+		}
+
+		if unquoteTag(strings.TrimSpace(matches[2])) == oldTag {
+			lines[i] = matches[1] + requoteTag(strings.TrimSpace(matches[2]), newTag)
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// unquoteTag strips matching single or double quotes around a tag.
+func unquoteTag(token string) string {
+	if len(token) >= 2 && (token[0] == '"' || token[0] == '\'') && token[len(token)-1] == token[0] {
+		return token[1 : len(token)-1]
+	}
+
+	return token
+}
+
+// requoteTag returns tag quoted the same way as the original token.
+func requoteTag(original, tag string) string {
+	if unquoteTag(original) != original {
+		return string(original[0]) + tag + string(original[0])
+	}
+
+	return tag
+}
@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRenameTag(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"comma.md":     "---\nctx-tags: go, testing\n---\n\n# Comma\n",
+		"flow.md":      "---\nctx-tags: [\"go\", rust]\nctx-priority: 1\n---\n\nBody mentions go\n",
+		"block.md":     "---\nctx-tags:\n  - rust\n  - go\n---\n\n- go\n",
+		"duplicate.md": "---\nctx-tags: go, golang\n---\n",
+		"untouched.md": "---\nctx-tags: rust\n---\n\nctx-tags: go\n",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	expectedPaths := []string{
+		filepath.Join(dir, "block.md"),
+		filepath.Join(dir, "comma.md"),
+		filepath.Join(dir, "duplicate.md"),
+		filepath.Join(dir, "flow.md"),
+	}
+
+	preview, err := PreviewRenameTag(dir, "go", "golang")
+	if err != nil {
+		t.Fatalf("PreviewRenameTag failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(preview, expectedPaths) {
+		t.Errorf("Expected preview %v, got %v", expectedPaths, preview)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "comma.md"))
+	if string(data) != files["comma.md"] {
+		t.Errorf("PreviewRenameTag modified a file: %q", data)
+	}
+
+	modified, err := RenameTag(dir, "go", "golang")
+	if err != nil {
+		t.Fatalf("RenameTag failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(modified, expectedPaths) {
+		t.Errorf("Expected modified %v, got %v", expectedPaths, modified)
+	}
+
+	expected := map[string]string{
+		"comma.md":     "---\nctx-tags: golang, testing\n---\n\n# Comma\n",
+		"flow.md":      "---\nctx-tags: [\"golang\", rust]\nctx-priority: 1\n---\n\nBody mentions go\n",
+		"block.md":     "---\nctx-tags:\n  - rust\n  - golang\n---\n\n- go\n",
+		"duplicate.md": "---\nctx-tags: golang\n---\n",
+		"untouched.md": files["untouched.md"],
+	}
+
+	for name, want := range expected {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+
+		if string(data) != want {
+			t.Errorf("%s: expected %q, got %q", name, want, data)
+		}
+	}
+
+	fragments, err := ScanFragments(dir)
+	if err != nil {
+		t.Fatalf("ScanFragments failed: %v", err)
+	}
+
+	for _, fragment := range fragments {
+		for _, tag := range fragment.Tags {
+			if tag == "go" {
+				t.Errorf("%s still has tag go", fragment.Path)
+			}
+		}
+	}
+}
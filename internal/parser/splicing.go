@@ -1,29 +1,159 @@
 package parser
 
 import (
+	"cmp"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
+// DefaultSeparator is written between fragments unless configured otherwise.
+const DefaultSeparator = "\n\n"
+
+// FragmentPathPlaceholder in a separator is replaced with the path of the fragment that follows it.
+const FragmentPathPlaceholder = "{{.FragmentPath}}"
+
+// FragmentNamePlaceholder in a source comment is replaced with the filename of the fragment.
+const FragmentNamePlaceholder = "{{.FragmentName}}"
+
+// DefaultSourceComment is the source comment written above each fragment when enabled.
+const DefaultSourceComment = "<!-- ctx: " + FragmentNamePlaceholder + " -->"
+
+// SpliceOptions controls how fragments are combined into a single output.
+type SpliceOptions struct {
+	// Deduplicate includes fragments with identical content (after trimming whitespace) only once.
+	Deduplicate bool
+	// Separator is written verbatim between fragments, with FragmentPathPlaceholder expanded.
+	Separator string
+	// SourceComment, if set, is written on its own line above the content of each fragment,
+	// with FragmentNamePlaceholder and FragmentPathPlaceholder expanded.
+	SourceComment string
+	// IncludeFrontmatter writes the RawFrontmatter of each fragment, wrapped in its
+	// FrontmatterDelimiter lines ("---" if unset), above its content. Fragments without frontmatter are written as they are.
+	IncludeFrontmatter bool
+}
+
+// DefaultSpliceOptions returns the options used by SpliceFragments.
+func DefaultSpliceOptions() SpliceOptions {
+	return SpliceOptions{
+		Separator: DefaultSeparator,
+	}
+}
+
 // SpliceFragments combines multiple fragments into a single output.
 func SpliceFragments(fragments []Fragment) string {
+	return SpliceFragmentsWithOptions(fragments, DefaultSpliceOptions())
+}
+
+// SpliceFragmentsWithOptions combines multiple fragments into a single output using the given options.
+func SpliceFragmentsWithOptions(fragments []Fragment, opts SpliceOptions) string {
 	if len(fragments) == 0 {
 		return ""
 	}
 
 	var result strings.Builder
 
+	// Size the buffer up front so the output is allocated once
+	size := 0
+	for _, fragment := range fragments {
+		size += len(fragment.Content) + len(opts.Separator)
+	}
+
+	result.Grow(size)
+
+	// Writing to a strings.Builder never fails
+	_ = SpliceFragmentsToWithOptions(&result, fragments, opts)
+
+	return result.String()
+}
+
+// SpliceFragmentsTo writes the fragments to w with sep between them, without building the
+// output in memory. FragmentPathPlaceholder in sep is replaced with the path of the
+// following fragment.
+func SpliceFragmentsTo(w io.Writer, fragments []Fragment, sep string) error {
+	return SpliceFragmentsToWithOptions(w, fragments, SpliceOptions{Separator: sep})
+}
+
+// SpliceFragmentsToWithOptions writes the fragments to w using the given options, without
+// building the output in memory.
+func SpliceFragmentsToWithOptions(w io.Writer, fragments []Fragment, opts SpliceOptions) error {
+	if opts.Deduplicate {
+		var skipped []string
+
+		fragments, skipped = deduplicateFragments(fragments)
+		if len(skipped) > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: skipped fragments with duplicate content: %s\n", strings.Join(skipped, ", "))
+		}
+	}
+
+	hasPlaceholder := strings.Contains(opts.Separator, FragmentPathPlaceholder)
+
 	for i, fragment := range fragments {
 		// Add a separator between fragments (except for the first one)
 		if i > 0 {
-			result.WriteString("\n\n")
+			separator := opts.Separator
+			if hasPlaceholder {
+				separator = strings.ReplaceAll(separator, FragmentPathPlaceholder, fragment.Path)
+			}
+
+			if _, err := io.WriteString(w, separator); err != nil {
+				return err
+			}
+		}
+
+		if opts.IncludeFrontmatter && fragment.RawFrontmatter != "" {
+			delimiter := cmp.Or(fragment.FrontmatterDelimiter, yamlDelimiter)
+			if _, err := io.WriteString(w, delimiter+"\n"+fragment.RawFrontmatter+"\n"+delimiter+"\n"); err != nil {
+				return err
+			}
+		}
+
+		if opts.SourceComment != "" {
+			if _, err := io.WriteString(w, sourceComment(opts.SourceComment, fragment)+"\n"); err != nil {
+				return err
+			}
 		}
 
 		// Add fragment content
-		result.WriteString(fragment.Content)
+		if _, err := io.WriteString(w, fragment.Content); err != nil {
+			return err
+		}
 	}
 
-	return result.String()
+	return nil
+}
+
+// sourceComment expands the placeholders of the source comment format for the fragment.
+func sourceComment(format string, fragment Fragment) string {
+	return strings.NewReplacer(
+		FragmentNamePlaceholder, filepath.Base(fragment.Path),
+		FragmentPathPlaceholder, fragment.Path,
+	).Replace(format)
+}
+
+// deduplicateFragments removes fragments whose trimmed content matches an earlier fragment.
+// It returns the remaining fragments and the paths of the skipped ones.
+func deduplicateFragments(fragments []Fragment) (unique []Fragment, skipped []string) {
+	seen := make(map[string]bool)
+
+	for _, fragment := range fragments {
+		key := strings.TrimSpace(fragment.Content)
+		if seen[key] {
+			skipped = append(skipped, fragment.Path)
+			continue
+		}
+
+		seen[key] = true
+
+		unique = append(unique, fragment)
+	}
+
+	return unique, skipped
 }
 
 // GenerateCommandFile creates a command file for replication.
@@ -52,3 +182,61 @@ func GenerateCommandFile(fragments []Fragment, selectedTags []string) string {
 
 	return result.String()
 }
+
+// yamlDocument is one fragment in the output of SpliceFragmentsYAML.
+type yamlDocument struct {
+	Path    string   `yaml:"path"`
+	Tags    []string `yaml:"tags,flow"`
+	Content string   `yaml:"content"`
+}
+
+// jsonFragment is one fragment in the output of SerializeFragmentsJSON, using the field
+// names of Fragment so that the output can be read back into []Fragment.
+type jsonFragment struct {
+	Path    string   `json:"path"`
+	Tags    []string `json:"tags"`
+	Content string   `json:"content"`
+}
+
+// SerializeFragmentsJSON returns the path, tags and content of the fragments as an indented
+// JSON array, ending in a newline.
+func SerializeFragmentsJSON(fragments []Fragment) (string, error) {
+	serialized := make([]jsonFragment, len(fragments))
+	for i, fragment := range fragments {
+		serialized[i] = jsonFragment{Path: fragment.Path, Tags: fragment.Tags, Content: fragment.Content}
+		if serialized[i].Tags == nil {
+			serialized[i].Tags = []string{}
+		}
+	}
+
+	data, err := json.MarshalIndent(serialized, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal fragments: %w", err)
+	}
+
+	return string(data) + "\n", nil
+}
+
+// SpliceFragmentsYAML combines the fragments into a multi-document YAML stream with one
+// document per fragment, each starting with "---" and holding its path, tags and content.
+// Multi-line content is written as a literal block.
+func SpliceFragmentsYAML(fragments []Fragment) (string, error) {
+	var result strings.Builder
+
+	for _, fragment := range fragments {
+		tags := fragment.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+
+		data, err := yaml.Marshal(yamlDocument{Path: fragment.Path, Tags: tags, Content: fragment.Content})
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal fragment %s: %w", fragment.Path, err)
+		}
+
+		result.WriteString("---\n")
+		result.Write(data)
+	}
+
+	return result.String(), nil
+}
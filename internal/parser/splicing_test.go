@@ -0,0 +1,326 @@
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestSpliceFragments(t *testing.T) {
+	fragments := []Fragment{
+		{Path: "a.md", Content: "# A"},
+		{Path: "b.md", Content: "# B"},
+	}
+
+	result := SpliceFragments(fragments)
+
+	expected := "# A\n\n# B"
+	if result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+
+	if SpliceFragments(nil) != "" {
+		t.Error("Expected empty output for no fragments")
+	}
+}
+
+func TestSpliceFragmentsWithOptions_Deduplicate(t *testing.T) {
+	fragments := []Fragment{
+		{Path: "global/common.md", Content: "# Common\nShared content."},
+		{Path: "local/common.md", Content: "\n# Common\nShared content.\n"},
+		{Path: "other.md", Content: "# Other"},
+	}
+
+	tests := []struct {
+		name     string
+		opts     SpliceOptions
+		expected string
+	}{
+		{
+			name:     "deduplication disabled",
+			opts:     SpliceOptions{Separator: DefaultSeparator},
+			expected: "# Common\nShared content.\n\n\n# Common\nShared content.\n\n\n# Other",
+		},
+		{
+			name:     "deduplication enabled",
+			opts:     SpliceOptions{Deduplicate: true, Separator: DefaultSeparator},
+			expected: "# Common\nShared content.\n\n# Other",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := SpliceFragmentsWithOptions(fragments, tt.opts)
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestDeduplicateFragments(t *testing.T) {
+	fragments := []Fragment{
+		{Path: "a.md", Content: "same"},
+		{Path: "b.md", Content: "  same  "},
+		{Path: "c.md", Content: "different"},
+		{Path: "d.md", Content: "same"},
+	}
+
+	unique, skipped := deduplicateFragments(fragments)
+
+	if len(unique) != 2 || unique[0].Path != "a.md" || unique[1].Path != "c.md" {
+		t.Errorf("Unexpected unique fragments: %v", unique)
+	}
+
+	if len(skipped) != 2 || skipped[0] != "b.md" || skipped[1] != "d.md" {
+		t.Errorf("Unexpected skipped paths: %v", skipped)
+	}
+}
+
+func TestSpliceFragmentsWithOptions_Separator(t *testing.T) {
+	fragments := []Fragment{
+		{Path: "a.md", Content: "# A"},
+		{Path: "b.md", Content: "# B"},
+		{Path: "c.md", Content: "# C"},
+	}
+
+	tests := []struct {
+		name      string
+		separator string
+		expected  string
+	}{
+		{
+			name:      "empty separator",
+			separator: "",
+			expected:  "# A# B# C",
+		},
+		{
+			name:      "custom separator",
+			separator: "\n\n---\n\n",
+			expected:  "# A\n\n---\n\n# B\n\n---\n\n# C",
+		},
+		{
+			name:      "template separator",
+			separator: "\n<!-- " + FragmentPathPlaceholder + " -->\n",
+			expected:  "# A\n<!-- b.md -->\n# B\n<!-- c.md -->\n# C",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := SpliceFragmentsWithOptions(fragments, SpliceOptions{Separator: tt.separator})
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestSpliceFragmentsWithOptions_SourceComment(t *testing.T) {
+	fragments := []Fragment{
+		{Path: "/fragments/typescript.md", Content: "# TS"},
+		{Path: "/fragments/go/style.md", Content: "# Go"},
+	}
+
+	tests := []struct {
+		name     string
+		comment  string
+		expected string
+	}{
+		{
+			name:     "default comment",
+			comment:  DefaultSourceComment,
+			expected: "<!-- ctx: typescript.md -->\n# TS\n\n<!-- ctx: style.md -->\n# Go",
+		},
+		{
+			name:     "custom comment",
+			comment:  "<!-- from " + FragmentPathPlaceholder + " -->",
+			expected: "<!-- from /fragments/typescript.md -->\n# TS\n\n<!-- from /fragments/go/style.md -->\n# Go",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := SpliceFragmentsWithOptions(fragments, SpliceOptions{Separator: DefaultSeparator, SourceComment: tt.comment})
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestSpliceFragmentsWithOptions_IncludeFrontmatter(t *testing.T) {
+	fragments := []Fragment{
+		{Path: "go.md", Content: "# Go", RawFrontmatter: "ctx-tags: go\nctx-priority: 1"},
+		{Path: "plain.md", Content: "# Plain"},
+		{Path: "rust.md", Content: "# Rust", RawFrontmatter: "ctx-tags = \"rust\"", FrontmatterDelimiter: "+++"},
+	}
+
+	result := SpliceFragmentsWithOptions(fragments, SpliceOptions{Separator: DefaultSeparator, IncludeFrontmatter: true})
+
+	expected := "---\nctx-tags: go\nctx-priority: 1\n---\n# Go\n\n# Plain\n\n+++\nctx-tags = \"rust\"\n+++\n# Rust"
+	if result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+}
+
+// failingWriter fails after accepting limit bytes.
+type failingWriter struct {
+	limit   int
+	written int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.written+len(p) > w.limit {
+		return 0, errors.New("disk full")
+	}
+
+	w.written += len(p)
+
+	return len(p), nil
+}
+
+func TestSpliceFragmentsTo(t *testing.T) {
+	fragments := []Fragment{
+		{Path: "a.md", Content: "# A"},
+		{Path: "b.md", Content: "# B"},
+	}
+
+	var result strings.Builder
+	if err := SpliceFragmentsTo(&result, fragments, "\n<!-- "+FragmentPathPlaceholder+" -->\n"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "# A\n<!-- b.md -->\n# B"
+	if result.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, result.String())
+	}
+
+	if err := SpliceFragmentsTo(&failingWriter{limit: 4}, fragments, "\n\n"); err == nil {
+		t.Error("Expected write error to be returned")
+	}
+}
+
+// benchmarkFragments returns a corpus of n fragments of about 4 KiB each.
+func benchmarkFragments(n int) []Fragment {
+	content := strings.Repeat("Lorem ipsum dolor sit amet, consectetur adipiscing elit.\n", 72)
+	fragments := make([]Fragment, n)
+
+	for i := range fragments {
+		fragments[i] = Fragment{Path: fmt.Sprintf("fragment-%d.md", i), Content: content}
+	}
+
+	return fragments
+}
+
+func BenchmarkSpliceFragments(b *testing.B) {
+	fragments := benchmarkFragments(1000)
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		_ = SpliceFragments(fragments)
+	}
+}
+
+func BenchmarkSpliceFragmentsTo(b *testing.B) {
+	fragments := benchmarkFragments(1000)
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		if err := SpliceFragmentsTo(io.Discard, fragments, DefaultSeparator); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestSpliceFragmentsYAML(t *testing.T) {
+	fragments := []Fragment{
+		{Path: "/fragments/go.md", Tags: []string{"go", "backend"}, Content: "# Go\n\nUse gofmt.\n  Indented: yes"},
+		{Path: "/fragments/untagged.md", Content: "key: value --- not a document"},
+	}
+
+	output, err := SpliceFragmentsYAML(fragments)
+	if err != nil {
+		t.Fatalf("SpliceFragmentsYAML failed: %v", err)
+	}
+
+	if !strings.HasPrefix(output, "---\npath: /fragments/go.md\ntags: [go, backend]\ncontent: |") {
+		t.Errorf("Expected a document starting with ---, the path and flow tags, got:\n%s", output)
+	}
+
+	decoder := yaml.NewDecoder(strings.NewReader(output))
+
+	var documents []yamlDocument
+
+	for {
+		var document yamlDocument
+
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("Output is not valid multi-document YAML: %v\n%s", err, output)
+		}
+
+		documents = append(documents, document)
+	}
+
+	expected := []yamlDocument{
+		{Path: "/fragments/go.md", Tags: []string{"go", "backend"}, Content: fragments[0].Content},
+		{Path: "/fragments/untagged.md", Tags: []string{}, Content: fragments[1].Content},
+	}
+
+	if !reflect.DeepEqual(documents, expected) {
+		t.Errorf("Expected documents %+v, got %+v", expected, documents)
+	}
+}
+
+func TestSerializeFragmentsJSON(t *testing.T) {
+	fragments := []Fragment{
+		{Path: "/fragments/go.md", Tags: []string{"go", "backend"}, Content: "# Go\n\nUse \"gofmt\" <always>."},
+		{Path: "/fragments/untagged.md", Tags: []string{}, Content: ""},
+	}
+
+	output, err := SerializeFragmentsJSON(fragments)
+	if err != nil {
+		t.Fatalf("SerializeFragmentsJSON failed: %v", err)
+	}
+
+	if !json.Valid([]byte(output)) {
+		t.Fatalf("Expected valid JSON, got:\n%s", output)
+	}
+
+	var raw []map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &raw); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+
+	for i, entry := range raw {
+		if content, ok := entry["content"].(string); !ok || content != fragments[i].Content {
+			t.Errorf("Expected the content of fragment %d as a string, got %#v", i, entry["content"])
+		}
+	}
+
+	var parsed []Fragment
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+		t.Fatalf("Failed to parse JSON into fragments: %v", err)
+	}
+
+	if !reflect.DeepEqual(parsed, fragments) {
+		t.Errorf("Expected the original fragments %+v, got %+v", fragments, parsed)
+	}
+
+	if empty, err := SerializeFragmentsJSON(nil); err != nil || empty != "[]\n" {
+		t.Errorf("Expected an empty array for no fragments, got %q (%v)", empty, err)
+	}
+}
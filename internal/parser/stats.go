@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// Stats holds content statistics of a fragment.
+type Stats struct {
+	Path  string `json:"path,omitempty"`
+	Words int    `json:"words"`
+	Chars int    `json:"chars"`
+	Lines int    `json:"lines"`
+	Tags  int    `json:"tags"`
+	// SizeBytes is the size of the fragment file on disk, including its frontmatter.
+	SizeBytes int64 `json:"sizeBytes"`
+}
+
+// FragmentStats returns the statistics of a fragment. Words, characters and lines are
+// counted in the fragment content with includes resolved; the size is zero when the
+// fragment file cannot be read.
+func FragmentStats(f Fragment) Stats {
+	stats := Stats{
+		Path:  f.Path,
+		Words: len(strings.Fields(f.Content)),
+		Chars: utf8.RuneCountInString(f.Content),
+		Lines: countLines(f.Content),
+		Tags:  len(f.Tags),
+	}
+
+	if info, err := os.Stat(f.Path); err == nil {
+		stats.SizeBytes = info.Size()
+	}
+
+	return stats
+}
+
+// Add adds the counts of other to s; the path is left unchanged.
+func (s *Stats) Add(other Stats) {
+	s.Words += other.Words
+	s.Chars += other.Chars
+	s.Lines += other.Lines
+	s.Tags += other.Tags
+	s.SizeBytes += other.SizeBytes
+}
+
+// countLines returns the number of lines in content, not counting a trailing newline.
+func countLines(content string) int {
+	if content == "" {
+		return 0
+	}
+
+	return strings.Count(strings.TrimSuffix(content, "\n"), "\n") + 1
+}
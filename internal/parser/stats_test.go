@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFragmentStats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.md")
+	fileContent := "---\nctx-tags: go, testing\n---\nHello wörld\nsecond line\n"
+
+	if err := os.WriteFile(path, []byte(fileContent), 0o600); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+
+	fragment, err := ParseFragment(path)
+	if err != nil {
+		t.Fatalf("ParseFragment failed: %v", err)
+	}
+
+	expected := Stats{
+		Path:      path,
+		Words:     4,
+		Chars:     len([]rune(fragment.Content)),
+		Lines:     2,
+		Tags:      2,
+		SizeBytes: int64(len(fileContent)),
+	}
+
+	if stats := FragmentStats(*fragment); stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+}
+
+func TestFragmentStatsMissingFile(t *testing.T) {
+	stats := FragmentStats(Fragment{Path: "/nonexistent/a.md", Content: "one two"})
+
+	if stats.Words != 2 || stats.Lines != 1 || stats.SizeBytes != 0 {
+		t.Errorf("Expected 2 words, 1 line and size 0, got %+v", stats)
+	}
+}
+
+func TestCountLines(t *testing.T) {
+	tests := map[string]int{
+		"":             0,
+		"one":          1,
+		"one\n":        1,
+		"one\ntwo":     2,
+		"one\n\ntwo\n": 3,
+	}
+
+	for content, expected := range tests {
+		if lines := countLines(content); lines != expected {
+			t.Errorf("countLines(%q): expected %d, got %d", content, expected, lines)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// AddTagsToFragment adds tags to the ctx-tags frontmatter of the fragment at path, skipping
+// tags it already has. Comma-separated, flow sequence and block sequence tag lists keep
+// their style, and all other frontmatter keys are preserved. A ctx-tags key, and a
+// frontmatter block if needed, is added when the fragment has none.
+func AddTagsToFragment(path string, tags []string) error {
+	if err := validateTags(tags); err != nil {
+		return err
+	}
+
+	return editFragmentTags(path, func(tokens []string) []string {
+		for _, tag := range tags {
+			if !slices.ContainsFunc(tokens, func(token string) bool { return unquoteTag(token) == tag }) {
+				tokens = append(tokens, tag)
+			}
+		}
+
+		return tokens
+	})
+}
+
+// RemoveTagsFromFragment removes tags from the ctx-tags frontmatter of the fragment at path.
+// Tags it does not have are ignored. Like AddTagsToFragment it keeps the style of the tag
+// list and all other frontmatter keys; removing every tag leaves "ctx-tags: []".
+func RemoveTagsFromFragment(path string, tags []string) error {
+	return editFragmentTags(path, func(tokens []string) []string {
+		return slices.DeleteFunc(tokens, func(token string) bool {
+			return slices.Contains(tags, unquoteTag(token))
+		})
+	})
+}
+
+// validateTags checks that tags can be written to a ctx-tags list.
+func validateTags(tags []string) error {
+	for _, tag := range tags {
+		if strings.TrimSpace(tag) == "" {
+			return fmt.Errorf("tag names cannot be empty")
+		}
+
+		if strings.ContainsAny(tag, ",[]\n") {
+			return fmt.Errorf("invalid tag %q: tags cannot contain commas, brackets or newlines", tag)
+		}
+	}
+
+	return nil
+}
+
+// editFragmentTags replaces the ctx-tags list of the fragment at path with the result of
+// edit, which receives the current tags as written (including any quotes). The file is
+// only written when the list changes.
+func editFragmentTags(path string, edit func(tokens []string) []string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	lines := strings.Split(string(data), "\n")
+	if _, delimiter := frontmatterBlock(lines); delimiter == tomlDelimiter {
+		return errTOMLFrontmatter(path)
+	}
+
+	edited, changed := editTagsInLines(lines, edit)
+	if !changed {
+		return nil
+	}
+
+	if err := os.WriteFile(path, []byte(strings.Join(edited, "\n")), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// editTagsInLines returns lines with the ctx-tags list replaced by the result of edit and
+// reports whether it changed.
+func editTagsInLines(lines []string, edit func(tokens []string) []string) ([]string, bool) {
+	start, end := frontmatterBounds(lines)
+	if start < 0 {
+		tokens := edit(nil)
+		if len(tokens) == 0 {
+			return lines, false
+		}
+
+		return append([]string{"---", "ctx-tags: " + strings.Join(tokens, ", "), "---"}, lines...), true
+	}
+
+	for i := start + 1; i < end; i++ {
+		matches := ctxTagsLineRegex.FindStringSubmatch(lines[i])
+		if matches == nil {
+			continue
+		}
+
+		if strings.TrimSpace(matches[2]) != "" {
+			value, changed := editInlineTags(matches[2], edit)
+			if !changed {
+				return lines, false
+			}
+
+			return slices.Concat(lines[:i], []string{matches[1] + value}, lines[i+1:]), true
+		}
+
+		return editBlockTags(lines, i, end, edit)
+	}
+
+	tokens := edit(nil)
+	if len(tokens) == 0 {
+		return lines, false
+	}
+
+	return slices.Concat(lines[:start+1], []string{"ctx-tags: " + strings.Join(tokens, ", ")}, lines[start+1:]), true
+}
+
+// frontmatterBounds returns the indexes of the opening and closing "---" lines of the
+// frontmatter block, or -1 and -1 when there is none.
+func frontmatterBounds(lines []string) (int, int) {
+	mask := frontmatterMask(lines)
+	start := -1
+
+	for i, line := range lines {
+		if !mask[i] || strings.TrimSpace(line) != "---" {
+			continue
+		}
+
+		if start >= 0 {
+			return start, i
+		}
+
+		start = i
+	}
+
+	return -1, -1
+}
+
+// editInlineTags applies edit to a comma-separated or flow sequence value.
+func editInlineTags(value string, edit func(tokens []string) []string) (string, bool) {
+	trimmed := strings.TrimSpace(value)
+	flow := strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]")
+
+	if flow {
+		trimmed = trimmed[1 : len(trimmed)-1]
+	}
+
+	var tokens []string
+
+	for _, token := range strings.Split(trimmed, ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+
+	edited := edit(slices.Clone(tokens))
+	if slices.Equal(edited, tokens) {
+		return value, false
+	}
+
+	if flow || len(edited) == 0 {
+		return "[" + strings.Join(edited, ", ") + "]", true
+	}
+
+	return strings.Join(edited, ", "), true
+}
+
+// editBlockTags applies edit to the block sequence items following the ctx-tags line at
+// index key, before the frontmatter end.
+func editBlockTags(lines []string, key, end int, edit func(tokens []string) []string) ([]string, bool) {
+	prefix := "  - "
+	itemsEnd := key + 1
+
+	var tokens []string
+
+	for ; itemsEnd < end; itemsEnd++ {
+		matches := blockItemRegex.FindStringSubmatch(lines[itemsEnd])
+		if matches == nil {
+			break
+		}
+
+		if len(tokens) == 0 {
+			prefix = matches[1]
+		}
+
+		tokens = append(tokens, strings.TrimSpace(matches[2]))
+	}
+
+	edited := edit(slices.Clone(tokens))
+	if slices.Equal(edited, tokens) {
+		return lines, false
+	}
+
+	if len(edited) == 0 {
+		return slices.Concat(lines[:key], []string{"ctx-tags: []"}, lines[itemsEnd:]), true
+	}
+
+	items := make([]string, 0, len(edited))
+	for _, token := range edited {
+		items = append(items, prefix+token)
+	}
+
+	return slices.Concat(lines[:key+1], items, lines[itemsEnd:]), true
+}
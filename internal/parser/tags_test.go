@@ -0,0 +1,231 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAddTagsToFragment(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+		tags     []string
+	}{
+		{
+			name:     "comma separated",
+			content:  "---\nctx-tags: go, testing\nctx-priority: 2\n---\n\n# Go\n",
+			expected: "---\nctx-tags: go, testing, lint\nctx-priority: 2\n---\n\n# Go\n",
+			tags:     []string{"go", "testing", "lint"},
+		},
+		{
+			name:     "flow sequence",
+			content:  "---\nctx-description: Go rules\nctx-tags: [\"go\", rust]\n---\n\nBody\n",
+			expected: "---\nctx-description: Go rules\nctx-tags: [\"go\", rust, lint]\n---\n\nBody\n",
+			tags:     []string{"go", "rust", "lint"},
+		},
+		{
+			name:     "block sequence",
+			content:  "---\nctx-tags:\n    - go\nctx-priority: 1\n---\n\n- go\n",
+			expected: "---\nctx-tags:\n    - go\n    - lint\n    - ci\nctx-priority: 1\n---\n\n- go\n",
+			tags:     []string{"go", "lint", "ci"},
+		},
+		{
+			name:     "empty flow sequence",
+			content:  "---\nctx-tags: []\n---\n\nBody\n",
+			expected: "---\nctx-tags: [lint]\n---\n\nBody\n",
+			tags:     []string{"lint"},
+		},
+		{
+			name:     "frontmatter without tags",
+			content:  "---\nctx-priority: 3\n---\n\nBody\n",
+			expected: "---\nctx-tags: lint, ci\nctx-priority: 3\n---\n\nBody\n",
+			tags:     []string{"lint", "ci"},
+		},
+		{
+			name:     "no frontmatter",
+			content:  "# Title\n\nBody\n",
+			expected: "---\nctx-tags: lint\n---\n# Title\n\nBody\n",
+			tags:     []string{"lint", "lint"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "fragment.md")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("Failed to write fragment: %v", err)
+			}
+
+			if err := AddTagsToFragment(path, tt.tags); err != nil {
+				t.Fatalf("AddTagsToFragment failed: %v", err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read fragment: %v", err)
+			}
+
+			if string(data) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, data)
+			}
+
+			fragment, err := ParseFragment(path)
+			if err != nil {
+				t.Fatalf("ParseFragment failed: %v", err)
+			}
+
+			seen := make(map[string]int)
+			for _, tag := range fragment.Tags {
+				seen[tag]++
+			}
+
+			for _, tag := range tt.tags {
+				if seen[tag] != 1 {
+					t.Errorf("Expected tag %q exactly once, got %d in %v", tag, seen[tag], fragment.Tags)
+				}
+			}
+		})
+	}
+}
+
+func TestAddTagsToFragmentUnchanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fragment.md")
+	content := "---\nctx-tags: go,   testing\n---\n"
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write fragment: %v", err)
+	}
+
+	if err := AddTagsToFragment(path, []string{"testing"}); err != nil {
+		t.Fatalf("AddTagsToFragment failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != content {
+		t.Errorf("Expected file to be unchanged, got %q", data)
+	}
+}
+
+func TestAddTagsToFragmentInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fragment.md")
+	if err := os.WriteFile(path, []byte("---\nctx-tags: go\n---\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write fragment: %v", err)
+	}
+
+	for _, tags := range [][]string{{""}, {"a,b"}, {"[a]"}} {
+		if err := AddTagsToFragment(path, tags); err == nil {
+			t.Errorf("Expected an error for tags %v", tags)
+		}
+	}
+
+	fragment, err := ParseFragment(path)
+	if err != nil {
+		t.Fatalf("ParseFragment failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(fragment.Tags, []string{"go"}) {
+		t.Errorf("Expected tags [go], got %v", fragment.Tags)
+	}
+}
+
+func TestAddTagsToFragmentTOMLFrontmatter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fragment.md")
+	content := "+++\nctx-tags = [\"go\"]\n+++\n"
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write fragment: %v", err)
+	}
+
+	if err := AddTagsToFragment(path, []string{"testing"}); err == nil || !strings.Contains(err.Error(), "TOML") {
+		t.Errorf("Expected a TOML frontmatter error, got %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != content {
+		t.Errorf("Expected file to be unchanged, got %q", data)
+	}
+}
+
+func TestRemoveTagsFromFragment(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+		tags     []string
+	}{
+		{
+			name:     "comma separated",
+			content:  "---\nctx-tags: go, testing, lint\nctx-priority: 2\n---\n\n# Go\n",
+			expected: "---\nctx-tags: go, lint\nctx-priority: 2\n---\n\n# Go\n",
+			tags:     []string{"testing", "missing"},
+		},
+		{
+			name:     "flow sequence",
+			content:  "---\nctx-description: Go rules\nctx-tags: [\"go\", rust]\n---\n\nBody\n",
+			expected: "---\nctx-description: Go rules\nctx-tags: [rust]\n---\n\nBody\n",
+			tags:     []string{"go"},
+		},
+		{
+			name:     "block sequence",
+			content:  "---\nctx-tags:\n  - go\n  - lint\nctx-priority: 1\n---\n\n- go\n",
+			expected: "---\nctx-tags:\n  - lint\nctx-priority: 1\n---\n\n- go\n",
+			tags:     []string{"go"},
+		},
+		{
+			name:     "last comma separated tag",
+			content:  "---\nctx-tags: go\n---\n\nBody\n",
+			expected: "---\nctx-tags: []\n---\n\nBody\n",
+			tags:     []string{"go"},
+		},
+		{
+			name:     "last block sequence tag",
+			content:  "---\nctx-tags:\n  - go\nctx-priority: 1\n---\n",
+			expected: "---\nctx-tags: []\nctx-priority: 1\n---\n",
+			tags:     []string{"go"},
+		},
+		{
+			name:     "no frontmatter",
+			content:  "# Title\n",
+			expected: "# Title\n",
+			tags:     []string{"go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "fragment.md")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("Failed to write fragment: %v", err)
+			}
+
+			if err := RemoveTagsFromFragment(path, tt.tags); err != nil {
+				t.Fatalf("RemoveTagsFromFragment failed: %v", err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read fragment: %v", err)
+			}
+
+			if string(data) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, data)
+			}
+
+			fragment, err := ParseFragment(path)
+			if err != nil {
+				t.Fatalf("ParseFragment failed: %v", err)
+			}
+
+			for _, tag := range tt.tags {
+				if slices.Contains(fragment.Tags, tag) {
+					t.Errorf("Expected tag %q to be removed, got %v", tag, fragment.Tags)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// ApplyOutputTemplate renders the text/template at templatePath around the spliced content.
+// The template refers to the content as {{.Content}} and to each entry of vars by its key,
+// e.g. {{.Tags}}. Referencing a variable that is not defined is an error.
+func ApplyOutputTemplate(templatePath, content string, vars map[string]string) (string, error) {
+	data, err := os.ReadFile(filepath.Clean(templatePath))
+	if err != nil {
+		return "", fmt.Errorf("failed to read output template: %w", err)
+	}
+
+	tmpl, err := template.New(filepath.Base(templatePath)).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse output template: %w", err)
+	}
+
+	values := make(map[string]string, len(vars)+1)
+	for key, value := range vars {
+		values[key] = value
+	}
+
+	values["Content"] = content
+
+	var result strings.Builder
+	if err := tmpl.Execute(&result, values); err != nil {
+		return "", fmt.Errorf("failed to execute output template: %w", err)
+	}
+
+	return result.String(), nil
+}
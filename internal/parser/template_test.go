@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTemplate(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "output.tmpl")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	return path
+}
+
+func TestApplyOutputTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		vars     map[string]string
+		expected string
+		wantErr  string
+	}{
+		{
+			name:     "wraps content",
+			template: "# System Prompt\n\n{{.Content}}\n<!-- footer -->\n",
+			expected: "# System Prompt\n\nbody\n<!-- footer -->\n",
+		},
+		{
+			name:     "additional variables",
+			template: "<!-- tags: {{.Tags}} at {{.Timestamp}} -->\n{{.Content}}",
+			vars:     map[string]string{"Tags": "go,react", "Timestamp": "2025-01-02T03:04:05Z"},
+			expected: "<!-- tags: go,react at 2025-01-02T03:04:05Z -->\nbody",
+		},
+		{
+			name:     "content cannot be overridden by vars",
+			template: "{{.Content}}",
+			vars:     map[string]string{"Content": "other"},
+			expected: "body",
+		},
+		{
+			name:     "unknown variable",
+			template: "{{.Missing}}{{.Content}}",
+			wantErr:  "failed to execute output template",
+		},
+		{
+			name:     "invalid syntax",
+			template: "{{.Content",
+			wantErr:  "failed to parse output template",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ApplyOutputTemplate(writeTemplate(t, tt.template), "body", tt.vars)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestApplyOutputTemplateMissingFile(t *testing.T) {
+	_, err := ApplyOutputTemplate(filepath.Join(t.TempDir(), "missing.tmpl"), "body", nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to read output template") {
+		t.Errorf("Expected read error, got %v", err)
+	}
+}
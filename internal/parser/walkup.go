@@ -0,0 +1,48 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WalkUpLocalFragmentsDirs returns the .ctx/fragments directories of the current working
+// directory and its parent directories, outermost first, so that scanning them in order
+// with ScanFragmentsDirs lets fragments in deeper directories override those in parent
+// directories. The walk ends at the filesystem root, before crossing onto another device,
+// or after the first directory for which stop returns true.
+func WalkUpLocalFragmentsDirs(stop func(dir string) (bool, error)) ([]string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	startDevice, knownDevice := deviceID(cwd)
+
+	var dirs []string
+
+	for dir := cwd; ; {
+		if device, ok := deviceID(dir); knownDevice && ok && device != startDevice {
+			break
+		}
+
+		fragmentsDir := filepath.Join(dir, ".ctx", "fragments")
+		if info, err := os.Stat(fragmentsDir); err == nil && info.IsDir() {
+			dirs = append([]string{fragmentsDir}, dirs...)
+		}
+
+		stopped, err := stop(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		parent := filepath.Dir(dir)
+		if stopped || parent == dir {
+			break
+		}
+
+		dir = parent
+	}
+
+	return dirs, nil
+}
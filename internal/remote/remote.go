@@ -0,0 +1,315 @@
+// Package remote fetches fragment collections shared over HTTP.
+package remote
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+// maxFileSize is the largest fragment file or listing accepted from a remote source.
+const maxFileSize = 10 << 20
+
+// DefaultTimeout limits each download when Options.Client is nil, so that a stalled
+// server cannot hang a build.
+const DefaultTimeout = 30 * time.Second
+
+// fetchedMarker is the file in a cache directory whose modification time records the last fetch.
+const fetchedMarker = ".fetched"
+
+// hrefPattern matches the link targets of an HTML directory listing.
+var hrefPattern = regexp.MustCompile(`(?i)href\s*=\s*["']([^"'#?]+)`)
+
+// Options controls how remote fragments are fetched.
+type Options struct {
+	// CacheDir is the directory fetched fragments are stored in, one subdirectory per
+	// URL. Empty uses DefaultCacheDir.
+	CacheDir string
+	// CacheTTL is how long fetched fragments are reused before they are downloaded
+	// again; zero always downloads them.
+	CacheTTL time.Duration
+	// Client is the HTTP client used for downloads; nil uses a client with DefaultTimeout.
+	Client *http.Client
+	// NoNormalize keeps the content of the fragments as written, see parser.ParseOptions.
+	NoNormalize bool
+}
+
+// DefaultCacheDir returns the directory remote fragments are cached in by default.
+func DefaultCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user cache directory: %w", err)
+	}
+
+	return filepath.Join(cacheDir, "ctx", "remote"), nil
+}
+
+// FetchRemoteFragments downloads and parses the fragments at rawURL, which is either an
+// HTTP directory listing linking to .md files or a tarball (optionally gzipped)
+// containing them.
+func FetchRemoteFragments(ctx context.Context, rawURL string) ([]parser.Fragment, error) {
+	return FetchRemoteFragmentsWithOptions(ctx, rawURL, Options{})
+}
+
+// FetchRemoteFragmentsWithOptions fetches remote fragments like FetchRemoteFragments using
+// the given options. The fragments keep the paths of their cached files. Their includes
+// must resolve inside the cache directory of the URL, so a remote source cannot splice
+// local files into the output.
+func FetchRemoteFragmentsWithOptions(ctx context.Context, rawURL string, opts Options) ([]parser.Fragment, error) {
+	base, err := url.Parse(rawURL)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") {
+		return nil, fmt.Errorf("invalid remote URL %q: must be an http or https URL", rawURL)
+	}
+
+	cacheRoot := opts.CacheDir
+	if cacheRoot == "" {
+		cacheRoot, err = DefaultCacheDir()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sum := sha256.Sum256([]byte(rawURL))
+	dir := filepath.Join(cacheRoot, hex.EncodeToString(sum[:8]))
+
+	if !isFresh(dir, opts.CacheTTL) {
+		client := opts.Client
+		if client == nil {
+			client = &http.Client{Timeout: DefaultTimeout}
+		}
+
+		if err := refreshCache(ctx, client, base, cacheRoot, dir); err != nil {
+			return nil, err
+		}
+	}
+
+	return parser.ScanFragmentsWithOptions(dir, parser.ScanOptions{NoNormalize: opts.NoNormalize, IncludeRoot: dir})
+}
+
+// isFresh reports whether dir was fetched less than ttl ago.
+func isFresh(dir string, ttl time.Duration) bool {
+	if ttl <= 0 {
+		return false
+	}
+
+	info, err := os.Stat(filepath.Join(dir, fetchedMarker))
+
+	return err == nil && time.Since(info.ModTime()) < ttl
+}
+
+// refreshCache downloads the fragments at base into a staging directory and replaces dir
+// with it, so a failed download leaves the previous cache intact.
+func refreshCache(ctx context.Context, client *http.Client, base *url.URL, cacheRoot, dir string) error {
+	if err := os.MkdirAll(cacheRoot, 0o750); err != nil {
+		return fmt.Errorf("failed to create remote cache directory: %w", err)
+	}
+
+	staging, err := os.MkdirTemp(cacheRoot, "fetch-")
+	if err != nil {
+		return fmt.Errorf("failed to create remote cache directory: %w", err)
+	}
+
+	defer func() { _ = os.RemoveAll(staging) }()
+
+	if err := download(ctx, client, base, staging); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(staging, fetchedMarker), nil, 0o600); err != nil {
+		return fmt.Errorf("failed to write remote cache: %w", err)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to replace remote cache: %w", err)
+	}
+
+	if err := os.Rename(staging, dir); err != nil {
+		return fmt.Errorf("failed to replace remote cache: %w", err)
+	}
+
+	return nil
+}
+
+// download fetches base and stores the fragments it contains in dir.
+func download(ctx context.Context, client *http.Client, base *url.URL, dir string) error {
+	body, err := get(ctx, client, base)
+	if err != nil {
+		return err
+	}
+
+	if isTarball(base, body) {
+		return extractTarball(body, dir)
+	}
+
+	links := listingLinks(base, body)
+	if len(links) == 0 {
+		return fmt.Errorf("no .md files found at %s", base)
+	}
+
+	for _, link := range links {
+		content, err := get(ctx, client, link)
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, path.Base(link.Path)), content, 0o600); err != nil {
+			return fmt.Errorf("failed to write remote fragment: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// get returns the body of a successful GET request for target.
+func get(ctx context.Context, client *http.Client, target *url.URL) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", target, err)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", target, err)
+	}
+
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", target, response.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", target, err)
+	}
+
+	if len(body) > maxFileSize {
+		return nil, fmt.Errorf("failed to fetch %s: response exceeds %d bytes", target, maxFileSize)
+	}
+
+	return body, nil
+}
+
+// isTarball reports whether body is a tar archive, judged by the URL extension or,
+// for gzip data, by its magic bytes.
+func isTarball(target *url.URL, body []byte) bool {
+	name := strings.ToLower(target.Path)
+
+	return strings.HasSuffix(name, ".tar") || strings.HasSuffix(name, ".tar.gz") ||
+		strings.HasSuffix(name, ".tgz") || bytes.HasPrefix(body, []byte{0x1f, 0x8b})
+}
+
+// extractTarball writes the .md files of a tar archive, optionally gzipped, to dir,
+// keeping their relative paths. Entries escaping dir are rejected.
+func extractTarball(body []byte, dir string) error {
+	var reader io.Reader = bytes.NewReader(body)
+
+	if bytes.HasPrefix(body, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return fmt.Errorf("failed to read remote tarball: %w", err)
+		}
+
+		defer func() { _ = gz.Close() }()
+
+		reader = gz
+	}
+
+	archive := tar.NewReader(bufio.NewReader(reader))
+
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to read remote tarball: %w", err)
+		}
+
+		if header.Typeflag != tar.TypeReg || !strings.HasSuffix(strings.ToLower(header.Name), ".md") {
+			continue
+		}
+
+		if err := extractFile(archive, header.Name, dir); err != nil {
+			return err
+		}
+	}
+}
+
+// extractFile writes the current entry of archive to name below dir.
+func extractFile(archive *tar.Reader, name, dir string) error {
+	rel := filepath.FromSlash(path.Clean("/" + name))[1:]
+	if rel == "" {
+		return fmt.Errorf("invalid file name in remote tarball: %q", name)
+	}
+
+	target := filepath.Join(dir, rel)
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return fmt.Errorf("failed to write remote fragment: %w", err)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(archive, maxFileSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read remote tarball: %w", err)
+	}
+
+	if len(content) > maxFileSize {
+		return fmt.Errorf("remote fragment %s exceeds %d bytes", name, maxFileSize)
+	}
+
+	if err := os.WriteFile(target, content, 0o600); err != nil {
+		return fmt.Errorf("failed to write remote fragment: %w", err)
+	}
+
+	return nil
+}
+
+// listingLinks returns the .md files linked from a directory listing at base. Only
+// links to files directly in the listed directory are followed.
+func listingLinks(base *url.URL, body []byte) []*url.URL {
+	dir := *base
+	if !strings.HasSuffix(dir.Path, "/") {
+		dir.Path += "/"
+	}
+
+	dirPath := path.Clean(dir.Path)
+	seen := make(map[string]bool)
+
+	var links []*url.URL
+
+	for _, match := range hrefPattern.FindAllSubmatch(body, -1) {
+		ref, err := url.Parse(string(match[1]))
+		if err != nil {
+			continue
+		}
+
+		link := dir.ResolveReference(ref)
+		if link.Host != dir.Host || path.Dir(link.Path) != dirPath ||
+			!strings.HasSuffix(strings.ToLower(link.Path), ".md") || seen[link.Path] {
+			continue
+		}
+
+		seen[link.Path] = true
+		links = append(links, link)
+	}
+
+	return links
+}
@@ -0,0 +1,215 @@
+package remote
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fragmentNames(t *testing.T, rawURL string, opts Options) []string {
+	t.Helper()
+
+	fragments, err := FetchRemoteFragmentsWithOptions(context.Background(), rawURL, opts)
+	if err != nil {
+		t.Fatalf("FetchRemoteFragmentsWithOptions failed: %v", err)
+	}
+
+	names := make([]string, 0, len(fragments))
+	for _, fragment := range fragments {
+		names = append(names, filepath.Base(fragment.Path)+":"+fragment.Content)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func TestFetchRemoteFragmentsDirectoryListing(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/fragments/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<a href="go.md">go.md</a> <a href="/fragments/web.md">web.md</a>
+<a href="notes.txt">notes.txt</a> <a href="../other.md">other</a> <a href="http://example.com/x.md">x</a>`))
+	})
+	mux.HandleFunc("/fragments/go.md", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("---\nctx-tags: go\n---\nGo"))
+	})
+	mux.HandleFunc("/fragments/web.md", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("---\nctx-tags: web\n---\nWeb"))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	names := fragmentNames(t, server.URL+"/fragments", Options{CacheDir: t.TempDir()})
+
+	expected := []string{"go.md:Go", "web.md:Web"}
+	if len(names) != len(expected) || names[0] != expected[0] || names[1] != expected[1] {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+}
+
+func TestFetchRemoteFragmentsTarball(t *testing.T) {
+	var archive bytes.Buffer
+
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+
+	files := map[string]string{
+		"lib/go.md":        "---\nctx-tags: go\n---\nGo",
+		"../../escape.md":  "---\nctx-tags: go\n---\nEscape",
+		"lib/README.txt":   "ignored",
+		"lib/nested/a.md":  "---\nctx-tags: a\n---\nA",
+		"lib/nested/b.bin": "ignored",
+	}
+
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write tar entry: %v", err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close tar writer: %v", err)
+	}
+
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Failed to close gzip writer: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archive.Bytes())
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	names := fragmentNames(t, server.URL+"/fragments.tar.gz", Options{CacheDir: cacheDir})
+
+	expected := []string{"a.md:A", "escape.md:Escape", "go.md:Go"}
+	if len(names) != len(expected) || names[0] != expected[0] || names[1] != expected[1] || names[2] != expected[2] {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(cacheDir), "escape.md"))
+	if len(matches) != 0 {
+		t.Errorf("Expected tar entries not to escape the cache directory, found %v", matches)
+	}
+}
+
+func TestFetchRemoteFragmentsConfinesIncludes(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "secret.md")
+	if err := os.WriteFile(secret, []byte("top secret"), 0o600); err != nil {
+		t.Fatalf("Failed to create local file: %v", err)
+	}
+
+	fragments := map[string]string{
+		"/fragments/shared.md": "Shared",
+		"/fragments/good.md":   "---\nctx-tags: go\nctx-include: shared.md\n---\nGood",
+		"/fragments/evil.md":   "---\nctx-tags: go\nctx-include: " + secret + "\n---\nEvil",
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/fragments/", func(w http.ResponseWriter, r *http.Request) {
+		if content, ok := fragments[r.URL.Path]; ok {
+			_, _ = w.Write([]byte(content))
+			return
+		}
+
+		for name := range fragments {
+			_, _ = w.Write([]byte(`<a href="` + path.Base(name) + `">` + name + `</a>`))
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	_, err := FetchRemoteFragmentsWithOptions(context.Background(), server.URL+"/fragments/", Options{CacheDir: t.TempDir()})
+	if err == nil || !strings.Contains(err.Error(), "outside of") {
+		t.Fatalf("Expected an include outside the cache directory to be rejected, got %v", err)
+	}
+
+	delete(fragments, "/fragments/evil.md")
+
+	names := fragmentNames(t, server.URL+"/fragments/", Options{CacheDir: t.TempDir()})
+	if len(names) != 2 || names[0] != "good.md:Shared\nGood" {
+		t.Errorf("Expected includes inside the cache directory to resolve, got %v", names)
+	}
+}
+
+func TestFetchRemoteFragmentsTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+
+	defer server.Close()
+	defer close(release)
+
+	client := &http.Client{Timeout: 50 * time.Millisecond}
+
+	_, err := FetchRemoteFragmentsWithOptions(context.Background(), server.URL+"/fragments", Options{CacheDir: t.TempDir(), Client: client})
+	if err == nil {
+		t.Fatal("Expected a stalled server to fail the fetch")
+	}
+}
+
+func TestFetchRemoteFragmentsCacheTTL(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/a.md" {
+			requests++
+			_, _ = w.Write([]byte("---\nctx-tags: go\n---\nA"))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`<a href="a.md">a.md</a>`))
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+
+	fragmentNames(t, server.URL+"/", Options{CacheDir: cacheDir, CacheTTL: time.Hour})
+	fragmentNames(t, server.URL+"/", Options{CacheDir: cacheDir, CacheTTL: time.Hour})
+
+	if requests != 1 {
+		t.Errorf("Expected the cached fragments to be reused, got %d downloads", requests)
+	}
+
+	fragmentNames(t, server.URL+"/", Options{CacheDir: cacheDir})
+
+	if requests != 2 {
+		t.Errorf("Expected a download without a cache TTL, got %d downloads", requests)
+	}
+}
+
+func TestFetchRemoteFragmentsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing/" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write([]byte("no links here"))
+	}))
+	defer server.Close()
+
+	for _, rawURL := range []string{"ftp://example.com/fragments", server.URL + "/missing/", server.URL + "/empty/"} {
+		if _, err := FetchRemoteFragmentsWithOptions(context.Background(), rawURL, Options{CacheDir: t.TempDir()}); err == nil {
+			t.Errorf("Expected error for %s, got nil", rawURL)
+		}
+	}
+}
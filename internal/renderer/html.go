@@ -0,0 +1,37 @@
+// Package renderer converts spliced Markdown output into other document formats.
+package renderer
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+const htmlHeader = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>ctx</title>
+</head>
+<body>
+`
+
+const htmlFooter = `</body>
+</html>
+`
+
+// markdown is the converter used by RenderHTML, with GitHub Flavored Markdown enabled.
+var markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// RenderHTML renders markdown as a complete HTML5 document.
+func RenderHTML(source string) (string, error) {
+	var body bytes.Buffer
+
+	if err := markdown.Convert([]byte(source), &body); err != nil {
+		return "", fmt.Errorf("failed to render HTML: %w", err)
+	}
+
+	return htmlHeader + body.String() + htmlFooter, nil
+}
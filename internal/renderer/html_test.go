@@ -0,0 +1,41 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderHTML(t *testing.T) {
+	source := "# Title\n\nSome *text*.\n\n```go\nfmt.Println(\"<hi>\")\n```\n"
+
+	html, err := RenderHTML(source)
+	if err != nil {
+		t.Fatalf("RenderHTML failed: %v", err)
+	}
+
+	expected := []string{
+		"<h1>Title</h1>",
+		"<em>text</em>",
+		`<pre><code class="language-go">fmt.Println(&quot;&lt;hi&gt;&quot;)`,
+	}
+
+	for _, want := range expected {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected HTML to contain %q, got:\n%s", want, html)
+		}
+	}
+
+	if !strings.HasPrefix(html, "<!DOCTYPE html>\n<html>") {
+		t.Errorf("Expected HTML5 doctype and html element, got:\n%s", html)
+	}
+
+	for _, tag := range []string{"html", "head", "body"} {
+		if strings.Count(html, "<"+tag+">") != 1 || strings.Count(html, "</"+tag+">") != 1 {
+			t.Errorf("Expected exactly one <%s> element, got:\n%s", tag, html)
+		}
+	}
+
+	if !strings.Contains(html, `<meta charset="utf-8">`) {
+		t.Errorf("Expected charset declaration, got:\n%s", html)
+	}
+}
@@ -0,0 +1,127 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+// ArchiveFragmentOptions represents the options for the fragment archive and restore commands.
+type ArchiveFragmentOptions struct {
+	ConfigFile string
+	Name       string
+}
+
+// RunArchiveFragment moves the fragment named opts.Name to the archive directory of its
+// fragments directory. When several fragments share the name, the active one is archived.
+func RunArchiveFragment(opts *ArchiveFragmentOptions) error {
+	dirs, err := configuredFragmentsDirs(opts.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	dir, rel, matches, err := locateFragment(dirs, opts.Name, func(dir string) string { return dir })
+	if err != nil {
+		return err
+	}
+
+	archived, err := parser.ArchiveFragment(dir, rel)
+	if err != nil {
+		return fmt.Errorf("failed to archive fragment: %w", err)
+	}
+
+	fmt.Printf("Archived %s to %s\n", filepath.Join(dir, rel), archived)
+	printOtherMatches(matches, opts.Name)
+
+	return nil
+}
+
+// RunRestoreFragment moves the archived fragment named opts.Name back to its fragments directory.
+func RunRestoreFragment(opts *ArchiveFragmentOptions) error {
+	dirs, err := configuredFragmentsDirs(opts.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	dir, rel, matches, err := locateFragment(dirs, opts.Name, parser.ArchiveDir)
+	if err != nil {
+		return fmt.Errorf("archived %w", err)
+	}
+
+	restored, err := parser.RestoreFragment(dir, rel)
+	if err != nil {
+		return fmt.Errorf("failed to restore fragment: %w", err)
+	}
+
+	fmt.Printf("Restored %s to %s\n", filepath.Join(parser.ArchiveDir(dir), rel), restored)
+	printOtherMatches(matches, opts.Name)
+
+	return nil
+}
+
+// configuredFragmentsDirs returns the global fragments directories followed by the local one.
+func configuredFragmentsDirs(configFile string) ([]string, error) {
+	cfg, err := config.LoadMergedConfig(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	dirs, err := config.GetFragmentsDirs(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fragments directory: %w", err)
+	}
+
+	localDir, err := parser.LocalFragmentsDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(dirs, localDir), nil
+}
+
+// locateFragment finds the fragments called name in the directories that searchDir returns
+// for each fragments directory. It returns the fragments directory and relative path of the
+// last match, which takes precedence like in a build, and the number of matches.
+func locateFragment(dirs []string, name string, searchDir func(dir string) string) (string, string, int, error) {
+	var foundDir, foundRel string
+
+	matches := 0
+
+	for _, dir := range dirs {
+		root := searchDir(dir)
+
+		paths, err := parser.FindFragmentFiles(root)
+		if err != nil {
+			return "", "", 0, fmt.Errorf("failed to scan %s: %w", root, err)
+		}
+
+		for _, path := range paths {
+			if !fragmentNameMatches(root, path, name) {
+				continue
+			}
+
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return "", "", 0, err
+			}
+
+			foundDir, foundRel = dir, rel
+			matches++
+		}
+	}
+
+	if matches == 0 {
+		return "", "", 0, fmt.Errorf("fragment %q not found", name)
+	}
+
+	return foundDir, foundRel, matches, nil
+}
+
+// printOtherMatches notes that other fragments with the same name were left in place.
+func printOtherMatches(matches int, name string) {
+	if matches > 1 {
+		fmt.Printf("Note: %d other fragment(s) named %q were left in place.\n", matches-1, name)
+	}
+}
@@ -0,0 +1,84 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// writeFile and rename are indirections over the os functions so tests can simulate failures.
+var (
+	writeFile = os.WriteFile
+	rename    = os.Rename
+)
+
+// atomicTempPath returns the temporary file used while writing filename.
+func atomicTempPath(filename string) string {
+	return filepath.Join(filepath.Dir(filename), "."+filepath.Base(filename)+".ctx.tmp")
+}
+
+// writeFileAtomic writes data to a temporary file next to filename and renames it into
+// place, so an interrupted write never leaves a partial file at filename. If the rename
+// fails the content is copied over instead and the temporary file removed.
+func writeFileAtomic(filename string, data []byte) error {
+	return writeAtomic(filename, func(tmp string) error {
+		return writeFile(tmp, data, 0o600)
+	})
+}
+
+// writeStreamAtomic is writeFileAtomic for content that write produces piece by piece, so
+// it never has to be held in memory as a whole.
+func writeStreamAtomic(filename string, write func(w io.Writer) error) error {
+	return writeAtomic(filename, func(tmp string) error {
+		file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			return err
+		}
+
+		buffered := bufio.NewWriter(file)
+		if err := write(buffered); err != nil {
+			_ = file.Close()
+			return err
+		}
+
+		if err := buffered.Flush(); err != nil {
+			_ = file.Close()
+			return err
+		}
+
+		return file.Close()
+	})
+}
+
+// writeAtomic implements writeFileAtomic with writeTmp writing the temporary file.
+func writeAtomic(filename string, writeTmp func(tmp string) error) error {
+	tmp := atomicTempPath(filename)
+
+	if err := writeTmp(tmp); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	if err := rename(tmp, filename); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: atomic rename of %s failed, copying instead: %v\n", filename, err)
+
+		copyErr := copyFile(tmp, filename)
+		_ = os.Remove(tmp)
+
+		return copyErr
+	}
+
+	return nil
+}
+
+// copyFile copies the content of src to dst.
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(dst, data, 0o600)
+}
@@ -0,0 +1,111 @@
+package tui
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	tests := []struct {
+		name            string
+		writeFile       func(string, []byte, os.FileMode) error
+		rename          func(string, string) error
+		expectError     bool
+		expectedContent string
+	}{
+		{
+			name:            "successful write",
+			expectedContent: "new",
+		},
+		{
+			name: "write failure leaves target untouched",
+			writeFile: func(name string, data []byte, perm os.FileMode) error {
+				// Simulate a write interrupted after a partial write
+				_ = os.WriteFile(name, data[:1], perm)
+				return errors.New("disk full")
+			},
+			expectError:     true,
+			expectedContent: "old",
+		},
+		{
+			name: "rename failure falls back to copy",
+			rename: func(string, string) error {
+				return errors.New("cross-device link")
+			},
+			expectedContent: "new",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			originalWriteFile, originalRename := writeFile, rename
+			defer func() { writeFile, rename = originalWriteFile, originalRename }()
+
+			if tt.writeFile != nil {
+				writeFile = tt.writeFile
+			}
+
+			if tt.rename != nil {
+				rename = tt.rename
+			}
+
+			target := filepath.Join(t.TempDir(), "AGENTS.md")
+			if err := os.WriteFile(target, []byte("old"), 0o600); err != nil {
+				t.Fatalf("Failed to create target file: %v", err)
+			}
+
+			err := writeFileAtomic(target, []byte("new"))
+			if (err != nil) != tt.expectError {
+				t.Fatalf("Expected error %v, got %v", tt.expectError, err)
+			}
+
+			content, err := os.ReadFile(target)
+			if err != nil {
+				t.Fatalf("Failed to read target file: %v", err)
+			}
+
+			if string(content) != tt.expectedContent {
+				t.Errorf("Expected content %q, got %q", tt.expectedContent, content)
+			}
+
+			if pathExists(atomicTempPath(target)) {
+				t.Error("Expected temporary file to be removed")
+			}
+		})
+	}
+}
+
+func TestWriteStreamAtomic(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "AGENTS.md")
+	if err := os.WriteFile(target, []byte("old"), 0o600); err != nil {
+		t.Fatalf("Failed to create target file: %v", err)
+	}
+
+	// A failing stream leaves the target untouched
+	err := writeStreamAtomic(target, func(w io.Writer) error {
+		_, _ = io.WriteString(w, "partial")
+		return errors.New("stream failed")
+	})
+	if err == nil {
+		t.Fatal("Expected the stream error")
+	}
+
+	if content, _ := os.ReadFile(target); string(content) != "old" || pathExists(atomicTempPath(target)) {
+		t.Fatalf("Expected the old content and no temporary file, got %q", content)
+	}
+
+	err = writeStreamAtomic(target, func(w io.Writer) error {
+		_, err := io.WriteString(w, "new")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("writeStreamAtomic failed: %v", err)
+	}
+
+	if content, _ := os.ReadFile(target); string(content) != "new" {
+		t.Errorf("Expected the streamed content, got %q", content)
+	}
+}
@@ -1,14 +1,24 @@
 package tui
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/Lewenhaupt/ctx/internal/config"
 	"github.com/Lewenhaupt/ctx/internal/parser"
+	"github.com/Lewenhaupt/ctx/internal/remote"
 	"github.com/charmbracelet/huh"
+	"github.com/mattn/go-isatty"
 )
 
 // BuildOptions represents the options for the build command.
@@ -17,149 +27,1253 @@ type BuildOptions struct {
 	Tags            []string
 	NonInteractive  bool
 	OutputFormats   []string
-	OutputFile      string
+	OutputFiles     []string
 	Stdout          bool
 	NoLocalOverride bool
+	Deduplicate     bool
+	DryRun          bool
+	SkipIfUnchanged bool
+	TagsFile        string
+	BuildReport     string
+	Profile         string
+	Check           bool
+	IgnoreTags      []string
+	OutputDir       string
+	Groups          []string
+	Parallel        bool
+	OutputHTML      bool
+	OutputTemplate  string
+	HashManifest    string
+	Append          bool
+	SkipHooks       bool
+	// SortStrategy orders the fragments in the output, see parser.SortFragments; empty sorts by priority.
+	SortStrategy string
+	// Remote is the URL of a remote fragment collection combined with the configured
+	// fragments at the lowest priority.
+	Remote string
+	// RemoteCacheTTL is how long fetched remote fragments are reused; zero always fetches them.
+	RemoteCacheTTL time.Duration
+	// Since skips the build unless a selected fragment was modified after it; zero disables the check.
+	Since time.Time
+	// NoLocal skips the local .ctx/fragments directory and the project configs; it cannot be combined with NoLocalOverride.
+	NoLocal bool
+	// Verbose logs the scanned, excluded and spliced fragments and the output files to Logger.
+	Verbose bool
+	// Logger receives the verbose log; when nil it is written to stderr if Verbose is set
+	// and discarded otherwise.
+	Logger Logger
+	// Stdin adds the content piped to stdin to the spliced fragments, at StdinPosition
+	// ("before" or "after"; empty means after).
+	Stdin         bool
+	StdinPosition string
+	// OutputEncoding is the encoding of the output, utf8 or ascii; empty means utf8.
+	OutputEncoding string
+	// NoSeparator joins the fragments without a separator, overriding the configured one.
+	NoSeparator bool
+	// Quiet leaves out the summary line printed after a build that wrote files.
+	Quiet bool
+	// ExtraFragments are paths of files parsed as fragments and added after the fragments
+	// selected by tags, regardless of their tags.
+	ExtraFragments []string
+	// NoNormalize keeps the fragment content as written, overriding normalizeContent from
+	// the config.
+	NoNormalize bool
+	// AllowCollision lets several output formats write the same file, the last one
+	// winning, instead of failing the build.
+	AllowCollision bool
+	// OverwritePolicy decides what happens to existing output files: OverwritePolicyAsk,
+	// OverwritePolicyAlways or OverwritePolicyNever. Empty uses overwritePolicy from the
+	// config, or OverwritePolicyAsk.
+	OverwritePolicy string
+	// StdinTags reads the tags from stdin, one per line like TagsFile; it cannot be
+	// combined with Tags, TagsFile or Stdin.
+	StdinTags bool
+	// UpdateIndex regenerates the fragment index at DefaultIndexPath after the build,
+	// recording Version as the ctx version.
+	UpdateIndex bool
+	Version     string
+	// InteractivePreview shows every selected fragment in a pager before the build is
+	// confirmed; it has no effect with NonInteractive.
+	InteractivePreview bool
+	// NoHeader leaves out the outputHeader from the config for this build.
+	NoHeader bool
+	// IncludeFrontmatter keeps the frontmatter of each fragment in the output instead of
+	// stripping it.
+	IncludeFrontmatter bool
+	// SourceComments writes a comment naming the source file above each fragment, formatted
+	// with SourceCommentFormat or, when that is empty, parser.DefaultSourceComment.
+	SourceComments      bool
+	SourceCommentFormat string
+	// WriteMetadata writes a SidecarMetadata file next to each output file, see SidecarPath.
+	WriteMetadata bool
+	// EventHandler, if set, receives build progress events in addition to the printed output.
+	EventHandler func(event BuildEvent)
+	// FailOnMissingTags fails the build when a selected tag matches no fragment, instead of
+	// only when the selected tags match no fragment at all.
+	FailOnMissingTags bool
+	// ColorEnabled colors the status messages: written files green, skipped files yellow
+	// and cancellations red.
+	ColorEnabled bool
+	// WalkUp also scans the .ctx/fragments directories of the parent directories, see
+	// parser.WalkUpLocalFragmentsDirs; it cannot be combined with NoLocal.
+	WalkUp bool
+	// SkipPreprocessors splices the fragments without piping them through the configured
+	// preprocessors.
+	SkipPreprocessors bool
+	// TagPrefixes select every tag starting with one of the prefixes, in addition to Tags.
+	TagPrefixes []string
+	// FailOnEmptyPrefix fails the build when no tag starts with one of TagPrefixes, instead
+	// of printing a warning.
+	FailOnEmptyPrefix bool
+	// OutputPermissions is the octal mode, e.g. "0644", of the written output files; it
+	// overrides the permissions of the output formats and outputPermissions from the config.
+	OutputPermissions string
+	// MaxFragments fails the build when more fragments are selected; zero uses maxFragments
+	// from the config and a negative value disables the limit.
+	MaxFragments int
+	// Zip writes the output files into a zip archive at this path instead of to disk,
+	// together with a ZipManifestName manifest; it cannot be combined with Stdout.
+	Zip string
 }
 
-// RunBuild executes the build command with TUI.
-func RunBuild(opts *BuildOptions) error {
-	cfg, fragments, err := loadConfigAndFragments(opts.ConfigFile, opts.NoLocalOverride)
+// BuildResult describes a completed build for callers embedding ctx as a library.
+type BuildResult struct {
+	// Summary is only filled in for builds that wrote files.
+	Summary BuildSummary
+	// SelectedTags are the tags the fragments were selected with, after expansion.
+	SelectedTags []string
+	// Fragments are the spliced fragments in output order.
+	Fragments []parser.Fragment
+	// OutputFiles are the files actually written; empty for stdout, dry-run and check builds.
+	OutputFiles []string
+	// Stdout reports whether the output was printed to stdout instead of written to files.
+	Stdout bool
+	// Skipped reports whether the build was skipped because no fragment changed since the
+	// build recorded in the hash manifest.
+	Skipped bool
+}
+
+// ErrOutputOutdated is returned by RunBuild in check mode when an output file would change.
+var ErrOutputOutdated = errors.New("output files are out of date")
+
+// dryRunPreviewLines is the number of content lines shown per file in dry-run mode.
+const dryRunPreviewLines = 10
+
+// buildPlan holds the resolved inputs of a build before any output is written.
+type buildPlan struct {
+	cfg           *config.Config
+	selectedTags  []string
+	fragments     []parser.Fragment
+	outputFormats []string
+	outputFiles   []string
+}
+
+// RunBuild executes the build command with TUI and describes the build in the returned
+// result. The result is nil when the build fails or is cancelled by the user.
+func RunBuild(opts *BuildOptions) (*BuildResult, error) {
+	plan, err := planBuild(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	skipped, err := skipUnchangedBuild(opts, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	if skipped {
+		return &BuildResult{SelectedTags: plan.selectedTags, Fragments: plan.fragments, Skipped: true}, nil
+	}
+
+	if !opts.NonInteractive {
+		confirmed, err := previewAndConfirmBuild(opts, plan)
+		if err != nil {
+			return nil, err
+		}
+
+		if !confirmed {
+			opts.printStatus(errorStyle, "Build cancelled.")
+			return nil, nil
+		}
+	}
+
+	start := time.Now()
+
+	plan.fragments, err = preprocessFragments(opts, plan.cfg, plan.fragments)
+	if err != nil {
+		return nil, err
+	}
+
+	output, written, err := writeBuildOutput(opts, plan)
 	if err != nil {
+		return nil, err
+	}
+
+	if err := writeBuildArtifacts(opts, plan, written, output); err != nil {
+		return nil, err
+	}
+
+	if err := runPostBuildHook(opts, plan.cfg); err != nil {
+		return nil, err
+	}
+
+	opts.emit(BuildCompleteEvent{Fragments: len(plan.fragments), OutputFiles: written})
+
+	var summary BuildSummary
+	if len(written) > 0 {
+		summary = newBuildSummary(len(plan.fragments), written, start)
+		if !opts.Quiet {
+			opts.printStatus(successStyle, "%s", summary)
+		}
+	}
+
+	return &BuildResult{
+		Summary:      summary,
+		SelectedTags: plan.selectedTags,
+		Fragments:    plan.fragments,
+		OutputFiles:  written,
+		Stdout:       opts.Stdout,
+	}, nil
+}
+
+// skipUnchangedBuild reports whether the build can be skipped because none of the selected
+// fragments changed since Since or since the build recorded in the hash manifest, and prints why.
+// Builds to stdout, dry runs and checks are never skipped.
+func skipUnchangedBuild(opts *BuildOptions, plan *buildPlan) (bool, error) {
+	if opts.Stdout || opts.DryRun || opts.Check {
+		return false, nil
+	}
+
+	if !opts.Since.IsZero() && len(parser.FilterFragmentsModifiedSince(plan.fragments, opts.Since)) == 0 {
+		exist, err := outputFilesExist(opts, plan)
+		if err != nil || !exist {
+			return false, err
+		}
+
+		opts.printStatus(skippedStyle, "no fragments changed since %s", opts.Since.Format(time.RFC3339))
+
+		return true, nil
+	}
+
+	unchanged, err := fragmentsUnchanged(opts, plan)
+	if err != nil || !unchanged {
+		return false, err
+	}
+
+	opts.printStatus(skippedStyle, "No fragment changes since the last build recorded in %s; skipping build.", opts.HashManifest)
+
+	return true, nil
+}
+
+// fragmentsUnchanged reports whether the build can be skipped because the hash manifest
+// lists exactly the planned fragments with unchanged checksums, was rendered with the same
+// options and all output files exist. Builds that do not write files are never skipped, and
+// neither are builds with Stdin, whose content is not recorded.
+func fragmentsUnchanged(opts *BuildOptions, plan *buildPlan) (bool, error) {
+	if opts.HashManifest == "" || opts.Stdout || opts.DryRun || opts.Check || opts.Stdin {
+		return false, nil
+	}
+
+	manifest, err := ReadHashManifest(opts.HashManifest)
+	if err != nil || manifest == nil {
+		return false, err
+	}
+
+	current, err := newHashManifest(opts, plan)
+	if err != nil || !manifest.Matches(current) {
+		return false, err
+	}
+
+	return outputFilesExist(opts, plan)
+}
+
+// outputFilesExist reports whether the output files of all planned formats exist, or with
+// Zip whether the zip archive exists.
+func outputFilesExist(opts *BuildOptions, plan *buildPlan) (bool, error) {
+	if opts.Zip != "" {
+		_, err := os.Stat(opts.Zip)
+		return err == nil, nil
+	}
+
+	for i, format := range plan.outputFormats {
+		filename, err := resolveOutputFilename(format, i, plan.outputFiles, opts.OutputDir, plan.cfg)
+		if err != nil {
+			return false, err
+		}
+
+		if _, err := os.Stat(filename); err != nil {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// writeBuildArtifacts writes the build report, hash manifest, sidecar files and fragment
+// index of a build that wrote files.
+func writeBuildArtifacts(opts *BuildOptions, plan *buildPlan, written []string, output *buildOutput) error {
+	if opts.DryRun || opts.Check {
+		return nil
+	}
+
+	if opts.BuildReport != "" {
+		report := newBuildReport(plan, written, outputContents(opts, plan, output), time.Now())
+		if err := WriteBuildReport(opts.BuildReport, report); err != nil {
+			return err
+		}
+	}
+
+	if opts.HashManifest != "" {
+		manifest, err := newHashManifest(opts, plan)
+		if err != nil {
+			return err
+		}
+
+		if err := WriteHashManifest(opts.HashManifest, manifest); err != nil {
+			return err
+		}
+	}
+
+	if opts.WriteMetadata {
+		if err := writeSidecarFiles(written, newSidecarMetadata(plan, time.Now())); err != nil {
+			return err
+		}
+	}
+
+	if opts.UpdateIndex {
+		if _, err := writeFragmentIndex(plan.cfg, DefaultIndexPath, opts.Version); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// planBuild loads the configuration and fragments and resolves the tags and output formats to use.
+// The pre-build hook runs between loading the configuration and scanning the fragments.
+func planBuild(opts *BuildOptions) (*buildPlan, error) {
+	loadConfig := config.LoadMergedConfig
+	if opts.NoLocal {
+		loadConfig = config.LoadGlobalConfig
+	}
+
+	cfg, err := loadConfig(opts.ConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := validateBuildOptions(opts, cfg); err != nil {
+		return nil, err
+	}
+
+	if err := runPreBuildHook(opts, cfg); err != nil {
+		return nil, err
+	}
+
+	fragments, err := loadBuildFragments(cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fragment := range fragments {
+		opts.emit(FragmentScannedEvent{Fragment: fragment})
+		opts.logf("scanned: %s (tags: %s)", fragment.Path, formatLogTags(fragment.Tags))
+	}
+
+	opts, err = applyProfile(opts, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	selectedTags, err := determineSelectedTags(opts, cfg, fragments)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.emit(TagsSelectedEvent{Tags: selectedTags})
+
+	filteredFragments, err := selectBuildFragments(opts, cfg, fragments, selectedTags)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkMaxFragments(opts, cfg, filteredFragments); err != nil {
+		return nil, err
+	}
+
+	logFragmentSelection(opts, fragments, filteredFragments)
+
+	selectedOutputFormats, outputFiles, err := determineOutputFormats(opts, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &buildPlan{
+		cfg:           cfg,
+		selectedTags:  selectedTags,
+		fragments:     filteredFragments,
+		outputFormats: selectedOutputFormats,
+		outputFiles:   outputFiles,
+	}, nil
+}
+
+// selectBuildFragments returns the fragments matching the selected tags and none of the
+// ignored ones in splice order, followed by the opts.ExtraFragments, which bypass the tag
+// filter. It fails when no fragment is left.
+func selectBuildFragments(opts *BuildOptions, cfg *config.Config, fragments []parser.Fragment, selectedTags []string) ([]parser.Fragment, error) {
+	ignoreTags, err := parser.ExpandAliases(opts.IgnoreTags, cfg.Aliases)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand tag aliases: %w", err)
+	}
+
+	selected := parser.FilterFragmentsByTagExpression(fragments, selectedTags)
+	selected = parser.ExcludeFragmentsByTags(selected, ignoreTags)
+	selected = parser.SortFragments(selected, opts.SortStrategy)
+
+	selected, err = appendExtraFragments(opts, cfg, selected)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no fragments match the selected tags: %s", strings.Join(selectedTags, ", "))
+	}
+
+	return selected, nil
+}
+
+// appendExtraFragments parses the files given with --fragment and appends those that are
+// not selected already.
+func appendExtraFragments(opts *BuildOptions, cfg *config.Config, selected []parser.Fragment) ([]parser.Fragment, error) {
+	included := make(map[string]bool, len(selected))
+	for _, fragment := range selected {
+		included[absPath(fragment.Path)] = true
+	}
+
+	for _, path := range opts.ExtraFragments {
+		if included[absPath(path)] {
+			continue
+		}
+
+		fragment, err := parser.ParseFragmentWithOptions(path, parser.ParseOptions{NoNormalize: buildScanOptions(opts, cfg).NoNormalize})
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse fragment %s: %w", path, err)
+		}
+
+		included[absPath(path)] = true
+		selected = append(selected, *fragment)
+
+		opts.logf("added with --fragment: %s", path)
+	}
+
+	return selected, nil
+}
+
+// absPath returns the absolute form of path, or path itself when it cannot be determined.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+
+	return path
+}
+
+// checkMaxFragments fails when more fragments were selected than opts.MaxFragments or, when
+// that is zero, maxFragments from the config allows. A negative opts.MaxFragments, set by an
+// explicit --max-fragments 0, disables the limit.
+func checkMaxFragments(opts *BuildOptions, cfg *config.Config, fragments []parser.Fragment) error {
+	limit := opts.MaxFragments
+	if limit == 0 {
+		limit = cfg.MaxFragments
+	}
+
+	if limit <= 0 || len(fragments) <= limit {
+		return nil
+	}
+
+	return fmt.Errorf("%d fragments match the selected tags, more than the limit of %d; select narrower tags or raise --max-fragments", len(fragments), limit)
+}
+
+// validateBuildOptions rejects invalid build options and output permissions before the
+// pre-build hook runs.
+func validateBuildOptions(opts *BuildOptions, cfg *config.Config) error {
+	if opts.SortStrategy != "" && !slices.Contains(parser.SortStrategies, opts.SortStrategy) {
+		return fmt.Errorf("invalid sort strategy %q (expected %s)", opts.SortStrategy, strings.Join(parser.SortStrategies, ", "))
+	}
+
+	if opts.Zip != "" && opts.Stdout {
+		return fmt.Errorf("--zip and --stdout cannot be used together")
+	}
+
+	if opts.Append && opts.Parallel {
+		return fmt.Errorf("--append and --parallel cannot be used together")
+	}
+
+	if err := validateLocalOptions(opts); err != nil {
+		return err
+	}
+
+	if err := validateStdinTags(opts); err != nil {
+		return err
+	}
+
+	if _, err := overwritePolicy(opts, cfg); err != nil {
 		return err
 	}
 
-	selectedTags, err := determineSelectedTags(opts, cfg, fragments)
-	if err != nil {
-		return err
+	_, err := defaultOutputPermissions(opts, cfg)
+
+	return err
+}
+
+// validateStdinTags rejects StdinTags combined with other tag sources or other input
+// read from stdin.
+func validateStdinTags(opts *BuildOptions) error {
+	if !opts.StdinTags {
+		return nil
+	}
+
+	if len(opts.Tags) > 0 || opts.TagsFile != "" {
+		return fmt.Errorf("--stdin-tags cannot be combined with --tags or --tags-file")
+	}
+
+	if opts.Stdin {
+		return fmt.Errorf("--stdin-tags and --stdin cannot be used together")
+	}
+
+	return nil
+}
+
+func loadConfigAndFragments(configFile string, noLocalOverride bool) (*config.Config, []parser.Fragment, error) {
+	cfg, err := config.LoadMergedConfig(configFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	fragments, err := loadFragments(cfg, noLocalOverride)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cfg, fragments, nil
+}
+
+// loadFragments scans the configured fragments and fails if there are none.
+func loadFragments(cfg *config.Config, noLocalOverride bool) ([]parser.Fragment, error) {
+	fragments, fragmentsDirs, err := scanConfiguredFragments(cfg, scanOptions(cfg), noLocalOverride)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fragments) == 0 {
+		return nil, fmt.Errorf("no fragments found in %s or local .ctx/fragments", strings.Join(fragmentsDirs, ", "))
+	}
+
+	return fragments, nil
+}
+
+// loadBuildFragments loads the configured fragments and, when opts.Remote is set, the
+// remote fragments, which are overridden by configured fragments with the same filename.
+// Local fragments are skipped when opts.NoLocal is set.
+func loadBuildFragments(cfg *config.Config, opts *BuildOptions) ([]parser.Fragment, error) {
+	scan := scanConfiguredFragments
+	sources := "local .ctx/fragments"
+
+	switch {
+	case opts.NoLocal:
+		scan = scanGlobalFragments
+		sources = ""
+	case opts.WalkUp:
+		scan = scanWalkUpFragments
+		sources = "local .ctx/fragments directories"
+	}
+
+	scanOpts := buildScanOptions(opts, cfg)
+
+	fragments, fragmentsDirs, err := scan(cfg, scanOpts, opts.NoLocalOverride)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Remote != "" {
+		remoteOpts := remote.Options{CacheTTL: opts.RemoteCacheTTL, NoNormalize: scanOpts.NoNormalize}
+
+		remoteFragments, err := remote.FetchRemoteFragmentsWithOptions(context.Background(), opts.Remote, remoteOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch remote fragments: %w", err)
+		}
+
+		fragments = parser.CombineFragments(remoteFragments, fragments, opts.NoLocalOverride)
+		sources = strings.TrimPrefix(sources+", "+opts.Remote, ", ")
+	}
+
+	if len(fragments) == 0 {
+		if sources == "" {
+			return nil, fmt.Errorf("no fragments found in %s", strings.Join(fragmentsDirs, ", "))
+		}
+
+		return nil, fmt.Errorf("no fragments found in %s or %s", strings.Join(fragmentsDirs, ", "), sources)
+	}
+
+	return fragments, nil
+}
+
+// validateLocalOptions rejects combinations of the options selecting local fragments.
+func validateLocalOptions(opts *BuildOptions) error {
+	if opts.NoLocal && opts.NoLocalOverride {
+		return fmt.Errorf("--no-local and --no-local-override cannot be used together")
+	}
+
+	if opts.NoLocal && opts.WalkUp {
+		return fmt.Errorf("--no-local and --walk-up cannot be used together")
+	}
+
+	return nil
+}
+
+// scanConfiguredFragments scans the global fragments directories of cfg and the local
+// .ctx/fragments directory with scanOpts. It also returns the global directories that were
+// scanned.
+func scanConfiguredFragments(cfg *config.Config, scanOpts parser.ScanOptions, noLocalOverride bool) ([]parser.Fragment, []string, error) {
+	globalFragments, fragmentsDirs, err := scanGlobalFragments(cfg, scanOpts, noLocalOverride)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	localFragments, err := parser.ScanLocalFragmentsWithOptions(scanOpts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to scan local fragments: %w", err)
+	}
+
+	return parser.CombineFragments(globalFragments, localFragments, noLocalOverride), fragmentsDirs, nil
+}
+
+// scanWalkUpFragments scans the global fragments directories of cfg and the .ctx/fragments
+// directories of the current working directory and its parents. Local fragments override
+// global ones, and fragments in deeper directories override those in parent directories.
+// It also returns the global directories that were scanned.
+func scanWalkUpFragments(cfg *config.Config, scanOpts parser.ScanOptions, noLocalOverride bool) ([]parser.Fragment, []string, error) {
+	globalFragments, fragmentsDirs, err := scanGlobalFragments(cfg, scanOpts, noLocalOverride)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	localDirs, err := parser.WalkUpLocalFragmentsDirs(walkUpStopsAt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find local fragments: %w", err)
+	}
+
+	// A global fragments directory inside the walked directories is not scanned twice.
+	localDirs = slices.DeleteFunc(localDirs, func(dir string) bool {
+		return slices.Contains(fragmentsDirs, dir)
+	})
+
+	localFragments, err := parser.ScanFragmentsDirs(localDirs, false, scanOpts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to scan local fragments: %w", err)
+	}
+
+	return parser.CombineFragments(globalFragments, localFragments, noLocalOverride), fragmentsDirs, nil
+}
+
+// walkUpStopsAt reports whether the .ctx config of dir sets walkUp to false.
+func walkUpStopsAt(dir string) (bool, error) {
+	path := config.DirConfigPath(dir)
+	if _, err := os.Stat(path); err != nil {
+		return false, nil
+	}
+
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to load config %s: %w", path, err)
+	}
+
+	return cfg.WalkUp != nil && !*cfg.WalkUp, nil
+}
+
+// scanGlobalFragments scans only the global fragments directories of cfg and returns
+// the fragments along with the directories that were scanned.
+func scanGlobalFragments(cfg *config.Config, scanOpts parser.ScanOptions, noLocalOverride bool) ([]parser.Fragment, []string, error) {
+	fragmentsDirs, err := config.GetFragmentsDirs(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get fragments directory: %w", err)
+	}
+
+	fragments, err := parser.ScanFragmentsDirs(fragmentsDirs, noLocalOverride, scanOpts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to scan global fragments: %w", err)
+	}
+
+	return fragments, fragmentsDirs, nil
+}
+
+// applyProfile returns a copy of opts with the tags and output formats of the profile
+// named in opts.Profile filled in. Tags and output formats given on the command line
+// take precedence over the profile.
+func applyProfile(opts *BuildOptions, cfg *config.Config) (*BuildOptions, error) {
+	if opts.Profile == "" {
+		return opts, nil
+	}
+
+	profile, ok := cfg.Profiles[opts.Profile]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q (available: %s)", opts.Profile, strings.Join(profileNames(cfg), ", "))
+	}
+
+	resolved := *opts
+
+	if len(resolved.Tags) == 0 && len(resolved.Groups) == 0 && resolved.TagsFile == "" && !resolved.StdinTags {
+		resolved.Tags = profile.Tags
+	}
+
+	if len(resolved.OutputFormats) == 0 && len(resolved.OutputFiles) == 0 {
+		resolved.OutputFormats = profile.OutputFormats
+	}
+
+	return &resolved, nil
+}
+
+// scanOptions returns the fragment scan options configured in cfg.
+func scanOptions(cfg *config.Config) parser.ScanOptions {
+	return parser.ScanOptions{
+		NamespaceFromDir: cfg.NamespaceFromDir,
+		NoNormalize:      cfg.NormalizeContent != nil && !*cfg.NormalizeContent,
+	}
+}
+
+// parseOptions returns the fragment parse options configured in cfg.
+func parseOptions(cfg *config.Config) parser.ParseOptions {
+	return scanOptions(cfg).ParseOptions()
+}
+
+// buildScanOptions returns the scan options of a build: those configured in cfg, with
+// opts.NoNormalize overriding normalizeContent.
+func buildScanOptions(opts *BuildOptions, cfg *config.Config) parser.ScanOptions {
+	scanOpts := scanOptions(cfg)
+	scanOpts.NoNormalize = scanOpts.NoNormalize || opts.NoNormalize
+
+	return scanOpts
+}
+
+// determineSelectedTags returns the tags to build with, expanding any tag groups and aliases
+// they name. With FailOnMissingTags every tag must be carried by at least one fragment.
+func determineSelectedTags(opts *BuildOptions, cfg *config.Config, fragments []parser.Fragment) ([]string, error) {
+	for _, group := range opts.Groups {
+		if _, ok := cfg.TagGroups[group]; !ok {
+			return nil, fmt.Errorf("unknown tag group %q", group)
+		}
+	}
+
+	selectedTags, err := requestedOrSelectedTags(opts, cfg, fragments)
+	if err != nil {
+		return nil, err
+	}
+
+	selectedTags, err = parser.ExpandTagGroups(selectedTags, cfg.TagGroups)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand tag groups: %w", err)
+	}
+
+	selectedTags, err = parser.ExpandAliases(selectedTags, cfg.Aliases)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand tag aliases: %w", err)
+	}
+
+	selectedTags, err = expandTagGlobs(opts, selectedTags, fragments)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.FailOnMissingTags {
+		if err := checkMissingTags(selectedTags, fragments); err != nil {
+			return nil, err
+		}
+	}
+
+	return selectedTags, nil
+}
+
+// expandTagGlobs replaces the glob patterns among the tags, such as lang-*, with the tags
+// of the fragments they match. A pattern matching no tag prints a warning, or fails with
+// FailOnMissingTags; the build fails if no tag is left.
+func expandTagGlobs(opts *BuildOptions, tags []string, fragments []parser.Fragment) ([]string, error) {
+	allTags := parser.GetAllTags(fragments)
+
+	for _, tag := range tags {
+		if !strings.ContainsAny(tag, "*?[") || len(parser.ExpandGlobTagTerms([]string{tag}, allTags)) > 0 {
+			continue
+		}
+
+		if opts.FailOnMissingTags {
+			return nil, fmt.Errorf("unknown tag %q: no fragment tag matches the pattern", tag)
+		}
+
+		fmt.Fprintf(os.Stderr, "Warning: no tags match %q\n", tag)
+	}
+
+	expanded := parser.ExpandGlobTagTerms(tags, allTags)
+	if len(tags) > 0 && len(expanded) == 0 {
+		return nil, fmt.Errorf("no tags match %s", strings.Join(tags, ", "))
+	}
+
+	return expanded, nil
+}
+
+// checkMissingTags returns an error listing every tag that no fragment carries.
+func checkMissingTags(tags []string, fragments []parser.Fragment) error {
+	var errs []error
+
+	for _, tag := range parser.ValidateTags(tags, fragments) {
+		errs = append(errs, fmt.Errorf("unknown tag %q: no fragment is tagged with it", tag))
+	}
+
+	return errors.Join(errs...)
+}
+
+// requestedOrSelectedTags returns the tags given on the command line, the default tags
+// in non-interactive mode, or the tags selected interactively.
+func requestedOrSelectedTags(opts *BuildOptions, cfg *config.Config, fragments []parser.Fragment) ([]string, error) {
+	allTags := parser.GetAllTagInfo(fragments)
+	if len(allTags) == 0 {
+		return nil, fmt.Errorf("no tags found in fragments")
+	}
+
+	listedTags, err := readTagList(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	requestedTags := unionTags(unionTags(opts.Tags, opts.Groups), listedTags)
+
+	prefixTags, err := expandTagPrefixes(opts, fragments)
+	if err != nil {
+		return nil, err
+	}
+
+	requestedTags = unionTags(requestedTags, prefixTags)
+
+	if len(requestedTags) > 0 {
+		return requestedTags, nil
+	}
+
+	if opts.NonInteractive {
+		return cfg.DefaultTags, nil
+	}
+
+	selectedTags, err := selectTags(allTags, cfg.DefaultTags)
+	if err != nil {
+		return nil, fmt.Errorf("tag selection failed: %w", err)
+	}
+
+	return selectedTags, nil
+}
+
+// expandTagPrefixes returns the tags of the fragments starting with any of opts.TagPrefixes.
+// A prefix no tag starts with prints a warning, or fails with opts.FailOnEmptyPrefix.
+func expandTagPrefixes(opts *BuildOptions, fragments []parser.Fragment) ([]string, error) {
+	var tags []string
+
+	for _, prefix := range opts.TagPrefixes {
+		matches := parser.TagsWithPrefix(prefix, fragments)
+		if len(matches) > 0 {
+			tags = unionTags(tags, matches)
+			continue
+		}
+
+		if opts.FailOnEmptyPrefix {
+			return nil, fmt.Errorf("no tags start with prefix %q", prefix)
+		}
+
+		fmt.Fprintf(os.Stderr, "Warning: no tags start with prefix %q\n", prefix)
+	}
+
+	return tags, nil
+}
+
+// readTagList returns the tags listed one per line in opts.TagsFile or, with
+// opts.StdinTags, piped to stdin.
+func readTagList(opts *BuildOptions) ([]string, error) {
+	switch {
+	case opts.TagsFile != "":
+		return readTagsFile(opts.TagsFile)
+	case opts.StdinTags:
+		return readStdinTags()
+	default:
+		return nil, nil
+	}
+}
+
+// readStdinTags reads tags from stdin, one per line. Stdin must not be a terminal.
+func readStdinTags() ([]string, error) {
+	if isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd()) {
+		return nil, fmt.Errorf("--stdin-tags requires the tags to be piped to stdin")
+	}
+
+	tags, err := parseTagLines(os.Stdin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tags from stdin: %w", err)
+	}
+
+	return tags, nil
+}
+
+// readTagsFile reads tags from a file, one per line.
+func readTagsFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tags file: %w", err)
+	}
+
+	defer func() { _ = file.Close() }()
+
+	tags, err := parseTagLines(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tags file %s: %w", path, err)
+	}
+
+	return tags, nil
+}
+
+// parseTagLines reads one tag per line, trimming whitespace and skipping blank lines
+// and lines starting with #.
+func parseTagLines(r io.Reader) ([]string, error) {
+	var tags []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		tags = append(tags, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// unionTags returns the tags of both lists without duplicates, preserving order.
+func unionTags(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+
+	var result []string
+
+	for _, tag := range append(append([]string{}, a...), b...) {
+		if !seen[tag] {
+			seen[tag] = true
+
+			result = append(result, tag)
+		}
+	}
+
+	return result
+}
+
+// determineOutputFormats returns the output formats to write and the files given for the
+// custom format. Formats writing the same file are an error unless AllowCollision is set.
+func determineOutputFormats(opts *BuildOptions, cfg *config.Config) (selectedFormats, outputFiles []string, err error) {
+	selectedFormats, outputFiles, err = requestedOutputFormats(opts, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !opts.AllowCollision {
+		if err := checkOutputCollisions(opts, cfg, selectedFormats, outputFiles); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := checkStructuredAppend(opts, cfg, selectedFormats); err != nil {
+		return nil, nil, err
+	}
+
+	return selectedFormats, outputFiles, nil
+}
+
+// checkStructuredAppend rejects appending to the file of a structured format, which would
+// leave a file that no longer parses as a single document.
+func checkStructuredAppend(opts *BuildOptions, cfg *config.Config, formats []string) error {
+	for _, format := range formats {
+		if _, structured := structuredFormat(cfg, format); !structured {
+			continue
+		}
+
+		if formatConfig, _ := cfg.OutputFormat(format); opts.Append || formatConfig.AppendMode {
+			return fmt.Errorf("output format %s cannot be appended to", format)
+		}
+	}
+
+	return nil
+}
+
+// checkOutputCollisions returns an error naming the formats of every output file that more
+// than one of the formats would write.
+func checkOutputCollisions(opts *BuildOptions, cfg *config.Config, formats, customFiles []string) error {
+	writers := make(map[string][]string)
+
+	var filenames []string
+
+	for i, format := range formats {
+		if format == "stdout" {
+			continue
+		}
+
+		filename, err := resolveOutputFilename(format, i, customFiles, opts.OutputDir, cfg)
+		if err != nil {
+			// Unknown formats are reported when the files are written
+			continue
+		}
+
+		filename = filepath.Clean(filename)
+		if _, seen := writers[filename]; !seen {
+			filenames = append(filenames, filename)
+		}
+
+		writers[filename] = append(writers[filename], format)
+	}
+
+	var errs []error
+
+	for _, filename := range filenames {
+		if names := writers[filename]; len(names) > 1 {
+			slices.Sort(names)
+			errs = append(errs, fmt.Errorf("output formats %s all write to %s; configure different files or pass --allow-collision to let the last one win", strings.Join(names, ", "), filename))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// requestedOutputFormats returns the output formats given on the command line, all
+// configured formats in non-interactive mode, or the formats selected interactively.
+func requestedOutputFormats(opts *BuildOptions, cfg *config.Config) (selectedFormats, outputFiles []string, err error) {
+	if opts.Stdout {
+		return []string{"stdout"}, nil, nil
+	}
+
+	// "--output-format custom" is accepted alongside the output files for backward compatibility.
+	if len(opts.OutputFiles) > 0 && (len(opts.OutputFormats) == 0 || slices.Equal(opts.OutputFormats, []string{"custom"})) {
+		formats := make([]string, len(opts.OutputFiles))
+		for i := range formats {
+			formats[i] = "custom"
+		}
+
+		return formats, opts.OutputFiles, nil
+	}
+
+	if len(opts.OutputFormats) > 0 {
+		return opts.OutputFormats, nil, nil
+	}
+
+	if opts.NonInteractive {
+		if len(cfg.OutputFormats) == 0 {
+			return nil, nil, fmt.Errorf("no output formats configured and none specified")
+		}
+
+		var selectedOutputFormats []string
+
+		for format := range cfg.OutputFormats {
+			selectedOutputFormats = append(selectedOutputFormats, format)
+		}
+
+		return selectedOutputFormats, nil, nil
+	}
+
+	selectedOutputFormats, err := selectOutputFormats(cfg.OutputFormats)
+	if err != nil {
+		return nil, nil, fmt.Errorf("output format selection failed: %w", err)
+	}
+
+	return selectedOutputFormats, nil, nil
+}
+
+// handleOutput prints or writes the output and returns the paths of the files written.
+func handleOutput(opts *BuildOptions, output *buildOutput, plan *buildPlan) ([]string, error) {
+	selectedOutputFormats, outputFiles, cfg := plan.outputFormats, plan.outputFiles, plan.cfg
+
+	if opts.Stdout {
+		fmt.Print(output.content)
+		return nil, nil
+	}
+
+	if opts.Check {
+		return nil, checkOutputFiles(opts, output, selectedOutputFormats, outputFiles, cfg)
 	}
 
-	filteredFragments := parser.FilterFragmentsByTags(fragments, selectedTags)
-	if len(filteredFragments) == 0 {
-		return fmt.Errorf("no fragments match the selected tags: %s", strings.Join(selectedTags, ", "))
+	if opts.DryRun {
+		return nil, previewOutputFiles(output, selectedOutputFormats, outputFiles, opts.OutputDir, cfg)
 	}
 
-	selectedOutputFormats, outputFiles, err := determineOutputFormats(opts, cfg)
-	if err != nil {
-		return err
+	if opts.Zip != "" {
+		return writeZipArchive(opts, output, plan)
 	}
 
-	if !opts.NonInteractive {
-		confirmed, err := confirmBuild(filteredFragments, selectedTags, selectedOutputFormats)
+	if opts.Append {
+		written, err := appendOutputFiles(opts, output, appendSeparator(opts, plan), selectedOutputFormats, outputFiles, cfg)
 		if err != nil {
-			return fmt.Errorf("confirmation failed: %w", err)
+			return nil, fmt.Errorf("failed to append to output files: %w", err)
 		}
 
-		if !confirmed {
-			fmt.Println("Build cancelled.")
-			return nil
-		}
+		return written, nil
 	}
 
-	output := parser.SpliceFragments(filteredFragments)
-
-	return handleOutput(opts, output, selectedOutputFormats, outputFiles, cfg)
+	return writeOrAppendOutputFiles(opts, output, plan)
 }
 
-func loadConfigAndFragments(configFile string, noLocalOverride bool) (*config.Config, []parser.Fragment, error) {
-	cfg, err := config.LoadConfig(configFile)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+// writeOrAppendOutputFiles writes the output files, appending to those of formats
+// configured with append mode, and returns the paths of the files written.
+func writeOrAppendOutputFiles(opts *BuildOptions, output *buildOutput, plan *buildPlan) ([]string, error) {
+	var writeFormats, appendFormats []string
+
+	for _, format := range plan.outputFormats {
+		if formatConfig, _ := plan.cfg.OutputFormat(format); formatConfig.AppendMode {
+			appendFormats = append(appendFormats, format)
+		} else {
+			writeFormats = append(writeFormats, format)
+		}
 	}
 
-	fragmentsDir, err := config.GetFragmentsDir(cfg)
+	written, err := writeOutputFiles(opts, output, writeFormats, plan.outputFiles, plan.cfg)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get fragments directory: %w", err)
+		return nil, fmt.Errorf("failed to write output files: %w", err)
 	}
 
-	globalFragments, err := parser.ScanFragments(fragmentsDir)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to scan global fragments: %w", err)
+	if len(appendFormats) == 0 {
+		return written, nil
 	}
 
-	localFragments, err := parser.ScanLocalFragments()
+	appended, err := appendOutputFiles(opts, output, appendSeparator(opts, plan), appendFormats, nil, plan.cfg)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to scan local fragments: %w", err)
+		return nil, fmt.Errorf("failed to append to output files: %w", err)
 	}
 
-	fragments := parser.CombineFragments(globalFragments, localFragments, noLocalOverride)
+	return append(written, appended...), nil
+}
 
-	if len(fragments) == 0 {
-		return nil, nil, fmt.Errorf("no fragments found in %s or local .ctx/fragments", fragmentsDir)
-	}
+// previewOutputFiles prints the files a build would write and the start of their content
+// without touching the filesystem.
+func previewOutputFiles(output *buildOutput, formats, customFiles []string, outputDir string, cfg *config.Config) error {
+	count := 0
 
-	return cfg, fragments, nil
-}
+	for i, format := range formats {
+		if format == "stdout" {
+			continue
+		}
 
-func determineSelectedTags(opts *BuildOptions, cfg *config.Config, fragments []parser.Fragment) ([]string, error) {
-	allTags := parser.GetAllTags(fragments)
-	if len(allTags) == 0 {
-		return nil, fmt.Errorf("no tags found in fragments")
-	}
+		filename, err := resolveOutputFilename(format, i, customFiles, outputDir, cfg)
+		if err != nil {
+			return err
+		}
 
-	if len(opts.Tags) > 0 {
-		return opts.Tags, nil
-	}
+		count++
 
-	if opts.NonInteractive {
-		return cfg.DefaultTags, nil
-	}
+		lines := strings.Split(output.fileContent(format), "\n")
+		if len(lines) > dryRunPreviewLines {
+			lines = lines[:dryRunPreviewLines]
+		}
 
-	selectedTags, err := selectTags(allTags, cfg.DefaultTags)
-	if err != nil {
-		return nil, fmt.Errorf("tag selection failed: %w", err)
+		fmt.Printf("[dry-run] %s (preview of first %d lines):\n%s\n\n", filename, dryRunPreviewLines, strings.Join(lines, "\n"))
 	}
 
-	return selectedTags, nil
-}
+	fmt.Printf("[dry-run] would write %d file(s)\n", count)
 
-func determineOutputFormats(opts *BuildOptions, cfg *config.Config) (selectedFormats, outputFiles []string, err error) {
-	if opts.Stdout {
-		return []string{"stdout"}, nil, nil
-	}
+	return nil
+}
 
-	if len(opts.OutputFormats) > 0 {
-		return opts.OutputFormats, nil, nil
-	}
+// checkOutputFiles compares the output with the existing output files without writing
+// anything. It prints the state of each file and returns ErrOutputOutdated if any file
+// would change; a missing file counts as a change.
+func checkOutputFiles(opts *BuildOptions, output *buildOutput, formats, customFiles []string, cfg *config.Config) error {
+	outdated := 0
 
-	if opts.OutputFile != "" {
-		return []string{"custom"}, []string{opts.OutputFile}, nil
-	}
+	for i, format := range formats {
+		if format == "stdout" {
+			continue
+		}
 
-	if opts.NonInteractive {
-		if len(cfg.OutputFormats) == 0 {
-			return nil, nil, fmt.Errorf("no output formats configured and none specified")
+		filename, err := resolveOutputFilename(format, i, customFiles, opts.OutputDir, cfg)
+		if err != nil {
+			return err
 		}
 
-		var selectedOutputFormats []string
+		existing, err := os.ReadFile(filename)
 
-		for format := range cfg.OutputFormats {
-			selectedOutputFormats = append(selectedOutputFormats, format)
-		}
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			opts.printStatus(skippedStyle, "would create: %s", filename)
 
-		return selectedOutputFormats, nil, nil
+			outdated++
+		case err != nil:
+			return fmt.Errorf("failed to read output file %s: %w", filename, err)
+		case string(existing) != output.fileContent(format):
+			opts.printStatus(skippedStyle, "would change: %s", filename)
+
+			outdated++
+		default:
+			opts.printStatus(successStyle, "up to date: %s", filename)
+		}
 	}
 
-	selectedOutputFormats, err := selectOutputFormats(cfg.OutputFormats)
-	if err != nil {
-		return nil, nil, fmt.Errorf("output format selection failed: %w", err)
+	if outdated > 0 {
+		return fmt.Errorf("%w: %d file(s) would change", ErrOutputOutdated, outdated)
 	}
 
-	return selectedOutputFormats, nil, nil
+	opts.printStatus(successStyle, "All output files are up to date.")
+
+	return nil
 }
 
-func handleOutput(opts *BuildOptions, output string, selectedOutputFormats, outputFiles []string, cfg *config.Config) error {
-	if opts.Stdout {
-		fmt.Print(output)
-		return nil
+// Policies for existing output files.
+const (
+	// OverwritePolicyAsk asks whether to overwrite, skip or cancel in interactive mode and
+	// overwrites in non-interactive mode.
+	OverwritePolicyAsk    = "ask"
+	OverwritePolicyAlways = "always"
+	OverwritePolicyNever  = "never"
+)
+
+// OverwritePolicies lists the valid values of --overwrite-policy.
+var OverwritePolicies = []string{OverwritePolicyAsk, OverwritePolicyAlways, OverwritePolicyNever}
+
+// overwritePolicy returns the policy for existing output files: opts.OverwritePolicy,
+// overwritePolicy from the config, or OverwritePolicyAsk.
+func overwritePolicy(opts *BuildOptions, cfg *config.Config) (string, error) {
+	policy, source := opts.OverwritePolicy, "--overwrite-policy"
+	if policy == "" {
+		policy, source = cfg.OverwritePolicy, "overwritePolicy in config"
 	}
 
-	err := writeOutputFiles(opts, output, selectedOutputFormats, outputFiles, cfg)
+	if policy == "" {
+		return OverwritePolicyAsk, nil
+	}
+
+	if !slices.Contains(OverwritePolicies, policy) {
+		return "", fmt.Errorf("invalid %s %q (expected %s)", source, policy, strings.Join(OverwritePolicies, ", "))
+	}
+
+	return policy, nil
+}
+
+// overwriteAction returns "overwrite", "skip" or "cancel" for an existing output file
+// according to the overwrite policy.
+func overwriteAction(opts *BuildOptions, cfg *config.Config, filename, format string) (string, error) {
+	policy, err := overwritePolicy(opts, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to write output files: %w", err)
+		return "", err
 	}
 
-	return nil
+	switch policy {
+	case OverwritePolicyAlways:
+		return "overwrite", nil
+	case OverwritePolicyNever:
+		return "skip", nil
+	default:
+		return handleFileOverwrite(opts, filename, format)
+	}
 }
 
 // handleFileOverwrite handles the case when an output file already exists.
@@ -194,7 +1308,7 @@ func handleFileOverwrite(opts *BuildOptions, filename, format string) (string, e
 }
 
 // selectTags presents an interactive multi-select for tag selection.
-func selectTags(allTags, defaultTags []string) ([]string, error) {
+func selectTags(allTags []parser.TagInfo, defaultTags []string) ([]string, error) {
 	// Pre-select default tags that exist in allTags
 	defaultTagsMap := make(map[string]bool)
 	for _, tag := range defaultTags {
@@ -203,16 +1317,16 @@ func selectTags(allTags, defaultTags []string) ([]string, error) {
 
 	var selectedTags []string
 
-	for _, tag := range allTags {
-		if defaultTagsMap[tag] {
-			selectedTags = append(selectedTags, tag)
+	for _, info := range allTags {
+		if defaultTagsMap[info.Tag] {
+			selectedTags = append(selectedTags, info.Tag)
 		}
 	}
 
-	// Create options for multi-select
+	// Create options for multi-select, labelled with the number of fragments using the tag
 	options := make([]huh.Option[string], len(allTags))
-	for i, tag := range allTags {
-		options[i] = huh.NewOption(tag, tag)
+	for i, info := range allTags {
+		options[i] = huh.NewOption(fmt.Sprintf("%s (%d)", info.Tag, info.Count), info.Tag)
 	}
 
 	form := huh.NewForm(
@@ -241,7 +1355,7 @@ func selectTags(allTags, defaultTags []string) ([]string, error) {
 }
 
 // selectOutputFormats presents an interactive multi-select for output format selection.
-func selectOutputFormats(availableFormats map[string]string) ([]string, error) {
+func selectOutputFormats(availableFormats map[string]config.OutputFormatConfig) ([]string, error) {
 	var selectedFormats []string
 
 	// Create options for multi-select
@@ -279,6 +1393,28 @@ func selectOutputFormats(availableFormats map[string]string) ([]string, error) {
 	return selectedFormats, nil
 }
 
+// previewAndConfirmBuild shows the planned fragments in a pager with InteractivePreview and
+// then asks for confirmation, reporting whether the build should go ahead.
+func previewAndConfirmBuild(opts *BuildOptions, plan *buildPlan) (bool, error) {
+	if opts.InteractivePreview {
+		proceed, err := previewFragments(plan.fragments)
+		if err != nil {
+			return false, fmt.Errorf("fragment preview failed: %w", err)
+		}
+
+		if !proceed {
+			return false, nil
+		}
+	}
+
+	confirmed, err := confirmBuild(plan.fragments, plan.selectedTags, plan.outputFormats)
+	if err != nil {
+		return false, fmt.Errorf("confirmation failed: %w", err)
+	}
+
+	return confirmed, nil
+}
+
 // confirmBuild shows a confirmation dialog before building.
 func confirmBuild(fragments []parser.Fragment, selectedTags, outputFormats []string) (bool, error) {
 	var confirmed bool
@@ -310,57 +1446,356 @@ func confirmBuild(fragments []parser.Fragment, selectedTags, outputFormats []str
 	return confirmed, nil
 }
 
-// writeOutputFiles writes the output to the specified files based on formats.
-func writeOutputFiles(opts *BuildOptions, output string, formats, customFiles []string, cfg *config.Config) error {
-	for i, format := range formats {
-		var filename string
+// writeOutputFiles writes the output to the specified files based on formats
+// and returns the paths of the files written.
+func writeOutputFiles(opts *BuildOptions, output *buildOutput, formats, customFiles []string, cfg *config.Config) ([]string, error) {
+	targets, err := selectOutputTargets(opts, output, formats, customFiles, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return writeOutputTargets(opts, targets)
+}
+
+// writeOutputTargets writes the targets and returns the paths of the files written. With
+// Parallel the files are written concurrently.
+func writeOutputTargets(opts *BuildOptions, targets []outputTarget) ([]string, error) {
+	for _, target := range targets {
+		opts.logf("writing: %s", target.filename)
+	}
+
+	if opts.Parallel {
+		return writeOutputTargetsParallel(opts, targets)
+	}
+
+	var written []string
+
+	for _, target := range targets {
+		if err := writeOutputFile(target); err != nil {
+			return nil, err
+		}
+
+		reportOutputWritten(opts, target)
+
+		written = append(written, target.filename)
+	}
+
+	return written, nil
+}
+
+// outputTarget is an output file to write together with its content and mode.
+type outputTarget struct {
+	filename string
+	content  string
+	// stream, if set, writes the content to the file instead of content, see
+	// streamOutputFiles.
+	stream      func(w io.Writer) error
+	permissions os.FileMode
+}
+
+// selectOutputTargets resolves the files to write for the formats, leaving out unchanged
+// files with SkipIfUnchanged and existing files the user chose to skip.
+func selectOutputTargets(opts *BuildOptions, output *buildOutput, formats, customFiles []string, cfg *config.Config) ([]outputTarget, error) {
+	var targets []outputTarget
 
+	for i, format := range formats {
 		if format == "stdout" {
 			// Skip stdout in file writing
 			continue
-		} else if format == "custom" && i < len(customFiles) {
-			filename = customFiles[i]
-		} else if outputFile, exists := cfg.OutputFormats[format]; exists {
-			filename = outputFile
-		} else {
-			return fmt.Errorf("unknown output format: %s", format)
+		}
+
+		filename, err := resolveOutputFilename(format, i, customFiles, opts.OutputDir, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		content := output.fileContent(format)
+
+		if opts.SkipIfUnchanged && outputUnchanged(filename, content) {
+			opts.printStatus(skippedStyle, "skipped: %s (unchanged)", filename)
+			continue
 		}
 
 		// Check if file already exists and handle overwrite
 		if _, err := os.Stat(filename); err == nil {
 			// File exists, check what to do
-			action, err := handleFileOverwrite(opts, filename, format)
+			action, err := overwriteAction(opts, cfg, filename, format)
 			if err != nil {
-				return fmt.Errorf("failed to handle file overwrite for %s: %w", filename, err)
+				return nil, fmt.Errorf("failed to handle file overwrite for %s: %w", filename, err)
 			}
 
 			switch action {
 			case "cancel":
-				fmt.Println("Build cancelled.")
-				return fmt.Errorf("build cancelled by user")
+				opts.printStatus(errorStyle, "Build cancelled.")
+				return nil, fmt.Errorf("build cancelled by user")
 			case "skip":
-				fmt.Printf("Skipping output format: %s (file %s already exists)\n", format, filename)
+				opts.printStatus(skippedStyle, "Skipping output format: %s (file %s already exists)", format, filename)
 				continue
 			case "overwrite":
 				// Continue with writing the file
 			}
 		}
 
-		// Ensure directory exists
-		dir := filepath.Dir(filename)
-		if dir != "." {
-			if err := os.MkdirAll(dir, 0o750); err != nil {
-				return fmt.Errorf("failed to create directory %s: %w", dir, err)
-			}
+		targets = append(targets, outputTarget{
+			filename:    filename,
+			content:     content,
+			permissions: outputFilePermissions(opts, cfg, format),
+		})
+	}
+
+	return targets, nil
+}
+
+// writeOutputTargetsParallel writes all targets concurrently and returns every write error joined.
+// Results are reported in target order once all writes have finished.
+func writeOutputTargetsParallel(opts *BuildOptions, targets []outputTarget) ([]string, error) {
+	errs := make([]error, len(targets))
+
+	var wg sync.WaitGroup
+
+	for i, target := range targets {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			errs[i] = writeOutputFile(target)
+		}()
+	}
+
+	wg.Wait()
+
+	var written []string
+
+	for i, target := range targets {
+		if errs[i] == nil {
+			reportOutputWritten(opts, target)
+
+			written = append(written, target.filename)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return written, nil
+}
+
+// writeOutputFile writes the content of the target to its file, creating the directory
+// if needed, and applies the configured permissions.
+func writeOutputFile(target outputTarget) error {
+	filename := target.filename
+
+	// Ensure directory exists
+	dir := filepath.Dir(filename)
+	if dir != "." {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+
+	var err error
+	if target.stream != nil {
+		err = writeStreamAtomic(filename, target.stream)
+	} else {
+		err = writeFileAtomic(filename, []byte(target.content))
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to write file %s: %w", filename, err)
+	}
+
+	return applyOutputPermissions(filename, target.permissions)
+}
+
+// defaultOutputPermissions returns the mode of output files whose output format sets no
+// permissions: --output-permissions, or else outputPermissions from the config. Zero keeps
+// the mode files are written with.
+func defaultOutputPermissions(opts *BuildOptions, cfg *config.Config) (os.FileMode, error) {
+	if opts.OutputPermissions != "" {
+		permissions, err := config.ParseFileMode(opts.OutputPermissions)
+		if err != nil {
+			return 0, fmt.Errorf("invalid --output-permissions: %w", err)
 		}
 
-		// Write file
-		if err := os.WriteFile(filename, []byte(output), 0o600); err != nil {
-			return fmt.Errorf("failed to write file %s: %w", filename, err)
+		return permissions, nil
+	}
+
+	if cfg.OutputPermissions != "" {
+		permissions, err := config.ParseFileMode(cfg.OutputPermissions)
+		if err != nil {
+			return 0, fmt.Errorf("invalid outputPermissions in config: %w", err)
 		}
 
-		fmt.Printf("Output written to: %s\n", filename)
+		return permissions, nil
+	}
+
+	return 0, nil
+}
+
+// outputFilePermissions returns the mode of the output file of a format. --output-permissions
+// overrides the permissions of the output format, which override outputPermissions from the config.
+func outputFilePermissions(opts *BuildOptions, cfg *config.Config, format string) os.FileMode {
+	if formatConfig, _ := cfg.OutputFormat(format); formatConfig.Permissions != 0 && opts.OutputPermissions == "" {
+		return formatConfig.Permissions
+	}
+
+	// Invalid modes are rejected by validateBuildOptions before any file is written.
+	permissions, _ := defaultOutputPermissions(opts, cfg)
+
+	return permissions
+}
+
+// applyOutputPermissions sets the mode of an output file; zero keeps the mode it was written with.
+func applyOutputPermissions(filename string, permissions os.FileMode) error {
+	if permissions == 0 {
+		return nil
+	}
+
+	if err := os.Chmod(filename, permissions); err != nil {
+		return fmt.Errorf("failed to set permissions of %s: %w", filename, err)
 	}
 
 	return nil
 }
+
+// reportOutputWritten prints and emits that the target was written.
+func reportOutputWritten(opts *BuildOptions, target outputTarget) {
+	size := len(target.content)
+	if target.stream != nil {
+		if info, err := os.Stat(target.filename); err == nil {
+			size = int(info.Size())
+		}
+	}
+
+	opts.printStatus(successStyle, "Output written to: %s", target.filename)
+	opts.emit(OutputWrittenEvent{Path: target.filename, SizeBytes: size})
+}
+
+// appendSeparator returns the separator written between existing output and appended
+// content, with the fragment path placeholder expanded to the first appended fragment.
+func appendSeparator(opts *BuildOptions, plan *buildPlan) string {
+	separator := fragmentSeparator(opts, plan.cfg)
+
+	if len(plan.fragments) > 0 {
+		separator = strings.ReplaceAll(separator, parser.FragmentPathPlaceholder, plan.fragments[0].Path)
+	}
+
+	return separator
+}
+
+// appendOutputFiles appends the output to the files for the formats, preceded by separator
+// when a file already has content, and returns the paths of the files written. Files are
+// written in place rather than atomically, and the outputHeader is not repeated.
+func appendOutputFiles(opts *BuildOptions, output *buildOutput, separator string, formats, customFiles []string, cfg *config.Config) ([]string, error) {
+	if opts.NonInteractive {
+		fmt.Fprintln(os.Stderr, "Warning: append mode is set; output is appended to existing files instead of replacing them.")
+	}
+
+	var written []string
+
+	for i, format := range formats {
+		if format == "stdout" {
+			continue
+		}
+
+		filename, err := resolveOutputFilename(format, i, customFiles, opts.OutputDir, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		content := output.forFormat(format)
+
+		opts.logf("appending: %s", filename)
+
+		offset, err := appendOutputFile(filename, content, separator)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := applyOutputPermissions(filename, outputFilePermissions(opts, cfg, format)); err != nil {
+			return nil, err
+		}
+
+		opts.printStatus(successStyle, "Output appended to: %s (at byte offset %d)", filename, offset)
+		opts.emit(OutputWrittenEvent{Path: filename, SizeBytes: len(content)})
+
+		written = append(written, filename)
+	}
+
+	return written, nil
+}
+
+// appendOutputFile appends output to filename, creating it if needed, and returns the
+// byte offset the new content starts at. The separator is written first unless the
+// file is empty.
+func appendOutputFile(filename, output, separator string) (int64, error) {
+	if dir := filepath.Dir(filename); dir != "." {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return 0, fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o600)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open file %s: %w", filename, err)
+	}
+
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat file %s: %w", filename, err)
+	}
+
+	offset := info.Size()
+	if offset > 0 {
+		output = separator + output
+		offset += int64(len(separator))
+	}
+
+	if _, err := file.WriteString(output); err != nil {
+		return 0, fmt.Errorf("failed to write file %s: %w", filename, err)
+	}
+
+	if err := file.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write file %s: %w", filename, err)
+	}
+
+	return offset, nil
+}
+
+// outputUnchanged reports whether filename exists and its content hash matches output.
+func outputUnchanged(filename, output string) bool {
+	existing, err := os.ReadFile(filename)
+	if err != nil {
+		return false
+	}
+
+	return parser.ComputeContentHash(string(existing)) == parser.ComputeContentHash(output)
+}
+
+// resolveOutputFilename returns the file path the output for the format at index i is written to.
+// Relative paths are placed under outputDir, or the configured output directory, when it is set.
+func resolveOutputFilename(format string, i int, customFiles []string, outputDir string, cfg *config.Config) (string, error) {
+	var filename string
+
+	if outputDir == "" {
+		outputDir = cfg.OutputDir
+	}
+
+	if format == "custom" && i < len(customFiles) {
+		filename = customFiles[i]
+	} else if formatConfig, exists := cfg.OutputFormat(format); exists {
+		filename = formatConfig.Filename
+	} else {
+		return "", fmt.Errorf("unknown output format: %s", format)
+	}
+
+	if outputDir != "" && !filepath.IsAbs(filename) {
+		filename = filepath.Join(outputDir, filename)
+	}
+
+	return filename, nil
+}
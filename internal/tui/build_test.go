@@ -1,16 +1,31 @@
 package tui
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"reflect"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/Lewenhaupt/ctx/internal/config"
 	"github.com/Lewenhaupt/ctx/internal/parser"
+	"gopkg.in/yaml.v3"
 )
 
 func TestDetermineSelectedTags(t *testing.T) {
+	tagsFile := filepath.Join(t.TempDir(), "tags.txt")
+	if err := os.WriteFile(tagsFile, []byte("# selected tags\nrust\n\n  go  \ntypescript\n"), 0o600); err != nil {
+		t.Fatalf("Failed to create tags file: %v", err)
+	}
+
 	tests := []struct {
 		name         string
 		opts         *BuildOptions
@@ -49,6 +64,181 @@ func TestDetermineSelectedTags(t *testing.T) {
 			expectedTags: []string{"go", "python"},
 			expectError:  false,
 		},
+		{
+			name: "tags file merged with provided tags",
+			opts: &BuildOptions{
+				Tags:     []string{"typescript"},
+				TagsFile: tagsFile,
+			},
+			cfg: &config.Config{},
+			fragments: []parser.Fragment{
+				{Tags: []string{"typescript", "go"}},
+				{Tags: []string{"rust", "python"}},
+			},
+			expectedTags: []string{"typescript", "rust", "go"},
+			expectError:  false,
+		},
+		{
+			name: "missing tags file",
+			opts: &BuildOptions{
+				TagsFile: "does-not-exist.txt",
+			},
+			cfg: &config.Config{},
+			fragments: []parser.Fragment{
+				{Tags: []string{"typescript"}},
+			},
+			expectError: true,
+		},
+		{
+			name: "nested tag groups expanded",
+			opts: &BuildOptions{
+				Tags:   []string{"rust", "frontend"},
+				Groups: []string{"backend"},
+			},
+			cfg: &config.Config{
+				TagGroups: map[string][]string{
+					"frontend": {"typescript", "css"},
+					"backend":  {"go", "frontend"},
+				},
+			},
+			fragments: []parser.Fragment{
+				{Tags: []string{"typescript", "go"}},
+			},
+			expectedTags: []string{"rust", "typescript", "css", "go"},
+		},
+		{
+			name: "unknown tag group",
+			opts: &BuildOptions{
+				Groups: []string{"missing"},
+			},
+			cfg: &config.Config{},
+			fragments: []parser.Fragment{
+				{Tags: []string{"typescript"}},
+			},
+			expectError: true,
+		},
+		{
+			name: "circular tag group",
+			opts: &BuildOptions{
+				Tags: []string{"a"},
+			},
+			cfg: &config.Config{
+				TagGroups: map[string][]string{"a": {"b"}, "b": {"a"}},
+			},
+			fragments: []parser.Fragment{
+				{Tags: []string{"typescript"}},
+			},
+			expectError: true,
+		},
+		{
+			name: "missing tags allowed by default",
+			opts: &BuildOptions{
+				Tags: []string{"typescript", "typscript"},
+			},
+			cfg: &config.Config{},
+			fragments: []parser.Fragment{
+				{Tags: []string{"typescript"}},
+			},
+			expectedTags: []string{"typescript", "typscript"},
+		},
+		{
+			name: "fail on missing tags after alias expansion",
+			opts: &BuildOptions{
+				Tags:              []string{"ts", "go"},
+				FailOnMissingTags: true,
+			},
+			cfg: &config.Config{
+				Aliases: map[string][]string{"ts": {"typescript"}},
+			},
+			fragments: []parser.Fragment{
+				{Tags: []string{"typescript", "go"}},
+			},
+			expectedTags: []string{"typescript", "go"},
+		},
+		{
+			name: "fail on missing tags",
+			opts: &BuildOptions{
+				Tags:              []string{"typscript", "go", "rust+unsafe"},
+				FailOnMissingTags: true,
+			},
+			cfg: &config.Config{},
+			fragments: []parser.Fragment{
+				{Tags: []string{"typescript", "go", "rust"}},
+			},
+			expectError: true,
+		},
+		{
+			name: "tag prefixes unioned with provided tags",
+			opts: &BuildOptions{
+				Tags:        []string{"env-production", "lang-rust"},
+				TagPrefixes: []string{"lang-"},
+			},
+			cfg: &config.Config{},
+			fragments: []parser.Fragment{
+				{Tags: []string{"lang-typescript", "env-production"}},
+				{Tags: []string{"lang-rust", "language"}},
+			},
+			expectedTags: []string{"env-production", "lang-rust", "lang-typescript"},
+		},
+		{
+			name: "tag prefix without matches warns",
+			opts: &BuildOptions{
+				Tags:        []string{"language"},
+				TagPrefixes: []string{"os-"},
+			},
+			cfg: &config.Config{},
+			fragments: []parser.Fragment{
+				{Tags: []string{"lang-rust", "language"}},
+			},
+			expectedTags: []string{"language"},
+		},
+		{
+			name: "tag prefix without matches fails with fail-on-empty-prefix",
+			opts: &BuildOptions{
+				TagPrefixes:       []string{"lang-", "os-"},
+				FailOnEmptyPrefix: true,
+			},
+			cfg: &config.Config{},
+			fragments: []parser.Fragment{
+				{Tags: []string{"lang-rust", "language"}},
+			},
+			expectError: true,
+		},
+		{
+			name: "glob tags expanded",
+			opts: &BuildOptions{
+				Tags: []string{"lang-*", "os-*", "strict"},
+			},
+			cfg: &config.Config{},
+			fragments: []parser.Fragment{
+				{Tags: []string{"lang-typescript", "env-production"}},
+				{Tags: []string{"lang-rust", "strict"}},
+			},
+			expectedTags: []string{"lang-rust", "lang-typescript", "strict"},
+		},
+		{
+			name: "glob tag without matches fails with fail-on-missing-tags",
+			opts: &BuildOptions{
+				Tags:              []string{"lang-*", "os-*"},
+				FailOnMissingTags: true,
+			},
+			cfg: &config.Config{},
+			fragments: []parser.Fragment{
+				{Tags: []string{"lang-rust"}},
+			},
+			expectError: true,
+		},
+		{
+			name: "only glob tags without matches",
+			opts: &BuildOptions{
+				Tags: []string{"os-*"},
+			},
+			cfg: &config.Config{},
+			fragments: []parser.Fragment{
+				{Tags: []string{"lang-rust"}},
+			},
+			expectError: true,
+		},
 		{
 			name: "no tags found in fragments",
 			opts: &BuildOptions{},
@@ -233,22 +423,41 @@ func TestDetermineOutputFormats(t *testing.T) {
 		{
 			name: "custom output file",
 			opts: &BuildOptions{
-				OutputFile: "custom.md",
+				OutputFiles: []string{"custom.md"},
 			},
 			cfg:             &config.Config{},
 			expectedFormats: []string{"custom"},
 			expectedFiles:   []string{"custom.md"},
 			expectError:     false,
 		},
+		{
+			name: "multiple output files",
+			opts: &BuildOptions{
+				OutputFiles: []string{"AGENTS.md", "CONTEXT.md"},
+			},
+			cfg:             &config.Config{},
+			expectedFormats: []string{"custom", "custom"},
+			expectedFiles:   []string{"AGENTS.md", "CONTEXT.md"},
+		},
+		{
+			name: "custom output format with output file",
+			opts: &BuildOptions{
+				OutputFormats: []string{"custom"},
+				OutputFiles:   []string{"custom.md"},
+			},
+			cfg:             &config.Config{},
+			expectedFormats: []string{"custom"},
+			expectedFiles:   []string{"custom.md"},
+		},
 		{
 			name: "non-interactive with configured formats",
 			opts: &BuildOptions{
 				NonInteractive: true,
 			},
 			cfg: &config.Config{
-				OutputFormats: map[string]string{
-					"opencode": "AGENTS.md",
-					"gemini":   "GEMINI.md",
+				OutputFormats: map[string]config.OutputFormatConfig{
+					"opencode": {Filename: "AGENTS.md"},
+					"gemini":   {Filename: "GEMINI.md"},
 				},
 			},
 			expectedFormats: []string{"opencode", "gemini"},
@@ -261,7 +470,7 @@ func TestDetermineOutputFormats(t *testing.T) {
 				NonInteractive: true,
 			},
 			cfg: &config.Config{
-				OutputFormats: map[string]string{},
+				OutputFormats: map[string]config.OutputFormatConfig{},
 			},
 			expectError: true,
 		},
@@ -310,3 +519,1479 @@ func TestDetermineOutputFormats(t *testing.T) {
 		})
 	}
 }
+
+func TestDetermineOutputFormatsCollision(t *testing.T) {
+	cfg := &config.Config{
+		OutputFormats: map[string]config.OutputFormatConfig{
+			"opencode":  {Filename: "AGENTS.md"},
+			"duplicate": {Filename: "./AGENTS.md"},
+			"gemini":    {Filename: "GEMINI.md"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		opts        *BuildOptions
+		expectError bool
+	}{
+		{name: "formats writing the same file", opts: &BuildOptions{OutputFormats: []string{"opencode", "gemini", "duplicate"}}, expectError: true},
+		{name: "all configured formats", opts: &BuildOptions{NonInteractive: true}, expectError: true},
+		{name: "same output file twice", opts: &BuildOptions{OutputFiles: []string{"OUT.md", "OUT.md"}}, expectError: true},
+		{name: "collision allowed", opts: &BuildOptions{OutputFormats: []string{"opencode", "duplicate"}, AllowCollision: true}},
+		{name: "distinct files", opts: &BuildOptions{OutputFormats: []string{"opencode", "gemini"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := determineOutputFormats(tt.opts, cfg)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("determineOutputFormats() error = %v, expectError %v", err, tt.expectError)
+			}
+
+			if err != nil && tt.opts.OutputFormats != nil && !strings.Contains(err.Error(), "duplicate, opencode all write to AGENTS.md") {
+				t.Errorf("Expected the error to name the colliding formats and file, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	cfg := &config.Config{
+		Profiles: map[string]config.ProfileConfig{
+			"ts": {Tags: []string{"typescript", "strict"}, OutputFormats: []string{"opencode"}},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		opts            BuildOptions
+		expectedTags    []string
+		expectedFormats []string
+		expectError     bool
+	}{
+		{
+			name:         "no profile",
+			opts:         BuildOptions{Tags: []string{"rust"}},
+			expectedTags: []string{"rust"},
+		},
+		{
+			name:            "profile values",
+			opts:            BuildOptions{Profile: "ts"},
+			expectedTags:    []string{"typescript", "strict"},
+			expectedFormats: []string{"opencode"},
+		},
+		{
+			name:            "flags override profile",
+			opts:            BuildOptions{Profile: "ts", Tags: []string{"rust"}, OutputFormats: []string{"gemini"}},
+			expectedTags:    []string{"rust"},
+			expectedFormats: []string{"gemini"},
+		},
+		{
+			name:         "output file overrides profile formats",
+			opts:         BuildOptions{Profile: "ts", OutputFiles: []string{"OUT.md"}},
+			expectedTags: []string{"typescript", "strict"},
+		},
+		{
+			name:        "unknown profile",
+			opts:        BuildOptions{Profile: "missing"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := applyProfile(&tt.opts, cfg)
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(resolved.Tags, tt.expectedTags) {
+				t.Errorf("Expected tags %v, got %v", tt.expectedTags, resolved.Tags)
+			}
+
+			if !reflect.DeepEqual(resolved.OutputFormats, tt.expectedFormats) {
+				t.Errorf("Expected output formats %v, got %v", tt.expectedFormats, resolved.OutputFormats)
+			}
+		})
+	}
+}
+
+func TestResolveOutputFilename(t *testing.T) {
+	cfg := &config.Config{
+		OutputFormats: map[string]config.OutputFormatConfig{
+			"opencode": {Filename: "AGENTS.md"},
+			"absolute": {Filename: "/etc/ctx/ABSOLUTE.md"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		format    string
+		outputDir string
+		expected  string
+	}{
+		{name: "no output dir", format: "opencode", expected: "AGENTS.md"},
+		{name: "relative joined", format: "opencode", outputDir: "/tmp/out", expected: "/tmp/out/AGENTS.md"},
+		{name: "absolute kept", format: "absolute", outputDir: "/tmp/out", expected: "/etc/ctx/ABSOLUTE.md"},
+		{name: "built-in format not in config", format: "gemini", expected: "GEMINI.md"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename, err := resolveOutputFilename(tt.format, 0, nil, tt.outputDir, cfg)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if filename != filepath.FromSlash(tt.expected) {
+				t.Errorf("Expected %s, got %s", tt.expected, filename)
+			}
+		})
+	}
+}
+
+func TestStreamsOutput(t *testing.T) {
+	header := "<!-- generated -->"
+	cfg := &config.Config{OutputFormats: map[string]config.OutputFormatConfig{
+		"plain":     {Filename: "PLAIN.md"},
+		"templated": {Filename: "TEMPLATED.md", Template: "wrap.tmpl"},
+	}}
+
+	tests := []struct {
+		name     string
+		opts     BuildOptions
+		formats  []string
+		header   string
+		expected bool
+	}{
+		{name: "plain build", formats: []string{"plain"}, expected: true},
+		{name: "utf8 encoding", opts: BuildOptions{OutputEncoding: OutputEncodingUTF8}, formats: []string{"plain"}, expected: true},
+		{name: "html", opts: BuildOptions{OutputHTML: true}, formats: []string{"plain"}},
+		{name: "check", opts: BuildOptions{Check: true}, formats: []string{"plain"}},
+		{name: "output template", opts: BuildOptions{OutputTemplate: "wrap.tmpl"}, formats: []string{"plain"}},
+		{name: "format template", formats: []string{"plain", "templated"}},
+		{name: "structured format", formats: []string{config.JSONFormat}},
+		{name: "header", formats: []string{"plain"}, header: header},
+		{name: "header left out", opts: BuildOptions{NoHeader: true}, formats: []string{"plain"}, header: header, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			planCfg := *cfg
+			planCfg.OutputHeader = tt.header
+			plan := &buildPlan{cfg: &planCfg, outputFormats: tt.formats}
+
+			if got := streamsOutput(&tt.opts, plan); got != tt.expected {
+				t.Errorf("Expected streamsOutput %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestWriteOutputFilesParallel(t *testing.T) {
+	formats := []string{"a", "b", "c", "d", "e"}
+	cfg := &config.Config{OutputFormats: map[string]config.OutputFormatConfig{}}
+
+	for _, format := range formats {
+		cfg.OutputFormats[format] = config.OutputFormatConfig{Filename: format + ".md"}
+	}
+
+	origWriteFile := writeFile
+
+	t.Cleanup(func() { writeFile = origWriteFile })
+
+	// Every write waits until all writes have started, which only completes when they run
+	// concurrently. The timeout only bounds a failing run.
+	var started sync.WaitGroup
+
+	started.Add(len(formats))
+
+	allStarted := make(chan struct{})
+
+	go func() {
+		started.Wait()
+		close(allStarted)
+	}()
+
+	writeFile = func(name string, data []byte, perm os.FileMode) error {
+		started.Done()
+
+		select {
+		case <-allStarted:
+		case <-time.After(10 * time.Second):
+			return errors.New("writes did not run concurrently")
+		}
+
+		return os.WriteFile(name, data, perm)
+	}
+
+	opts := &BuildOptions{NonInteractive: true, OutputDir: t.TempDir(), Parallel: true}
+
+	written, err := writeOutputFiles(opts, &buildOutput{content: "content"}, formats, nil, cfg)
+	if err != nil {
+		t.Fatalf("writeOutputFiles failed: %v", err)
+	}
+
+	if len(written) != len(formats) {
+		t.Fatalf("Expected %d files written, got %v", len(formats), written)
+	}
+
+	for i, path := range written {
+		if filepath.Base(path) != formats[i]+".md" {
+			t.Errorf("Expected written files in format order, got %v", written)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil || string(content) != "content" {
+			t.Errorf("Expected %s to contain the output, got %q (%v)", path, content, err)
+		}
+	}
+}
+
+func TestWriteOutputFilesParallelCollectsErrors(t *testing.T) {
+	cfg := &config.Config{OutputFormats: map[string]config.OutputFormatConfig{"a": {Filename: "a.md"}, "b": {Filename: "b.md"}, "c": {Filename: "c.md"}}}
+
+	origWriteFile := writeFile
+
+	t.Cleanup(func() { writeFile = origWriteFile })
+
+	writeFile = func(name string, data []byte, perm os.FileMode) error {
+		if strings.Contains(name, ".c.md") {
+			return os.WriteFile(name, data, perm)
+		}
+
+		return errors.New("disk full")
+	}
+
+	opts := &BuildOptions{NonInteractive: true, OutputDir: t.TempDir(), Parallel: true}
+
+	_, err := writeOutputFiles(opts, &buildOutput{content: "content"}, []string{"a", "b", "c"}, nil, cfg)
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	for _, name := range []string{"a.md", "b.md"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("Expected error to mention %s, got: %v", name, err)
+		}
+	}
+
+	if _, statErr := os.Stat(filepath.Join(opts.OutputDir, "c.md")); statErr != nil {
+		t.Errorf("Expected c.md to be written despite other failures: %v", statErr)
+	}
+}
+
+func TestRunBuildResult(t *testing.T) {
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	files := map[string]string{
+		"a.md": "---\nctx-tags: go\nctx-priority: 2\n---\nA",
+		"b.md": "---\nctx-tags: go, rust\nctx-priority: 1\n---\nB",
+		"c.md": "---\nctx-tags: python\n---\nC",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(fragmentsDir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create fragment: %v", err)
+		}
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+fragmentsDir+`", "outputFormats": {}}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	outputPath := filepath.Join(tmpDir, "AGENTS.md")
+
+	tests := []struct {
+		name          string
+		opts          BuildOptions
+		expectedFiles []string
+		expectStdout  bool
+	}{
+		{
+			name:          "write files",
+			opts:          BuildOptions{OutputFiles: []string{outputPath}},
+			expectedFiles: []string{outputPath},
+		},
+		{
+			name:         "stdout",
+			opts:         BuildOptions{Stdout: true},
+			expectStdout: true,
+		},
+		{
+			name: "dry run",
+			opts: BuildOptions{OutputFiles: []string{filepath.Join(tmpDir, "DRY.md")}, DryRun: true},
+		},
+		{
+			name: "check up to date",
+			opts: BuildOptions{OutputFiles: []string{outputPath}, Check: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.ConfigFile = configPath
+			opts.Tags = []string{"go"}
+			opts.NonInteractive = true
+
+			result, err := RunBuild(&opts)
+			if err != nil {
+				t.Fatalf("RunBuild failed: %v", err)
+			}
+
+			if !reflect.DeepEqual(result.SelectedTags, []string{"go"}) {
+				t.Errorf("Expected selected tags [go], got %v", result.SelectedTags)
+			}
+
+			var names []string
+			for _, fragment := range result.Fragments {
+				names = append(names, filepath.Base(fragment.Path))
+			}
+
+			if !reflect.DeepEqual(names, []string{"b.md", "a.md"}) {
+				t.Errorf("Expected fragments [b.md a.md] in priority order, got %v", names)
+			}
+
+			if !reflect.DeepEqual(result.OutputFiles, tt.expectedFiles) {
+				t.Errorf("Expected output files %v, got %v", tt.expectedFiles, result.OutputFiles)
+			}
+
+			if result.Stdout != tt.expectStdout {
+				t.Errorf("Expected Stdout %v, got %v", tt.expectStdout, result.Stdout)
+			}
+		})
+	}
+
+	result, err := RunBuild(&BuildOptions{ConfigFile: configPath, Tags: []string{"missing"}, NonInteractive: true, Stdout: true})
+	if err == nil || result != nil {
+		t.Errorf("Expected failed build to return a nil result and an error, got %+v, %v", result, err)
+	}
+
+	result, err = RunBuild(&BuildOptions{ConfigFile: configPath, Tags: []string{"go"}, NonInteractive: true, Stdout: true, SortStrategy: "alpha"})
+	if err != nil {
+		t.Fatalf("RunBuild with alpha sort failed: %v", err)
+	}
+
+	if len(result.Fragments) != 2 || filepath.Base(result.Fragments[0].Path) != "a.md" {
+		t.Errorf("Expected fragments sorted by path with --sort alpha, got %v", result.Fragments)
+	}
+
+	if _, err := RunBuild(&BuildOptions{ConfigFile: configPath, Tags: []string{"go"}, NonInteractive: true, Stdout: true, SortStrategy: "size"}); err == nil {
+		t.Error("Expected error for an invalid sort strategy, got nil")
+	}
+}
+
+func TestValidateBuildOptionsAppendParallel(t *testing.T) {
+	opts := &BuildOptions{Append: true, Parallel: true}
+	if err := validateBuildOptions(opts, config.DefaultConfig()); err == nil || !strings.Contains(err.Error(), "--append and --parallel") {
+		t.Errorf("Expected --append with --parallel to be rejected, got %v", err)
+	}
+}
+
+func TestAppendOutputFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "out", "AGENTS.md")
+
+	offset, err := appendOutputFile(filename, "first", "\n---\n")
+	if err != nil {
+		t.Fatalf("appendOutputFile failed: %v", err)
+	}
+
+	if offset != 0 {
+		t.Errorf("Expected a new file to be written at offset 0, got %d", offset)
+	}
+
+	offset, err = appendOutputFile(filename, "second", "\n---\n")
+	if err != nil {
+		t.Fatalf("appendOutputFile failed: %v", err)
+	}
+
+	if offset != int64(len("first\n---\n")) {
+		t.Errorf("Expected appended content at offset %d, got %d", len("first\n---\n"), offset)
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+
+	if string(content) != "first\n---\nsecond" {
+		t.Errorf("Expected appended content with separator, got %q", content)
+	}
+}
+
+func TestRunBuildOutputFormatConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(fragmentsDir, "a.md"), []byte("---\nctx-tags: go\n---\nA"), 0o600); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+
+	templatePath := filepath.Join(tmpDir, "wrap.tmpl")
+	if err := os.WriteFile(templatePath, []byte("# Prompt\n{{.Content}}\n# End"), 0o600); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+
+	templated := filepath.Join(tmpDir, "TEMPLATED.md")
+	plain := filepath.Join(tmpDir, "PLAIN.md")
+	appended := filepath.Join(tmpDir, "APPENDED.md")
+
+	if err := os.WriteFile(appended, []byte("existing"), 0o600); err != nil {
+		t.Fatalf("Failed to create existing output: %v", err)
+	}
+
+	formats, err := json.Marshal(map[string]config.OutputFormatConfig{
+		"templated": {Filename: templated, Template: templatePath},
+		"plain":     {Filename: plain, Permissions: 0o640},
+		"appended":  {Filename: appended, AppendMode: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal output formats: %v", err)
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	configContent := `{"fragmentsDir": "` + fragmentsDir + `", "separator": "\n---\n", "outputFormats": ` + string(formats) + `}`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	opts := BuildOptions{
+		ConfigFile:     configPath,
+		Tags:           []string{"go"},
+		NonInteractive: true,
+		OutputFormats:  []string{"templated", "plain", "appended"},
+	}
+
+	result, err := RunBuild(&opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(result.OutputFiles) != 3 {
+		t.Errorf("Expected 3 output files, got %v", result.OutputFiles)
+	}
+
+	expectedContents := map[string]string{
+		templated: "# Prompt\nA\n# End",
+		plain:     "A",
+		appended:  "existing\n---\nA",
+	}
+
+	for path, expected := range expectedContents {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", path, err)
+		}
+
+		if string(content) != expected {
+			t.Errorf("Expected %s to contain %q, got %q", filepath.Base(path), expected, content)
+		}
+	}
+
+	info, err := os.Stat(plain)
+	if err != nil {
+		t.Fatalf("Failed to stat output: %v", err)
+	}
+
+	if info.Mode().Perm() != 0o640 {
+		t.Errorf("Expected permissions 0640, got %04o", info.Mode().Perm())
+	}
+}
+
+func TestRunBuildRemote(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(fragmentsDir, "shared.md"), []byte("---\nctx-tags: go\n---\nLocal shared"), 0o600); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+fragmentsDir+`", "outputFormats": {}}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	remoteFiles := map[string]string{
+		"/":          `<a href="shared.md">shared.md</a> <a href="remote.md">remote.md</a>`,
+		"/shared.md": "---\nctx-tags: go\n---\nRemote shared",
+		"/remote.md": "---\nctx-tags: go\n---\nRemote only",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(remoteFiles[r.URL.Path]))
+	}))
+	defer server.Close()
+
+	outputPath := filepath.Join(tmpDir, "AGENTS.md")
+	opts := BuildOptions{
+		ConfigFile:     configPath,
+		Tags:           []string{"go"},
+		NonInteractive: true,
+		OutputFiles:    []string{outputPath},
+		Remote:         server.URL + "/",
+	}
+
+	if _, err := RunBuild(&opts); err != nil {
+		t.Fatalf("RunBuild failed: %v", err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+
+	if !strings.Contains(string(content), "Remote only") || !strings.Contains(string(content), "Local shared") {
+		t.Errorf("Expected remote and local fragments in the output, got %q", content)
+	}
+
+	if strings.Contains(string(content), "Remote shared") {
+		t.Errorf("Expected the local fragment to override the remote one, got %q", content)
+	}
+}
+
+func TestRunBuildOutputEncoding(t *testing.T) {
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	fragment := "---\nctx-tags: go\n---\nCafé rules — keep it “simple”"
+	if err := os.WriteFile(filepath.Join(fragmentsDir, "a.md"), []byte(fragment), 0o600); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+fragmentsDir+`", "outputFormats": {}}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	tests := []struct {
+		encoding string
+		expected string
+	}{
+		{encoding: "", expected: "Café rules — keep it “simple”"},
+		{encoding: OutputEncodingUTF8, expected: "Café rules — keep it “simple”"},
+		{encoding: OutputEncodingASCII, expected: "Cafe rules -- keep it \"simple\""},
+	}
+
+	for _, tt := range tests {
+		outputPath := filepath.Join(tmpDir, "AGENTS-"+tt.encoding+".md")
+		opts := BuildOptions{
+			ConfigFile:     configPath,
+			Tags:           []string{"go"},
+			NonInteractive: true,
+			OutputFiles:    []string{outputPath},
+			OutputEncoding: tt.encoding,
+		}
+
+		if _, err := RunBuild(&opts); err != nil {
+			t.Fatalf("RunBuild with encoding %q failed: %v", tt.encoding, err)
+		}
+
+		content, err := os.ReadFile(outputPath)
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+
+		if string(content) != tt.expected {
+			t.Errorf("Expected output %q with encoding %q, got %q", tt.expected, tt.encoding, content)
+		}
+	}
+
+	opts := BuildOptions{ConfigFile: configPath, Tags: []string{"go"}, NonInteractive: true, Stdout: true, OutputEncoding: "latin1"}
+	if _, err := RunBuild(&opts); err == nil || !strings.Contains(err.Error(), "invalid output encoding") {
+		t.Errorf("Expected an invalid output encoding error, got %v", err)
+	}
+}
+
+func TestRunBuildNoSeparator(t *testing.T) {
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	files := map[string]string{
+		"a.md": "---\nctx-tags: go\n---\nFirst body",
+		"b.md": "---\nctx-tags: go\n---\nSecond body",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(fragmentsDir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create fragment: %v", err)
+		}
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+fragmentsDir+`", "outputFormats": {}, "separator": "\n---\n"}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		noSeparator bool
+		expected    string
+	}{
+		{name: "configured separator", expected: "First body\n---\nSecond body"},
+		{name: "no separator", noSeparator: true, expected: "First bodySecond body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "AGENTS.md")
+			opts := BuildOptions{
+				ConfigFile:     configPath,
+				Tags:           []string{"go"},
+				NonInteractive: true,
+				OutputFiles:    []string{outputPath},
+				NoSeparator:    tt.noSeparator,
+			}
+
+			if _, err := RunBuild(&opts); err != nil {
+				t.Fatalf("RunBuild failed: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+
+			if string(content) != tt.expected {
+				t.Errorf("Expected output %q, got %q", tt.expected, content)
+			}
+		})
+	}
+}
+
+func TestRunBuildOutputHeader(t *testing.T) {
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(fragmentsDir, "go.md"), []byte("---\nctx-tags: go\n---\nGo body"), 0o600); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	config := `{"fragmentsDir": "` + fragmentsDir + `", "outputFormats": {}, "outputHeader": "<!-- generated – do not edit -->"}`
+
+	if err := os.WriteFile(configPath, []byte(config), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	tests := []struct {
+		name               string
+		noHeader           bool
+		includeFrontmatter bool
+		encoding           string
+		expected           string
+	}{
+		{name: "configured header", expected: "<!-- generated – do not edit -->\nGo body"},
+		{name: "no header", noHeader: true, expected: "Go body"},
+		{name: "encoded header", encoding: OutputEncodingASCII, expected: "<!-- generated - do not edit -->\nGo body"},
+		{name: "kept frontmatter", includeFrontmatter: true, expected: "---\nctx-tags: go\n---\nGo body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(t.TempDir(), "AGENTS.md")
+			opts := BuildOptions{
+				ConfigFile:         configPath,
+				Tags:               []string{"go"},
+				NonInteractive:     true,
+				OutputFiles:        []string{outputPath},
+				NoHeader:           tt.noHeader,
+				IncludeFrontmatter: tt.includeFrontmatter,
+				OutputEncoding:     tt.encoding,
+			}
+
+			if _, err := RunBuild(&opts); err != nil {
+				t.Fatalf("RunBuild failed: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+
+			// The header must start at byte offset 0
+			if string(content) != tt.expected {
+				t.Errorf("Expected output %q, got %q", tt.expected, content)
+			}
+		})
+	}
+}
+
+func TestRunBuildOutputTemplateTimestamp(t *testing.T) {
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	fragmentPath := filepath.Join(fragmentsDir, "go.md")
+	if err := os.WriteFile(fragmentPath, []byte("---\nctx-tags: go\n---\nGo body"), 0o600); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+
+	modTime := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(fragmentPath, modTime, modTime); err != nil {
+		t.Fatalf("Failed to set fragment modification time: %v", err)
+	}
+
+	templatePath := filepath.Join(tmpDir, "wrap.tmpl")
+	if err := os.WriteFile(templatePath, []byte("<!-- {{.Timestamp}} -->\n{{.Content}}"), 0o600); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+fragmentsDir+`", "outputFormats": {}}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	outputPath := filepath.Join(tmpDir, "AGENTS.md")
+	build := func(check bool) error {
+		_, err := RunBuild(&BuildOptions{
+			ConfigFile:     configPath,
+			Tags:           []string{"go"},
+			NonInteractive: true,
+			OutputFiles:    []string{outputPath},
+			OutputTemplate: templatePath,
+			Check:          check,
+			Quiet:          true,
+		})
+
+		return err
+	}
+
+	if err := build(false); err != nil {
+		t.Fatalf("RunBuild failed: %v", err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+
+	if expected := "<!-- 2025-01-02T03:04:05Z -->\nGo body"; string(content) != expected {
+		t.Errorf("Expected output %q, got %q", expected, content)
+	}
+
+	if err := build(true); err != nil {
+		t.Errorf("Expected the output to be up to date, got %v", err)
+	}
+}
+
+func TestRunBuildIncludeFrontmatter(t *testing.T) {
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	fragment := "---\nctx-tags: go\nctx-description: Go style\n---\nGo body"
+	if err := os.WriteFile(filepath.Join(fragmentsDir, "go.md"), []byte(fragment), 0o600); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+fragmentsDir+`", "outputFormats": {}}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	outputPath := filepath.Join(tmpDir, "AGENTS.md")
+	opts := BuildOptions{
+		ConfigFile:         configPath,
+		Tags:               []string{"go"},
+		NonInteractive:     true,
+		OutputFiles:        []string{outputPath},
+		IncludeFrontmatter: true,
+	}
+
+	if _, err := RunBuild(&opts); err != nil {
+		t.Fatalf("RunBuild failed: %v", err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+
+	if !strings.HasPrefix(string(content), "---\n") {
+		t.Errorf("Expected output to start with the frontmatter delimiter, got %q", content)
+	}
+
+	for _, key := range []string{"ctx-tags: go", "ctx-description: Go style", "---\nGo body"} {
+		if !strings.Contains(string(content), key) {
+			t.Errorf("Expected output to contain %q, got %q", key, content)
+		}
+	}
+}
+
+func TestRunBuildOverwritePolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(fragmentsDir, "go.md"), []byte("---\nctx-tags: go\n---\nGo body"), 0o600); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		configPolicy string
+		policy       string
+		expected     string
+		expectError  bool
+	}{
+		{name: "never skips existing file", policy: OverwritePolicyNever, expected: "Existing content"},
+		{name: "never from config", configPolicy: OverwritePolicyNever, expected: "Existing content"},
+		{name: "flag overrides config", configPolicy: OverwritePolicyNever, policy: OverwritePolicyAlways, expected: "Go body"},
+		{name: "ask overwrites in non-interactive mode", policy: OverwritePolicyAsk, expected: "Go body"},
+		{name: "default overwrites in non-interactive mode", expected: "Go body"},
+		{name: "invalid policy", policy: "sometimes", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			configPath := filepath.Join(dir, "config.json")
+			config := `{"fragmentsDir": "` + fragmentsDir + `", "outputFormats": {}, "overwritePolicy": "` + tt.configPolicy + `"}`
+
+			if err := os.WriteFile(configPath, []byte(config), 0o600); err != nil {
+				t.Fatalf("Failed to create config: %v", err)
+			}
+
+			outputPath := filepath.Join(dir, "AGENTS.md")
+			if err := os.WriteFile(outputPath, []byte("Existing content"), 0o600); err != nil {
+				t.Fatalf("Failed to create existing output: %v", err)
+			}
+
+			opts := BuildOptions{
+				ConfigFile:      configPath,
+				Tags:            []string{"go"},
+				NonInteractive:  true,
+				OutputFiles:     []string{outputPath},
+				OverwritePolicy: tt.policy,
+			}
+
+			_, err := RunBuild(&opts)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected an invalid overwrite policy to fail")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("RunBuild failed: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+
+			if string(content) != tt.expected {
+				t.Errorf("Expected output %q, got %q", tt.expected, content)
+			}
+		})
+	}
+}
+
+func TestValidateStdinTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    BuildOptions
+		wantErr bool
+	}{
+		{name: "stdin tags alone", opts: BuildOptions{StdinTags: true}},
+		{name: "stdin tags with groups", opts: BuildOptions{StdinTags: true, Groups: []string{"frontend"}}},
+		{name: "stdin tags with tags", opts: BuildOptions{StdinTags: true, Tags: []string{"go"}}, wantErr: true},
+		{name: "stdin tags with tags file", opts: BuildOptions{StdinTags: true, TagsFile: "tags.txt"}, wantErr: true},
+		{name: "stdin tags with stdin", opts: BuildOptions{StdinTags: true, Stdin: true}, wantErr: true},
+		{name: "tags without stdin tags", opts: BuildOptions{Tags: []string{"go"}, TagsFile: "tags.txt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateStdinTags(&tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateStdinTags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunBuildMaxFragments(t *testing.T) {
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	for _, name := range []string{"a.md", "b.md", "c.md"} {
+		if err := os.WriteFile(filepath.Join(fragmentsDir, name), []byte("---\nctx-tags: go\n---\nBody"), 0o600); err != nil {
+			t.Fatalf("Failed to create fragment: %v", err)
+		}
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+fragmentsDir+`", "outputFormats": {}, "maxFragments": 2}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		maxFragments int
+		expectedErr  string
+	}{
+		{name: "config limit exceeded", expectedErr: "3 fragments match the selected tags, more than the limit of 2"},
+		{name: "flag overrides config", maxFragments: 3},
+		{name: "flag limit exceeded", maxFragments: 1, expectedErr: "3 fragments match the selected tags, more than the limit of 1"},
+		{name: "limit disabled", maxFragments: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := BuildOptions{
+				ConfigFile:     configPath,
+				Tags:           []string{"go"},
+				NonInteractive: true,
+				OutputFiles:    []string{filepath.Join(t.TempDir(), "AGENTS.md")},
+				MaxFragments:   tt.maxFragments,
+			}
+
+			_, err := RunBuild(&opts)
+
+			if tt.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("RunBuild failed: %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestRunBuildNormalizeContent(t *testing.T) {
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	fragments := map[string]string{
+		"a.md": "---\r\nctx-tags: go\r\n---\r\nWindows line \r\nendings\r\n\r\n",
+		"b.md": "---\nctx-tags: go\n---\nUnix\nendings\n",
+	}
+
+	for name, content := range fragments {
+		if err := os.WriteFile(filepath.Join(fragmentsDir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create fragment: %v", err)
+		}
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+fragmentsDir+`", "outputFormats": {}}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	for _, noNormalize := range []bool{false, true} {
+		outputFile := filepath.Join(t.TempDir(), "AGENTS.md")
+		opts := BuildOptions{
+			ConfigFile:     configPath,
+			Tags:           []string{"go"},
+			NonInteractive: true,
+			OutputFiles:    []string{outputFile},
+			NoNormalize:    noNormalize,
+		}
+
+		if _, err := RunBuild(&opts); err != nil {
+			t.Fatalf("RunBuild failed: %v", err)
+		}
+
+		data, err := os.ReadFile(outputFile)
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+
+		if noNormalize {
+			if !strings.Contains(string(data), "endings\n\n\nUnix") {
+				t.Errorf("Expected the trailing newlines to be kept with NoNormalize, got %q", data)
+			}
+
+			continue
+		}
+
+		if strings.Contains(string(data), "\r") || !strings.Contains(string(data), "Windows line\nendings\n\nUnix\nendings") {
+			t.Errorf("Expected normalized fragments with only \\n line endings, got %q", data)
+		}
+	}
+}
+
+func TestRunBuildExtraFragments(t *testing.T) {
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+	otherDir := filepath.Join(tmpDir, "elsewhere")
+
+	for _, dir := range []string{fragmentsDir, otherDir} {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+	}
+
+	files := map[string]string{
+		filepath.Join(fragmentsDir, "go.md"):   "---\nctx-tags: go\n---\nGo body",
+		filepath.Join(otherDir, "one-off.md"):  "---\nctx-tags: unrelated\n---\nOne-off body",
+		filepath.Join(otherDir, "untagged.md"): "Untagged body",
+	}
+
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create fragment: %v", err)
+		}
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+fragmentsDir+`", "outputFormats": {}}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "AGENTS.md")
+	opts := BuildOptions{
+		ConfigFile:     configPath,
+		Tags:           []string{"go"},
+		NonInteractive: true,
+		OutputFiles:    []string{outputFile},
+		ExtraFragments: []string{
+			filepath.Join(otherDir, "one-off.md"),
+			filepath.Join(otherDir, "untagged.md"),
+			filepath.Join(fragmentsDir, "go.md"),
+		},
+	}
+
+	if _, err := RunBuild(&opts); err != nil {
+		t.Fatalf("RunBuild failed: %v", err)
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+
+	if expected := "Go body\n\nOne-off body\n\nUntagged body"; !strings.Contains(string(data), expected) {
+		t.Errorf("Expected the extra fragments after the selected ones, each once, got %q", data)
+	}
+
+	opts.ExtraFragments = []string{filepath.Join(otherDir, "missing.md")}
+	if _, err := RunBuild(&opts); err == nil || !strings.Contains(err.Error(), "missing.md") {
+		t.Errorf("Expected an error naming the missing fragment, got %v", err)
+	}
+}
+
+func TestRunBuildYAMLMultiDoc(t *testing.T) {
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+	outputDir := filepath.Join(tmpDir, "out")
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	for name, content := range map[string]string{
+		"a.md": "---\nctx-tags: go, style\n---\nFirst line\nSecond line",
+		"b.md": "---\nctx-tags: go\n---\nOther",
+	} {
+		if err := os.WriteFile(filepath.Join(fragmentsDir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create fragment: %v", err)
+		}
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+fragmentsDir+`", "outputFormats": {}, "outputHeader": "<!-- generated -->"}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	opts := BuildOptions{
+		ConfigFile:     configPath,
+		Tags:           []string{"go"},
+		NonInteractive: true,
+		OutputFormats:  []string{config.YAMLMultiDocFormat, "opencode"},
+		OutputDir:      outputDir,
+		Quiet:          true,
+	}
+
+	if _, err := RunBuild(&opts); err != nil {
+		t.Fatalf("RunBuild failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, "context.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read YAML output: %v", err)
+	}
+
+	decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+
+	var paths []string
+
+	for {
+		var document struct {
+			Path    string   `yaml:"path"`
+			Tags    []string `yaml:"tags"`
+			Content string   `yaml:"content"`
+		}
+
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("Output is not valid multi-document YAML: %v\n%s", err, data)
+		}
+
+		paths = append(paths, filepath.Base(document.Path))
+
+		if document.Path == filepath.Join(fragmentsDir, "a.md") && (document.Content != "First line\nSecond line" || !reflect.DeepEqual(document.Tags, []string{"go", "style"})) {
+			t.Errorf("Unexpected document for a.md: %+v", document)
+		}
+	}
+
+	if !reflect.DeepEqual(paths, []string{"a.md", "b.md"}) {
+		t.Errorf("Expected one document per fragment, got %v", paths)
+	}
+
+	markdown, err := os.ReadFile(filepath.Join(outputDir, "AGENTS.md"))
+	if err != nil {
+		t.Fatalf("Failed to read Markdown output: %v", err)
+	}
+
+	if !strings.HasPrefix(string(markdown), "<!-- generated -->\nFirst line") {
+		t.Errorf("Expected the Markdown output to keep the header, got %q", markdown)
+	}
+}
+
+func TestRunBuildJSONFormat(t *testing.T) {
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+	outputDir := filepath.Join(tmpDir, "out")
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(fragmentsDir, "go.md"), []byte("---\nctx-tags: go\n---\nGo body"), 0o600); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+fragmentsDir+`", "outputFormats": {}, "outputHeader": "<!-- generated -->"}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	opts := BuildOptions{
+		ConfigFile:     configPath,
+		Tags:           []string{"go"},
+		NonInteractive: true,
+		OutputFormats:  []string{config.JSONFormat},
+		OutputDir:      outputDir,
+		Quiet:          true,
+	}
+
+	if _, err := RunBuild(&opts); err != nil {
+		t.Fatalf("RunBuild failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, "ctx-output.json"))
+	if err != nil {
+		t.Fatalf("Failed to read JSON output: %v", err)
+	}
+
+	var fragments []parser.Fragment
+	if err := json.Unmarshal(data, &fragments); err != nil {
+		t.Fatalf("Output is not a JSON array of fragments: %v\n%s", err, data)
+	}
+
+	expected := []parser.Fragment{{Path: filepath.Join(fragmentsDir, "go.md"), Tags: []string{"go"}, Content: "Go body"}}
+	if !reflect.DeepEqual(fragments, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, fragments)
+	}
+}
+
+func TestRunBuildJSONFormatWithTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+	outputDir := filepath.Join(tmpDir, "out")
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(fragmentsDir, "go.md"), []byte("---\nctx-tags: go\n---\nGo body"), 0o600); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+
+	templatePath := filepath.Join(tmpDir, "json.tmpl")
+	if err := os.WriteFile(templatePath, []byte(`{"context": {{printf "%q" .Content}}}`), 0o600); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	configJSON := `{"fragmentsDir": "` + fragmentsDir + `", "outputFormats": {"json": {"file": "ctx-output.json", "template": "` + templatePath + `"}}}`
+
+	if err := os.WriteFile(configPath, []byte(configJSON), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	opts := BuildOptions{
+		ConfigFile:     configPath,
+		Tags:           []string{"go"},
+		NonInteractive: true,
+		OutputFormats:  []string{config.JSONFormat},
+		OutputDir:      outputDir,
+		Quiet:          true,
+	}
+
+	if _, err := RunBuild(&opts); err != nil {
+		t.Fatalf("RunBuild failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, "ctx-output.json"))
+	if err != nil {
+		t.Fatalf("Failed to read JSON output: %v", err)
+	}
+
+	if string(data) != `{"context": "Go body"}` {
+		t.Errorf("Expected the configured json format to render its template, got %s", data)
+	}
+}
+
+func TestRunBuildStructuredFormatAppend(t *testing.T) {
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(fragmentsDir, "go.md"), []byte("---\nctx-tags: go\n---\nGo body"), 0o600); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+fragmentsDir+`", "outputFormats": {"yaml-multi-doc": {"file": "context.yaml", "append": true}}}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	for _, tt := range []struct {
+		format string
+		append bool
+	}{
+		{format: config.JSONFormat, append: true},
+		{format: config.YAMLMultiDocFormat},
+	} {
+		opts := BuildOptions{
+			ConfigFile:     configPath,
+			Tags:           []string{"go"},
+			NonInteractive: true,
+			OutputFormats:  []string{tt.format},
+			OutputDir:      tmpDir,
+			Append:         tt.append,
+			Quiet:          true,
+		}
+
+		if _, err := RunBuild(&opts); err == nil || !strings.Contains(err.Error(), "cannot be appended to") {
+			t.Errorf("Expected appending to %s to fail, got %v", tt.format, err)
+		}
+	}
+}
+
+func TestRunBuildOutputPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permission modes are not supported on Windows")
+	}
+
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(fragmentsDir, "go.md"), []byte("---\nctx-tags: go\n---\nGo body"), 0o600); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+
+	tests := []struct {
+		name              string
+		configPermissions string
+		flagPermissions   string
+		expected          map[string]os.FileMode
+		expectError       bool
+	}{
+		{
+			name:     "default",
+			expected: map[string]os.FileMode{"PLAIN.md": 0o600, "FORMAT.md": 0o640},
+		},
+		{
+			name:              "config",
+			configPermissions: "0644",
+			expected:          map[string]os.FileMode{"PLAIN.md": 0o644, "FORMAT.md": 0o640},
+		},
+		{
+			name:              "flag overrides config and output format",
+			configPermissions: "0644",
+			flagPermissions:   "604",
+			expected:          map[string]os.FileMode{"PLAIN.md": 0o604, "FORMAT.md": 0o604},
+		},
+		{
+			name:            "invalid flag",
+			flagPermissions: "0999",
+			expectError:     true,
+		},
+		{
+			name:              "invalid config",
+			configPermissions: "rw-r--r--",
+			expectError:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputDir := t.TempDir()
+			configPath := filepath.Join(outputDir, "config.json")
+			configContent := `{"fragmentsDir": "` + fragmentsDir + `", "outputPermissions": "` + tt.configPermissions + `", ` +
+				`"outputFormats": {"plain": "PLAIN.md", "format": {"file": "FORMAT.md", "permissions": "0640"}}}`
+
+			if err := os.WriteFile(configPath, []byte(configContent), 0o600); err != nil {
+				t.Fatalf("Failed to create config: %v", err)
+			}
+
+			opts := BuildOptions{
+				ConfigFile:        configPath,
+				Tags:              []string{"go"},
+				NonInteractive:    true,
+				OutputFormats:     []string{"plain", "format"},
+				OutputDir:         outputDir,
+				OutputPermissions: tt.flagPermissions,
+			}
+
+			_, err := RunBuild(&opts)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+
+				if _, statErr := os.Stat(filepath.Join(outputDir, "PLAIN.md")); !os.IsNotExist(statErr) {
+					t.Error("Expected no output file to be written with invalid permissions")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("RunBuild failed: %v", err)
+			}
+
+			for name, expected := range tt.expected {
+				info, err := os.Stat(filepath.Join(outputDir, name))
+				if err != nil {
+					t.Fatalf("Failed to stat %s: %v", name, err)
+				}
+
+				if info.Mode().Perm() != expected {
+					t.Errorf("Expected %s to have permissions %04o, got %04o", name, expected, info.Mode().Perm())
+				}
+			}
+		})
+	}
+}
+
+func TestRunBuildMultipleOutputFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(fragmentsDir, "go.md"), []byte("---\nctx-tags: go\n---\nGo body"), 0o600); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+fragmentsDir+`", "outputFormats": {}}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	outputPaths := []string{filepath.Join(tmpDir, "AGENTS.md"), filepath.Join(tmpDir, "docs", "CONTEXT.md")}
+	opts := BuildOptions{
+		ConfigFile:     configPath,
+		Tags:           []string{"go"},
+		NonInteractive: true,
+		OutputFiles:    outputPaths,
+	}
+
+	if _, err := RunBuild(&opts); err != nil {
+		t.Fatalf("RunBuild failed: %v", err)
+	}
+
+	for _, path := range outputPaths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read output %s: %v", path, err)
+		}
+
+		if string(content) != "Go body" {
+			t.Errorf("Expected %s to contain %q, got %q", path, "Go body", content)
+		}
+	}
+}
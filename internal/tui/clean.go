@@ -0,0 +1,97 @@
+package tui
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+)
+
+// CleanOptions represents the options for the clean command.
+type CleanOptions struct {
+	ConfigFile  string
+	Formats     []string
+	DryRun      bool
+	BuildReport string
+}
+
+// RunClean removes the output files of the configured output formats, or the files listed
+// in a build report, together with their metadata sidecars. Files that do not exist are
+// skipped silently.
+func RunClean(opts *CleanOptions) error {
+	targets, err := cleanTargets(opts)
+	if err != nil {
+		return err
+	}
+
+	for _, target := range withSidecars(targets) {
+		if !pathExists(target) {
+			continue
+		}
+
+		if opts.DryRun {
+			fmt.Printf("would remove: %s\n", target)
+			continue
+		}
+
+		if err := os.Remove(target); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", target, err)
+		}
+
+		fmt.Printf("removed: %s\n", target)
+	}
+
+	return nil
+}
+
+// cleanTargets returns the files the clean command should remove.
+func cleanTargets(opts *CleanOptions) ([]string, error) {
+	if opts.BuildReport != "" {
+		report, err := ReadBuildReport(opts.BuildReport)
+		if err != nil {
+			return nil, err
+		}
+
+		targets := make([]string, 0, len(report.OutputFiles))
+		for _, output := range report.OutputFiles {
+			targets = append(targets, output.Path)
+		}
+
+		return targets, nil
+	}
+
+	cfg, err := config.LoadMergedConfig(opts.ConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	formats := opts.Formats
+	if len(formats) == 0 {
+		formats = slices.Sorted(maps.Keys(cfg.OutputFormats))
+	}
+
+	targets := make([]string, 0, len(formats))
+
+	for _, format := range formats {
+		formatConfig, ok := cfg.OutputFormat(format)
+		if !ok {
+			return nil, fmt.Errorf("unknown output format: %s", format)
+		}
+
+		targets = append(targets, formatConfig.Filename)
+	}
+
+	return targets, nil
+}
+
+// withSidecars returns the output files each followed by its metadata sidecar.
+func withSidecars(outputs []string) []string {
+	files := make([]string, 0, 2*len(outputs))
+	for _, output := range outputs {
+		files = append(files, output, SidecarPath(output))
+	}
+
+	return files
+}
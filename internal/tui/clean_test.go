@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRunClean(t *testing.T) {
+	tmpDir := t.TempDir()
+	agents := filepath.Join(tmpDir, "AGENTS.md")
+	gemini := filepath.Join(tmpDir, "GEMINI.md")
+	missing := filepath.Join(tmpDir, "MISSING.md")
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	configContent := `{"outputFormats": {"opencode": "` + agents + `", "gemini": "` + gemini + `", "other": "` + missing + `"}}`
+
+	if err := os.WriteFile(configPath, []byte(configContent), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	reportPath := filepath.Join(tmpDir, "report.json")
+	if err := WriteBuildReport(reportPath, &BuildReport{OutputFiles: []BuildReportOutput{{Path: gemini}}}); err != nil {
+		t.Fatalf("Failed to create build report: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		opts      CleanOptions
+		remaining []string
+	}{
+		{name: "all formats", opts: CleanOptions{}, remaining: nil},
+		{name: "selected formats", opts: CleanOptions{Formats: []string{"opencode"}}, remaining: []string{gemini}},
+		{name: "dry run", opts: CleanOptions{DryRun: true}, remaining: []string{agents, gemini}},
+		{name: "build report", opts: CleanOptions{BuildReport: reportPath}, remaining: []string{agents}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, path := range []string{agents, gemini} {
+				if err := os.WriteFile(path, []byte("output"), 0o600); err != nil {
+					t.Fatalf("Failed to create output file: %v", err)
+				}
+			}
+
+			tt.opts.ConfigFile = configPath
+			if err := RunClean(&tt.opts); err != nil {
+				t.Fatalf("RunClean failed: %v", err)
+			}
+
+			var remaining []string
+
+			for _, path := range []string{agents, gemini} {
+				if pathExists(path) {
+					remaining = append(remaining, path)
+				}
+			}
+
+			if !reflect.DeepEqual(remaining, tt.remaining) {
+				t.Errorf("Expected remaining files %v, got %v", tt.remaining, remaining)
+			}
+		})
+	}
+
+	if err := RunClean(&CleanOptions{ConfigFile: configPath, Formats: []string{"unknown"}}); err == nil {
+		t.Error("Expected error for unknown format, got nil")
+	}
+}
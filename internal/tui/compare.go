@@ -0,0 +1,86 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+	"github.com/Lewenhaupt/ctx/internal/diff"
+	"github.com/mattn/go-isatty"
+)
+
+// CompareFragmentsOptions represents the options for the fragment compare command.
+type CompareFragmentsOptions struct {
+	ConfigFile string
+	A          string
+	B          string
+	NoColor    bool
+}
+
+// RunCompareFragments prints a unified diff between the bodies of two fragments and
+// reports whether they differ. Each argument is a fragment name, resolved like for
+// fragment show, or the path of a fragment file. Names are searched in the global
+// directories, then the local one, and the first match is used; when both arguments
+// name the same fragment, the first match is compared with the one that takes
+// precedence in a build, e.g. a global fragment with its local override.
+func RunCompareFragments(opts *CompareFragmentsOptions) (bool, error) {
+	cfg, err := config.LoadMergedConfig(opts.ConfigFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	a, err := resolveComparedFragment(cfg, opts.A, false)
+	if err != nil {
+		return false, err
+	}
+
+	b, err := resolveComparedFragment(cfg, opts.B, opts.A == opts.B)
+	if err != nil {
+		return false, err
+	}
+
+	if a.Path == b.Path {
+		return false, fmt.Errorf("%q and %q resolve to the same fragment %s", opts.A, opts.B, a.Path)
+	}
+
+	unified := diff.Unified(a.Path, b.Path, a.Content, b.Content)
+	if unified == "" {
+		fmt.Printf("Fragments are identical: %s and %s\n", a.Path, b.Path)
+		return false, nil
+	}
+
+	if !opts.NoColor && isatty.IsTerminal(os.Stdout.Fd()) {
+		unified = diff.Colorize(unified)
+	}
+
+	fmt.Print(unified)
+
+	return true, nil
+}
+
+// resolveComparedFragment returns the fragment called name, or the fragment file at
+// name if it is a path. It returns the first match, or with last the one activeFragment picks.
+func resolveComparedFragment(cfg *config.Config, name string, last bool) (*FragmentDetails, error) {
+	if strings.HasSuffix(name, ".md") {
+		if _, err := os.Stat(name); err == nil {
+			return loadFragmentDetails(cfg, filepath.Dir(name), name, "")
+		}
+	}
+
+	details, err := findFragmentsByName(cfg, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(details) == 0 {
+		return nil, fmt.Errorf("fragment %q not found", name)
+	}
+
+	if last {
+		return activeFragment(details), nil
+	}
+
+	return &details[0], nil
+}
@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunCompareFragments(t *testing.T) {
+	tmpDir := t.TempDir()
+	globalDir := filepath.Join(tmpDir, "global")
+	projectDir := filepath.Join(tmpDir, "project")
+	localDir := filepath.Join(projectDir, ".ctx", "fragments")
+
+	files := map[string]string{
+		filepath.Join(globalDir, "style.md"): "---\nctx-tags: style\n---\nUse tabs for indentation.\nKeep lines short.",
+		filepath.Join(globalDir, "copy.md"):  "---\nctx-tags: other\n---\nUse tabs for indentation.\nKeep lines short.",
+		filepath.Join(localDir, "style.md"):  "---\nctx-tags: style\n---\nUse spaces for indentation.\nKeep lines short.",
+	}
+
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create fragment: %v", err)
+		}
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+globalDir+`"}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+
+	defer func() { _ = os.Chdir(originalWd) }()
+
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+
+	compare := func(a, b string) (bool, string, error) {
+		reader, writer, err := os.Pipe()
+		if err != nil {
+			t.Fatalf("Failed to create pipe: %v", err)
+		}
+
+		stdout := os.Stdout
+		os.Stdout = writer
+
+		different, compareErr := RunCompareFragments(&CompareFragmentsOptions{ConfigFile: configPath, A: a, B: b, NoColor: true})
+
+		os.Stdout = stdout
+		_ = writer.Close()
+
+		output, _ := io.ReadAll(reader)
+
+		return different, string(output), compareErr
+	}
+
+	// The same name twice compares the global fragment with its local override
+	different, output, err := compare("style", "style")
+	if err != nil {
+		t.Fatalf("RunCompareFragments failed: %v", err)
+	}
+
+	if !different {
+		t.Error("Expected the fragments to differ")
+	}
+
+	if !strings.Contains(output, "-Use tabs for indentation.\n+Use spaces for indentation.\n") {
+		t.Errorf("Expected a diff of the changed word, got:\n%s", output)
+	}
+
+	if strings.Contains(output, "-Keep lines short.") || strings.Contains(output, "\x1b[") {
+		t.Errorf("Expected only the changed line without colors, got:\n%s", output)
+	}
+
+	different, _, err = compare("style", filepath.Join(globalDir, "copy.md"))
+	if err != nil || different {
+		t.Errorf("Expected identical fragments, got different=%v, err=%v", different, err)
+	}
+
+	if _, _, err := compare("style", "missing"); err == nil {
+		t.Error("Expected error for a missing fragment, got nil")
+	}
+
+	if _, _, err := compare("copy", "copy"); err == nil {
+		t.Error("Expected error when both arguments resolve to the same file, got nil")
+	}
+}
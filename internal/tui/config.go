@@ -0,0 +1,178 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+	"github.com/Lewenhaupt/ctx/internal/diff"
+)
+
+// ConfigMigrateOptions represents the options for the config migrate command.
+type ConfigMigrateOptions struct {
+	ConfigFile string
+}
+
+// RunConfigMigrate upgrades the config file to the current schema version and prints what changed.
+func RunConfigMigrate(opts *ConfigMigrateOptions) error {
+	configPath, err := config.ResolveConfigPath(opts.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to get config directory: %w", err)
+	}
+
+	before, err := os.ReadFile(configPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("no config file found at %s", configPath)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	after, err := config.MarshalConfigAs(cfg, config.FormatForPath(configPath))
+	if err != nil {
+		return err
+	}
+
+	if string(before) == string(after) {
+		fmt.Printf("Configuration is already up to date: %s\n", configPath)
+		return nil
+	}
+
+	if err := createConfigBackup(configPath); err != nil {
+		return fmt.Errorf("failed to create config backup: %w", err)
+	}
+
+	if err := config.SaveConfig(cfg, configPath); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Print(diff.Unified(configPath, configPath+" (migrated)", string(before), string(after)))
+	fmt.Printf("Configuration migrated to version %d: %s\n", config.CurrentVersion, configPath)
+
+	return nil
+}
+
+// ConfigConvertOptions represents the options for the config convert command.
+type ConfigConvertOptions struct {
+	ConfigFile string
+	To         string
+}
+
+// RunConfigConvert rewrites the config file in another format (json, yaml or toml). The new
+// file is written next to the original with the matching extension and the original
+// is replaced by a timestamped backup, so the converted file is the one loaded.
+func RunConfigConvert(opts *ConfigConvertOptions) error {
+	format := strings.ToLower(opts.To)
+	if format == "yml" {
+		format = config.FormatYAML
+	}
+
+	if format != config.FormatJSON && format != config.FormatYAML && format != config.FormatTOML {
+		return fmt.Errorf("invalid format %q (expected %s, %s or %s)", opts.To, config.FormatJSON, config.FormatYAML, config.FormatTOML)
+	}
+
+	configPath, err := config.ResolveConfigPath(opts.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to get config directory: %w", err)
+	}
+
+	if _, err := os.Stat(configPath); errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("no config file found at %s", configPath)
+	}
+
+	if config.FormatForPath(configPath) == format {
+		fmt.Printf("Configuration is already in %s format: %s\n", format, configPath)
+		return nil
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	targetPath := strings.TrimSuffix(configPath, filepath.Ext(configPath)) + "." + format
+	if _, err := os.Stat(targetPath); err == nil {
+		return fmt.Errorf("target config file already exists: %s", targetPath)
+	}
+
+	if err := config.SaveConfig(cfg, targetPath); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	if err := createConfigBackup(configPath); err != nil {
+		return fmt.Errorf("failed to create config backup: %w", err)
+	}
+
+	if err := os.Remove(configPath); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", configPath, err)
+	}
+
+	fmt.Printf("Configuration converted to %s: %s\n", format, targetPath)
+
+	return nil
+}
+
+// ConfigShowOptions represents the options for the config show command.
+type ConfigShowOptions struct {
+	ConfigFile string
+	JSON       bool
+}
+
+// configShowJSON is the document printed by config show --json.
+type configShowJSON struct {
+	Config  *config.Config    `json:"config"`
+	Sources map[string]string `json:"sources"`
+}
+
+// RunConfigShow prints the effective configuration after merging the global and local
+// config files, with the file each field was taken from.
+func RunConfigShow(opts *ConfigShowOptions) error {
+	cfg, fields, err := config.LoadMergedConfigWithSources(opts.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if opts.JSON {
+		document := configShowJSON{Config: cfg, Sources: make(map[string]string, len(fields))}
+		for _, field := range fields {
+			document.Sources[field.Name] = field.Source
+		}
+
+		data, err := json.MarshalIndent(document, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal config: %w", err)
+		}
+
+		fmt.Println(string(data))
+
+		return nil
+	}
+
+	fmt.Print(formatConfigFields(fields))
+
+	return nil
+}
+
+// formatConfigFields renders the config fields as a table of name, value and source.
+func formatConfigFields(fields []config.ConfigField) string {
+	var result strings.Builder
+
+	result.WriteString(fmt.Sprintf("%-18s %-40s %s\n", "FIELD", "VALUE", "SOURCE"))
+
+	for _, field := range fields {
+		result.WriteString(fmt.Sprintf("%-18s %-40s %s\n", field.Name, string(field.Value), field.Source))
+	}
+
+	return result.String()
+}
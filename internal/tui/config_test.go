@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+)
+
+func TestRunConfigConvert(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "config.json")
+	yamlPath := filepath.Join(dir, "config.yaml")
+
+	if err := os.WriteFile(jsonPath, []byte(`{"defaultTags": ["go"], "outputFormats": {"opencode": "AGENTS.md"}}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config file: %v", err)
+	}
+
+	if err := RunConfigConvert(&ConfigConvertOptions{ConfigFile: jsonPath, To: "xml"}); err == nil {
+		t.Error("Expected error for unsupported format, got nil")
+	}
+
+	if err := RunConfigConvert(&ConfigConvertOptions{ConfigFile: jsonPath, To: "yaml"}); err != nil {
+		t.Fatalf("RunConfigConvert failed: %v", err)
+	}
+
+	if _, err := os.Stat(jsonPath); !os.IsNotExist(err) {
+		t.Error("Expected the original JSON config to be replaced by a backup")
+	}
+
+	cfg, err := config.LoadConfig(yamlPath)
+	if err != nil {
+		t.Fatalf("Failed to load converted config: %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg.DefaultTags, []string{"go"}) || cfg.OutputFormats["opencode"].Filename != "AGENTS.md" {
+		t.Errorf("Converted config lost values: %+v", cfg)
+	}
+
+	if err := RunConfigConvert(&ConfigConvertOptions{ConfigFile: yamlPath, To: "json"}); err != nil {
+		t.Fatalf("RunConfigConvert back to JSON failed: %v", err)
+	}
+
+	if _, err := os.Stat(jsonPath); err != nil {
+		t.Errorf("Expected JSON config after converting back: %v", err)
+	}
+
+	tomlPath := filepath.Join(dir, "config.toml")
+
+	if err := RunConfigConvert(&ConfigConvertOptions{ConfigFile: jsonPath, To: "toml"}); err != nil {
+		t.Fatalf("RunConfigConvert to TOML failed: %v", err)
+	}
+
+	cfg, err = config.LoadConfig(tomlPath)
+	if err != nil {
+		t.Fatalf("Failed to load converted config: %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg.DefaultTags, []string{"go"}) || cfg.OutputFormats["opencode"].Filename != "AGENTS.md" {
+		t.Errorf("Converted TOML config lost values: %+v", cfg)
+	}
+}
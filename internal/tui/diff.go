@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/Lewenhaupt/ctx/internal/diff"
+	"github.com/mattn/go-isatty"
+)
+
+// DiffOptions represents the options for the diff command.
+type DiffOptions struct {
+	BuildOptions
+	NoColor bool
+}
+
+// RunDiff builds the output like RunBuild but, instead of writing it, prints a unified
+// diff against the existing output files. It reports whether any output file differs.
+func RunDiff(opts *DiffOptions) (bool, error) {
+	// Build hooks may have side effects, so they never run for a diff
+	buildOpts := opts.BuildOptions
+	buildOpts.SkipHooks = true
+
+	plan, err := planBuild(&buildOpts)
+	if err != nil {
+		return false, err
+	}
+
+	plan.fragments, err = preprocessFragments(&buildOpts, plan.cfg, plan.fragments)
+	if err != nil {
+		return false, err
+	}
+
+	output, err := renderBuildOutput(&buildOpts, plan)
+	if err != nil {
+		return false, err
+	}
+
+	color := !opts.NoColor && isatty.IsTerminal(os.Stdout.Fd())
+	changed := false
+	compared := 0
+
+	for i, format := range plan.outputFormats {
+		if format == "stdout" {
+			continue
+		}
+
+		filename, err := resolveOutputFilename(format, i, plan.outputFiles, opts.OutputDir, plan.cfg)
+		if err != nil {
+			return false, err
+		}
+
+		compared++
+
+		existing, err := readExistingOutput(filename)
+		if err != nil {
+			return false, err
+		}
+
+		unified := diff.Unified(filename, filename+" (built)", existing, output.fileContent(format))
+		if unified == "" {
+			continue
+		}
+
+		changed = true
+
+		if color {
+			unified = diff.Colorize(unified)
+		}
+
+		fmt.Print(unified)
+	}
+
+	if compared == 0 {
+		return false, fmt.Errorf("no output files to compare; diff requires at least one file output format")
+	}
+
+	return changed, nil
+}
+
+// readExistingOutput returns the content of an output file, or an empty string if it does not exist yet.
+func readExistingOutput(filename string) (string, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to read output file %s: %w", filename, err)
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,241 @@
+package tui
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+// DoctorOptions represents the options for the doctor command.
+type DoctorOptions struct {
+	ConfigFile string
+	Fix        bool
+}
+
+// DoctorCheck is the result of a single doctor check.
+type DoctorCheck struct {
+	Name    string
+	Passed  bool
+	Message string
+}
+
+// RunDoctor checks the configuration and environment and prints the result of each check.
+// With opts.Fix, problems that can be repaired automatically (such as missing directories)
+// are fixed. It reports whether all checks passed.
+func RunDoctor(opts *DoctorOptions) (bool, error) {
+	configPath, err := config.ResolveConfigPath(opts.ConfigFile)
+	if err != nil {
+		return false, err
+	}
+
+	cfg, checks := checkConfig(opts.ConfigFile, configPath)
+
+	dirs, err := config.GetFragmentsDirs(cfg)
+	if err != nil {
+		return false, fmt.Errorf("failed to get fragments directory: %w", err)
+	}
+
+	localDir, err := parser.LocalFragmentsDir()
+	if err != nil {
+		return false, err
+	}
+
+	checks = append(checks, checkFragmentsDirs(dirs, opts.Fix)...)
+	checks = append(checks, checkTaggedFragments(append(dirs, localDir), scanOptions(cfg)))
+	checks = append(checks, checkOutputTargets(cfg.OutputFormats, opts.Fix)...)
+	checks = append(checks, checkLocalFragments(localDir))
+
+	passed := true
+
+	for _, check := range checks {
+		mark := "✓"
+		if !check.Passed {
+			mark = "✗"
+			passed = false
+		}
+
+		fmt.Printf("%s %s: %s\n", mark, check.Name, check.Message)
+	}
+
+	return passed, nil
+}
+
+// checkConfig verifies that the config file, and a local config if one is merged, can be loaded.
+// When loading fails the default config is returned so the remaining checks can still run.
+func checkConfig(configFile, configPath string) (*config.Config, []DoctorCheck) {
+	check := DoctorCheck{Name: "config", Passed: true}
+
+	if !pathExists(configPath) {
+		check.Message = fmt.Sprintf("%s not found, using defaults", configPath)
+	} else {
+		check.Message = fmt.Sprintf("%s is valid", configPath)
+	}
+
+	cfg, err := config.LoadMergedConfig(configFile)
+	if err != nil {
+		check.Passed = false
+		check.Message = fmt.Sprintf("failed to load config: %v", err)
+		cfg = config.DefaultConfig()
+	}
+
+	return cfg, []DoctorCheck{check}
+}
+
+// checkFragmentsDirs verifies that each global fragments directory exists and is readable,
+// creating missing directories when fix is set.
+func checkFragmentsDirs(dirs []string, fix bool) []DoctorCheck {
+	checks := make([]DoctorCheck, 0, len(dirs))
+
+	for _, dir := range dirs {
+		check := DoctorCheck{Name: "fragments directory"}
+
+		_, err := os.ReadDir(dir)
+
+		switch {
+		case err == nil:
+			check.Passed = true
+			check.Message = fmt.Sprintf("%s exists and is readable", dir)
+		case os.IsNotExist(err) && fix:
+			if mkErr := os.MkdirAll(dir, 0o750); mkErr != nil {
+				check.Message = fmt.Sprintf("failed to create %s: %v", dir, mkErr)
+			} else {
+				check.Passed = true
+				check.Message = fmt.Sprintf("%s was missing and has been created", dir)
+			}
+		case os.IsNotExist(err):
+			check.Message = fmt.Sprintf("%s does not exist (run with --fix to create it)", dir)
+		default:
+			check.Message = fmt.Sprintf("%s is not readable: %v", dir, err)
+		}
+
+		checks = append(checks, check)
+	}
+
+	return checks
+}
+
+// checkTaggedFragments verifies that the fragments in dirs parse and at least one has tags,
+// counting the implicit namespace tags when scanOpts enables them.
+func checkTaggedFragments(dirs []string, scanOpts parser.ScanOptions) DoctorCheck {
+	check := DoctorCheck{Name: "tagged fragments"}
+
+	issues, _, err := validateFragmentDirs(dirs, scanOpts)
+	if err != nil {
+		check.Message = err.Error()
+		return check
+	}
+
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			check.Message = fmt.Sprintf("%s: %s (run ctx validate for details)", issue.Path, issue.Message)
+			return check
+		}
+	}
+
+	fragments, err := parser.ScanFragmentsDirs(dirs, true, scanOpts)
+	if err != nil {
+		check.Message = err.Error()
+		return check
+	}
+
+	tagged := 0
+
+	for _, fragment := range fragments {
+		if len(fragment.Tags) > 0 {
+			tagged++
+		}
+	}
+
+	if tagged == 0 {
+		check.Message = "no fragment has ctx-tags"
+		return check
+	}
+
+	check.Passed = true
+	check.Message = fmt.Sprintf("%d of %d fragment(s) have ctx-tags", tagged, len(fragments))
+
+	return check
+}
+
+// checkOutputTargets verifies that every output format target can be written, creating
+// missing parent directories when fix is set.
+func checkOutputTargets(outputFormats map[string]config.OutputFormatConfig, fix bool) []DoctorCheck {
+	checks := make([]DoctorCheck, 0, len(outputFormats))
+
+	for _, format := range slices.Sorted(maps.Keys(outputFormats)) {
+		filename := outputFormats[format].Filename
+		check := DoctorCheck{Name: fmt.Sprintf("output %s", format)}
+
+		dir := filepath.Dir(filename)
+		if !pathExists(dir) && fix {
+			if err := os.MkdirAll(dir, 0o750); err != nil {
+				check.Message = fmt.Sprintf("failed to create %s: %v", dir, err)
+				checks = append(checks, check)
+
+				continue
+			}
+		}
+
+		if err := checkWritable(filename); err != nil {
+			check.Message = fmt.Sprintf("%s is not writable: %v", filename, err)
+		} else {
+			check.Passed = true
+			check.Message = fmt.Sprintf("%s is writable", filename)
+		}
+
+		checks = append(checks, check)
+	}
+
+	return checks
+}
+
+// checkWritable reports whether filename can be written without modifying it.
+func checkWritable(filename string) error {
+	if pathExists(filename) {
+		file, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return err
+		}
+
+		return file.Close()
+	}
+
+	probe, err := os.CreateTemp(filepath.Dir(filename), ".ctx-doctor-*")
+	if err != nil {
+		return err
+	}
+
+	_ = probe.Close()
+
+	return os.Remove(probe.Name())
+}
+
+// checkLocalFragments verifies that a local fragments directory, if present, contains fragments.
+func checkLocalFragments(localDir string) DoctorCheck {
+	check := DoctorCheck{Name: "local fragments", Passed: true}
+
+	if !pathExists(localDir) {
+		check.Message = fmt.Sprintf("%s not present (optional)", localDir)
+		return check
+	}
+
+	paths, err := parser.FindFragmentFiles(localDir)
+
+	switch {
+	case err != nil:
+		check.Passed = false
+		check.Message = fmt.Sprintf("failed to scan %s: %v", localDir, err)
+	case len(paths) == 0:
+		check.Passed = false
+		check.Message = fmt.Sprintf("%s exists but contains no fragments", localDir)
+	default:
+		check.Message = fmt.Sprintf("%s contains %d fragment(s)", localDir, len(paths))
+	}
+
+	return check
+}
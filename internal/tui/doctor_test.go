@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+func TestCheckFragmentsDirs(t *testing.T) {
+	tmpDir := t.TempDir()
+	missing := filepath.Join(tmpDir, "missing")
+
+	checks := checkFragmentsDirs([]string{tmpDir, missing}, false)
+	if !checks[0].Passed || checks[1].Passed {
+		t.Errorf("Expected existing dir to pass and missing dir to fail, got %+v", checks)
+	}
+
+	checks = checkFragmentsDirs([]string{missing}, true)
+	if !checks[0].Passed {
+		t.Errorf("Expected --fix to create the missing directory, got %+v", checks[0])
+	}
+
+	if !pathExists(missing) {
+		t.Error("Expected missing directory to be created")
+	}
+}
+
+func TestCheckTaggedFragments(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string
+		namespace bool
+		expected  bool
+	}{
+		{name: "tagged fragment", files: map[string]string{"a.md": "---\nctx-tags: a\n---\nA"}, expected: true},
+		{name: "namespace tags only", files: map[string]string{"react/a.md": "A"}, namespace: true, expected: true},
+		{name: "namespace tags disabled", files: map[string]string{"react/a.md": "A"}, expected: false},
+		{name: "untagged fragments only", files: map[string]string{"a.md": "A"}, expected: false},
+		{name: "invalid frontmatter", files: map[string]string{"a.md": "---\nctx-tags: a\n---\n", "b.md": "---\nctx-tags: {a: b}\n---\n"}, expected: false},
+		{name: "no fragments", files: map[string]string{}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+
+			for name, content := range tt.files {
+				path := filepath.Join(tmpDir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+					t.Fatalf("Failed to create fragment directory: %v", err)
+				}
+
+				if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+					t.Fatalf("Failed to create fragment: %v", err)
+				}
+			}
+
+			check := checkTaggedFragments([]string{tmpDir}, parser.ScanOptions{NamespaceFromDir: tt.namespace})
+			if check.Passed != tt.expected {
+				t.Errorf("Expected passed %v, got %+v", tt.expected, check)
+			}
+		})
+	}
+}
+
+func TestCheckOutputTargets(t *testing.T) {
+	tmpDir := t.TempDir()
+	nested := filepath.Join(tmpDir, "nested", "OUT.md")
+
+	checks := checkOutputTargets(map[string]config.OutputFormatConfig{
+		"a": {Filename: filepath.Join(tmpDir, "AGENTS.md")},
+		"b": {Filename: nested},
+	}, false)
+
+	if !checks[0].Passed || checks[1].Passed {
+		t.Errorf("Expected writable target to pass and missing directory to fail, got %+v", checks)
+	}
+
+	checks = checkOutputTargets(map[string]config.OutputFormatConfig{"b": {Filename: nested}}, true)
+	if !checks[0].Passed {
+		t.Errorf("Expected --fix to create the output directory, got %+v", checks[0])
+	}
+
+	if pathExists(nested) {
+		t.Error("Expected the output file itself not to be created")
+	}
+}
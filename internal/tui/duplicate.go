@@ -0,0 +1,60 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+// DuplicateFragmentOptions represents the options for the fragment duplicate command.
+type DuplicateFragmentOptions struct {
+	ConfigFile string
+	Source     string
+	Name       string
+	Force      bool
+}
+
+// RunDuplicateFragment copies the fragment named opts.Source to a new fragment called
+// opts.Name in the same directory. The source is searched in the global directories,
+// then the local one, and the first match is copied.
+func RunDuplicateFragment(opts *DuplicateFragmentOptions) error {
+	if err := validateFragmentName(opts.Name); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadMergedConfig(opts.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	details, err := findFragmentsByName(cfg, opts.Source)
+	if err != nil {
+		return err
+	}
+
+	if len(details) == 0 {
+		return fmt.Errorf("fragment %q not found", opts.Source)
+	}
+
+	srcPath := details[0].Path
+	dstPath := filepath.Join(filepath.Dir(srcPath), fragmentFileName(opts.Name))
+
+	if _, err := os.Stat(dstPath); err == nil && !opts.Force {
+		return fmt.Errorf("fragment file already exists: %s (use --force to overwrite)", dstPath)
+	}
+
+	if err := parser.DuplicateFragment(srcPath, dstPath); err != nil {
+		return fmt.Errorf("failed to duplicate fragment: %w", err)
+	}
+
+	fmt.Printf("Duplicated %s to %s\n", srcPath, dstPath)
+
+	if len(details) > 1 {
+		fmt.Printf("Note: %d fragments are named %q; the first one found was copied.\n", len(details), opts.Source)
+	}
+
+	return nil
+}
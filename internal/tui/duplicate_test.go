@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunDuplicateFragment(t *testing.T) {
+	tmpDir := t.TempDir()
+	globalDir := filepath.Join(tmpDir, "global")
+	projectDir := filepath.Join(tmpDir, "project")
+
+	if err := os.MkdirAll(filepath.Join(globalDir, "react"), 0o750); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	if err := os.MkdirAll(projectDir, 0o750); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	srcPath := filepath.Join(globalDir, "react", "hooks.md")
+	if err := os.WriteFile(srcPath, []byte("---\nctx-tags: react\n---\nHooks"), 0o600); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+globalDir+`"}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+
+	defer func() { _ = os.Chdir(originalWd) }()
+
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+
+	opts := DuplicateFragmentOptions{ConfigFile: configPath, Source: "hooks", Name: "effects"}
+	if err := RunDuplicateFragment(&opts); err != nil {
+		t.Fatalf("RunDuplicateFragment failed: %v", err)
+	}
+
+	dstPath := filepath.Join(globalDir, "react", "effects.md")
+
+	data, err := os.ReadFile(dstPath)
+	if err != nil {
+		t.Fatalf("Expected the duplicate next to the source: %v", err)
+	}
+
+	if !strings.Contains(string(data), "ctx-description: Copy of hooks") {
+		t.Errorf("Expected a copy description, got %q", data)
+	}
+
+	if err := RunDuplicateFragment(&opts); err == nil {
+		t.Error("Expected error when the destination exists, got nil")
+	}
+
+	opts.Force = true
+	if err := RunDuplicateFragment(&opts); err != nil {
+		t.Errorf("Expected --force to overwrite the destination, got %v", err)
+	}
+
+	for _, invalid := range []DuplicateFragmentOptions{
+		{ConfigFile: configPath, Source: "missing", Name: "copy"},
+		{ConfigFile: configPath, Source: "hooks", Name: "nested/copy"},
+	} {
+		if err := RunDuplicateFragment(&invalid); err == nil {
+			t.Errorf("Expected error for %+v, got nil", invalid)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package tui
+
+import "github.com/Lewenhaupt/ctx/internal/parser"
+
+// Build event kinds.
+const (
+	EventFragmentScanned = "fragment-scanned"
+	EventTagsSelected    = "tags-selected"
+	EventOutputWritten   = "output-written"
+	EventBuildComplete   = "build-complete"
+)
+
+// BuildEvent is emitted to BuildOptions.EventHandler while a build runs.
+type BuildEvent interface {
+	Kind() string
+}
+
+// FragmentScannedEvent is emitted for each fragment found before tag filtering.
+type FragmentScannedEvent struct {
+	Fragment parser.Fragment
+}
+
+// Kind implements BuildEvent.
+func (FragmentScannedEvent) Kind() string { return EventFragmentScanned }
+
+// TagsSelectedEvent is emitted once the tags to build with are known, after alias expansion.
+type TagsSelectedEvent struct {
+	Tags []string
+}
+
+// Kind implements BuildEvent.
+func (TagsSelectedEvent) Kind() string { return EventTagsSelected }
+
+// OutputWrittenEvent is emitted after an output file has been written.
+type OutputWrittenEvent struct {
+	Path      string
+	SizeBytes int
+}
+
+// Kind implements BuildEvent.
+func (OutputWrittenEvent) Kind() string { return EventOutputWritten }
+
+// BuildCompleteEvent is emitted when a build has finished successfully.
+type BuildCompleteEvent struct {
+	Fragments   int
+	OutputFiles []string
+}
+
+// Kind implements BuildEvent.
+func (BuildCompleteEvent) Kind() string { return EventBuildComplete }
+
+// emit passes event to the options' event handler, if one is set.
+func (opts *BuildOptions) emit(event BuildEvent) {
+	if opts.EventHandler != nil {
+		opts.EventHandler(event)
+	}
+}
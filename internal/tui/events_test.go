@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRunBuildEvents(t *testing.T) {
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	files := map[string]string{
+		"a.md": "---\nctx-tags: go\n---\nA",
+		"b.md": "---\nctx-tags: rust\n---\nB",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(fragmentsDir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create fragment: %v", err)
+		}
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+fragmentsDir+`", "outputFormats": {}}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	outputPath := filepath.Join(tmpDir, "AGENTS.md")
+
+	var events []BuildEvent
+
+	opts := BuildOptions{
+		ConfigFile:     configPath,
+		Tags:           []string{"go"},
+		NonInteractive: true,
+		OutputFiles:    []string{outputPath},
+		EventHandler: func(event BuildEvent) {
+			events = append(events, event)
+		},
+	}
+
+	if _, err := RunBuild(&opts); err != nil {
+		t.Fatalf("RunBuild failed: %v", err)
+	}
+
+	kinds := make([]string, 0, len(events))
+	for _, event := range events {
+		kinds = append(kinds, event.Kind())
+	}
+
+	expectedKinds := []string{
+		EventFragmentScanned,
+		EventFragmentScanned,
+		EventTagsSelected,
+		EventOutputWritten,
+		EventBuildComplete,
+	}
+	if !reflect.DeepEqual(kinds, expectedKinds) {
+		t.Fatalf("Expected event kinds %v, got %v", expectedKinds, kinds)
+	}
+
+	if tags := events[2].(TagsSelectedEvent).Tags; !reflect.DeepEqual(tags, []string{"go"}) {
+		t.Errorf("Expected selected tags [go], got %v", tags)
+	}
+
+	if written := events[3].(OutputWrittenEvent); written.Path != outputPath || written.SizeBytes != 1 {
+		t.Errorf("Expected output written event for %s with 1 byte, got %+v", outputPath, written)
+	}
+
+	complete := events[4].(BuildCompleteEvent)
+	if complete.Fragments != 1 || !reflect.DeepEqual(complete.OutputFiles, []string{outputPath}) {
+		t.Errorf("Unexpected build complete event: %+v", complete)
+	}
+}
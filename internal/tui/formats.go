@@ -0,0 +1,107 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+)
+
+// FormatsOptions represents the options for the formats command.
+type FormatsOptions struct {
+	ConfigFile string
+	JSON       bool
+}
+
+// Sources of an output format listed by the formats command.
+const (
+	FormatSourceBuiltIn = "built-in"
+	FormatSourceConfig  = "config"
+)
+
+// OutputFormatInfo describes an output format a build can use.
+type OutputFormatInfo struct {
+	Name string `json:"name"`
+	// File is the path the output is written to, under the configured outputDir if set.
+	File string `json:"file"`
+	// Source is FormatSourceBuiltIn for built-in formats the config does not change and
+	// FormatSourceConfig for all others.
+	Source string `json:"source"`
+}
+
+// RunFormats prints the output formats available to build --output-format.
+func RunFormats(opts *FormatsOptions) error {
+	formats, err := AvailableOutputFormats(opts.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		data, err := json.MarshalIndent(formats, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal output formats: %w", err)
+		}
+
+		fmt.Println(string(data))
+
+		return nil
+	}
+
+	fmt.Print(formatOutputFormats(formats))
+
+	return nil
+}
+
+// AvailableOutputFormats loads the config and returns the built-in and configured output
+// formats, sorted by name.
+func AvailableOutputFormats(configFile string) ([]OutputFormatInfo, error) {
+	cfg, err := config.LoadMergedConfig(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	formats := config.AvailableOutputFormats(cfg)
+	builtIn := config.BuiltInOutputFormats()
+	infos := make([]OutputFormatInfo, 0, len(formats))
+
+	for _, name := range slices.Sorted(maps.Keys(formats)) {
+		file, err := resolveOutputFilename(name, 0, nil, "", cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		source := FormatSourceConfig
+		if format, ok := builtIn[name]; ok && format == formats[name] {
+			source = FormatSourceBuiltIn
+		}
+
+		infos = append(infos, OutputFormatInfo{Name: name, File: file, Source: source})
+	}
+
+	return infos, nil
+}
+
+// formatOutputFormats renders the output formats, one per line with their target file.
+func formatOutputFormats(formats []OutputFormatInfo) string {
+	width := 0
+	for _, format := range formats {
+		width = max(width, len(format.Name))
+	}
+
+	var result strings.Builder
+
+	for _, format := range formats {
+		result.WriteString(fmt.Sprintf("%-*s  %s", width, format.Name, format.File))
+
+		if format.Source == FormatSourceBuiltIn {
+			result.WriteString(" (built-in)")
+		}
+
+		result.WriteString("\n")
+	}
+
+	return result.String()
+}
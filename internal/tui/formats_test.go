@@ -0,0 +1,53 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAvailableOutputFormats(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Chdir(tmpDir)
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	config := `{"outputDir": "out", "outputFormats": {"opencode": "docs/AGENTS.md", "cursor": ".cursorrules"}}`
+
+	if err := os.WriteFile(configPath, []byte(config), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	formats, err := AvailableOutputFormats(configPath)
+	if err != nil {
+		t.Fatalf("AvailableOutputFormats failed: %v", err)
+	}
+
+	expected := []OutputFormatInfo{
+		{Name: "cursor", File: filepath.Join("out", ".cursorrules"), Source: FormatSourceConfig},
+		{Name: "gemini", File: filepath.Join("out", "GEMINI.md"), Source: FormatSourceBuiltIn},
+		{Name: "json", File: filepath.Join("out", "ctx-output.json"), Source: FormatSourceBuiltIn},
+		{Name: "opencode", File: filepath.Join("out", "docs", "AGENTS.md"), Source: FormatSourceConfig},
+		{Name: "yaml-multi-doc", File: filepath.Join("out", "context.yaml"), Source: FormatSourceBuiltIn},
+	}
+
+	if !reflect.DeepEqual(formats, expected) {
+		t.Errorf("Expected formats %v, got %v", expected, formats)
+	}
+
+	if _, err := AvailableOutputFormats(filepath.Join(tmpDir, "missing.json")); err != nil {
+		t.Errorf("Expected a missing config to list the built-in formats, got error: %v", err)
+	}
+}
+
+func TestFormatOutputFormats(t *testing.T) {
+	formats := []OutputFormatInfo{
+		{Name: "cursor", File: ".cursorrules", Source: FormatSourceConfig},
+		{Name: "opencode", File: "AGENTS.md", Source: FormatSourceBuiltIn},
+	}
+
+	expected := "cursor    .cursorrules\nopencode  AGENTS.md (built-in)\n"
+	if got := formatOutputFormats(formats); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
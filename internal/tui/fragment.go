@@ -0,0 +1,246 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+	"github.com/Lewenhaupt/ctx/internal/parser"
+	"github.com/charmbracelet/huh"
+)
+
+// NewFragmentOptions represents the options for the fragment new command.
+type NewFragmentOptions struct {
+	ConfigFile     string
+	Name           string
+	Tags           []string
+	Description    string
+	Priority       *int
+	Local          bool
+	Force          bool
+	NonInteractive bool
+	// Content is the body written below the frontmatter; when empty a heading with the
+	// fragment name is written instead.
+	Content string
+}
+
+// RunNewFragment scaffolds a new fragment file with properly formatted frontmatter.
+func RunNewFragment(opts *NewFragmentOptions) error {
+	if !opts.NonInteractive {
+		if err := promptNewFragment(opts); err != nil {
+			return fmt.Errorf("fragment prompt failed: %w", err)
+		}
+	}
+
+	if opts.Name == "" {
+		return fmt.Errorf("fragment name is required")
+	}
+
+	if len(opts.Tags) == 0 {
+		return fmt.Errorf("at least one tag is required")
+	}
+
+	dir, err := fragmentTargetDir(opts.ConfigFile, opts.Local)
+	if err != nil {
+		return err
+	}
+
+	path, err := writeNewFragment(dir, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Fragment created: %s\n", path)
+
+	return nil
+}
+
+// fragmentTargetDir returns the local or global fragments directory.
+func fragmentTargetDir(configFile string, local bool) (string, error) {
+	if local {
+		return parser.LocalFragmentsDir()
+	}
+
+	cfg, err := config.LoadMergedConfig(configFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to load config: %w", err)
+	}
+
+	fragmentsDir, err := config.GetFragmentsDir(cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to get fragments directory: %w", err)
+	}
+
+	return fragmentsDir, nil
+}
+
+// promptNewFragment asks for the fragment details, pre-filled with any values given as flags.
+func promptNewFragment(opts *NewFragmentOptions) error {
+	tagsInput := strings.Join(opts.Tags, ", ")
+
+	var priorityInput string
+	if opts.Priority != nil {
+		priorityInput = strconv.Itoa(*opts.Priority)
+	}
+
+	location := "global"
+	if opts.Local {
+		location = "local"
+	}
+
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Fragment name").
+				Description("The file name of the fragment (e.g., 'typescript' creates typescript.md)").
+				Value(&opts.Name).
+				Validate(validateFragmentName),
+			huh.NewInput().
+				Title("Tags").
+				Description("Comma-separated list of tags for this fragment").
+				Value(&tagsInput).
+				Validate(func(val string) error {
+					if len(splitTags(val)) == 0 {
+						return fmt.Errorf("at least one tag is required")
+					}
+
+					return nil
+				}),
+			huh.NewInput().
+				Title("Description").
+				Description("A short description of the fragment (optional)").
+				Value(&opts.Description),
+			huh.NewInput().
+				Title("Priority").
+				Description("A number controlling the fragment order (optional)").
+				Value(&priorityInput).
+				Validate(func(val string) error {
+					if val == "" {
+						return nil
+					}
+
+					if _, err := strconv.Atoi(val); err != nil {
+						return fmt.Errorf("priority must be a number")
+					}
+
+					return nil
+				}),
+			huh.NewSelect[string]().
+				Title("Where should the fragment be stored?").
+				Options(
+					huh.NewOption("Global fragments directory", "global"),
+					huh.NewOption("Local .ctx/fragments directory", "local"),
+				).
+				Value(&location),
+		),
+	)
+
+	if err := form.Run(); err != nil {
+		return err
+	}
+
+	opts.Tags = splitTags(tagsInput)
+	opts.Local = location == "local"
+	opts.Priority = nil
+
+	if priorityInput != "" {
+		priority, err := strconv.Atoi(priorityInput)
+		if err != nil {
+			return fmt.Errorf("invalid priority: %w", err)
+		}
+
+		opts.Priority = &priority
+	}
+
+	return nil
+}
+
+// writeNewFragment writes the scaffolded fragment to dir and returns its path.
+// Existing files are only overwritten when opts.Force is set.
+func writeNewFragment(dir string, opts *NewFragmentOptions) (string, error) {
+	if err := validateFragmentName(opts.Name); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, fragmentFileName(opts.Name))
+
+	if _, err := os.Stat(path); err == nil && !opts.Force {
+		return "", fmt.Errorf("fragment already exists: %s (use --force to overwrite)", path)
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create fragments directory: %w", err)
+	}
+
+	if err := os.WriteFile(path, []byte(renderNewFragment(opts)), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write fragment: %w", err)
+	}
+
+	return path, nil
+}
+
+// renderNewFragment returns the content of a new fragment including its frontmatter.
+func renderNewFragment(opts *NewFragmentOptions) string {
+	var result strings.Builder
+
+	result.WriteString("---\n")
+	result.WriteString(fmt.Sprintf("ctx-tags: %s\n", strings.Join(opts.Tags, ", ")))
+
+	if opts.Description != "" {
+		result.WriteString(fmt.Sprintf("ctx-description: %s\n", opts.Description))
+	}
+
+	if opts.Priority != nil {
+		result.WriteString(fmt.Sprintf("ctx-priority: %d\n", *opts.Priority))
+	}
+
+	result.WriteString("---\n\n")
+
+	if opts.Content != "" {
+		result.WriteString(opts.Content)
+		return result.String()
+	}
+
+	result.WriteString(fmt.Sprintf("# %s\n", strings.TrimSuffix(strings.TrimSuffix(opts.Name, ".markdown"), ".md")))
+
+	return result.String()
+}
+
+// validateFragmentName checks that name can be used as a fragment file name.
+func validateFragmentName(name string) error {
+	if name == "" {
+		return fmt.Errorf("fragment name cannot be empty")
+	}
+
+	if strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("fragment name cannot contain path separators")
+	}
+
+	return nil
+}
+
+// fragmentFileName returns the markdown file name for a fragment name.
+func fragmentFileName(name string) string {
+	if strings.HasSuffix(name, ".md") || strings.HasSuffix(name, ".markdown") {
+		return name
+	}
+
+	return name + ".md"
+}
+
+// splitTags splits a comma-separated tag list, dropping empty entries.
+func splitTags(input string) []string {
+	var tags []string
+
+	for _, tag := range strings.Split(input, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
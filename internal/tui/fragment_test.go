@@ -0,0 +1,123 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+func TestRenderNewFragment(t *testing.T) {
+	priority := 5
+
+	tests := []struct {
+		name     string
+		opts     *NewFragmentOptions
+		expected string
+	}{
+		{
+			name: "tags only",
+			opts: &NewFragmentOptions{
+				Name: "typescript",
+				Tags: []string{"typescript", "frontend"},
+			},
+			expected: "---\nctx-tags: typescript, frontend\n---\n\n# typescript\n",
+		},
+		{
+			name: "all fields",
+			opts: &NewFragmentOptions{
+				Name:        "rust.md",
+				Tags:        []string{"rust"},
+				Description: "Rust guidelines",
+				Priority:    &priority,
+			},
+			expected: "---\nctx-tags: rust\nctx-description: Rust guidelines\nctx-priority: 5\n---\n\n# rust\n",
+		},
+		{
+			name: "content instead of heading",
+			opts: &NewFragmentOptions{
+				Name:    "api",
+				Tags:    []string{"api"},
+				Content: "## API Usage\n",
+			},
+			expected: "---\nctx-tags: api\n---\n\n## API Usage\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := renderNewFragment(tt.opts)
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestWriteNewFragment(t *testing.T) {
+	tmpDir := t.TempDir()
+	dir := filepath.Join(tmpDir, "fragments")
+
+	opts := &NewFragmentOptions{
+		Name: "typescript",
+		Tags: []string{"typescript", "frontend"},
+	}
+
+	path, err := writeNewFragment(dir, opts)
+	if err != nil {
+		t.Fatalf("writeNewFragment failed: %v", err)
+	}
+
+	if path != filepath.Join(dir, "typescript.md") {
+		t.Errorf("Unexpected fragment path: %s", path)
+	}
+
+	// The written fragment must be parseable with the expected tags
+	fragment, err := parser.ParseFragment(path)
+	if err != nil {
+		t.Fatalf("ParseFragment failed: %v", err)
+	}
+
+	if strings.Join(fragment.Tags, ",") != "typescript,frontend" {
+		t.Errorf("Expected tags typescript,frontend, got %v", fragment.Tags)
+	}
+
+	// Writing again without force must fail and keep the original
+	if err := os.WriteFile(path, []byte("edited"), 0o600); err != nil {
+		t.Fatalf("Failed to edit fragment: %v", err)
+	}
+
+	if _, err := writeNewFragment(dir, opts); err == nil {
+		t.Error("Expected error when fragment already exists")
+	}
+
+	content, _ := os.ReadFile(path)
+	if string(content) != "edited" {
+		t.Error("Existing fragment should not be overwritten without force")
+	}
+
+	// With force the fragment is overwritten
+	opts.Force = true
+	if _, err := writeNewFragment(dir, opts); err != nil {
+		t.Fatalf("writeNewFragment with force failed: %v", err)
+	}
+
+	content, _ = os.ReadFile(path)
+	if string(content) == "edited" {
+		t.Error("Expected fragment to be overwritten with force")
+	}
+}
+
+func TestValidateFragmentName(t *testing.T) {
+	if err := validateFragmentName("valid-name"); err != nil {
+		t.Errorf("Unexpected error for valid name: %v", err)
+	}
+
+	for _, name := range []string{"", "nested/name", `back\slash`} {
+		if err := validateFragmentName(name); err == nil {
+			t.Errorf("Expected error for name %q", name)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+)
+
+// GitignoreFile is the name of the ignore file init adds the output files to.
+const GitignoreFile = ".gitignore"
+
+// AddToGitignore appends the entries a .gitignore at path does not list yet, creating the
+// file if needed, and returns the entries added. An entry is already listed when a line
+// matches it with or without a leading slash.
+func AddToGitignore(path string, entries []string) ([]string, error) {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	listed := make(map[string]bool)
+	for _, line := range strings.Split(string(existing), "\n") {
+		listed[strings.TrimPrefix(strings.TrimSpace(line), "/")] = true
+	}
+
+	var added []string
+
+	for _, entry := range entries {
+		if !listed[entry] {
+			listed[entry] = true
+
+			added = append(added, entry)
+		}
+	}
+
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	var content strings.Builder
+
+	if len(existing) > 0 && existing[len(existing)-1] != '\n' {
+		content.WriteString("\n")
+	}
+
+	for _, entry := range added {
+		content.WriteString(entry + "\n")
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o644) //nolint:gosec // .gitignore is meant to be world-readable
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	if _, err := file.WriteString(content.String()); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return added, nil
+}
+
+// gitignoreEntries returns the relative output files of the configured output formats,
+// placed under the configured output directory, in sorted order. Absolute paths are left out.
+func gitignoreEntries(cfg *config.Config) []string {
+	var entries []string
+
+	for _, format := range cfg.OutputFormats {
+		filename := format.Filename
+		if filename == "" || filepath.IsAbs(filename) {
+			continue
+		}
+
+		if cfg.OutputDir != "" {
+			if filepath.IsAbs(cfg.OutputDir) {
+				continue
+			}
+
+			filename = filepath.Join(cfg.OutputDir, filename)
+		}
+
+		entries = append(entries, filepath.ToSlash(filepath.Clean(filename)))
+	}
+
+	slices.Sort(entries)
+
+	return slices.Compact(entries)
+}
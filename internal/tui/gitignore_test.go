@@ -0,0 +1,116 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+)
+
+func TestAddToGitignore(t *testing.T) {
+	tests := []struct {
+		name          string
+		existing      string
+		entries       []string
+		expectedAdded []string
+		expected      string
+	}{
+		{
+			name:          "creates file",
+			entries:       []string{"AGENTS.md", "GEMINI.md"},
+			expectedAdded: []string{"AGENTS.md", "GEMINI.md"},
+			expected:      "AGENTS.md\nGEMINI.md\n",
+		},
+		{
+			name:          "appends to existing file without trailing newline",
+			existing:      "node_modules/\n*.log",
+			entries:       []string{"AGENTS.md"},
+			expectedAdded: []string{"AGENTS.md"},
+			expected:      "node_modules/\n*.log\nAGENTS.md\n",
+		},
+		{
+			name:          "skips listed entries",
+			existing:      "AGENTS.md\n/GEMINI.md\n",
+			entries:       []string{"AGENTS.md", "GEMINI.md", "docs/CLAUDE.md", "docs/CLAUDE.md"},
+			expectedAdded: []string{"docs/CLAUDE.md"},
+			expected:      "AGENTS.md\n/GEMINI.md\ndocs/CLAUDE.md\n",
+		},
+		{
+			name:     "all entries listed",
+			existing: "  AGENTS.md  \n",
+			entries:  []string{"AGENTS.md"},
+			expected: "  AGENTS.md  \n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), GitignoreFile)
+
+			// An empty existing content means there is no .gitignore yet
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0o600); err != nil {
+					t.Fatalf("Failed to create .gitignore: %v", err)
+				}
+			}
+
+			added, err := AddToGitignore(path, tt.entries)
+			if err != nil {
+				t.Fatalf("AddToGitignore failed: %v", err)
+			}
+
+			if !reflect.DeepEqual(added, tt.expectedAdded) {
+				t.Errorf("Expected added entries %v, got %v", tt.expectedAdded, added)
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read .gitignore: %v", err)
+			}
+
+			if string(content) != tt.expected {
+				t.Errorf("Expected .gitignore %q, got %q", tt.expected, content)
+			}
+		})
+	}
+}
+
+func TestAddToGitignoreReadOnly(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("file permissions are not enforced for root")
+	}
+
+	path := filepath.Join(t.TempDir(), GitignoreFile)
+	if err := os.WriteFile(path, []byte("*.log\n"), 0o400); err != nil {
+		t.Fatalf("Failed to create .gitignore: %v", err)
+	}
+
+	if _, err := AddToGitignore(path, []string{"AGENTS.md"}); err == nil {
+		t.Error("Expected an error for a read-only .gitignore")
+	}
+}
+
+func TestGitignoreEntries(t *testing.T) {
+	cfg := &config.Config{
+		OutputFormats: map[string]config.OutputFormatConfig{
+			"opencode": {Filename: "AGENTS.md"},
+			"claude":   {Filename: "./docs/CLAUDE.md"},
+			"absolute": {Filename: "/etc/ctx/ABSOLUTE.md"},
+			"agents":   {Filename: "AGENTS.md"},
+		},
+	}
+
+	expected := []string{"AGENTS.md", "docs/CLAUDE.md"}
+	if entries := gitignoreEntries(cfg); !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Expected entries %v, got %v", expected, entries)
+	}
+
+	cfg.OutputDir = "out"
+
+	expected = []string{"out/AGENTS.md", "out/docs/CLAUDE.md"}
+	if entries := gitignoreEntries(cfg); !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Expected entries under the output directory %v, got %v", expected, entries)
+	}
+}
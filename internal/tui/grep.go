@@ -0,0 +1,97 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/Lewenhaupt/ctx/internal/parser"
+	"github.com/mattn/go-isatty"
+)
+
+const (
+	ansiRed     = "\033[31m"
+	ansiGreen   = "\033[32m"
+	ansiMagenta = "\033[35m"
+)
+
+// GrepOptions represents the options for the fragment grep command.
+type GrepOptions struct {
+	ConfigFile       string
+	Pattern          string
+	Tags             []string
+	FilesWithMatches bool
+	Count            bool
+}
+
+// RunGrep matches a regular expression against the body of all global and local fragments
+// and prints the matching lines as path:line:content.
+func RunGrep(opts *GrepOptions) error {
+	pattern, err := regexp.Compile(opts.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", opts.Pattern, err)
+	}
+
+	cfg, fragments, err := loadConfigAndFragments(opts.ConfigFile, false)
+	if err != nil {
+		return err
+	}
+
+	if len(opts.Tags) > 0 {
+		terms, err := parser.ExpandAliases(opts.Tags, cfg.Aliases)
+		if err != nil {
+			return fmt.Errorf("failed to expand tag aliases: %w", err)
+		}
+
+		fragments = parser.FilterFragmentsByTagExpression(fragments, terms)
+	}
+
+	results, err := searchFragments(fragments, pattern, false)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(formatGrepResults(results, pattern, opts, isatty.IsTerminal(os.Stdout.Fd())))
+
+	return nil
+}
+
+// formatGrepResults renders the results as path:line:content lines, as paths only with
+// FilesWithMatches, or as path:count lines with Count. With color the paths, line numbers
+// and matches are highlighted like grep does.
+func formatGrepResults(results []SearchResult, pattern *regexp.Regexp, opts *GrepOptions, color bool) string {
+	var result strings.Builder
+
+	paint := func(code, text string) string {
+		if !color {
+			return text
+		}
+
+		return code + text + ansiReset
+	}
+
+	for _, match := range results {
+		path := paint(ansiMagenta, match.Path)
+
+		switch {
+		case opts.FilesWithMatches:
+			result.WriteString(path + "\n")
+		case opts.Count:
+			result.WriteString(fmt.Sprintf("%s:%d\n", path, len(match.Matches)))
+		default:
+			for _, line := range match.Matches {
+				text := line.Text
+				if color {
+					text = pattern.ReplaceAllStringFunc(text, func(s string) string {
+						return ansiBold + ansiRed + s + ansiReset
+					})
+				}
+
+				result.WriteString(fmt.Sprintf("%s:%s:%s\n", path, paint(ansiGreen, fmt.Sprint(line.Line)), text))
+			}
+		}
+	}
+
+	return result.String()
+}
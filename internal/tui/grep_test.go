@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+func TestFormatGrepResults(t *testing.T) {
+	results := []SearchResult{
+		{Path: "/fragments/go.md", Matches: []parser.SearchMatch{{Line: 4, Text: "use gofmt"}, {Line: 9, Text: "run go vet"}}},
+		{Path: "/fragments/rust.md", Matches: []parser.SearchMatch{{Line: 5, Text: "cargo fmt"}}},
+	}
+	pattern := regexp.MustCompile(`fmt|vet`)
+
+	tests := []struct {
+		name     string
+		opts     GrepOptions
+		color    bool
+		expected string
+	}{
+		{
+			name:     "matching lines",
+			expected: "/fragments/go.md:4:use gofmt\n/fragments/go.md:9:run go vet\n/fragments/rust.md:5:cargo fmt\n",
+		},
+		{
+			name:     "files with matches",
+			opts:     GrepOptions{FilesWithMatches: true},
+			expected: "/fragments/go.md\n/fragments/rust.md\n",
+		},
+		{
+			name:     "count",
+			opts:     GrepOptions{Count: true},
+			expected: "/fragments/go.md:2\n/fragments/rust.md:1\n",
+		},
+		{
+			name:     "color",
+			color:    true,
+			expected: "\033[35m/fragments/go.md\033[0m:\033[32m4\033[0m:use go\033[1m\033[31mfmt\033[0m\n\033[35m/fragments/go.md\033[0m:\033[32m9\033[0m:run go \033[1m\033[31mvet\033[0m\n\033[35m/fragments/rust.md\033[0m:\033[32m5\033[0m:cargo \033[1m\033[31mfmt\033[0m\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := formatGrepResults(results, pattern, &tt.opts, tt.color); result != tt.expected {
+				t.Errorf("Expected:\n%q\ngot:\n%q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestRunGrepInvalidPattern(t *testing.T) {
+	err := RunGrep(&GrepOptions{Pattern: "("})
+	if err == nil {
+		t.Fatal("Expected error for invalid pattern, got nil")
+	}
+}
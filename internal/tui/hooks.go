@@ -0,0 +1,104 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+// runHook runs a configured build hook with sh -c in the current working directory. The
+// hook does not read stdin, which may carry the fragments or tags of the build, and its
+// output goes to stderr so it never mixes with output written to stdout. An empty command
+// does nothing; a non-zero exit fails.
+func runHook(name, command string) error {
+	if command == "" {
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Running %s hook: %s\n", name, command)
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdin = nil
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s hook failed: %w", name, err)
+	}
+
+	return nil
+}
+
+// writesOutputFiles reports whether the build writes output files, which is when the
+// build hooks run: stdout, dry run and check builds do not trigger them.
+func writesOutputFiles(opts *BuildOptions) bool {
+	return !opts.Stdout && !opts.DryRun && !opts.Check
+}
+
+// runPreBuildHook runs the pre-build hook before the fragments are scanned.
+func runPreBuildHook(opts *BuildOptions, cfg *config.Config) error {
+	if opts.SkipHooks || !writesOutputFiles(opts) {
+		return nil
+	}
+
+	return runHook("pre-build", cfg.PreBuildHook)
+}
+
+// runPostBuildHook runs the post-build hook once output files have been written.
+func runPostBuildHook(opts *BuildOptions, cfg *config.Config) error {
+	if opts.SkipHooks || !writesOutputFiles(opts) {
+		return nil
+	}
+
+	return runHook("post-build", cfg.PostBuildHook)
+}
+
+// preprocessFragments pipes the content of each fragment through the configured
+// preprocessors in order, unless opts.SkipPreprocessors is set. Unlike the build hooks they
+// also run for stdout, dry run, check and diff builds, so those render the same content as
+// a build that writes files. A preprocessor receives the content on stdin and writes the
+// transformed content to stdout; a non-zero exit fails with the fragment path and the command.
+func preprocessFragments(opts *BuildOptions, cfg *config.Config, fragments []parser.Fragment) ([]parser.Fragment, error) {
+	preprocessors := cfg.Preprocessors
+	if opts.SkipPreprocessors || len(preprocessors) == 0 {
+		return fragments, nil
+	}
+
+	processed := make([]parser.Fragment, len(fragments))
+
+	for i, fragment := range fragments {
+		for _, command := range preprocessors {
+			content, err := runPreprocessor(command, fragment.Content)
+			if err != nil {
+				return nil, fmt.Errorf("preprocessor %q failed for %s: %w", command, fragment.Path, err)
+			}
+
+			fragment.Content = content
+		}
+
+		processed[i] = fragment
+	}
+
+	return processed, nil
+}
+
+// runPreprocessor runs a preprocessor with sh -c and returns what it wrote to stdout.
+func runPreprocessor(command, content string) (string, error) {
+	var out bytes.Buffer
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdin = strings.NewReader(content)
+	cmd.Stdout = &out
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	return out.String(), nil
+}
@@ -0,0 +1,73 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+// VerifyIncludesOptions represents the options for the fragment verify-includes command.
+type VerifyIncludesOptions struct {
+	ConfigFile string
+}
+
+// RunVerifyIncludes checks that the ctx-include references of every global and local
+// fragment resolve to existing files and prints the ones that do not, with similar
+// filenames as hints. It reports whether all references resolve.
+func RunVerifyIncludes(opts *VerifyIncludesOptions) (bool, error) {
+	cfg, err := config.LoadMergedConfig(opts.ConfigFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	dirs, err := config.GetFragmentsDirs(cfg)
+	if err != nil {
+		return false, fmt.Errorf("failed to get fragments directory: %w", err)
+	}
+
+	localDir, err := parser.LocalFragmentsDir()
+	if err != nil {
+		return false, err
+	}
+
+	dirs = append(dirs, localDir)
+
+	var paths []string
+
+	for _, dir := range dirs {
+		dirPaths, err := parser.FindFragmentFiles(dir)
+		if err != nil {
+			return false, fmt.Errorf("failed to scan %s: %w", dir, err)
+		}
+
+		paths = append(paths, dirPaths...)
+	}
+
+	errs := parser.VerifyIncludes(includeReferences(paths), dirs)
+	for _, err := range errs {
+		fmt.Println(err.Error())
+	}
+
+	fmt.Printf("%d fragment(s) checked, %d unresolved include(s)\n", len(paths), len(errs))
+
+	return len(errs) == 0, nil
+}
+
+// includeReferences reads the ctx-include names of the fragment files into fragments for
+// parser.VerifyIncludes. Files whose frontmatter cannot be read are left out; validate
+// reports them.
+func includeReferences(paths []string) []parser.Fragment {
+	var fragments []parser.Fragment
+
+	for _, path := range paths {
+		includes, err := parser.ReadIncludeNames(path)
+		if err != nil || len(includes) == 0 {
+			continue
+		}
+
+		fragments = append(fragments, parser.Fragment{Path: path, Includes: includes})
+	}
+
+	return fragments
+}
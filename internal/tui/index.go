@@ -0,0 +1,169 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+// DefaultIndexPath is where fragment index and build --update-index write the index,
+// relative to the current directory.
+var DefaultIndexPath = filepath.Join(".ctx", "index.json")
+
+// FragmentIndex is the catalogue of all fragments written by fragment index, meant for
+// tools such as editors and web UIs. The format is stable: fields are only ever added,
+// never renamed or removed, within a major version of ctx.
+type FragmentIndex struct {
+	// GeneratedAt is the RFC 3339 time the index was written.
+	GeneratedAt string `json:"generatedAt"`
+	// CtxVersion is the version of the ctx binary that wrote the index.
+	CtxVersion string `json:"ctxVersion"`
+	// Fragments lists the global fragments, then the local ones, each sorted by path.
+	Fragments []IndexEntry `json:"fragments"`
+}
+
+// IndexEntry describes one fragment in the FragmentIndex.
+type IndexEntry struct {
+	// Name is the path relative to the fragments directory without extension, as
+	// accepted by fragment show.
+	Name string `json:"name"`
+	// Path is the path of the fragment file.
+	Path string `json:"path"`
+	// Source is FragmentSourceGlobal or FragmentSourceLocal.
+	Source string `json:"source"`
+	// Tags are the ctx-tags of the fragment, including namespace tags with namespaceFromDir.
+	Tags        []string `json:"tags"`
+	Description string   `json:"description"`
+	Priority    int      `json:"priority"`
+	// Checksum is the hex SHA-256 of the fragment content.
+	Checksum string `json:"checksum"`
+	// ModTime is the RFC 3339 modification time of the fragment or the latest of its includes.
+	ModTime string `json:"modTime"`
+}
+
+// IndexOptions represents the options for the fragment index command.
+type IndexOptions struct {
+	ConfigFile string
+	// Output is the path the index is written to; empty means DefaultIndexPath.
+	Output string
+	// Version is recorded as the ctxVersion of the index.
+	Version string
+}
+
+// RunFragmentIndex scans all global and local fragments and writes their index.
+func RunFragmentIndex(opts *IndexOptions) error {
+	cfg, err := config.LoadMergedConfig(opts.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	output := opts.Output
+	if output == "" {
+		output = DefaultIndexPath
+	}
+
+	index, err := writeFragmentIndex(cfg, output, opts.Version)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Indexed %d fragments in %s\n", len(index.Fragments), output)
+
+	return nil
+}
+
+// writeFragmentIndex builds the index of the configured fragments and writes it to path.
+func writeFragmentIndex(cfg *config.Config, path, version string) (*FragmentIndex, error) {
+	index, err := buildFragmentIndex(cfg, version, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal fragment index: %w", err)
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write fragment index %s: %w", path, err)
+	}
+
+	return index, nil
+}
+
+// buildFragmentIndex scans the global fragments directories and the local one.
+func buildFragmentIndex(cfg *config.Config, version string, generatedAt time.Time) (*FragmentIndex, error) {
+	dirs, err := config.GetFragmentsDirs(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fragments directory: %w", err)
+	}
+
+	localDir, err := parser.LocalFragmentsDir()
+	if err != nil {
+		return nil, err
+	}
+
+	if version == "" {
+		version = DevVersion
+	}
+
+	index := &FragmentIndex{
+		GeneratedAt: generatedAt.Format(time.RFC3339),
+		CtxVersion:  version,
+		Fragments:   []IndexEntry{},
+	}
+
+	for i, dir := range append(dirs, localDir) {
+		source := FragmentSourceGlobal
+		if i == len(dirs) {
+			source = FragmentSourceLocal
+		}
+
+		fragments, err := parser.ScanFragmentsWithOptions(dir, scanOptions(cfg))
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", dir, err)
+		}
+
+		for _, fragment := range fragments {
+			index.Fragments = append(index.Fragments, newIndexEntry(dir, source, fragment))
+		}
+	}
+
+	return index, nil
+}
+
+// newIndexEntry describes a fragment found in dir.
+func newIndexEntry(dir, source string, fragment parser.Fragment) IndexEntry {
+	name := filepath.Base(fragment.Path)
+	if rel, err := filepath.Rel(dir, fragment.Path); err == nil {
+		name = filepath.ToSlash(rel)
+	}
+
+	tags := fragment.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return IndexEntry{
+		Name:        strings.TrimSuffix(name, filepath.Ext(name)),
+		Path:        fragment.Path,
+		Source:      source,
+		Tags:        tags,
+		Description: fragment.Description,
+		Priority:    fragment.Priority,
+		Checksum:    fragment.Checksum,
+		ModTime:     fragment.ModTime.Format(time.RFC3339),
+	}
+}
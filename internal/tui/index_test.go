@@ -0,0 +1,152 @@
+package tui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+)
+
+func writeIndexFixtures(t *testing.T) (globalDir, projectDir string) {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	globalDir = filepath.Join(tmpDir, "global")
+	projectDir = filepath.Join(tmpDir, "project")
+
+	files := map[string]string{
+		filepath.Join(globalDir, "style.md"):                            "---\nctx-tags: style\nctx-description: Code style\nctx-priority: 2\n---\nGlobal body",
+		filepath.Join(globalDir, "react", "hooks.md"):                   "---\nctx-tags: hooks\n---\nHooks body",
+		filepath.Join(projectDir, ".ctx", "fragments", "local-only.md"): "Local body",
+	}
+
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create fragment: %v", err)
+		}
+	}
+
+	t.Chdir(projectDir)
+
+	return globalDir, projectDir
+}
+
+func TestBuildFragmentIndex(t *testing.T) {
+	globalDir, _ := writeIndexFixtures(t)
+	generatedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	index, err := buildFragmentIndex(&config.Config{FragmentsDir: globalDir}, "v1.2.3", generatedAt)
+	if err != nil {
+		t.Fatalf("buildFragmentIndex failed: %v", err)
+	}
+
+	if index.GeneratedAt != "2024-01-02T03:04:05Z" || index.CtxVersion != "v1.2.3" {
+		t.Errorf("Unexpected index header: generatedAt %q, ctxVersion %q", index.GeneratedAt, index.CtxVersion)
+	}
+
+	var names, sources []string
+
+	for _, entry := range index.Fragments {
+		names = append(names, entry.Name)
+		sources = append(sources, entry.Source)
+	}
+
+	if expected := []string{"react/hooks", "style", "local-only"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected names %v, got %v", expected, names)
+	}
+
+	if expected := []string{FragmentSourceGlobal, FragmentSourceGlobal, FragmentSourceLocal}; !reflect.DeepEqual(sources, expected) {
+		t.Errorf("Expected sources %v, got %v", expected, sources)
+	}
+
+	style := index.Fragments[1]
+	if style.Description != "Code style" || style.Priority != 2 || !reflect.DeepEqual(style.Tags, []string{"style"}) {
+		t.Errorf("Expected the frontmatter of style.md in its entry, got %+v", style)
+	}
+
+	if style.Checksum == "" || style.ModTime == "" {
+		t.Errorf("Expected a checksum and modification time, got %+v", style)
+	}
+
+	if index.Fragments[2].Tags == nil {
+		t.Error("Expected untagged fragments to have an empty tag list")
+	}
+}
+
+func TestRunFragmentIndex(t *testing.T) {
+	globalDir, projectDir := writeIndexFixtures(t)
+
+	configPath := filepath.Join(projectDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+globalDir+`"}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	for _, output := range []string{"", filepath.Join("out", "fragments.json")} {
+		if err := RunFragmentIndex(&IndexOptions{ConfigFile: configPath, Output: output}); err != nil {
+			t.Fatalf("RunFragmentIndex failed: %v", err)
+		}
+
+		path := output
+		if path == "" {
+			path = DefaultIndexPath
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read index: %v", err)
+		}
+
+		var index FragmentIndex
+		if err := json.Unmarshal(data, &index); err != nil {
+			t.Fatalf("Failed to parse index: %v", err)
+		}
+
+		if len(index.Fragments) != 3 || index.CtxVersion != DevVersion {
+			t.Errorf("Expected 3 fragments indexed by the dev version in %s, got %+v", path, index)
+		}
+	}
+}
+
+func TestRunBuildUpdateIndex(t *testing.T) {
+	globalDir, projectDir := writeIndexFixtures(t)
+
+	configPath := filepath.Join(projectDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+globalDir+`", "outputFormats": {}}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	opts := BuildOptions{
+		ConfigFile:     configPath,
+		Tags:           []string{"style"},
+		NonInteractive: true,
+		OutputFiles:    []string{"AGENTS.md"},
+		UpdateIndex:    true,
+		Version:        "v1.0.0",
+	}
+
+	if _, err := RunBuild(&opts); err != nil {
+		t.Fatalf("RunBuild failed: %v", err)
+	}
+
+	data, err := os.ReadFile(DefaultIndexPath)
+	if err != nil {
+		t.Fatalf("Expected the build to write the index: %v", err)
+	}
+
+	var index FragmentIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		t.Fatalf("Failed to parse index: %v", err)
+	}
+
+	if len(index.Fragments) != 3 || index.CtxVersion != "v1.0.0" {
+		t.Errorf("Expected all 3 fragments indexed by v1.0.0, got %+v", index)
+	}
+}
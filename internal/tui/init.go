@@ -3,8 +3,11 @@ package tui
 import (
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/Lewenhaupt/ctx/internal/config"
@@ -12,45 +15,53 @@ import (
 )
 
 // InitOptions represents the options for the init command.
+// The answer fields are only used in non-interactive mode or with a preset.
 type InitOptions struct {
-	ConfigFile string
+	ConfigFile     string
+	NonInteractive bool
+	Preset         string
+	Force          bool
+	FragmentsDir   string
+	OutputFormats  map[string]string
+	DefaultTags    []string
+	CreateSample   bool
+	// AddGitignore adds the output files to the .gitignore in the current directory
+	// without asking; in interactive mode the user is asked instead.
+	AddGitignore bool
+	// OutputHeader configures config.DefaultOutputHeader as the outputHeader.
+	OutputHeader bool
 }
 
 // InitAnswers holds the user's responses to the init questionnaire.
 type InitAnswers struct {
+	Preset           string
 	AddOutputFormats bool
 	CustomFormats    map[string]string
 	FragmentsDir     string
+	DefaultTags      []string
 	CreateSample     bool
+	OutputHeader     bool
 }
 
-// RunInit executes the init command with interactive questionnaire.
+// RunInit executes the init command with interactive questionnaire, or with the answers
+// from the options in non-interactive mode.
 func RunInit(opts *InitOptions) error {
-	// Check if config already exists
-	configPath := opts.ConfigFile
-	if configPath == "" {
-		configDir, err := config.GetConfigDir()
-		if err != nil {
-			return fmt.Errorf("failed to get config directory: %w", err)
+	if opts.Preset != "" {
+		if _, err := config.Preset(opts.Preset); err != nil {
+			return err
 		}
+	}
 
-		configPath = filepath.Join(configDir, "config.json")
+	// Check if config already exists
+	configPath, err := config.ResolveConfigPath(opts.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to get config directory: %w", err)
 	}
 
 	if _, err := os.Stat(configPath); err == nil {
-		var overwrite bool
-
-		form := huh.NewForm(
-			huh.NewGroup(
-				huh.NewConfirm().
-					Title("Configuration file already exists").
-					Description(fmt.Sprintf("A configuration file already exists at %s. Do you want to overwrite it? (A backup will be created)", configPath)).
-					Value(&overwrite),
-			),
-		)
-
-		if err := form.Run(); err != nil {
-			return fmt.Errorf("failed to get overwrite confirmation: %w", err)
+		overwrite, err := confirmConfigOverwrite(opts, configPath)
+		if err != nil {
+			return err
 		}
 
 		if !overwrite {
@@ -64,10 +75,9 @@ func RunInit(opts *InitOptions) error {
 		}
 	}
 
-	// Run interactive questionnaire
-	answers, err := runQuestionnaire()
+	answers, err := initAnswers(opts)
 	if err != nil {
-		return fmt.Errorf("questionnaire failed: %w", err)
+		return err
 	}
 
 	// Generate configuration
@@ -83,7 +93,22 @@ func RunInit(opts *InitOptions) error {
 
 	fmt.Printf("Configuration saved to: %s\n", configPath)
 
-	// Create fragments directory
+	if err := createFragmentsDir(cfg, answers.CreateSample); err != nil {
+		return err
+	}
+
+	if err := offerGitignoreEntries(opts, cfg); err != nil {
+		return err
+	}
+
+	fmt.Println("\nSetup complete! You can now run 'ctx build' to start using the tool.")
+
+	return nil
+}
+
+// createFragmentsDir creates the configured fragments directory and, if requested, the
+// sample fragment in it.
+func createFragmentsDir(cfg *config.Config, createSample bool) error {
 	fragmentsDir, err := config.GetFragmentsDir(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to get fragments directory: %w", err)
@@ -95,18 +120,119 @@ func RunInit(opts *InitOptions) error {
 
 	fmt.Printf("Fragments directory created: %s\n", fragmentsDir)
 
-	// Create sample fragment if requested
-	if answers.CreateSample {
+	if createSample {
 		if err := createSampleFragment(fragmentsDir); err != nil {
 			return fmt.Errorf("failed to create sample fragment: %w", err)
 		}
 	}
 
-	fmt.Println("\nSetup complete! You can now run 'ctx build' to start using the tool.")
+	return nil
+}
+
+// offerGitignoreEntries adds the configured output files to the .gitignore in the current
+// directory when the user agrees, or with AddGitignore in non-interactive mode and when a
+// preset is used. A .gitignore that cannot be written only prints a warning, as the config
+// has already been saved.
+func offerGitignoreEntries(opts *InitOptions, cfg *config.Config) error {
+	entries := gitignoreEntries(cfg)
+	if len(entries) == 0 {
+		return nil
+	}
+
+	add := opts.AddGitignore
+
+	if !opts.NonInteractive && opts.Preset == "" {
+		err := huh.NewForm(
+			huh.NewGroup(
+				huh.NewConfirm().
+					Title("Add output files to .gitignore?").
+					Description("Adds " + strings.Join(entries, ", ") + " to the .gitignore in the current directory").
+					Value(&add),
+			),
+		).Run()
+		if err != nil {
+			return fmt.Errorf("gitignore confirmation failed: %w", err)
+		}
+	}
+
+	if !add {
+		return nil
+	}
+
+	added, err := AddToGitignore(GitignoreFile, entries)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not update %s: %v\nAdd these entries manually: %s\n", GitignoreFile, err, strings.Join(entries, ", "))
+		return nil
+	}
+
+	if len(added) == 0 {
+		fmt.Printf("%s already lists the output files\n", GitignoreFile)
+		return nil
+	}
+
+	fmt.Printf("Added to %s: %s\n", GitignoreFile, strings.Join(added, ", "))
 
 	return nil
 }
 
+// confirmConfigOverwrite asks whether an existing config file may be overwritten.
+// In non-interactive mode it is only overwritten with Force.
+func confirmConfigOverwrite(opts *InitOptions, configPath string) (bool, error) {
+	if opts.NonInteractive {
+		if !opts.Force {
+			return false, fmt.Errorf("configuration file already exists at %s; use --force to overwrite it (a backup will be created)", configPath)
+		}
+
+		return true, nil
+	}
+
+	var overwrite bool
+
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title("Configuration file already exists").
+				Description(fmt.Sprintf("A configuration file already exists at %s. Do you want to overwrite it? (A backup will be created)", configPath)).
+				Value(&overwrite),
+		),
+	)
+
+	if err := form.Run(); err != nil {
+		return false, fmt.Errorf("failed to get overwrite confirmation: %w", err)
+	}
+
+	return overwrite, nil
+}
+
+// initAnswers runs the interactive questionnaire, or takes the answers from the options
+// in non-interactive mode and when a preset is used.
+func initAnswers(opts *InitOptions) (*InitAnswers, error) {
+	if !opts.NonInteractive && opts.Preset == "" {
+		answers, err := runQuestionnaire()
+		if err != nil {
+			return nil, fmt.Errorf("questionnaire failed: %w", err)
+		}
+
+		return answers, nil
+	}
+
+	for name, filename := range opts.OutputFormats {
+		if name == "" || filename == "" {
+			return nil, fmt.Errorf("invalid output format %q=%q: expected format=file", name, filename)
+		}
+	}
+
+	return &InitAnswers{
+		Preset:           opts.Preset,
+		AddOutputFormats: len(opts.OutputFormats) > 0,
+		CustomFormats:    opts.OutputFormats,
+		FragmentsDir:     opts.FragmentsDir,
+		DefaultTags:      opts.DefaultTags,
+		CreateSample:     opts.CreateSample || opts.Preset == config.PresetFull,
+		OutputHeader:     opts.OutputHeader,
+	}, nil
+}
+
 // runQuestionnaire presents the interactive questionnaire to the user.
 func runQuestionnaire() (*InitAnswers, error) {
 	answers := &InitAnswers{}
@@ -115,8 +241,8 @@ func runQuestionnaire() (*InitAnswers, error) {
 	defaultCfg := config.DefaultConfig()
 
 	var outputFormatsDesc string
-	for format, filename := range defaultCfg.OutputFormats {
-		outputFormatsDesc += fmt.Sprintf("- %s: %s\n", format, filename)
+	for format, formatConfig := range defaultCfg.OutputFormats {
+		outputFormatsDesc += fmt.Sprintf("- %s: %s\n", format, formatConfig.Filename)
 	}
 
 	// First, ask about output formats
@@ -176,6 +302,12 @@ func runQuestionnaire() (*InitAnswers, error) {
 				Description("This will create a hello-world example fragment").
 				Value(&answers.CreateSample),
 		),
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title("Add a header warning against manual edits to the output files?").
+				Description("Writes \""+config.DefaultOutputHeader+"\" at the top of every output file").
+				Value(&answers.OutputHeader),
+		),
 	)
 	if err := remainingForm.Run(); err != nil {
 		return nil, err
@@ -184,10 +316,20 @@ func runQuestionnaire() (*InitAnswers, error) {
 	return answers, nil
 }
 
-// generateConfig creates a configuration based on user answers.
+// generateConfig creates a configuration based on user answers, starting from the
+// default configuration or the chosen preset.
 func generateConfig(answers *InitAnswers) (*config.Config, error) {
 	cfg := config.DefaultConfig()
 
+	if answers.Preset != "" {
+		preset, err := config.Preset(answers.Preset)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg = preset
+	}
+
 	// Set fragments directory if provided
 	if answers.FragmentsDir != "" {
 		// Convert relative path to absolute
@@ -199,17 +341,56 @@ func generateConfig(answers *InitAnswers) (*config.Config, error) {
 		cfg.FragmentsDir = absPath
 	}
 
+	if len(answers.DefaultTags) > 0 {
+		cfg.DefaultTags = answers.DefaultTags
+	}
+
+	if answers.OutputHeader {
+		cfg.OutputHeader = config.DefaultOutputHeader
+	}
+
 	// Handle additional output formats if user requested them
 	if answers.AddOutputFormats && answers.CustomFormats != nil {
 		// Add custom formats to the config
 		for name, filename := range answers.CustomFormats {
-			cfg.OutputFormats[name] = filename
+			cfg.OutputFormats[name] = config.OutputFormatConfig{Filename: filename}
 		}
 	}
 
 	return cfg, nil
 }
 
+// RunListPresets prints the built-in init presets and their output formats.
+func RunListPresets() error {
+	fmt.Print(formatPresets(config.Presets()))
+	return nil
+}
+
+// formatPresets lists each preset with its output formats, one preset per line.
+func formatPresets(presets map[string]*config.Config) string {
+	var result strings.Builder
+
+	for _, name := range slices.Sorted(maps.Keys(presets)) {
+		formats := make([]string, 0, len(presets[name].OutputFormats))
+		for _, format := range slices.Sorted(maps.Keys(presets[name].OutputFormats)) {
+			formats = append(formats, fmt.Sprintf("%s=%s", format, presets[name].OutputFormats[format].Filename))
+		}
+
+		description := strings.Join(formats, ", ")
+		if description == "" {
+			description = "no output formats (use --stdout)"
+		}
+
+		if name == config.PresetFull {
+			description += " (with sample fragment)"
+		}
+
+		result.WriteString(fmt.Sprintf("%s: %s\n", name, description))
+	}
+
+	return result.String()
+}
+
 // createSampleFragment creates a hello-world sample fragment.
 func createSampleFragment(fragmentsDir string) error {
 	sampleContent := `---
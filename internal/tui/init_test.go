@@ -26,9 +26,11 @@ func TestGenerateConfig(t *testing.T) {
 			},
 			expected: &config.Config{
 				DefaultTags: []string{},
-				OutputFormats: map[string]string{
-					"opencode": "AGENTS.md",
-					"gemini":   "GEMINI.md",
+				OutputFormats: map[string]config.OutputFormatConfig{
+					"opencode":       {Filename: "AGENTS.md"},
+					"gemini":         {Filename: "GEMINI.md"},
+					"yaml-multi-doc": {Filename: "context.yaml"},
+					"json":           {Filename: "ctx-output.json"},
 				},
 				FragmentsDir:   "",
 				CustomSettings: make(map[string]interface{}),
@@ -46,9 +48,11 @@ func TestGenerateConfig(t *testing.T) {
 				absPath, _ := filepath.Abs("./custom-fragments")
 				return &config.Config{
 					DefaultTags: []string{},
-					OutputFormats: map[string]string{
-						"opencode": "AGENTS.md",
-						"gemini":   "GEMINI.md",
+					OutputFormats: map[string]config.OutputFormatConfig{
+						"opencode":       {Filename: "AGENTS.md"},
+						"gemini":         {Filename: "GEMINI.md"},
+						"yaml-multi-doc": {Filename: "context.yaml"},
+						"json":           {Filename: "ctx-output.json"},
 					},
 					FragmentsDir:   absPath,
 					CustomSettings: make(map[string]interface{}),
@@ -68,11 +72,13 @@ func TestGenerateConfig(t *testing.T) {
 			},
 			expected: &config.Config{
 				DefaultTags: []string{},
-				OutputFormats: map[string]string{
-					"opencode": "AGENTS.md",
-					"gemini":   "GEMINI.md",
-					"claude":   "CLAUDE.md",
-					"custom":   "CUSTOM.txt",
+				OutputFormats: map[string]config.OutputFormatConfig{
+					"opencode":       {Filename: "AGENTS.md"},
+					"gemini":         {Filename: "GEMINI.md"},
+					"yaml-multi-doc": {Filename: "context.yaml"},
+					"json":           {Filename: "ctx-output.json"},
+					"claude":         {Filename: "CLAUDE.md"},
+					"custom":         {Filename: "CUSTOM.txt"},
 				},
 				FragmentsDir:   "",
 				CustomSettings: make(map[string]interface{}),
@@ -105,6 +111,17 @@ func TestGenerateConfig(t *testing.T) {
 	}
 }
 
+func TestGenerateConfigWithOutputHeader(t *testing.T) {
+	cfg, err := generateConfig(&InitAnswers{OutputHeader: true})
+	if err != nil {
+		t.Fatalf("generateConfig failed: %v", err)
+	}
+
+	if cfg.OutputHeader != config.DefaultOutputHeader {
+		t.Errorf("Expected output header %q, got %q", config.DefaultOutputHeader, cfg.OutputHeader)
+	}
+}
+
 func TestCreateSampleFragment(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -257,3 +274,57 @@ func contains(s, substr string) bool {
 			contains(s[1:], substr) ||
 			(s != "" && s[:len(substr)] == substr))
 }
+
+func TestGenerateConfigWithPreset(t *testing.T) {
+	answers := &InitAnswers{
+		Preset:           "openai-codex",
+		AddOutputFormats: true,
+		CustomFormats:    map[string]string{"claude": "CLAUDE.md"},
+		DefaultTags:      []string{"go"},
+	}
+
+	cfg, err := generateConfig(answers)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedFormats := map[string]config.OutputFormatConfig{
+		"codex":  {Filename: "AGENTS.md"},
+		"claude": {Filename: "CLAUDE.md"},
+	}
+	if !reflect.DeepEqual(cfg.OutputFormats, expectedFormats) {
+		t.Errorf("Expected output formats %v, got %v", expectedFormats, cfg.OutputFormats)
+	}
+
+	if !reflect.DeepEqual(cfg.DefaultTags, []string{"go"}) {
+		t.Errorf("Expected default tags [go], got %v", cfg.DefaultTags)
+	}
+
+	if _, err := generateConfig(&InitAnswers{Preset: "unknown"}); err == nil {
+		t.Error("Expected error for unknown preset")
+	}
+}
+
+func TestFormatPresets(t *testing.T) {
+	expected := "full: gemini=GEMINI.md, json=ctx-output.json, opencode=AGENTS.md, yaml-multi-doc=context.yaml (with sample fragment)\n" +
+		"gemini: gemini=GEMINI.md, opencode=AGENTS.md\n" +
+		"minimal: no output formats (use --stdout)\n" +
+		"openai-codex: codex=AGENTS.md\n"
+
+	if result := formatPresets(config.Presets()); result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestRunInitUnknownPreset(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+
+	err := RunInit(&InitOptions{ConfigFile: configPath, Preset: "unknown"})
+	if err == nil || !strings.Contains(err.Error(), "valid presets") {
+		t.Errorf("Expected unknown preset error listing valid presets, got %v", err)
+	}
+
+	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
+		t.Error("Expected no config to be written for an unknown preset")
+	}
+}
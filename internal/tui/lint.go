@@ -0,0 +1,46 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+// LintOptions represents the options for the fragment lint command.
+type LintOptions struct {
+	ConfigFile string
+}
+
+// RunLint checks every global and local fragment against the lintRules of the config and
+// prints the issues found. It reports whether no issues were found.
+func RunLint(opts *LintOptions) (bool, error) {
+	cfg, err := config.LoadMergedConfig(opts.ConfigFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	rules, err := parser.ParseLintConfig(cfg.LintRules)
+	if err != nil {
+		return false, fmt.Errorf("invalid lintRules in config: %w", err)
+	}
+
+	if rules == (parser.LintConfig{}) {
+		fmt.Println("No lint rules enabled. Configure them under lintRules in the config.")
+		return true, nil
+	}
+
+	fragments, _, err := scanConfiguredFragments(cfg, scanOptions(cfg), false)
+	if err != nil {
+		return false, err
+	}
+
+	issues := parser.LintFragments(fragments, rules)
+	for _, issue := range issues {
+		fmt.Printf("%s: %s: %s\n", issue.Path, issue.Rule, issue.Message)
+	}
+
+	fmt.Printf("%d fragment(s) checked, %d issue(s)\n", len(fragments), len(issues))
+
+	return len(issues) == 0, nil
+}
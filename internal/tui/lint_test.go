@@ -0,0 +1,56 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunLint(t *testing.T) {
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	fragmentPath := filepath.Join(fragmentsDir, "a.md")
+	if err := os.WriteFile(fragmentPath, []byte("---\nctx-tags: go\n---\nA"), 0o600); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+fragmentsDir+`", "lintRules": {"require-description": true}}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	clean, err := RunLint(&LintOptions{ConfigFile: configPath})
+	if err != nil {
+		t.Fatalf("RunLint failed: %v", err)
+	}
+
+	if clean {
+		t.Error("Expected a fragment without ctx-description to be reported")
+	}
+
+	if err := os.WriteFile(fragmentPath, []byte("---\nctx-tags: go\nctx-description: Go rules\n---\nA"), 0o600); err != nil {
+		t.Fatalf("Failed to update fragment: %v", err)
+	}
+
+	clean, err = RunLint(&LintOptions{ConfigFile: configPath})
+	if err != nil {
+		t.Fatalf("RunLint failed: %v", err)
+	}
+
+	if !clean {
+		t.Error("Expected no issues once the fragment has a ctx-description")
+	}
+
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+fragmentsDir+`", "lintRules": {"no-todos": true}}`), 0o600); err != nil {
+		t.Fatalf("Failed to update config: %v", err)
+	}
+
+	if _, err := RunLint(&LintOptions{ConfigFile: configPath}); err == nil {
+		t.Error("Expected an error for an unknown lint rule")
+	}
+}
@@ -0,0 +1,166 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+// ListOptions represents the options for the list command.
+type ListOptions struct {
+	ConfigFile string
+	Tree       bool
+	// Archived lists the archived fragments of every fragments directory instead.
+	Archived bool
+}
+
+// RunList prints the fragments of every global and local fragments directory,
+// either as relative paths or as a directory tree.
+func RunList(opts *ListOptions) error {
+	cfg, err := config.LoadMergedConfig(opts.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	dirs, err := config.GetFragmentsDirs(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to get fragments directory: %w", err)
+	}
+
+	localDir, err := parser.LocalFragmentsDir()
+	if err != nil {
+		return err
+	}
+
+	found := false
+
+	for _, dir := range append(dirs, localDir) {
+		if opts.Archived {
+			dir = parser.ArchiveDir(dir)
+		}
+
+		fragments, err := parser.ScanFragmentsWithOptions(dir, scanOptions(cfg))
+		if err != nil {
+			return fmt.Errorf("failed to scan %s: %w", dir, err)
+		}
+
+		if len(fragments) == 0 {
+			continue
+		}
+
+		found = true
+
+		if opts.Tree {
+			fmt.Print(formatFragmentTree(dir, fragments))
+		} else {
+			fmt.Print(formatFragmentList(dir, fragments))
+		}
+	}
+
+	switch {
+	case found:
+	case opts.Archived:
+		fmt.Println("No archived fragments found.")
+	default:
+		fmt.Println("No fragments found. Create one with 'ctx fragment new'.")
+	}
+
+	return nil
+}
+
+// formatFragmentList renders the fragments of dir as one relative path per line.
+func formatFragmentList(dir string, fragments []parser.Fragment) string {
+	var result strings.Builder
+
+	result.WriteString(dir + "\n")
+
+	for _, fragment := range fragments {
+		result.WriteString(fmt.Sprintf("  %s%s\n", relativeFragmentPath(dir, fragment), formatTagSuffix(fragment.Tags)))
+	}
+
+	return result.String()
+}
+
+// fragmentTreeNode is a directory or fragment file in the tree rendered by formatFragmentTree.
+type fragmentTreeNode struct {
+	children map[string]*fragmentTreeNode
+	fragment *parser.Fragment
+}
+
+// formatFragmentTree renders the fragments of dir as a directory tree.
+func formatFragmentTree(dir string, fragments []parser.Fragment) string {
+	root := &fragmentTreeNode{children: map[string]*fragmentTreeNode{}}
+
+	for i := range fragments {
+		node := root
+
+		for _, part := range strings.Split(relativeFragmentPath(dir, fragments[i]), "/") {
+			child, ok := node.children[part]
+			if !ok {
+				child = &fragmentTreeNode{children: map[string]*fragmentTreeNode{}}
+				node.children[part] = child
+			}
+
+			node = child
+		}
+
+		node.fragment = &fragments[i]
+	}
+
+	var result strings.Builder
+
+	result.WriteString(dir + "\n")
+	writeFragmentTree(&result, root, "")
+
+	return result.String()
+}
+
+// writeFragmentTree writes the children of node, each line prefixed with prefix.
+func writeFragmentTree(result *strings.Builder, node *fragmentTreeNode, prefix string) {
+	names := make([]string, 0, len(node.children))
+	for name := range node.children {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for i, name := range names {
+		child := node.children[name]
+		branch, indent := "├── ", "│   "
+
+		if i == len(names)-1 {
+			branch, indent = "└── ", "    "
+		}
+
+		if child.fragment != nil {
+			result.WriteString(prefix + branch + name + formatTagSuffix(child.fragment.Tags) + "\n")
+			continue
+		}
+
+		result.WriteString(prefix + branch + name + "/\n")
+		writeFragmentTree(result, child, prefix+indent)
+	}
+}
+
+// relativeFragmentPath returns the slash-separated path of fragment relative to dir.
+func relativeFragmentPath(dir string, fragment parser.Fragment) string {
+	rel, err := filepath.Rel(dir, fragment.Path)
+	if err != nil {
+		return fragment.Path
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+// formatTagSuffix renders tags as a bracketed suffix, or nothing for an untagged fragment.
+func formatTagSuffix(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+
+	return "  [" + strings.Join(tags, ", ") + "]"
+}
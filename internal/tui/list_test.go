@@ -0,0 +1,29 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+func TestFormatFragmentTree(t *testing.T) {
+	fragments := []parser.Fragment{
+		{Path: "/fragments/general.md", Tags: []string{"general"}},
+		{Path: "/fragments/react/hooks.md", Tags: []string{"hooks", "react"}},
+		{Path: "/fragments/react/state/reducer.md"},
+		{Path: "/fragments/rust.md", Tags: []string{"rust"}},
+	}
+
+	expected := `/fragments
+├── general.md  [general]
+├── react/
+│   ├── hooks.md  [hooks, react]
+│   └── state/
+│       └── reducer.md
+└── rust.md  [rust]
+`
+
+	if result := formatFragmentTree("/fragments", fragments); result != expected {
+		t.Errorf("Expected tree:\n%s\ngot:\n%s", expected, result)
+	}
+}
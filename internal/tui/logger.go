@@ -0,0 +1,76 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+// Logger receives the verbose build log, one message per call.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// writerLogger is a Logger writing each message as a line to w.
+type writerLogger struct {
+	w io.Writer
+}
+
+// NewLogger returns a Logger that writes each message as a line to w.
+func NewLogger(w io.Writer) Logger {
+	return writerLogger{w: w}
+}
+
+// Printf implements Logger.
+func (l writerLogger) Printf(format string, args ...interface{}) {
+	fmt.Fprintf(l.w, format+"\n", args...)
+}
+
+// logger returns the options' logger: Logger if set, otherwise one writing to stderr
+// when Verbose is set and one discarding everything when it is not.
+func (opts *BuildOptions) logger() Logger {
+	switch {
+	case opts.Logger != nil:
+		return opts.Logger
+	case opts.Verbose:
+		return NewLogger(os.Stderr)
+	default:
+		return NewLogger(io.Discard)
+	}
+}
+
+// logf writes a message to the options' logger.
+func (opts *BuildOptions) logf(format string, args ...interface{}) {
+	opts.logger().Printf(format, args...)
+}
+
+// logFragmentSelection logs the scanned fragments excluded by the tag filter and the
+// order the selected fragments are spliced in.
+func logFragmentSelection(opts *BuildOptions, scanned, selected []parser.Fragment) {
+	isSelected := make(map[string]bool, len(selected))
+	for _, fragment := range selected {
+		isSelected[fragment.Path] = true
+	}
+
+	for _, fragment := range scanned {
+		if !isSelected[fragment.Path] {
+			opts.logf("excluded by tag filter: %s", fragment.Path)
+		}
+	}
+
+	for i, fragment := range selected {
+		opts.logf("splice order %d: %s", i+1, fragment.Path)
+	}
+}
+
+// formatLogTags returns the tags of a fragment for the verbose log.
+func formatLogTags(tags []string) string {
+	if len(tags) == 0 {
+		return "none"
+	}
+
+	return strings.Join(tags, ", ")
+}
@@ -0,0 +1,71 @@
+package tui
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunBuildVerboseLog(t *testing.T) {
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	files := map[string]string{
+		"a.md": "---\nctx-tags: go\n---\nA",
+		"b.md": "---\nctx-tags: rust\n---\nB",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(fragmentsDir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create fragment: %v", err)
+		}
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+fragmentsDir+`", "outputFormats": {}}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	outputPath := filepath.Join(tmpDir, "AGENTS.md")
+	aPath := filepath.Join(fragmentsDir, "a.md")
+	bPath := filepath.Join(fragmentsDir, "b.md")
+
+	var log bytes.Buffer
+
+	opts := BuildOptions{
+		ConfigFile:     configPath,
+		Tags:           []string{"go"},
+		NonInteractive: true,
+		OutputFiles:    []string{outputPath},
+		Logger:         NewLogger(&log),
+	}
+
+	if _, err := RunBuild(&opts); err != nil {
+		t.Fatalf("RunBuild failed: %v", err)
+	}
+
+	expected := "scanned: " + aPath + " (tags: go)\n" +
+		"scanned: " + bPath + " (tags: rust)\n" +
+		"excluded by tag filter: " + bPath + "\n" +
+		"splice order 1: " + aPath + "\n" +
+		"writing: " + outputPath + "\n"
+	if log.String() != expected {
+		t.Errorf("Expected log:\n%s\ngot:\n%s", expected, log.String())
+	}
+}
+
+func TestBuildOptionsLogger(t *testing.T) {
+	if logger := (&BuildOptions{}).logger(); logger != NewLogger(io.Discard) {
+		t.Errorf("Expected the log to be discarded without Verbose, got %#v", logger)
+	}
+
+	if logger := (&BuildOptions{Verbose: true}).logger(); logger != NewLogger(os.Stderr) {
+		t.Errorf("Expected the log to be written to stderr with Verbose, got %#v", logger)
+	}
+}
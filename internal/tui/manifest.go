@@ -0,0 +1,184 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+// HashManifest records the fragments of a build and the options their output was rendered
+// with. It is written by build --hash-manifest and read back to skip unchanged builds.
+type HashManifest struct {
+	// Fragments maps the path of each fragment to its content checksum.
+	Fragments map[string]string `json:"fragments"`
+	// Render is the checksum of the fragment order and the options the output depends on,
+	// see renderChecksum.
+	Render string `json:"render"`
+}
+
+// newHashManifest returns the manifest of the planned build.
+func newHashManifest(opts *BuildOptions, plan *buildPlan) (*HashManifest, error) {
+	render, err := renderChecksum(opts, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &HashManifest{Fragments: make(map[string]string, len(plan.fragments)), Render: render}
+
+	for _, fragment := range plan.fragments {
+		manifest.Fragments[fragment.Path] = fragment.Checksum
+	}
+
+	return manifest, nil
+}
+
+// Matches reports whether the manifest lists exactly the fragments of other with the same
+// checksums and was rendered the same way.
+func (m *HashManifest) Matches(other *HashManifest) bool {
+	if m.Render != other.Render || len(m.Fragments) != len(other.Fragments) {
+		return false
+	}
+
+	for path, checksum := range other.Fragments {
+		if m.Fragments[path] != checksum {
+			return false
+		}
+	}
+
+	return true
+}
+
+// renderInputs are the inputs besides the fragment contents that the output of a build
+// depends on.
+type renderInputs struct {
+	Order              []string
+	Formats            []string
+	Files              []string
+	FormatConfigs      map[string]config.OutputFormatConfig
+	Templates          map[string]string
+	Separator          string
+	Header             string
+	HTML               bool
+	Encoding           string
+	Deduplicate        bool
+	IncludeFrontmatter bool
+	Frontmatter        []string
+	SourceComment      string
+	NoNormalize        bool
+	Preprocessors      []string
+	Permissions        []string
+	Zip                string
+}
+
+// renderChecksum returns the SHA-256 checksum of the fragment order and the options that
+// change the output of a build without changing a fragment: the output formats and their
+// files, templates (by content), separator, header, HTML, encoding and the like.
+func renderChecksum(opts *BuildOptions, plan *buildPlan) (string, error) {
+	inputs := renderInputs{
+		FormatConfigs:      map[string]config.OutputFormatConfig{},
+		Templates:          map[string]string{},
+		Formats:            plan.outputFormats,
+		Separator:          fragmentSeparator(opts, plan.cfg),
+		Header:             outputHeader(opts, plan.cfg),
+		HTML:               opts.OutputHTML,
+		Encoding:           opts.OutputEncoding,
+		Deduplicate:        opts.Deduplicate,
+		IncludeFrontmatter: opts.IncludeFrontmatter,
+		NoNormalize:        opts.NoNormalize,
+		Permissions:        []string{opts.OutputPermissions, plan.cfg.OutputPermissions},
+		Zip:                opts.Zip,
+	}
+
+	if opts.SourceComments {
+		inputs.SourceComment = opts.SourceCommentFormat + "\x00" + parser.DefaultSourceComment
+	}
+
+	if !opts.SkipPreprocessors {
+		inputs.Preprocessors = plan.cfg.Preprocessors
+	}
+
+	for _, fragment := range plan.fragments {
+		inputs.Order = append(inputs.Order, fragment.Path)
+
+		if opts.IncludeFrontmatter {
+			inputs.Frontmatter = append(inputs.Frontmatter, fragment.RawFrontmatter)
+		}
+	}
+
+	templates := []string{opts.OutputTemplate}
+
+	for i, format := range plan.outputFormats {
+		filename, err := resolveOutputFilename(format, i, plan.outputFiles, opts.OutputDir, plan.cfg)
+		if err != nil {
+			return "", err
+		}
+
+		inputs.Files = append(inputs.Files, filename)
+		formatConfig, _ := plan.cfg.OutputFormat(format)
+		inputs.FormatConfigs[format] = formatConfig
+		templates = append(templates, formatConfig.Template)
+	}
+
+	for _, template := range templates {
+		if template == "" {
+			continue
+		}
+
+		// A missing template fails the build itself, so it needs no checksum here
+		if content, err := os.ReadFile(template); err == nil {
+			inputs.Templates[template] = parser.ComputeContentHash(string(content))
+		}
+	}
+
+	data, err := json.Marshal(inputs)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal render inputs: %w", err)
+	}
+
+	return parser.ComputeContentHash(string(data)), nil
+}
+
+// ReadHashManifest reads a manifest written by WriteHashManifest. A missing file yields a nil manifest.
+func ReadHashManifest(path string) (*HashManifest, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hash manifest: %w", err)
+	}
+
+	var manifest HashManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse hash manifest %s: %w", path, err)
+	}
+
+	return &manifest, nil
+}
+
+// WriteHashManifest writes the manifest as indented JSON to path.
+func WriteHashManifest(path string, manifest *HashManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal hash manifest: %w", err)
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write hash manifest %s: %w", path, err)
+	}
+
+	return nil
+}
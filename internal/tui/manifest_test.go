@@ -0,0 +1,162 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunBuildHashManifest(t *testing.T) {
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+	fragmentPath := filepath.Join(fragmentsDir, "a.md")
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	if err := os.WriteFile(fragmentPath, []byte("---\nctx-tags: go\n---\nA"), 0o600); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+fragmentsDir+`", "outputFormats": {}}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	outputPath := filepath.Join(tmpDir, "AGENTS.md")
+	manifestPath := filepath.Join(tmpDir, "manifest.json")
+
+	build := func(html bool) *BuildResult {
+		opts := BuildOptions{
+			ConfigFile:     configPath,
+			Tags:           []string{"go"},
+			NonInteractive: true,
+			OutputFiles:    []string{outputPath},
+			HashManifest:   manifestPath,
+			OutputHTML:     html,
+		}
+
+		result, err := RunBuild(&opts)
+		if err != nil {
+			t.Fatalf("RunBuild failed: %v", err)
+		}
+
+		return result
+	}
+
+	if result := build(false); result.Skipped {
+		t.Fatal("Expected the first build not to be skipped")
+	}
+
+	manifest, err := ReadHashManifest(manifestPath)
+	if err != nil {
+		t.Fatalf("ReadHashManifest failed: %v", err)
+	}
+
+	if len(manifest.Fragments) != 1 || manifest.Fragments[fragmentPath] == "" || manifest.Render == "" {
+		t.Fatalf("Expected a checksum for %s and the render options, got %v", fragmentPath, manifest)
+	}
+
+	if result := build(false); !result.Skipped {
+		t.Error("Expected the build to be skipped when no fragment changed")
+	}
+
+	if result := build(true); result.Skipped {
+		t.Error("Expected the build not to be skipped when the render options changed")
+	}
+
+	if result := build(false); result.Skipped {
+		t.Error("Expected the build not to be skipped when switching the render options back")
+	}
+
+	if err := os.Remove(outputPath); err != nil {
+		t.Fatalf("Failed to remove output file: %v", err)
+	}
+
+	if result := build(false); result.Skipped {
+		t.Error("Expected the build not to be skipped when an output file is missing")
+	}
+
+	if err := os.WriteFile(fragmentPath, []byte("---\nctx-tags: go\n---\nChanged"), 0o600); err != nil {
+		t.Fatalf("Failed to update fragment: %v", err)
+	}
+
+	if result := build(false); result.Skipped {
+		t.Error("Expected the build not to be skipped after a fragment changed")
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil || string(content) != "Changed" {
+		t.Errorf("Expected the rebuilt output to contain the change, got %q, %v", content, err)
+	}
+}
+
+func TestRunBuildSince(t *testing.T) {
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+	fragmentPath := filepath.Join(fragmentsDir, "a.md")
+	since := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	if err := os.WriteFile(fragmentPath, []byte("---\nctx-tags: go\n---\nA"), 0o600); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+
+	if err := os.Chtimes(fragmentPath, since.Add(-time.Hour), since.Add(-time.Hour)); err != nil {
+		t.Fatalf("Failed to set modification time: %v", err)
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+fragmentsDir+`", "outputFormats": {}}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	outputPath := filepath.Join(tmpDir, "AGENTS.md")
+
+	build := func() *BuildResult {
+		opts := BuildOptions{
+			ConfigFile:     configPath,
+			Tags:           []string{"go"},
+			NonInteractive: true,
+			OutputFiles:    []string{outputPath},
+			Since:          since,
+		}
+
+		result, err := RunBuild(&opts)
+		if err != nil {
+			t.Fatalf("RunBuild failed: %v", err)
+		}
+
+		return result
+	}
+
+	if result := build(); result.Skipped {
+		t.Fatal("Expected the build not to be skipped when the output file is missing")
+	}
+
+	if result := build(); !result.Skipped {
+		t.Error("Expected the build to be skipped when no fragment changed since the given time")
+	}
+
+	if err := os.WriteFile(fragmentPath, []byte("---\nctx-tags: go\n---\nChanged"), 0o600); err != nil {
+		t.Fatalf("Failed to update fragment: %v", err)
+	}
+
+	if err := os.Chtimes(fragmentPath, since.Add(time.Hour), since.Add(time.Hour)); err != nil {
+		t.Fatalf("Failed to set modification time: %v", err)
+	}
+
+	if result := build(); result.Skipped {
+		t.Error("Expected the build not to be skipped after a fragment changed")
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil || string(content) != "Changed" {
+		t.Errorf("Expected the rebuilt output to contain the change, got %q, %v", content, err)
+	}
+}
@@ -0,0 +1,84 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+// MoveFragmentOptions represents the options for the fragment move command.
+type MoveFragmentOptions struct {
+	ConfigFile string
+	Name       string
+	// Target is the store the fragment is moved to, FragmentSourceGlobal or FragmentSourceLocal.
+	Target string
+	Force  bool
+}
+
+// RunMoveFragment moves the fragment named opts.Name from the local fragments directory to
+// the global one, or from the global fragments directories to the local one, keeping its
+// relative path. Global fragments are moved to the first global directory. When several
+// fragments in the source store share the name, the one activeFragment picks among them
+// is moved.
+func RunMoveFragment(opts *MoveFragmentOptions) error {
+	if opts.Target != FragmentSourceGlobal && opts.Target != FragmentSourceLocal {
+		return fmt.Errorf("invalid target %q (expected %s or %s)", opts.Target, FragmentSourceGlobal, FragmentSourceLocal)
+	}
+
+	cfg, err := config.LoadMergedConfig(opts.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	globalDirs, err := config.GetFragmentsDirs(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to get fragments directory: %w", err)
+	}
+
+	localDir, err := parser.LocalFragmentsDir()
+	if err != nil {
+		return err
+	}
+
+	targetDir, source := localDir, FragmentSourceGlobal
+	if opts.Target == FragmentSourceGlobal {
+		targetDir, source = globalDirs[0], FragmentSourceLocal
+	}
+
+	details, err := findFragmentsByName(cfg, opts.Name)
+	if err != nil {
+		return err
+	}
+
+	candidates := slices.DeleteFunc(details, func(fragment FragmentDetails) bool { return fragment.Source != source })
+	if len(candidates) == 0 {
+		return fmt.Errorf("%s fragment %q not found", source, opts.Name)
+	}
+
+	fragment := activeFragment(candidates)
+
+	rel, err := filepath.Rel(fragment.dir, fragment.Path)
+	if err != nil {
+		return err
+	}
+
+	srcPath := fragment.Path
+	dstPath := filepath.Join(targetDir, rel)
+
+	if _, err := os.Stat(dstPath); err == nil && !opts.Force {
+		return fmt.Errorf("fragment file already exists: %s (use --force to overwrite)", dstPath)
+	}
+
+	if err := parser.MoveFragmentFile(srcPath, dstPath, opts.Force); err != nil {
+		return fmt.Errorf("failed to move fragment: %w", err)
+	}
+
+	fmt.Printf("Moved %s to %s\n", srcPath, dstPath)
+	printOtherMatches(len(candidates), opts.Name)
+
+	return nil
+}
@@ -0,0 +1,378 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+	"github.com/Lewenhaupt/ctx/internal/parser"
+	"github.com/Lewenhaupt/ctx/internal/renderer"
+	"github.com/mattn/go-isatty"
+)
+
+// buildOutput is the content a build writes. Output formats with their own template in
+// the config get their own content; all other formats share the default content. The
+// header is written above the content of every output file, but not to stdout.
+type buildOutput struct {
+	content string
+	formats map[string]string
+	header  string
+	// structured holds the formats whose content serializes the fragments, see structuredFormat.
+	structured map[string]bool
+}
+
+// forFormat returns the content built for the output format, without the header.
+func (o *buildOutput) forFormat(format string) string {
+	if content, exists := o.formats[format]; exists {
+		return content
+	}
+
+	return o.content
+}
+
+// fileContent returns the content written to the output file of the format: the header
+// followed by the content built for the format. Structured formats get no header, which is
+// Markdown and would make the file invalid.
+func (o *buildOutput) fileContent(format string) string {
+	if o.structured[format] {
+		return o.forFormat(format)
+	}
+
+	return o.header + o.forFormat(format)
+}
+
+// renderBuildOutput combines the planned fragments into the output of the selected formats.
+// OutputTemplate wraps the output of every format; without it, formats configuring a
+// template are wrapped in their own. The result is rendered as HTML with OutputHTML.
+func renderBuildOutput(opts *BuildOptions, plan *buildPlan) (*buildOutput, error) {
+	spliced, err := insertStdin(opts, parser.SpliceFragmentsWithOptions(plan.fragments, spliceOptions(opts, plan.cfg)))
+	if err != nil {
+		return nil, err
+	}
+
+	vars := map[string]string{
+		"Tags":      strings.Join(plan.selectedTags, ","),
+		"Timestamp": latestModTime(plan.fragments),
+	}
+
+	content, err := finishOutput(opts, spliced, opts.OutputTemplate, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := encodeOutput(outputHeader(opts, plan.cfg), opts.OutputEncoding)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &buildOutput{content: content, formats: map[string]string{}, header: header, structured: map[string]bool{}}
+	if err := renderStructuredFormats(opts, plan, output); err != nil {
+		return nil, err
+	}
+
+	if opts.OutputTemplate != "" {
+		return output, nil
+	}
+
+	for _, format := range plan.outputFormats {
+		formatConfig, exists := plan.cfg.OutputFormat(format)
+		if !exists || formatConfig.Template == "" {
+			continue
+		}
+
+		content, err := finishOutput(opts, spliced, formatConfig.Template, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		output.formats[format] = content
+	}
+
+	return output, nil
+}
+
+// spliceOptions returns the options the fragments of a build are spliced with.
+func spliceOptions(opts *BuildOptions, cfg *config.Config) parser.SpliceOptions {
+	spliceOpts := parser.DefaultSpliceOptions()
+	spliceOpts.Deduplicate = opts.Deduplicate
+	spliceOpts.Separator = fragmentSeparator(opts, cfg)
+	spliceOpts.IncludeFrontmatter = opts.IncludeFrontmatter
+
+	if opts.SourceComments {
+		spliceOpts.SourceComment = opts.SourceCommentFormat
+		if spliceOpts.SourceComment == "" {
+			spliceOpts.SourceComment = parser.DefaultSourceComment
+		}
+	}
+
+	return spliceOpts
+}
+
+// writeBuildOutput renders the output of the build and writes it, or streams it to the
+// output files when streamsOutput allows it; the returned output is nil then.
+func writeBuildOutput(opts *BuildOptions, plan *buildPlan) (*buildOutput, []string, error) {
+	if streamsOutput(opts, plan) {
+		written, err := streamOutputFiles(opts, plan)
+		return nil, written, err
+	}
+
+	output, err := renderBuildOutput(opts, plan)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	written, err := handleOutput(opts, output, plan)
+
+	return output, written, err
+}
+
+// streamsOutput reports whether the build writes the plain spliced fragments to files
+// unchanged, so they can be streamed to the files without rendering the output in memory:
+// no template, HTML, header, encoding, stdin content or deduplication, no structured or
+// append formats, and nothing that compares or records the complete output.
+func streamsOutput(opts *BuildOptions, plan *buildPlan) bool {
+	if opts.Stdout || opts.Check || opts.DryRun || opts.Zip != "" || opts.Append || opts.SkipIfUnchanged ||
+		opts.BuildReport != "" || opts.OutputTemplate != "" || opts.OutputHTML || opts.Stdin || opts.Deduplicate ||
+		(opts.OutputEncoding != "" && opts.OutputEncoding != OutputEncodingUTF8) || outputHeader(opts, plan.cfg) != "" {
+		return false
+	}
+
+	for _, format := range plan.outputFormats {
+		formatConfig, _ := plan.cfg.OutputFormat(format)
+		if _, structured := structuredFormat(plan.cfg, format); structured || formatConfig.Template != "" || formatConfig.AppendMode {
+			return false
+		}
+	}
+
+	return true
+}
+
+// streamOutputFiles splices the fragments straight into each output file and returns the
+// paths of the files written.
+func streamOutputFiles(opts *BuildOptions, plan *buildPlan) ([]string, error) {
+	// No content is needed to select the targets without SkipIfUnchanged
+	targets, err := selectOutputTargets(opts, &buildOutput{}, plan.outputFormats, plan.outputFiles, plan.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	spliceOpts := spliceOptions(opts, plan.cfg)
+
+	for i := range targets {
+		targets[i].stream = func(w io.Writer) error {
+			return parser.SpliceFragmentsToWithOptions(w, plan.fragments, spliceOpts)
+		}
+	}
+
+	written, err := writeOutputTargets(opts, targets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write output files: %w", err)
+	}
+
+	return written, nil
+}
+
+// structuredFormats maps the built-in output formats that serialize the fragments instead
+// of splicing them to their serializer.
+var structuredFormats = map[string]func([]parser.Fragment) (string, error){
+	config.YAMLMultiDocFormat: parser.SpliceFragmentsYAML,
+	config.JSONFormat:         parser.SerializeFragmentsJSON,
+}
+
+// structuredFormat returns the serializer of a structured output format. A format the
+// config gives a template is a user-defined format that only shares the name of the
+// built-in one; it is rendered from its template like any other format.
+func structuredFormat(cfg *config.Config, format string) (func([]parser.Fragment) (string, error), bool) {
+	serialize, structured := structuredFormats[format]
+	if !structured {
+		return nil, false
+	}
+
+	if formatConfig, _ := cfg.OutputFormat(format); formatConfig.Template != "" {
+		return nil, false
+	}
+
+	return serialize, true
+}
+
+// renderStructuredFormats builds the output of the selected structured formats from the
+// planned fragments. Templates, stdin content and HTML rendering only apply to the spliced
+// Markdown.
+func renderStructuredFormats(opts *BuildOptions, plan *buildPlan, output *buildOutput) error {
+	for _, format := range plan.outputFormats {
+		serialize, structured := structuredFormat(plan.cfg, format)
+		if !structured {
+			continue
+		}
+
+		content, err := serialize(plan.fragments)
+		if err != nil {
+			return err
+		}
+
+		content, err = encodeOutput(content, opts.OutputEncoding)
+		if err != nil {
+			return err
+		}
+
+		output.formats[format] = content
+		output.structured[format] = true
+	}
+
+	return nil
+}
+
+// latestModTime returns the Timestamp template variable: the latest modification time of the
+// fragments rather than the time of the build, so that rebuilding unchanged fragments renders
+// the same output and --check and --skip-if-unchanged do not report a change on every run.
+// It falls back to the current time when no fragment has a modification time.
+func latestModTime(fragments []parser.Fragment) string {
+	var latest time.Time
+
+	for _, fragment := range fragments {
+		if fragment.ModTime.After(latest) {
+			latest = fragment.ModTime
+		}
+	}
+
+	if latest.IsZero() {
+		latest = time.Now()
+	}
+
+	return latest.UTC().Format(time.RFC3339)
+}
+
+// outputHeader returns the configured outputHeader followed by a newline, or nothing with
+// NoHeader. It is also left out with IncludeFrontmatter: frontmatter is only recognized at
+// the top of a file, so a header above it would turn it into plain text.
+func outputHeader(opts *BuildOptions, cfg *config.Config) string {
+	if opts.NoHeader || opts.IncludeFrontmatter || cfg.OutputHeader == "" {
+		return ""
+	}
+
+	return cfg.OutputHeader + "\n"
+}
+
+// fragmentSeparator returns the separator written between fragments: none with
+// NoSeparator, otherwise the configured separator or the default one.
+func fragmentSeparator(opts *BuildOptions, cfg *config.Config) string {
+	switch {
+	case opts.NoSeparator:
+		return ""
+	case cfg.Separator != nil:
+		return *cfg.Separator
+	default:
+		return parser.DefaultSeparator
+	}
+}
+
+// finishOutput wraps the spliced fragments in the template, if one is given, and renders
+// the result as HTML with OutputHTML.
+func finishOutput(opts *BuildOptions, spliced, templatePath string, vars map[string]string) (string, error) {
+	output := spliced
+
+	if templatePath != "" {
+		wrapped, err := parser.ApplyOutputTemplate(templatePath, spliced, vars)
+		if err != nil {
+			return "", err
+		}
+
+		output = wrapped
+	}
+
+	if opts.OutputHTML {
+		html, err := renderer.RenderHTML(output)
+		if err != nil {
+			return "", err
+		}
+
+		output = html
+	}
+
+	return encodeOutput(output, opts.OutputEncoding)
+}
+
+// Output encodings.
+const (
+	OutputEncodingUTF8  = "utf8"
+	OutputEncodingASCII = "ascii"
+)
+
+// encodeOutput converts the output to the encoding; empty means utf8, which leaves the
+// output unchanged, and ascii transliterates it with parser.ToASCII.
+func encodeOutput(output, encoding string) (string, error) {
+	switch encoding {
+	case "", OutputEncodingUTF8:
+		return output, nil
+	case OutputEncodingASCII:
+		return parser.ToASCII(output), nil
+	default:
+		return "", fmt.Errorf("invalid output encoding %q (expected %s or %s)", encoding, OutputEncodingUTF8, OutputEncodingASCII)
+	}
+}
+
+// outputContents maps the file of every selected output format to the content written to it.
+func outputContents(opts *BuildOptions, plan *buildPlan, output *buildOutput) map[string]string {
+	contents := make(map[string]string, len(plan.outputFormats))
+
+	for i, format := range plan.outputFormats {
+		filename, err := resolveOutputFilename(format, i, plan.outputFiles, opts.OutputDir, plan.cfg)
+		if err != nil {
+			continue
+		}
+
+		contents[filename] = output.fileContent(format)
+	}
+
+	return contents
+}
+
+// Positions of the content read from stdin relative to the spliced fragments.
+const (
+	StdinBefore = "before"
+	StdinAfter  = "after"
+)
+
+// stdinSeparator separates the content read from stdin from the spliced fragments.
+const stdinSeparator = "\n\n"
+
+// insertStdin adds the content piped to stdin before or after the spliced fragments, as
+// selected by StdinPosition. Nothing is read unless Stdin is set and stdin is not a
+// terminal; trailing newlines of the piped content are dropped.
+func insertStdin(opts *BuildOptions, spliced string) (string, error) {
+	if !opts.Stdin {
+		return spliced, nil
+	}
+
+	position := opts.StdinPosition
+	if position == "" {
+		position = StdinAfter
+	}
+
+	if position != StdinBefore && position != StdinAfter {
+		return "", fmt.Errorf("invalid stdin position %q (expected %s or %s)", position, StdinBefore, StdinAfter)
+	}
+
+	if isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd()) {
+		return spliced, nil
+	}
+
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read stdin: %w", err)
+	}
+
+	piped := strings.TrimRight(string(data), "\r\n")
+	if piped == "" {
+		return spliced, nil
+	}
+
+	if position == StdinBefore {
+		return piped + stdinSeparator + spliced, nil
+	}
+
+	return spliced + stdinSeparator + piped, nil
+}
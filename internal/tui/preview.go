@@ -0,0 +1,131 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/Lewenhaupt/ctx/internal/parser"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Styles of the fragment preview.
+var (
+	previewTitleStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6"))
+	previewStatusStyle = lipgloss.NewStyle().Faint(true)
+)
+
+// previewHelp lists the keys of the fragment preview in its status bar.
+const previewHelp = "←/→ fragment  ↑/↓ scroll  enter continue  esc cancel"
+
+// previewModel is a pager showing one fragment at a time: its path above its content in
+// a scrollable viewport and a status bar with the position among all fragments.
+type previewModel struct {
+	fragments []parser.Fragment
+	index     int
+	viewport  viewport.Model
+	// done is set once the user is finished reviewing; cancelled when the build should
+	// not go ahead.
+	done      bool
+	cancelled bool
+}
+
+func newPreviewModel(fragments []parser.Fragment) previewModel {
+	model := previewModel{fragments: fragments, viewport: viewport.New(80, 20)}
+	model.showFragment(0)
+
+	return model
+}
+
+// showFragment displays the fragment at index, scrolled to its top.
+func (m *previewModel) showFragment(index int) {
+	m.index = index
+	m.viewport.SetContent(m.fragments[index].Content)
+	m.viewport.GotoTop()
+}
+
+func (m previewModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update switches fragments with left/right, finishes on enter past the last fragment
+// and hands all other keys to the viewport for scrolling.
+func (m previewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		// Leave room for the title and the status bar
+		m.viewport.Width = msg.Width
+		m.viewport.Height = max(msg.Height-2, 1)
+
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			m.done, m.cancelled = true, true
+			return m, tea.Quit
+		case "q":
+			m.done = true
+			return m, tea.Quit
+		case "right", "n", "tab":
+			if m.index < len(m.fragments)-1 {
+				m.showFragment(m.index + 1)
+			}
+
+			return m, nil
+		case "left", "p", "shift+tab":
+			if m.index > 0 {
+				m.showFragment(m.index - 1)
+			}
+
+			return m, nil
+		case "enter":
+			if m.index < len(m.fragments)-1 {
+				m.showFragment(m.index + 1)
+				return m, nil
+			}
+
+			m.done = true
+
+			return m, tea.Quit
+		}
+	}
+
+	var cmd tea.Cmd
+
+	m.viewport, cmd = m.viewport.Update(msg)
+
+	return m, cmd
+}
+
+func (m previewModel) View() string {
+	if m.done {
+		return ""
+	}
+
+	return previewTitleStyle.Render(m.fragments[m.index].Path) + "\n" +
+		m.viewport.View() + "\n" +
+		previewStatusStyle.Render(m.status())
+}
+
+// status returns the status bar: the position of the current fragment, how far it is
+// scrolled and the keys.
+func (m previewModel) status() string {
+	return fmt.Sprintf("Fragment %d of %d  %3.0f%%  %s", m.index+1, len(m.fragments), m.viewport.ScrollPercent()*100, previewHelp)
+}
+
+// previewFragments shows the fragments in a full-screen pager before the build is
+// confirmed and reports whether the build should go ahead.
+func previewFragments(fragments []parser.Fragment) (bool, error) {
+	if len(fragments) == 0 {
+		return true, nil
+	}
+
+	final, err := tea.NewProgram(newPreviewModel(fragments), tea.WithAltScreen()).Run()
+	if err != nil {
+		return false, err
+	}
+
+	model, ok := final.(previewModel)
+
+	return ok && !model.cancelled, nil
+}
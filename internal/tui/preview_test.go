@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Lewenhaupt/ctx/internal/parser"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func updatePreview(t *testing.T, model previewModel, msg tea.Msg) previewModel {
+	t.Helper()
+
+	updated, _ := model.Update(msg)
+
+	next, ok := updated.(previewModel)
+	if !ok {
+		t.Fatalf("Expected a previewModel, got %T", updated)
+	}
+
+	return next
+}
+
+func TestPreviewModelNavigation(t *testing.T) {
+	fragments := []parser.Fragment{
+		{Path: "a.md", Content: "First body"},
+		{Path: "b.md", Content: "Second body"},
+	}
+
+	model := newPreviewModel(fragments)
+
+	if !strings.Contains(model.View(), "a.md") || !strings.Contains(model.View(), "Fragment 1 of 2") {
+		t.Errorf("Expected the first fragment and its position in the view, got %q", model.View())
+	}
+
+	model = updatePreview(t, model, tea.KeyMsg{Type: tea.KeyLeft})
+	if model.index != 0 {
+		t.Errorf("Expected left on the first fragment to stay on it, got index %d", model.index)
+	}
+
+	model = updatePreview(t, model, tea.KeyMsg{Type: tea.KeyRight})
+	if model.index != 1 || !strings.Contains(model.View(), "Second body") || !strings.Contains(model.View(), "Fragment 2 of 2") {
+		t.Errorf("Expected right to show the second fragment, got %q", model.View())
+	}
+
+	model = updatePreview(t, model, tea.KeyMsg{Type: tea.KeyEnter})
+	if !model.done || model.cancelled {
+		t.Errorf("Expected enter on the last fragment to finish the preview, got done=%v cancelled=%v", model.done, model.cancelled)
+	}
+}
+
+func TestPreviewModelEnterAdvances(t *testing.T) {
+	model := newPreviewModel([]parser.Fragment{{Path: "a.md"}, {Path: "b.md"}})
+
+	model = updatePreview(t, model, tea.KeyMsg{Type: tea.KeyEnter})
+	if model.index != 1 || model.done {
+		t.Errorf("Expected enter to advance to the next fragment, got index %d done=%v", model.index, model.done)
+	}
+}
+
+func TestPreviewModelCancel(t *testing.T) {
+	model := newPreviewModel([]parser.Fragment{{Path: "a.md"}})
+
+	model = updatePreview(t, model, tea.KeyMsg{Type: tea.KeyEsc})
+	if !model.done || !model.cancelled {
+		t.Errorf("Expected esc to cancel the build, got done=%v cancelled=%v", model.done, model.cancelled)
+	}
+}
+
+func TestPreviewModelWindowSize(t *testing.T) {
+	model := newPreviewModel([]parser.Fragment{{Path: "a.md"}})
+
+	model = updatePreview(t, model, tea.WindowSizeMsg{Width: 100, Height: 30})
+	if model.viewport.Width != 100 || model.viewport.Height != 28 {
+		t.Errorf("Expected a 100x28 viewport, got %dx%d", model.viewport.Width, model.viewport.Height)
+	}
+}
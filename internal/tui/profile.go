@@ -0,0 +1,65 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+)
+
+// ProfileListOptions represents the options for the profile list command.
+type ProfileListOptions struct {
+	ConfigFile string
+}
+
+// RunProfileList prints the configured build profiles.
+func RunProfileList(opts *ProfileListOptions) error {
+	cfg, err := config.LoadMergedConfig(opts.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	fmt.Print(formatProfiles(cfg))
+
+	return nil
+}
+
+// formatProfiles renders the profiles of cfg, one per line, sorted by name.
+func formatProfiles(cfg *config.Config) string {
+	names := profileNames(cfg)
+	if len(names) == 0 {
+		return "No profiles configured. Add them under \"profiles\" in the config file.\n"
+	}
+
+	var result strings.Builder
+
+	for _, name := range names {
+		profile := cfg.Profiles[name]
+		result.WriteString(fmt.Sprintf("%s\n  tags:           %s\n  output formats: %s\n",
+			name, joinOrNone(profile.Tags), joinOrNone(profile.OutputFormats)))
+	}
+
+	return result.String()
+}
+
+// profileNames returns the names of the configured profiles in sorted order.
+func profileNames(cfg *config.Config) []string {
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// joinOrNone joins values with commas, or returns "(none)" for an empty list.
+func joinOrNone(values []string) string {
+	if len(values) == 0 {
+		return "(none)"
+	}
+
+	return strings.Join(values, ", ")
+}
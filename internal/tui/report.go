@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+// BuildReport is the JSON manifest written by build --build-report.
+type BuildReport struct {
+	SelectedTags []string              `json:"selectedTags"`
+	Fragments    []BuildReportFragment `json:"fragments"`
+	OutputFiles  []BuildReportOutput   `json:"outputFiles"`
+	BuiltAt      string                `json:"builtAt"`
+	// Vars are the variables declared by the fragments, see parser.MergeFragmentVars.
+	Vars map[string]string `json:"vars,omitempty"`
+}
+
+// BuildReportFragment describes a fragment included in a build.
+type BuildReportFragment struct {
+	Path string   `json:"path"`
+	Tags []string `json:"tags"`
+}
+
+// BuildReportOutput describes an output file written by a build.
+type BuildReportOutput struct {
+	Path      string `json:"path"`
+	SHA256    string `json:"sha256"`
+	SizeBytes int    `json:"sizeBytes"`
+}
+
+// newBuildReport assembles the report of a build that wrote output to the given files;
+// contents maps each written file to the content written to it.
+func newBuildReport(plan *buildPlan, written []string, contents map[string]string, builtAt time.Time) *BuildReport {
+	report := &BuildReport{
+		SelectedTags: plan.selectedTags,
+		Fragments:    make([]BuildReportFragment, 0, len(plan.fragments)),
+		OutputFiles:  make([]BuildReportOutput, 0, len(written)),
+		BuiltAt:      builtAt.Format(time.RFC3339),
+		Vars:         parser.MergeFragmentVars(plan.fragments),
+	}
+
+	for _, fragment := range plan.fragments {
+		tags := fragment.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+
+		report.Fragments = append(report.Fragments, BuildReportFragment{Path: fragment.Path, Tags: tags})
+	}
+
+	for _, path := range written {
+		report.OutputFiles = append(report.OutputFiles, BuildReportOutput{
+			Path:      path,
+			SHA256:    parser.ComputeContentHash(contents[path]),
+			SizeBytes: len(contents[path]),
+		})
+	}
+
+	return report
+}
+
+// WriteBuildReport writes the build report as indented JSON to path.
+func WriteBuildReport(path string, report *BuildReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal build report: %w", err)
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write build report %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// ReadBuildReport reads a build report written by WriteBuildReport.
+func ReadBuildReport(path string) (*BuildReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read build report: %w", err)
+	}
+
+	var report BuildReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse build report %s: %w", path, err)
+	}
+
+	return &report, nil
+}
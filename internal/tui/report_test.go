@@ -0,0 +1,61 @@
+package tui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+func TestWriteBuildReport(t *testing.T) {
+	plan := &buildPlan{
+		selectedTags: []string{"typescript"},
+		fragments: []parser.Fragment{
+			{Path: "/fragments/typescript.md", Tags: []string{"typescript", "web"}, Vars: map[string]string{"language": "TypeScript"}},
+			{Path: "/fragments/untagged.md"},
+		},
+	}
+
+	builtAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	report := newBuildReport(plan, []string{"AGENTS.md"}, map[string]string{"AGENTS.md": "hello"}, builtAt)
+
+	path := filepath.Join(t.TempDir(), "reports", "build.json")
+	if err := WriteBuildReport(path, report); err != nil {
+		t.Fatalf("WriteBuildReport failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read build report: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Build report is not valid JSON: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"selectedTags": []interface{}{"typescript"},
+		"fragments": []interface{}{
+			map[string]interface{}{"path": "/fragments/typescript.md", "tags": []interface{}{"typescript", "web"}},
+			map[string]interface{}{"path": "/fragments/untagged.md", "tags": []interface{}{}},
+		},
+		"outputFiles": []interface{}{
+			map[string]interface{}{
+				"path":      "AGENTS.md",
+				"sha256":    "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+				"sizeBytes": float64(5),
+			},
+		},
+		"builtAt": "2025-01-02T03:04:05Z",
+		"vars":    map[string]interface{}{"language": "TypeScript"},
+	}
+
+	if !reflect.DeepEqual(decoded, expected) {
+		t.Errorf("Expected report %v, got %v", expected, decoded)
+	}
+}
@@ -0,0 +1,137 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/Lewenhaupt/ctx/internal/parser"
+	"github.com/mattn/go-isatty"
+)
+
+const (
+	ansiBold  = "\033[1m"
+	ansiReset = "\033[0m"
+)
+
+// SearchOptions represents the options for the search command.
+type SearchOptions struct {
+	ConfigFile    string
+	Query         string
+	Tags          []string
+	JSON          bool
+	CaseSensitive bool
+	Regex         bool
+	Frontmatter   bool
+}
+
+// SearchResult holds the matching lines of a single fragment.
+type SearchResult struct {
+	Path    string               `json:"path"`
+	Matches []parser.SearchMatch `json:"matches"`
+}
+
+// RunSearch searches the content of all fragments for the query and prints the matches.
+func RunSearch(opts *SearchOptions) error {
+	pattern, err := compileSearchPattern(opts.Query, opts.CaseSensitive, opts.Regex)
+	if err != nil {
+		return err
+	}
+
+	cfg, fragments, err := loadConfigAndFragments(opts.ConfigFile, false)
+	if err != nil {
+		return err
+	}
+
+	if len(opts.Tags) > 0 {
+		terms, err := parser.ExpandAliases(opts.Tags, cfg.Aliases)
+		if err != nil {
+			return fmt.Errorf("failed to expand tag aliases: %w", err)
+		}
+
+		fragments = parser.FilterFragmentsByTagExpression(fragments, terms)
+	}
+
+	results, err := searchFragments(fragments, pattern, opts.Frontmatter)
+	if err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal search results: %w", err)
+		}
+
+		fmt.Println(string(data))
+
+		return nil
+	}
+
+	if len(results) == 0 {
+		fmt.Printf("No matches found for %q\n", opts.Query)
+		return nil
+	}
+
+	highlight := isatty.IsTerminal(os.Stdout.Fd())
+
+	for _, result := range results {
+		fmt.Println(result.Path)
+
+		for _, match := range result.Matches {
+			text := match.Text
+			if highlight {
+				text = pattern.ReplaceAllStringFunc(text, func(s string) string {
+					return ansiBold + s + ansiReset
+				})
+			}
+
+			fmt.Printf("%6d: %s\n", match.Line, text)
+		}
+	}
+
+	return nil
+}
+
+// compileSearchPattern builds the matcher for a search query. Plain queries match as
+// substrings; with useRegex the query is a regular expression.
+func compileSearchPattern(query string, caseSensitive, useRegex bool) (*regexp.Regexp, error) {
+	if query == "" {
+		return nil, fmt.Errorf("search query cannot be empty")
+	}
+
+	expr := query
+	if !useRegex {
+		expr = regexp.QuoteMeta(query)
+	}
+
+	if !caseSensitive {
+		expr = "(?i)" + expr
+	}
+
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search pattern %q: %w", query, err)
+	}
+
+	return pattern, nil
+}
+
+// searchFragments returns the fragments with at least one line matching the pattern.
+func searchFragments(fragments []parser.Fragment, pattern *regexp.Regexp, includeFrontmatter bool) ([]SearchResult, error) {
+	results := []SearchResult{}
+
+	for _, fragment := range fragments {
+		matches, err := parser.SearchFragment(fragment, pattern.MatchString, includeFrontmatter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search fragment %s: %w", fragment.Path, err)
+		}
+
+		if len(matches) > 0 {
+			results = append(results, SearchResult{Path: fragment.Path, Matches: matches})
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,88 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+func TestCompileSearchPattern(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		caseSensitive bool
+		regex         bool
+		line          string
+		expectMatch   bool
+		expectError   bool
+	}{
+		{name: "case-insensitive substring", query: "Strict", line: "use strict mode", expectMatch: true},
+		{name: "case-sensitive substring", query: "Strict", caseSensitive: true, line: "use strict mode", expectMatch: false},
+		{name: "special characters are literal", query: "a.b", line: "axb", expectMatch: false},
+		{name: "regex", query: "^use .+ mode$", regex: true, line: "use strict mode", expectMatch: true},
+		{name: "invalid regex", query: "(", regex: true, expectError: true},
+		{name: "empty query", query: "", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern, err := compileSearchPattern(tt.query, tt.caseSensitive, tt.regex)
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if pattern.MatchString(tt.line) != tt.expectMatch {
+				t.Errorf("Expected match %v for line %q", tt.expectMatch, tt.line)
+			}
+		})
+	}
+}
+
+func TestSearchFragments(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"a.md": "---\nctx-tags: a\n---\nkeyword here",
+		"b.md": "---\nctx-tags: b\n---\nnothing",
+	}
+
+	var fragments []parser.Fragment
+
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create fragment: %v", err)
+		}
+
+		fragments = append(fragments, parser.Fragment{Path: path})
+	}
+
+	pattern, err := compileSearchPattern("KEYWORD", false, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	results, err := searchFragments(fragments, pattern, false)
+	if err != nil {
+		t.Fatalf("searchFragments failed: %v", err)
+	}
+
+	if len(results) != 1 || filepath.Base(results[0].Path) != "a.md" {
+		t.Fatalf("Expected a single result for a.md, got %v", results)
+	}
+
+	if results[0].Matches[0].Line != 4 || results[0].Matches[0].Text != "keyword here" {
+		t.Errorf("Unexpected match: %v", results[0].Matches[0])
+	}
+}
@@ -0,0 +1,101 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+// clipboardCommands are the commands tried in order to read the system clipboard.
+var clipboardCommands = [][]string{
+	{"pbpaste"},
+	{"wl-paste", "--no-newline"},
+	{"xclip", "-selection", "clipboard", "-o"},
+	{"xsel", "--clipboard", "--output"},
+}
+
+// RunFragmentFromSelection creates a fragment whose content is read from stdin when it is
+// piped, or otherwise from the system clipboard. In non-interactive mode the content must
+// be piped to stdin; the name and tags are handled like in RunNewFragment.
+func RunFragmentFromSelection(opts *NewFragmentOptions) error {
+	content, err := readSelection(opts.NonInteractive)
+	if err != nil {
+		return err
+	}
+
+	opts.Content = content
+
+	return RunNewFragment(opts)
+}
+
+// readSelection returns the content piped to stdin or, in interactive mode when nothing is
+// piped, the clipboard content, with trailing newlines normalized to one.
+func readSelection(nonInteractive bool) (string, error) {
+	var (
+		content string
+		err     error
+	)
+
+	switch {
+	case !isatty.IsTerminal(os.Stdin.Fd()) && !isatty.IsCygwinTerminal(os.Stdin.Fd()):
+		content, err = readStdinSelection(os.Stdin)
+	case nonInteractive:
+		return "", fmt.Errorf("no content piped to stdin (the clipboard is only read in interactive mode)")
+	default:
+		content, err = readClipboard()
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	content = strings.TrimRight(content, "\r\n")
+	if strings.TrimSpace(content) == "" {
+		return "", fmt.Errorf("the selection is empty")
+	}
+
+	return content + "\n", nil
+}
+
+// readStdinSelection reads the whole selection from r.
+func readStdinSelection(r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read stdin: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// readClipboard returns the clipboard content using the first of clipboardCommands that
+// is installed.
+func readClipboard() (string, error) {
+	tried := make([]string, 0, len(clipboardCommands))
+
+	for _, command := range clipboardCommands {
+		tried = append(tried, command[0])
+
+		if _, err := exec.LookPath(command[0]); err != nil {
+			continue
+		}
+
+		var out bytes.Buffer
+
+		cmd := exec.Command(command[0], command[1:]...)
+		cmd.Stdout = &out
+		cmd.Stderr = os.Stderr
+
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("failed to read the clipboard with %s: %w", command[0], err)
+		}
+
+		return out.String(), nil
+	}
+
+	return "", fmt.Errorf("no content piped to stdin and no clipboard tool found (tried %s)", strings.Join(tried, ", "))
+}
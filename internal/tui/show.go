@@ -0,0 +1,255 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+// Fragment sources reported by the fragment show command.
+const (
+	FragmentSourceGlobal = "global"
+	FragmentSourceLocal  = "local"
+)
+
+// ShowFragmentOptions represents the options for the fragment show command.
+type ShowFragmentOptions struct {
+	ConfigFile string
+	Name       string
+	JSON       bool
+}
+
+// FragmentDetails describes a fragment file found by name.
+type FragmentDetails struct {
+	Path        string                 `json:"path"`
+	Source      string                 `json:"source"`
+	Active      bool                   `json:"active"`
+	Tags        []string               `json:"tags"`
+	Priority    int                    `json:"priority"`
+	Includes    []string               `json:"includes,omitempty"`
+	Frontmatter map[string]interface{} `json:"frontmatter"`
+	Content     string                 `json:"content"`
+	// dir is the fragments directory the fragment was found in.
+	dir string
+}
+
+// RunShowFragment prints the path, source, frontmatter and content of the fragments named
+// opts.Name. The name is the filename without extension, or the path relative to the
+// fragments directory without extension for fragments in subdirectories.
+func RunShowFragment(opts *ShowFragmentOptions) error {
+	cfg, err := config.LoadMergedConfig(opts.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	details, err := findFragmentsByName(cfg, opts.Name)
+	if err != nil {
+		return err
+	}
+
+	if len(details) == 0 {
+		return fmt.Errorf("fragment %q not found", opts.Name)
+	}
+
+	if opts.JSON {
+		data, err := json.MarshalIndent(details, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal fragment: %w", err)
+		}
+
+		fmt.Println(string(data))
+
+		return nil
+	}
+
+	if len(details) > 1 {
+		fmt.Printf("Note: %d fragments are named %q; the one marked active takes precedence.\n\n", len(details), opts.Name)
+	}
+
+	for i, fragment := range details {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		fmt.Print(formatFragmentDetails(fragment, len(details) > 1))
+	}
+
+	return nil
+}
+
+// findFragmentsByName returns the global and local fragments called name in scan order.
+// Those a build would splice are marked active: a fragment is overridden by any fragment
+// with the same filename in a later fragments directory or the local one, even if that
+// one is not called name.
+func findFragmentsByName(cfg *config.Config, name string) ([]FragmentDetails, error) {
+	dirs, err := config.GetFragmentsDirs(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fragments directory: %w", err)
+	}
+
+	localDir, err := parser.LocalFragmentsDir()
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := activeFragmentPaths(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var details []FragmentDetails
+
+	for i, dir := range append(dirs, localDir) {
+		source := FragmentSourceGlobal
+		if i == len(dirs) {
+			source = FragmentSourceLocal
+		}
+
+		paths, err := parser.FindFragmentFiles(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", dir, err)
+		}
+
+		for _, path := range paths {
+			if !fragmentNameMatches(dir, path, name) {
+				continue
+			}
+
+			fragment, err := loadFragmentDetails(cfg, dir, path, source)
+			if err != nil {
+				return nil, err
+			}
+
+			fragment.Active = active[absPath(path)]
+			details = append(details, *fragment)
+		}
+	}
+
+	return details, nil
+}
+
+// activeFragment returns the fragment of details that commands acting on a single fragment
+// use when several share a name: the one a build splices or, if a fragment with another
+// name overrides them all, the last one, which takes precedence among them.
+func activeFragment(details []FragmentDetails) *FragmentDetails {
+	for i := len(details) - 1; i >= 0; i-- {
+		if details[i].Active {
+			return &details[i]
+		}
+	}
+
+	return &details[len(details)-1]
+}
+
+// activeFragmentPaths returns the absolute paths of the fragments left after combining the
+// global and local fragments like a build does.
+func activeFragmentPaths(cfg *config.Config) (map[string]bool, error) {
+	fragments, _, err := scanConfiguredFragments(cfg, scanOptions(cfg), false)
+	if err != nil {
+		return nil, err
+	}
+
+	active := make(map[string]bool, len(fragments))
+	for _, fragment := range fragments {
+		active[absPath(fragment.Path)] = true
+	}
+
+	return active, nil
+}
+
+// fragmentNameMatches reports whether the fragment at path is called name, either by its
+// filename or by its path relative to dir, both without extension.
+func fragmentNameMatches(dir, path, name string) bool {
+	base := filepath.Base(path)
+	if strings.TrimSuffix(base, filepath.Ext(base)) == name {
+		return true
+	}
+
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+
+	rel = filepath.ToSlash(rel)
+
+	return strings.TrimSuffix(rel, filepath.Ext(rel)) == name
+}
+
+// loadFragmentDetails parses the fragment at path and reads its full frontmatter.
+func loadFragmentDetails(cfg *config.Config, dir, path, source string) (*FragmentDetails, error) {
+	fragment, err := parser.ParseFragmentWithOptions(path, parseOptions(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse fragment %s: %w", path, err)
+	}
+
+	frontmatter, err := parser.ReadFrontmatter(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read frontmatter of %s: %w", path, err)
+	}
+
+	tags := fragment.Tags
+	if cfg.NamespaceFromDir {
+		tags = unionTags(tags, parser.NamespaceTags(dir, path))
+	}
+
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return &FragmentDetails{
+		dir:         dir,
+		Path:        path,
+		Source:      source,
+		Tags:        tags,
+		Priority:    fragment.Priority,
+		Includes:    fragment.Includes,
+		Frontmatter: frontmatter,
+		Content:     fragment.Content,
+	}, nil
+}
+
+// formatFragmentDetails renders a fragment summary followed by its content.
+// With showActive the summary states whether the fragment takes precedence.
+func formatFragmentDetails(fragment FragmentDetails, showActive bool) string {
+	var result strings.Builder
+
+	source := fragment.Source
+	if showActive && fragment.Active {
+		source += " (active)"
+	} else if showActive {
+		source += " (overridden)"
+	}
+
+	result.WriteString(fmt.Sprintf("Path:      %s\n", fragment.Path))
+	result.WriteString(fmt.Sprintf("Source:    %s\n", source))
+	result.WriteString(fmt.Sprintf("Tags:      %s\n", joinOrNone(fragment.Tags)))
+	result.WriteString(fmt.Sprintf("Priority:  %d\n", fragment.Priority))
+
+	if len(fragment.Includes) > 0 {
+		result.WriteString(fmt.Sprintf("Includes:  %s\n", strings.Join(fragment.Includes, ", ")))
+	}
+
+	if len(fragment.Frontmatter) > 0 {
+		result.WriteString("Frontmatter:\n")
+
+		keys := make([]string, 0, len(fragment.Frontmatter))
+		for key := range fragment.Frontmatter {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			result.WriteString(fmt.Sprintf("  %s: %v\n", key, fragment.Frontmatter[key]))
+		}
+	}
+
+	result.WriteString("\n" + fragment.Content + "\n")
+
+	return result.String()
+}
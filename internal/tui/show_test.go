@@ -0,0 +1,91 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+)
+
+func TestFindFragmentsByName(t *testing.T) {
+	tmpDir := t.TempDir()
+	globalDir := filepath.Join(tmpDir, "global")
+	projectDir := filepath.Join(tmpDir, "project")
+	localDir := filepath.Join(projectDir, ".ctx", "fragments")
+
+	files := map[string]string{
+		filepath.Join(globalDir, "style.md"):          "---\nctx-tags: style\ndescription: Global style\n---\nGlobal body",
+		filepath.Join(globalDir, "react", "hooks.md"): "---\nctx-tags: [hooks]\nctx-priority: 3\n---\nHooks body",
+		filepath.Join(localDir, "style.md"):           "---\nctx-tags: style, local\n---\nLocal body",
+		filepath.Join(globalDir, "react", "state.md"): "---\nctx-tags: state\n---\nGlobal state",
+		filepath.Join(localDir, "state.md"):           "---\nctx-tags: state\n---\nLocal state",
+	}
+
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create fragment: %v", err)
+		}
+	}
+
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+
+	defer func() { _ = os.Chdir(originalWd) }()
+
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+
+	cfg := &config.Config{FragmentsDir: globalDir, NamespaceFromDir: true}
+
+	details, err := findFragmentsByName(cfg, "style")
+	if err != nil {
+		t.Fatalf("findFragmentsByName failed: %v", err)
+	}
+
+	if len(details) != 2 {
+		t.Fatalf("Expected 2 fragments named style, got %d", len(details))
+	}
+
+	if details[0].Source != FragmentSourceGlobal || details[0].Active {
+		t.Errorf("Expected an inactive global fragment first, got %+v", details[0])
+	}
+
+	if details[1].Source != FragmentSourceLocal || !details[1].Active || details[1].Content != "Local body" {
+		t.Errorf("Expected the active local fragment second, got %+v", details[1])
+	}
+
+	if details[0].Frontmatter["description"] != "Global style" {
+		t.Errorf("Expected all frontmatter fields, got %v", details[0].Frontmatter)
+	}
+
+	for _, name := range []string{"hooks", "react/hooks"} {
+		details, err := findFragmentsByName(cfg, name)
+		if err != nil {
+			t.Fatalf("findFragmentsByName(%q) failed: %v", name, err)
+		}
+
+		if len(details) != 1 || details[0].Priority != 3 || !reflect.DeepEqual(details[0].Tags, []string{"hooks", "react"}) {
+			t.Errorf("Expected the hooks fragment for %q, got %+v", name, details)
+		}
+	}
+
+	// The local state.md overrides react/state.md in a build, although it is not named react/state
+	details, err = findFragmentsByName(cfg, "react/state")
+	if err != nil || len(details) != 1 || details[0].Active {
+		t.Errorf("Expected the overridden react/state fragment to be inactive, got %+v, %v", details, err)
+	}
+
+	details, err = findFragmentsByName(cfg, "missing")
+	if err != nil || len(details) != 0 {
+		t.Errorf("Expected no fragments for an unknown name, got %+v, %v", details, err)
+	}
+}
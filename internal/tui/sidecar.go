@@ -0,0 +1,76 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// SidecarSuffix is appended to the path of an output file to name its metadata sidecar,
+// e.g. AGENTS.md.ctx-meta.json.
+const SidecarSuffix = ".ctx-meta.json"
+
+// SidecarMetadata is the content of the sidecar file written next to each output file
+// by build --write-metadata.
+type SidecarMetadata struct {
+	BuiltAt   string            `json:"builtAt"`
+	Tags      []string          `json:"tags"`
+	Fragments []SidecarFragment `json:"fragments"`
+}
+
+// SidecarFragment describes a fragment version that produced an output file.
+type SidecarFragment struct {
+	Path     string   `json:"path"`
+	Checksum string   `json:"checksum"`
+	Tags     []string `json:"tags"`
+}
+
+// SidecarPath returns the path of the metadata sidecar of an output file.
+func SidecarPath(outputPath string) string {
+	return outputPath + SidecarSuffix
+}
+
+// newSidecarMetadata assembles the sidecar metadata of a build.
+func newSidecarMetadata(plan *buildPlan, builtAt time.Time) *SidecarMetadata {
+	metadata := &SidecarMetadata{
+		BuiltAt:   builtAt.Format(time.RFC3339),
+		Tags:      plan.selectedTags,
+		Fragments: make([]SidecarFragment, 0, len(plan.fragments)),
+	}
+
+	if metadata.Tags == nil {
+		metadata.Tags = []string{}
+	}
+
+	for _, fragment := range plan.fragments {
+		tags := fragment.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+
+		metadata.Fragments = append(metadata.Fragments, SidecarFragment{
+			Path:     fragment.Path,
+			Checksum: fragment.Checksum,
+			Tags:     tags,
+		})
+	}
+
+	return metadata
+}
+
+// writeSidecarFiles writes the metadata sidecar of every written output file.
+func writeSidecarFiles(written []string, metadata *SidecarMetadata) error {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal output metadata: %w", err)
+	}
+
+	for _, path := range written {
+		if err := os.WriteFile(SidecarPath(path), append(data, '\n'), 0o600); err != nil {
+			return fmt.Errorf("failed to write output metadata %s: %w", SidecarPath(path), err)
+		}
+	}
+
+	return nil
+}
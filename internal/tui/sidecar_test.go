@@ -0,0 +1,92 @@
+package tui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+func TestRunBuildWriteMetadata(t *testing.T) {
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	files := map[string]string{
+		"a.md": "---\nctx-tags: go\n---\nA",
+		"b.md": "---\nctx-tags: go, rust\n---\nB",
+		"c.md": "---\nctx-tags: python\n---\nC",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(fragmentsDir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create fragment: %v", err)
+		}
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+fragmentsDir+`", "outputFormats": {}}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	outputPath := filepath.Join(tmpDir, "AGENTS.md")
+	opts := BuildOptions{
+		ConfigFile:     configPath,
+		Tags:           []string{"go"},
+		NonInteractive: true,
+		OutputFiles:    []string{outputPath},
+		WriteMetadata:  true,
+	}
+
+	if _, err := RunBuild(&opts); err != nil {
+		t.Fatalf("RunBuild failed: %v", err)
+	}
+
+	data, err := os.ReadFile(outputPath + ".ctx-meta.json")
+	if err != nil {
+		t.Fatalf("Failed to read sidecar file: %v", err)
+	}
+
+	var metadata SidecarMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		t.Fatalf("Sidecar file is not valid JSON: %v", err)
+	}
+
+	if !reflect.DeepEqual(metadata.Tags, []string{"go"}) || metadata.BuiltAt == "" {
+		t.Errorf("Unexpected sidecar tags or build time: %+v", metadata)
+	}
+
+	var expected []SidecarFragment
+
+	for _, name := range []string{"a.md", "b.md"} {
+		fragment, err := parser.ParseFragment(filepath.Join(fragmentsDir, name))
+		if err != nil {
+			t.Fatalf("ParseFragment failed: %v", err)
+		}
+
+		expected = append(expected, SidecarFragment{Path: fragment.Path, Checksum: fragment.Checksum, Tags: fragment.Tags})
+	}
+
+	if !reflect.DeepEqual(metadata.Fragments, expected) {
+		t.Errorf("Expected sidecar fragments %+v, got %+v", expected, metadata.Fragments)
+	}
+
+	reportPath := filepath.Join(tmpDir, "report.json")
+	if err := WriteBuildReport(reportPath, &BuildReport{OutputFiles: []BuildReportOutput{{Path: outputPath}}}); err != nil {
+		t.Fatalf("Failed to create build report: %v", err)
+	}
+
+	if err := RunClean(&CleanOptions{BuildReport: reportPath}); err != nil {
+		t.Fatalf("RunClean failed: %v", err)
+	}
+
+	if pathExists(SidecarPath(outputPath)) {
+		t.Error("Expected clean to remove the sidecar file")
+	}
+}
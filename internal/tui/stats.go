@@ -0,0 +1,135 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+// Sort orders accepted by the fragment stats command.
+const (
+	StatsSortPath  = "path"
+	StatsSortSize  = "size"
+	StatsSortWords = "words"
+	StatsSortLines = "lines"
+	StatsSortTags  = "tags"
+)
+
+// StatsSortOrders lists the sort orders accepted by the fragment stats command.
+var StatsSortOrders = []string{StatsSortPath, StatsSortSize, StatsSortWords, StatsSortLines, StatsSortTags}
+
+// FragmentStatsOptions represents the options for the fragment stats command.
+type FragmentStatsOptions struct {
+	ConfigFile string
+	Sort       string
+	JSON       bool
+}
+
+// fragmentStatsJSON is the document printed by fragment stats --json.
+type fragmentStatsJSON struct {
+	Fragments []parser.Stats `json:"fragments"`
+	Total     parser.Stats   `json:"total"`
+}
+
+// RunFragmentStats prints content statistics for every global and local fragment
+// followed by a totals row.
+func RunFragmentStats(opts *FragmentStatsOptions) error {
+	if !slices.Contains(StatsSortOrders, opts.Sort) {
+		return fmt.Errorf("invalid sort order %q (expected %s)", opts.Sort, strings.Join(StatsSortOrders, ", "))
+	}
+
+	cfg, err := config.LoadMergedConfig(opts.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	fragments, _, err := scanConfiguredFragments(cfg, scanOptions(cfg), false)
+	if err != nil {
+		return err
+	}
+
+	stats := collectFragmentStats(fragments, opts.Sort)
+
+	var total parser.Stats
+	for _, fragmentStats := range stats {
+		total.Add(fragmentStats)
+	}
+
+	if opts.JSON {
+		data, err := json.MarshalIndent(fragmentStatsJSON{Fragments: stats, Total: total}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal fragment stats: %w", err)
+		}
+
+		fmt.Println(string(data))
+
+		return nil
+	}
+
+	if len(stats) == 0 {
+		fmt.Println("No fragments found. Create one with 'ctx fragment new'.")
+		return nil
+	}
+
+	fmt.Print(formatFragmentStats(stats, total))
+
+	return nil
+}
+
+// collectFragmentStats returns the statistics of each fragment sorted by path, or in
+// descending order of the given field (ties broken by path).
+func collectFragmentStats(fragments []parser.Fragment, sortBy string) []parser.Stats {
+	stats := make([]parser.Stats, 0, len(fragments))
+	for _, fragment := range fragments {
+		stats = append(stats, parser.FragmentStats(fragment))
+	}
+
+	key := func(s parser.Stats) int64 {
+		switch sortBy {
+		case StatsSortSize:
+			return s.SizeBytes
+		case StatsSortWords:
+			return int64(s.Words)
+		case StatsSortLines:
+			return int64(s.Lines)
+		case StatsSortTags:
+			return int64(s.Tags)
+		default:
+			return 0
+		}
+	}
+
+	sort.SliceStable(stats, func(i, j int) bool {
+		if ki, kj := key(stats[i]), key(stats[j]); ki != kj {
+			return ki > kj
+		}
+
+		return stats[i].Path < stats[j].Path
+	})
+
+	return stats
+}
+
+// formatFragmentStats renders the statistics as a table with a totals row.
+func formatFragmentStats(stats []parser.Stats, total parser.Stats) string {
+	var result strings.Builder
+
+	row := func(path string, s parser.Stats) {
+		result.WriteString(fmt.Sprintf("%8d %8d %6d %4d %8d  %s\n", s.Words, s.Chars, s.Lines, s.Tags, s.SizeBytes, path))
+	}
+
+	result.WriteString(fmt.Sprintf("%8s %8s %6s %4s %8s  %s\n", "WORDS", "CHARS", "LINES", "TAGS", "BYTES", "PATH"))
+
+	for _, fragmentStats := range stats {
+		row(fragmentStats.Path, fragmentStats)
+	}
+
+	row(fmt.Sprintf("total (%d fragments)", len(stats)), total)
+
+	return result.String()
+}
@@ -0,0 +1,73 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+func TestCollectFragmentStats(t *testing.T) {
+	fragments := []parser.Fragment{
+		{Path: "/g/b.md", Tags: []string{"go"}, Content: "one two three"},
+		{Path: "/g/a.md", Tags: []string{}, Content: "one\ntwo"},
+		{Path: "/g/c.md", Tags: []string{"go", "web"}, Content: "one"},
+	}
+
+	tests := []struct {
+		sortBy   string
+		expected []string
+	}{
+		{sortBy: StatsSortPath, expected: []string{"/g/a.md", "/g/b.md", "/g/c.md"}},
+		{sortBy: StatsSortWords, expected: []string{"/g/b.md", "/g/a.md", "/g/c.md"}},
+		{sortBy: StatsSortLines, expected: []string{"/g/a.md", "/g/b.md", "/g/c.md"}},
+		{sortBy: StatsSortTags, expected: []string{"/g/c.md", "/g/b.md", "/g/a.md"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			var paths []string
+			for _, stats := range collectFragmentStats(fragments, tt.sortBy) {
+				paths = append(paths, stats.Path)
+			}
+
+			if !reflect.DeepEqual(paths, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, paths)
+			}
+		})
+	}
+}
+
+func TestFormatFragmentStats(t *testing.T) {
+	stats := []parser.Stats{
+		{Path: "/g/a.md", Words: 2, Chars: 7, Lines: 2, Tags: 0, SizeBytes: 30},
+		{Path: "/g/b.md", Words: 3, Chars: 13, Lines: 1, Tags: 1, SizeBytes: 40},
+	}
+
+	var total parser.Stats
+	for _, s := range stats {
+		total.Add(s)
+	}
+
+	output := formatFragmentStats(stats, total)
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+
+	if len(lines) != 4 {
+		t.Fatalf("Expected a header, two rows and a totals row, got:\n%s", output)
+	}
+
+	if fields := strings.Fields(lines[3]); !reflect.DeepEqual(fields[:5], []string{"5", "20", "3", "1", "70"}) {
+		t.Errorf("Expected totals 5 20 3 1 70, got %q", lines[3])
+	}
+
+	if !strings.HasSuffix(lines[3], "total (2 fragments)") {
+		t.Errorf("Expected totals label, got %q", lines[3])
+	}
+}
+
+func TestRunFragmentStatsInvalidSort(t *testing.T) {
+	if err := RunFragmentStats(&FragmentStatsOptions{Sort: "name"}); err == nil {
+		t.Error("Expected error for invalid sort order, got nil")
+	}
+}
@@ -0,0 +1,220 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+// StatusOptions represents the options for the status command.
+type StatusOptions struct {
+	ConfigFile string
+	JSON       bool
+}
+
+// DirStatus describes a fragments directory and whether it exists.
+type DirStatus struct {
+	Path   string `json:"path"`
+	Exists bool   `json:"exists"`
+}
+
+// FragmentCounts holds the number of fragments found in each location.
+type FragmentCounts struct {
+	Global   int `json:"global"`
+	Local    int `json:"local"`
+	Combined int `json:"combined"`
+}
+
+// Status describes the effective configuration and the available fragments.
+type Status struct {
+	ConfigFile          string            `json:"configFile"`
+	ConfigFileExists    bool              `json:"configFileExists"`
+	LocalConfigFile     string            `json:"localConfigFile,omitempty"`
+	ProjectConfigFile   string            `json:"projectConfigFile,omitempty"`
+	GlobalFragmentsDirs []DirStatus       `json:"globalFragmentsDirs"`
+	LocalFragmentsDir   DirStatus         `json:"localFragmentsDir"`
+	Fragments           FragmentCounts    `json:"fragments"`
+	Tags                []string          `json:"tags"`
+	OutputFormats       map[string]string `json:"outputFormats"`
+	Problems            []string          `json:"problems,omitempty"`
+}
+
+// RunStatus prints the effective configuration and a summary of the available fragments.
+func RunStatus(opts *StatusOptions) error {
+	status := collectStatus(opts.ConfigFile)
+
+	if opts.JSON {
+		data, err := json.MarshalIndent(status, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal status: %w", err)
+		}
+
+		fmt.Println(string(data))
+
+		return nil
+	}
+
+	fmt.Print(formatStatus(status))
+
+	return nil
+}
+
+// collectStatus gathers the status information. Problems such as missing directories or
+// an unreadable config are recorded in the result instead of being returned as errors.
+func collectStatus(configFile string) *Status {
+	status := &Status{
+		Tags:          []string{},
+		OutputFormats: map[string]string{},
+	}
+
+	configPath, err := config.ResolveConfigPath(configFile)
+	if err != nil {
+		status.Problems = append(status.Problems, fmt.Sprintf("failed to resolve config path: %v", err))
+	}
+
+	status.ConfigFile = configPath
+	status.ConfigFileExists = configPath != "" && pathExists(configPath)
+
+	if localPath, err := config.LocalConfigPath(); err == nil && configFile == "" && localPath != configPath && pathExists(localPath) {
+		status.LocalConfigFile = localPath
+	}
+
+	if projectPath, err := config.ProjectConfigPath(); err == nil && configFile == "" && projectPath != configPath && pathExists(projectPath) {
+		status.ProjectConfigFile = projectPath
+	}
+
+	cfg, err := config.LoadMergedConfig(configFile)
+	if err != nil {
+		status.Problems = append(status.Problems, fmt.Sprintf("failed to load config, using defaults: %v", err))
+		cfg = config.DefaultConfig()
+	}
+
+	for format, formatConfig := range cfg.OutputFormats {
+		status.OutputFormats[format] = formatConfig.Filename
+	}
+
+	var globalFragments, localFragments []parser.Fragment
+
+	fragmentsDirs, err := config.GetFragmentsDirs(cfg)
+	if err != nil {
+		status.Problems = append(status.Problems, fmt.Sprintf("failed to get fragments directory: %v", err))
+	}
+
+	for _, dir := range fragmentsDirs {
+		status.GlobalFragmentsDirs = append(status.GlobalFragmentsDirs, DirStatus{Path: dir, Exists: pathExists(dir)})
+		globalFragments = parser.CombineFragments(globalFragments, scanForStatus(status, dir, scanOptions(cfg)), false)
+	}
+
+	if localDir, err := parser.LocalFragmentsDir(); err != nil {
+		status.Problems = append(status.Problems, err.Error())
+	} else {
+		status.LocalFragmentsDir = DirStatus{Path: localDir, Exists: pathExists(localDir)}
+		localFragments = scanForStatus(status, localDir, scanOptions(cfg))
+	}
+
+	combined := parser.CombineFragments(globalFragments, localFragments, false)
+
+	status.Fragments = FragmentCounts{
+		Global:   len(globalFragments),
+		Local:    len(localFragments),
+		Combined: len(combined),
+	}
+
+	if tags := parser.GetAllTags(combined); tags != nil {
+		status.Tags = tags
+	}
+
+	return status
+}
+
+// scanForStatus scans a fragments directory, recording scan failures as problems.
+func scanForStatus(status *Status, dir string, opts parser.ScanOptions) []parser.Fragment {
+	fragments, err := parser.ScanFragmentsWithOptions(dir, opts)
+	if err != nil {
+		status.Problems = append(status.Problems, fmt.Sprintf("failed to scan %s: %v", dir, err))
+		return nil
+	}
+
+	return fragments
+}
+
+// formatStatus renders the status as human-readable text.
+func formatStatus(status *Status) string {
+	var result strings.Builder
+
+	configState := "found"
+	if !status.ConfigFileExists {
+		configState = "not found, using defaults"
+	}
+
+	result.WriteString(fmt.Sprintf("Config file:       %s (%s)\n", status.ConfigFile, configState))
+
+	if status.LocalConfigFile != "" {
+		result.WriteString(fmt.Sprintf("Local config:      %s (merged over config file)\n", status.LocalConfigFile))
+	}
+
+	if status.ProjectConfigFile != "" {
+		result.WriteString(fmt.Sprintf("Project config:    %s (merged over local config)\n", status.ProjectConfigFile))
+	}
+
+	for _, dir := range status.GlobalFragmentsDirs {
+		result.WriteString(fmt.Sprintf("Global fragments:  %s\n", formatDirStatus(dir)))
+	}
+
+	result.WriteString(fmt.Sprintf("Local fragments:   %s\n", formatDirStatus(status.LocalFragmentsDir)))
+	result.WriteString(fmt.Sprintf("Fragments:         %d global, %d local, %d combined\n",
+		status.Fragments.Global, status.Fragments.Local, status.Fragments.Combined))
+
+	tags := "(none)"
+	if len(status.Tags) > 0 {
+		tags = strings.Join(status.Tags, ", ")
+	}
+
+	result.WriteString(fmt.Sprintf("Tags:              %s\n", tags))
+	result.WriteString("Output formats:\n")
+
+	formats := make([]string, 0, len(status.OutputFormats))
+	for format := range status.OutputFormats {
+		formats = append(formats, format)
+	}
+
+	sort.Strings(formats)
+
+	if len(formats) == 0 {
+		result.WriteString("  (none)\n")
+	}
+
+	for _, format := range formats {
+		result.WriteString(fmt.Sprintf("  %s: %s\n", format, status.OutputFormats[format]))
+	}
+
+	if len(status.Problems) > 0 {
+		result.WriteString("Problems:\n")
+
+		for _, problem := range status.Problems {
+			result.WriteString(fmt.Sprintf("  - %s\n", problem))
+		}
+	}
+
+	return result.String()
+}
+
+// formatDirStatus renders a directory path with its existence state.
+func formatDirStatus(dir DirStatus) string {
+	if dir.Exists {
+		return dir.Path + " (exists)"
+	}
+
+	return dir.Path + " (missing)"
+}
+
+// pathExists reports whether a file or directory exists at path.
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
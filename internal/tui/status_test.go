@@ -0,0 +1,86 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCollectStatus(t *testing.T) {
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+
+	defer func() {
+		_ = os.Chdir(originalWd)
+	}()
+
+	tmpDir := t.TempDir()
+
+	err = os.Chdir(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to change to temp directory: %v", err)
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	globalDir := filepath.Join(tmpDir, "global")
+
+	// Missing config and directories are reported without failing
+	status := collectStatus(configPath)
+
+	if status.ConfigFileExists {
+		t.Error("Expected config file to be reported as missing")
+	}
+
+	if status.LocalFragmentsDir.Exists {
+		t.Error("Expected local fragments directory to be reported as missing")
+	}
+
+	if status.Fragments.Combined != 0 {
+		t.Errorf("Expected no fragments, got %d", status.Fragments.Combined)
+	}
+
+	// Add a config, a global and a local fragment
+	configContent := `{"fragmentsDir": "` + globalDir + `", "outputFormats": {"test": "TEST.md"}}`
+	if err := os.WriteFile(configPath, []byte(configContent), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	localDir := filepath.Join(tmpDir, ".ctx", "fragments")
+	for dir, content := range map[string]string{
+		globalDir: "---\nctx-tags: global, shared\n---\n# Global",
+		localDir:  "---\nctx-tags: local\n---\n# Local",
+	} {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, filepath.Base(dir)+".md"), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create fragment: %v", err)
+		}
+	}
+
+	status = collectStatus(configPath)
+
+	if !status.ConfigFileExists || !status.GlobalFragmentsDirs[0].Exists || !status.LocalFragmentsDir.Exists {
+		t.Errorf("Expected config and directories to exist, got %+v", status)
+	}
+
+	expectedCounts := FragmentCounts{Global: 1, Local: 1, Combined: 2}
+	if status.Fragments != expectedCounts {
+		t.Errorf("Expected counts %+v, got %+v", expectedCounts, status.Fragments)
+	}
+
+	expectedTags := []string{"global", "local", "shared"}
+	if !reflect.DeepEqual(status.Tags, expectedTags) {
+		t.Errorf("Expected tags %v, got %v", expectedTags, status.Tags)
+	}
+
+	text := formatStatus(status)
+	if !strings.Contains(text, "test: TEST.md") || !strings.Contains(text, "1 global, 1 local, 2 combined") {
+		t.Errorf("Unexpected status output:\n%s", text)
+	}
+}
@@ -0,0 +1,37 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// colorRenderer always renders ANSI colors; whether they are used is decided by
+// BuildOptions.ColorEnabled rather than by detecting the terminal.
+var colorRenderer = newColorRenderer()
+
+// Styles of the build status messages.
+var (
+	successStyle = colorRenderer.NewStyle().Foreground(lipgloss.Color("2"))
+	errorStyle   = colorRenderer.NewStyle().Foreground(lipgloss.Color("1"))
+	skippedStyle = colorRenderer.NewStyle().Foreground(lipgloss.Color("3"))
+)
+
+func newColorRenderer() *lipgloss.Renderer {
+	renderer := lipgloss.NewRenderer(io.Discard)
+	renderer.SetColorProfile(termenv.ANSI)
+
+	return renderer
+}
+
+// printStatus prints a status message line, rendered with style when ColorEnabled is set.
+func (opts *BuildOptions) printStatus(style lipgloss.Style, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if opts.ColorEnabled {
+		message = style.Render(message)
+	}
+
+	fmt.Println(message)
+}
@@ -0,0 +1,41 @@
+package tui
+
+import (
+	"io"
+	"os"
+	"testing"
+)
+
+func TestPrintStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		color    bool
+		expected string
+	}{
+		{name: "plain", expected: "Output written to: AGENTS.md\n"},
+		{name: "colored", color: true, expected: "\x1b[32mOutput written to: AGENTS.md\x1b[0m\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, writer, err := os.Pipe()
+			if err != nil {
+				t.Fatalf("Failed to create pipe: %v", err)
+			}
+
+			stdout := os.Stdout
+			os.Stdout = writer
+
+			opts := BuildOptions{ColorEnabled: tt.color}
+			opts.printStatus(successStyle, "Output written to: %s", "AGENTS.md")
+
+			os.Stdout = stdout
+			_ = writer.Close()
+
+			output, _ := io.ReadAll(reader)
+			if string(output) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// BuildSummary describes what a successful build wrote, printed as a single line after
+// the output files.
+type BuildSummary struct {
+	// FragmentCount is the number of spliced fragments.
+	FragmentCount int `json:"fragmentCount"`
+	// OutputFiles are the written files with their size after writing.
+	OutputFiles []OutputFileSummary `json:"outputFiles"`
+	// ElapsedMs is the time the build took after the confirmation prompt, in milliseconds.
+	ElapsedMs int64 `json:"elapsedMs"`
+}
+
+// OutputFileSummary is a file written by a build.
+type OutputFileSummary struct {
+	Name      string `json:"name"`
+	SizeBytes int64  `json:"sizeBytes"`
+}
+
+// newBuildSummary describes a build of fragmentCount fragments that wrote the files in
+// written and started at start. Files that cannot be stat'ed are reported with size 0.
+func newBuildSummary(fragmentCount int, written []string, start time.Time) BuildSummary {
+	summary := BuildSummary{
+		FragmentCount: fragmentCount,
+		OutputFiles:   []OutputFileSummary{},
+		ElapsedMs:     time.Since(start).Milliseconds(),
+	}
+
+	for _, filename := range written {
+		file := OutputFileSummary{Name: filename}
+		if info, err := os.Stat(filename); err == nil {
+			file.SizeBytes = info.Size()
+		}
+
+		summary.OutputFiles = append(summary.OutputFiles, file)
+	}
+
+	return summary
+}
+
+// String returns the summary line, e.g.
+// "Built: 5 fragments → AGENTS.md (12.4 KB), GEMINI.md (12.4 KB) in 23ms".
+func (s BuildSummary) String() string {
+	noun := "fragments"
+	if s.FragmentCount == 1 {
+		noun = "fragment"
+	}
+
+	files := make([]string, len(s.OutputFiles))
+	for i, file := range s.OutputFiles {
+		files[i] = fmt.Sprintf("%s (%s)", file.Name, formatFileSize(file.SizeBytes))
+	}
+
+	return fmt.Sprintf("Built: %d %s → %s in %dms", s.FragmentCount, noun, strings.Join(files, ", "), s.ElapsedMs)
+}
+
+// formatFileSize returns size in bytes below 1 KB and otherwise in KB or MB with one decimal.
+func formatFileSize(size int64) string {
+	const unit = 1024
+
+	switch {
+	case size < unit:
+		return fmt.Sprintf("%d B", size)
+	case size < unit*unit:
+		return fmt.Sprintf("%.1f KB", float64(size)/unit)
+	default:
+		return fmt.Sprintf("%.1f MB", float64(size)/(unit*unit))
+	}
+}
@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildSummaryString(t *testing.T) {
+	tests := []struct {
+		name     string
+		summary  BuildSummary
+		expected string
+	}{
+		{
+			name: "several files",
+			summary: BuildSummary{
+				FragmentCount: 5,
+				OutputFiles:   []OutputFileSummary{{Name: "AGENTS.md", SizeBytes: 12698}, {Name: "GEMINI.md", SizeBytes: 12698}},
+				ElapsedMs:     23,
+			},
+			expected: "Built: 5 fragments → AGENTS.md (12.4 KB), GEMINI.md (12.4 KB) in 23ms",
+		},
+		{
+			name: "single fragment and small file",
+			summary: BuildSummary{
+				FragmentCount: 1,
+				OutputFiles:   []OutputFileSummary{{Name: "AGENTS.md", SizeBytes: 512}},
+			},
+			expected: "Built: 1 fragment → AGENTS.md (512 B) in 0ms",
+		},
+		{
+			name: "large file",
+			summary: BuildSummary{
+				FragmentCount: 2,
+				OutputFiles:   []OutputFileSummary{{Name: "out/AGENTS.md", SizeBytes: 3 * 1024 * 1024}},
+				ElapsedMs:     1200,
+			},
+			expected: "Built: 2 fragments → out/AGENTS.md (3.0 MB) in 1200ms",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.summary.String(); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRunBuildSummary(t *testing.T) {
+	tmpDir := t.TempDir()
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	for _, name := range []string{"a.md", "b.md"} {
+		if err := os.WriteFile(filepath.Join(fragmentsDir, name), []byte("---\nctx-tags: go\n---\nBody"), 0o600); err != nil {
+			t.Fatalf("Failed to create fragment: %v", err)
+		}
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"fragmentsDir": "`+fragmentsDir+`", "outputFormats": {}}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "AGENTS.md")
+	opts := BuildOptions{
+		ConfigFile:     configPath,
+		Tags:           []string{"go"},
+		NonInteractive: true,
+		OutputFiles:    []string{outputFile},
+		Quiet:          true,
+	}
+
+	result, err := RunBuild(&opts)
+	if err != nil {
+		t.Fatalf("RunBuild failed: %v", err)
+	}
+
+	// "Body\n\nBody"
+	expected := []OutputFileSummary{{Name: outputFile, SizeBytes: 10}}
+	if result.Summary.FragmentCount != 2 || len(result.Summary.OutputFiles) != 1 || result.Summary.OutputFiles[0] != expected[0] {
+		t.Errorf("Expected a summary of 2 fragments written to %v, got %+v", expected, result.Summary)
+	}
+}
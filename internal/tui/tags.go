@@ -0,0 +1,419 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+	"github.com/Lewenhaupt/ctx/internal/parser"
+	"github.com/charmbracelet/huh"
+)
+
+// Sort orders accepted by the tags command.
+const (
+	TagSortAlpha = "alpha"
+	TagSortCount = "count"
+)
+
+// TagsOptions represents the options for the tags list command.
+type TagsOptions struct {
+	ConfigFile    string
+	Sort          string
+	JSON          bool
+	ShowFragments bool
+}
+
+// TagUsage describes how many fragments use a tag and which ones.
+type TagUsage struct {
+	Tag       string   `json:"tag"`
+	Count     int      `json:"count"`
+	Fragments []string `json:"fragments"`
+	// AliasFor is set for a config alias to the tags it expands to; its fragments are those
+	// carrying any of them.
+	AliasFor []string `json:"aliasFor,omitempty"`
+}
+
+// RunTags lists all unique tags across the global and local fragments with their usage
+// counts, together with the aliases from the config.
+func RunTags(opts *TagsOptions) error {
+	if opts.Sort != TagSortAlpha && opts.Sort != TagSortCount {
+		return fmt.Errorf("invalid sort order %q (expected %s or %s)", opts.Sort, TagSortAlpha, TagSortCount)
+	}
+
+	cfg, err := config.LoadMergedConfig(opts.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	fragments, _, err := scanConfiguredFragments(cfg, scanOptions(cfg), false)
+	if err != nil {
+		return err
+	}
+
+	usages, err := collectTagUsage(fragments, cfg.Aliases, opts.Sort)
+	if err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		data, err := json.MarshalIndent(usages, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal tags: %w", err)
+		}
+
+		fmt.Println(string(data))
+
+		return nil
+	}
+
+	if len(fragments) == 0 {
+		fmt.Println("No fragments found. Create one with 'ctx fragment new'.")
+		return nil
+	}
+
+	if len(usages) == 0 {
+		fmt.Println("No tags found in fragments.")
+		return nil
+	}
+
+	for _, usage := range usages {
+		if len(usage.AliasFor) > 0 {
+			fmt.Printf("%-30s %d (alias for %s)\n", usage.Tag, usage.Count, strings.Join(usage.AliasFor, ", "))
+		} else {
+			fmt.Printf("%-30s %d\n", usage.Tag, usage.Count)
+		}
+
+		if opts.ShowFragments {
+			for _, fragment := range usage.Fragments {
+				fmt.Printf("  %s\n", fragment)
+			}
+		}
+	}
+
+	return nil
+}
+
+// collectTagUsage counts the fragments using each tag and each alias. Tags are sorted
+// alphabetically, or by descending count (ties broken alphabetically) when sortBy is
+// TagSortCount.
+func collectTagUsage(fragments []parser.Fragment, aliases map[string][]string, sortBy string) ([]TagUsage, error) {
+	infos := parser.GetAllTagInfo(fragments)
+
+	usages := make([]TagUsage, 0, len(infos)+len(aliases))
+	for _, info := range infos {
+		usages = append(usages, TagUsage{Tag: info.Tag, Count: info.Count, Fragments: fragmentBaseNames(info.Fragments)})
+	}
+
+	for alias := range aliases {
+		tags, err := parser.ExpandAliases([]string{alias}, aliases)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand tag aliases: %w", err)
+		}
+
+		var paths []string
+		for _, fragment := range parser.FilterFragmentsByTags(fragments, tags) {
+			paths = append(paths, fragment.Path)
+		}
+
+		usages = append(usages, TagUsage{Tag: alias, Count: len(paths), Fragments: fragmentBaseNames(paths), AliasFor: tags})
+	}
+
+	sort.Slice(usages, func(i, j int) bool {
+		if sortBy == TagSortCount && usages[i].Count != usages[j].Count {
+			return usages[i].Count > usages[j].Count
+		}
+
+		return usages[i].Tag < usages[j].Tag
+	})
+
+	return usages, nil
+}
+
+// fragmentBaseNames returns the filenames of the fragment paths.
+func fragmentBaseNames(paths []string) []string {
+	names := make([]string, 0, len(paths))
+	for _, path := range paths {
+		names = append(names, filepath.Base(path))
+	}
+
+	return names
+}
+
+// TagsRenameOptions represents the options for the tags rename command.
+type TagsRenameOptions struct {
+	ConfigFile string
+	OldTag     string
+	NewTag     string
+	DryRun     bool
+}
+
+// RunTagsRename renames a tag in the frontmatter of all global and local fragments
+// and prints each modified file.
+func RunTagsRename(opts *TagsRenameOptions) error {
+	cfg, err := config.LoadMergedConfig(opts.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	dirs, err := config.GetFragmentsDirs(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to get fragments directory: %w", err)
+	}
+
+	localDir, err := parser.LocalFragmentsDir()
+	if err != nil {
+		return err
+	}
+
+	rename := parser.RenameTag
+	verb := "updated"
+
+	if opts.DryRun {
+		rename = parser.PreviewRenameTag
+		verb = "would update"
+	}
+
+	var modified []string
+
+	for _, dir := range append(dirs, localDir) {
+		paths, err := rename(dir, opts.OldTag, opts.NewTag)
+		if err != nil {
+			return fmt.Errorf("failed to rename tag in %s: %w", dir, err)
+		}
+
+		modified = append(modified, paths...)
+	}
+
+	if len(modified) == 0 {
+		fmt.Printf("No fragments use the tag '%s'.\n", opts.OldTag)
+		return nil
+	}
+
+	for _, path := range modified {
+		fmt.Printf("%s: %s\n", verb, path)
+	}
+
+	return nil
+}
+
+// TagsAddOptions represents the options for the tags add command.
+type TagsAddOptions struct {
+	ConfigFile string
+	Name       string
+	Tags       []string
+}
+
+// RunTagsAdd adds tags to the frontmatter of the fragment with the given name, see
+// activeFragment.
+func RunTagsAdd(opts *TagsAddOptions) error {
+	cfg, err := config.LoadMergedConfig(opts.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	details, err := findFragmentsByName(cfg, opts.Name)
+	if err != nil {
+		return err
+	}
+
+	if len(details) == 0 {
+		return fmt.Errorf("fragment %q not found", opts.Name)
+	}
+
+	path := activeFragment(details).Path
+
+	fragment, err := parser.ParseFragmentWithOptions(path, parseOptions(cfg))
+	if err != nil {
+		return err
+	}
+
+	var added []string
+
+	for _, tag := range opts.Tags {
+		if !slices.Contains(fragment.Tags, tag) && !slices.Contains(added, tag) {
+			added = append(added, tag)
+		}
+	}
+
+	if err := parser.AddTagsToFragment(path, opts.Tags); err != nil {
+		return fmt.Errorf("failed to add tags to %s: %w", path, err)
+	}
+
+	if len(added) == 0 {
+		fmt.Printf("%s already has the tags %s.\n", path, strings.Join(opts.Tags, ", "))
+	} else {
+		fmt.Printf("updated: %s (added %s)\n", path, strings.Join(added, ", "))
+	}
+
+	printOtherMatches(len(details), opts.Name)
+
+	return nil
+}
+
+// TagsRemoveOptions represents the options for the tags remove command.
+type TagsRemoveOptions struct {
+	ConfigFile     string
+	Name           string
+	Tags           []string
+	Force          bool
+	NonInteractive bool
+}
+
+// RunTagsRemove removes tags from the frontmatter of the fragment with the given name, see
+// activeFragment. Removing the last tag of a fragment has to be confirmed, or forced in non-interactive mode,
+// since a fragment without tags is never included in a build.
+func RunTagsRemove(opts *TagsRemoveOptions) error {
+	cfg, err := config.LoadMergedConfig(opts.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	details, err := findFragmentsByName(cfg, opts.Name)
+	if err != nil {
+		return err
+	}
+
+	if len(details) == 0 {
+		return fmt.Errorf("fragment %q not found", opts.Name)
+	}
+
+	fragment := activeFragment(details)
+
+	parsed, err := parser.ParseFragmentWithOptions(fragment.Path, parseOptions(cfg))
+	if err != nil {
+		return err
+	}
+
+	var removed []string
+
+	for _, tag := range parsed.Tags {
+		if slices.Contains(opts.Tags, tag) && !slices.Contains(removed, tag) {
+			removed = append(removed, tag)
+		}
+	}
+
+	if len(removed) == 0 {
+		fmt.Printf("%s has none of the tags %s.\n", fragment.Path, strings.Join(opts.Tags, ", "))
+		return nil
+	}
+
+	// fragment.Tags includes the namespace tags, which keep the fragment buildable.
+	remaining := slices.DeleteFunc(slices.Clone(fragment.Tags), func(tag string) bool {
+		return slices.Contains(removed, tag)
+	})
+
+	if len(remaining) == 0 {
+		confirmed, err := confirmRemoveLastTag(opts, fragment.Path)
+		if err != nil {
+			return err
+		}
+
+		if !confirmed {
+			fmt.Println("Tags not removed.")
+			return nil
+		}
+	}
+
+	if err := parser.RemoveTagsFromFragment(fragment.Path, opts.Tags); err != nil {
+		return fmt.Errorf("failed to remove tags from %s: %w", fragment.Path, err)
+	}
+
+	fmt.Printf("updated: %s (removed %s)\n", fragment.Path, strings.Join(removed, ", "))
+	printOtherMatches(len(details), opts.Name)
+
+	return nil
+}
+
+// confirmRemoveLastTag asks whether the last tags of a fragment may be removed.
+// In non-interactive mode they are only removed with Force.
+func confirmRemoveLastTag(opts *TagsRemoveOptions, path string) (bool, error) {
+	if opts.Force {
+		return true, nil
+	}
+
+	if opts.NonInteractive {
+		return false, fmt.Errorf("removing these tags would leave %s without tags, so it would never be included in a build; use --force to remove them anyway", path)
+	}
+
+	var confirmed bool
+
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title("Remove the last tags?").
+				Description(fmt.Sprintf("%s would be left without tags and never be included in a build.", path)).
+				Value(&confirmed),
+		),
+	)
+
+	if err := form.Run(); err != nil {
+		return false, fmt.Errorf("failed to get confirmation: %w", err)
+	}
+
+	return confirmed, nil
+}
+
+// FragmentTagListOptions represents the options for the fragment tag-list command.
+type FragmentTagListOptions struct {
+	ConfigFile string
+	Name       string
+	JSON       bool
+}
+
+// RunFragmentTagList prints the tags of the fragment with the given name one per line, or
+// as a JSON array with opts.JSON. The name may carry the .md extension; see activeFragment
+// for which fragment is used when several share the name.
+func RunFragmentTagList(opts *FragmentTagListOptions) error {
+	cfg, err := config.LoadMergedConfig(opts.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	name := strings.TrimSuffix(opts.Name, ".md")
+
+	details, err := findFragmentsByName(cfg, name)
+	if err != nil {
+		return err
+	}
+
+	if len(details) == 0 {
+		return fmt.Errorf("fragment %q not found", opts.Name)
+	}
+
+	output, err := formatTagList(activeFragment(details).Tags, opts.JSON)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(output)
+
+	return nil
+}
+
+// formatTagList returns the tags one per line, or as a single-line JSON array.
+func formatTagList(tags []string, asJSON bool) (string, error) {
+	if !asJSON {
+		var b strings.Builder
+
+		for _, tag := range tags {
+			b.WriteString(tag + "\n")
+		}
+
+		return b.String(), nil
+	}
+
+	if tags == nil {
+		tags = []string{}
+	}
+
+	data, err := json.Marshal(tags)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal tags: %w", err)
+	}
+
+	return string(data) + "\n", nil
+}
@@ -0,0 +1,94 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+func TestCollectTagUsage(t *testing.T) {
+	fragments := []parser.Fragment{
+		{Path: "/g/typescript.md", Tags: []string{"typescript", "web"}},
+		{Path: "/g/react.md", Tags: []string{"typescript", "react", "typescript"}},
+		{Path: "/l/.ctx/fragments/css.md", Tags: []string{"web", "css", "typescript"}},
+	}
+	aliases := map[string][]string{"fe": {"react", "css"}}
+
+	tests := []struct {
+		name     string
+		sortBy   string
+		expected []TagUsage
+	}{
+		{
+			name:   "alphabetical",
+			sortBy: TagSortAlpha,
+			expected: []TagUsage{
+				{Tag: "css", Count: 1, Fragments: []string{"css.md"}},
+				{Tag: "fe", Count: 2, Fragments: []string{"react.md", "css.md"}, AliasFor: []string{"react", "css"}},
+				{Tag: "react", Count: 1, Fragments: []string{"react.md"}},
+				{Tag: "typescript", Count: 3, Fragments: []string{"typescript.md", "react.md", "css.md"}},
+				{Tag: "web", Count: 2, Fragments: []string{"typescript.md", "css.md"}},
+			},
+		},
+		{
+			name:   "by count",
+			sortBy: TagSortCount,
+			expected: []TagUsage{
+				{Tag: "typescript", Count: 3, Fragments: []string{"typescript.md", "react.md", "css.md"}},
+				{Tag: "fe", Count: 2, Fragments: []string{"react.md", "css.md"}, AliasFor: []string{"react", "css"}},
+				{Tag: "web", Count: 2, Fragments: []string{"typescript.md", "css.md"}},
+				{Tag: "css", Count: 1, Fragments: []string{"css.md"}},
+				{Tag: "react", Count: 1, Fragments: []string{"react.md"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usages, err := collectTagUsage(fragments, aliases, tt.sortBy)
+			if err != nil {
+				t.Fatalf("collectTagUsage failed: %v", err)
+			}
+
+			if !reflect.DeepEqual(usages, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, usages)
+			}
+		})
+	}
+}
+
+func TestCollectTagUsageCircularAlias(t *testing.T) {
+	aliases := map[string][]string{"a": {"b"}, "b": {"a"}}
+
+	if _, err := collectTagUsage(nil, aliases, TagSortAlpha); err == nil {
+		t.Error("Expected an error for circular aliases")
+	}
+}
+
+func TestFormatTagList(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     []string
+		asJSON   bool
+		expected string
+	}{
+		{name: "lines", tags: []string{"typescript", "strict"}, expected: "typescript\nstrict\n"},
+		{name: "no tags", expected: ""},
+		{name: "json", tags: []string{"typescript", "strict"}, asJSON: true, expected: "[\"typescript\",\"strict\"]\n"},
+		{name: "json without tags", asJSON: true, expected: "[]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := formatTagList(tt.tags, tt.asJSON)
+			if err != nil {
+				t.Fatalf("formatTagList failed: %v", err)
+			}
+
+			if output != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/Lewenhaupt/ctx/internal/config"
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+// Validation issue severities.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// ValidateOptions represents the options for the validate command.
+type ValidateOptions struct {
+	ConfigFile string
+}
+
+// ValidationIssue is a problem found in a single fragment file.
+type ValidationIssue struct {
+	Path     string
+	Severity string
+	Message  string
+}
+
+// RunValidate parses every global and local fragment and prints the problems found.
+// It reports whether all fragments are valid; warnings do not make a fragment invalid.
+func RunValidate(opts *ValidateOptions) (bool, error) {
+	cfg, err := config.LoadMergedConfig(opts.ConfigFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	dirs, err := config.GetFragmentsDirs(cfg)
+	if err != nil {
+		return false, fmt.Errorf("failed to get fragments directory: %w", err)
+	}
+
+	localDir, err := parser.LocalFragmentsDir()
+	if err != nil {
+		return false, err
+	}
+
+	issues, checked, err := validateFragmentDirs(append(dirs, localDir), scanOptions(cfg))
+	if err != nil {
+		return false, err
+	}
+
+	var errorCount, warningCount int
+
+	for _, issue := range issues {
+		fmt.Printf("%s: %s: %s\n", issue.Severity, issue.Path, issue.Message)
+
+		if issue.Severity == SeverityError {
+			errorCount++
+		} else {
+			warningCount++
+		}
+	}
+
+	fmt.Printf("%d fragment(s) checked, %d error(s), %d warning(s)\n", checked, errorCount, warningCount)
+
+	return errorCount == 0, nil
+}
+
+// validateFragmentDirs parses each fragment file in dirs individually so that one broken
+// fragment does not hide problems in the others. Fragments with unresolvable includes are
+// reported with the hints of parser.VerifyIncludes. It returns the issues found and the
+// number of files checked.
+func validateFragmentDirs(dirs []string, scanOpts parser.ScanOptions) ([]ValidationIssue, int, error) {
+	var issues []ValidationIssue
+
+	checked := 0
+
+	for _, dir := range dirs {
+		paths, err := parser.FindFragmentFiles(dir)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan %s: %w", dir, err)
+		}
+
+		includeErrors := make(map[string][]parser.IncludeError)
+		for _, includeErr := range parser.VerifyIncludes(includeReferences(paths), dirs) {
+			includeErrors[includeErr.Fragment] = append(includeErrors[includeErr.Fragment], includeErr)
+		}
+
+		for _, path := range paths {
+			checked++
+
+			if errs := includeErrors[path]; len(errs) > 0 {
+				for _, includeErr := range errs {
+					issues = append(issues, ValidationIssue{Path: path, Severity: SeverityError, Message: includeErr.Message()})
+				}
+
+				continue
+			}
+
+			fragment, err := parser.ParseFragmentWithOptions(path, scanOpts.ParseOptions())
+			if err != nil {
+				issues = append(issues, ValidationIssue{Path: path, Severity: SeverityError, Message: err.Error()})
+				continue
+			}
+
+			for _, warning := range fragment.Warnings {
+				issues = append(issues, ValidationIssue{Path: path, Severity: SeverityWarning, Message: warning})
+			}
+		}
+	}
+
+	return issues, checked, nil
+}
@@ -0,0 +1,73 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Lewenhaupt/ctx/internal/parser"
+)
+
+func TestValidateFragmentDirs(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"valid.md":      "---\nctx-tags: a\nctx-priority: 1\n---\nValid",
+		"deprecated.md": "---\nctx-tags: a\nctx-order: 2\n---\nDeprecated",
+		"broken.md":     "---\nctx-tags: {a: b}\n---\nBroken",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create fragment %s: %v", name, err)
+		}
+	}
+
+	issues, checked, err := validateFragmentDirs([]string{tmpDir, filepath.Join(tmpDir, "missing")}, parser.ScanOptions{})
+	if err != nil {
+		t.Fatalf("validateFragmentDirs failed: %v", err)
+	}
+
+	if checked != 3 {
+		t.Errorf("Expected 3 fragments checked, got %d", checked)
+	}
+
+	var severities []string
+	for _, issue := range issues {
+		severities = append(severities, filepath.Base(issue.Path)+":"+issue.Severity)
+	}
+
+	expected := []string{"broken.md:error", "deprecated.md:warning"}
+	if !reflect.DeepEqual(severities, expected) {
+		t.Errorf("Expected issues %v, got %v", expected, severities)
+	}
+}
+
+func TestValidateFragmentDirsUnresolvedInclude(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"main.md":   "---\nctx-tags: a\nctx-include: heder.md\n---\nMain",
+		"header.md": "---\nctx-tags: a\n---\nHeader",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to create fragment %s: %v", name, err)
+		}
+	}
+
+	issues, _, err := validateFragmentDirs([]string{tmpDir}, parser.ScanOptions{})
+	if err != nil {
+		t.Fatalf("validateFragmentDirs failed: %v", err)
+	}
+
+	if len(issues) != 1 || issues[0].Severity != SeverityError || issues[0].Path != filepath.Join(tmpDir, "main.md") {
+		t.Fatalf("Expected one error for main.md, got %+v", issues)
+	}
+
+	if expected := `included file "heder.md" not found (did you mean header.md?)`; issues[0].Message != expected {
+		t.Errorf("Expected message %q, got %q", expected, issues[0].Message)
+	}
+}
@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Placeholders reported for build information that was not set at build time.
+const (
+	DevVersion       = "dev"
+	UnknownBuildInfo = "unknown"
+)
+
+// VersionInfo describes the build of the ctx binary.
+type VersionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+}
+
+// VersionOptions represents the options for the version command.
+type VersionOptions struct {
+	Info  VersionInfo
+	JSON  bool
+	Short bool
+}
+
+// RunVersion prints the build information, as JSON or only the version when requested.
+func RunVersion(opts *VersionOptions) error {
+	info := withVersionDefaults(opts.Info)
+
+	switch {
+	case opts.Short:
+		fmt.Println(info.Version)
+	case opts.JSON:
+		data, err := json.Marshal(info)
+		if err != nil {
+			return fmt.Errorf("failed to marshal version: %w", err)
+		}
+
+		fmt.Println(string(data))
+	default:
+		fmt.Print(formatVersion(info))
+	}
+
+	return nil
+}
+
+// withVersionDefaults fills in the placeholders for fields left empty because the
+// binary was built without the corresponding -ldflags.
+func withVersionDefaults(info VersionInfo) VersionInfo {
+	if info.Version == "" {
+		info.Version = DevVersion
+	}
+
+	if info.Commit == "" {
+		info.Commit = UnknownBuildInfo
+	}
+
+	if info.BuildTime == "" {
+		info.BuildTime = UnknownBuildInfo
+	}
+
+	return info
+}
+
+// formatVersion returns the human-readable build information.
+func formatVersion(info VersionInfo) string {
+	return fmt.Sprintf("ctx %s\ncommit: %s\nbuilt: %s\n", info.Version, info.Commit, info.BuildTime)
+}
@@ -0,0 +1,24 @@
+package tui
+
+import "testing"
+
+func TestWithVersionDefaults(t *testing.T) {
+	info := withVersionDefaults(VersionInfo{})
+	if info.Version != DevVersion || info.Commit != UnknownBuildInfo || info.BuildTime != UnknownBuildInfo {
+		t.Errorf("Expected placeholders for missing build information, got %+v", info)
+	}
+
+	set := VersionInfo{Version: "v1.2.3", Commit: "abc123", BuildTime: "2024-01-01T00:00:00Z"}
+	if info := withVersionDefaults(set); info != set {
+		t.Errorf("Expected build information to be kept, got %+v", info)
+	}
+}
+
+func TestFormatVersion(t *testing.T) {
+	got := formatVersion(VersionInfo{Version: "v1.2.3", Commit: "abc123", BuildTime: "2024-01-01T00:00:00Z"})
+	want := "ctx v1.2.3\ncommit: abc123\nbuilt: 2024-01-01T00:00:00Z\n"
+
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
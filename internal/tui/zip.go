@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ZipManifestName is the name of the manifest build --zip adds to the archive, listing the
+// tags, fragments and build time of the output files in it.
+const ZipManifestName = "ctx-manifest.json"
+
+// writeZipArchive writes the output files of the planned formats into a zip archive at
+// opts.Zip instead of to disk, together with a ZipManifestName manifest, and returns the
+// path of the archive.
+func writeZipArchive(opts *BuildOptions, output *buildOutput, plan *buildPlan) ([]string, error) {
+	builtAt := time.Now()
+	contents := outputContents(opts, plan, output)
+
+	var buf bytes.Buffer
+
+	archive := zip.NewWriter(&buf)
+
+	for _, filename := range slices.Sorted(maps.Keys(contents)) {
+		opts.logf("archiving: %s", filename)
+
+		if err := addZipEntry(archive, zipEntryName(filename), builtAt, []byte(contents[filename])); err != nil {
+			return nil, err
+		}
+	}
+
+	manifest, err := json.MarshalIndent(newSidecarMetadata(plan, builtAt), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal zip manifest: %w", err)
+	}
+
+	if err := addZipEntry(archive, ZipManifestName, builtAt, append(manifest, '\n')); err != nil {
+		return nil, err
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish zip archive: %w", err)
+	}
+
+	target := outputTarget{filename: opts.Zip, content: buf.String()}
+	if err := writeOutputFile(target); err != nil {
+		return nil, fmt.Errorf("failed to write zip archive: %w", err)
+	}
+
+	reportOutputWritten(opts, target)
+
+	return []string{opts.Zip}, nil
+}
+
+// addZipEntry adds a file with the given name and content to the archive.
+func addZipEntry(archive *zip.Writer, name string, modified time.Time, data []byte) error {
+	writer, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return fmt.Errorf("failed to add %s to zip archive: %w", name, err)
+	}
+
+	if _, err := writer.Write(data); err != nil {
+		return fmt.Errorf("failed to add %s to zip archive: %w", name, err)
+	}
+
+	return nil
+}
+
+// zipEntryName returns the name an output file is stored under in the zip archive: its path
+// relative to the current directory. Files outside the current directory are stored under
+// their base name.
+func zipEntryName(filename string) string {
+	name := filepath.Clean(filename)
+
+	if filepath.IsAbs(name) {
+		wd, err := os.Getwd()
+		if err == nil {
+			name, err = filepath.Rel(wd, name)
+		}
+
+		if err != nil {
+			return filepath.Base(filename)
+		}
+	}
+
+	if name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		return filepath.Base(filename)
+	}
+
+	return filepath.ToSlash(name)
+}
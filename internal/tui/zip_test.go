@@ -0,0 +1,138 @@
+package tui
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunBuildZip(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Chdir(tmpDir)
+
+	fragmentsDir := filepath.Join(tmpDir, "fragments")
+	if err := os.MkdirAll(fragmentsDir, 0o750); err != nil {
+		t.Fatalf("Failed to create fragments directory: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(fragmentsDir, "go.md"), []byte("---\nctx-tags: go\n---\nGo body"), 0o600); err != nil {
+		t.Fatalf("Failed to create fragment: %v", err)
+	}
+
+	configPath := filepath.Join(tmpDir, "config.json")
+	config := `{"fragmentsDir": "` + fragmentsDir + `", "outputFormats": {"opencode": "AGENTS.md", "docs": "docs/CONTEXT.md"}}`
+
+	if err := os.WriteFile(configPath, []byte(config), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	zipPath := filepath.Join(tmpDir, "out", "context.zip")
+	opts := BuildOptions{
+		ConfigFile:     configPath,
+		Tags:           []string{"go"},
+		NonInteractive: true,
+		OutputFormats:  []string{"opencode", "docs"},
+		Zip:            zipPath,
+	}
+
+	result, err := RunBuild(&opts)
+	if err != nil {
+		t.Fatalf("RunBuild failed: %v", err)
+	}
+
+	if len(result.OutputFiles) != 1 || result.OutputFiles[0] != zipPath {
+		t.Errorf("Expected only the zip archive to be reported as written, got %v", result.OutputFiles)
+	}
+
+	for _, name := range []string{"AGENTS.md", filepath.Join("docs", "CONTEXT.md")} {
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("Expected %s not to be written to disk with --zip", name)
+		}
+	}
+
+	archive, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatalf("Failed to open zip archive: %v", err)
+	}
+
+	defer func() { _ = archive.Close() }()
+
+	entries := make(map[string]string)
+
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s in zip archive: %v", file.Name, err)
+		}
+
+		data, err := io.ReadAll(reader)
+		_ = reader.Close()
+
+		if err != nil {
+			t.Fatalf("Failed to read %s in zip archive: %v", file.Name, err)
+		}
+
+		entries[file.Name] = string(data)
+	}
+
+	if len(entries) != 3 {
+		t.Errorf("Expected 3 entries in the zip archive, got %d: %v", len(entries), entries)
+	}
+
+	for _, name := range []string{"AGENTS.md", "docs/CONTEXT.md"} {
+		if entries[name] != "Go body" {
+			t.Errorf("Expected %s to contain %q, got %q", name, "Go body", entries[name])
+		}
+	}
+
+	var manifest SidecarMetadata
+	if err := json.Unmarshal([]byte(entries[ZipManifestName]), &manifest); err != nil {
+		t.Fatalf("Failed to parse %s: %v", ZipManifestName, err)
+	}
+
+	if len(manifest.Tags) != 1 || manifest.Tags[0] != "go" {
+		t.Errorf("Expected manifest tags [go], got %v", manifest.Tags)
+	}
+
+	if len(manifest.Fragments) != 1 || filepath.Base(manifest.Fragments[0].Path) != "go.md" {
+		t.Errorf("Expected manifest to list go.md, got %v", manifest.Fragments)
+	}
+
+	if manifest.BuiltAt == "" {
+		t.Error("Expected manifest to record the build time")
+	}
+}
+
+func TestRunBuildZipWithStdout(t *testing.T) {
+	opts := BuildOptions{NonInteractive: true, Stdout: true, Zip: "context.zip"}
+
+	if _, err := RunBuild(&opts); err == nil {
+		t.Error("Expected --zip combined with --stdout to fail")
+	}
+}
+
+func TestZipEntryName(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+
+	tests := []struct {
+		filename string
+		expected string
+	}{
+		{filename: "AGENTS.md", expected: "AGENTS.md"},
+		{filename: filepath.Join("docs", "CONTEXT.md"), expected: "docs/CONTEXT.md"},
+		{filename: filepath.Join(wd, "sub", "GEMINI.md"), expected: "sub/GEMINI.md"},
+		{filename: filepath.Join("..", "outside", "AGENTS.md"), expected: "AGENTS.md"},
+	}
+
+	for _, tt := range tests {
+		if got := zipEntryName(tt.filename); got != tt.expected {
+			t.Errorf("zipEntryName(%q) = %q, expected %q", tt.filename, got, tt.expected)
+		}
+	}
+}
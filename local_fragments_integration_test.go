@@ -226,6 +226,142 @@ func TestLocalFragmentsIntegration_NoLocalOverride(t *testing.T) {
 	}
 }
 
+func TestLocalFragmentsIntegration_NoLocal(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	// Run ctx build with --no-local flag, selecting the common tag shared by local and global fragments
+	cmd := exec.Command(setup.ctxBinary, "build", "--non-interactive", "--stdout", "--tags", "common", "--no-local")
+	cmd.Dir = setup.tmpDir
+
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("ctx build with --no-local failed: %v", err)
+	}
+
+	outputStr := string(output)
+
+	// Should contain only the global common fragment
+	if strings.Contains(outputStr, "Local Common Fragment") {
+		t.Error("Expected output to NOT contain local common fragment")
+	}
+
+	if !strings.Contains(outputStr, "Global Common Fragment") {
+		t.Error("Expected output to contain global common fragment")
+	}
+
+	// The project config is skipped as well
+	localConfig := filepath.Join(setup.tmpDir, ".ctx", "config.json")
+	if err := os.WriteFile(localConfig, []byte(`{"separator": "\n<!-- project separator -->\n"}`), 0o600); err != nil {
+		t.Fatalf("Failed to write local config: %v", err)
+	}
+
+	cmd = exec.Command(setup.ctxBinary, "build", "--non-interactive", "--stdout", "--tags", "common", "--no-local-override")
+	cmd.Dir = setup.tmpDir
+
+	output, err = cmd.Output()
+	if err != nil {
+		t.Fatalf("ctx build with a project config failed: %v", err)
+	}
+
+	if !strings.Contains(string(output), "project separator") {
+		t.Fatalf("Expected the project config separator without --no-local, got: %s", output)
+	}
+
+	cmd = exec.Command(setup.ctxBinary, "build", "--non-interactive", "--stdout", "--tags", "common", "--no-local")
+	cmd.Dir = setup.tmpDir
+
+	output, err = cmd.Output()
+	if err != nil {
+		t.Fatalf("ctx build with --no-local failed: %v", err)
+	}
+
+	if strings.Contains(string(output), "project separator") {
+		t.Errorf("Expected --no-local to skip the project config, got: %s", output)
+	}
+
+	// Combining --no-local with --no-local-override is an error
+	cmd = exec.Command(setup.ctxBinary, "build", "--non-interactive", "--stdout", "--tags", "common", "--no-local", "--no-local-override")
+	cmd.Dir = setup.tmpDir
+
+	output, err = cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("Expected ctx build with --no-local and --no-local-override to fail, got output: %s", output)
+	}
+
+	if !strings.Contains(string(output), "none of the others can be") {
+		t.Errorf("Expected a mutual exclusion error, got: %s", output)
+	}
+}
+
+func TestLocalFragmentsIntegration_WalkUp(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	serviceDir := filepath.Join(setup.tmpDir, "services", "api")
+	workDir := filepath.Join(serviceDir, "src")
+
+	if err := os.MkdirAll(filepath.Join(serviceDir, ".ctx", "fragments"), 0o750); err != nil {
+		t.Fatalf("Failed to create service fragments directory: %v", err)
+	}
+
+	if err := os.MkdirAll(workDir, 0o750); err != nil {
+		t.Fatalf("Failed to create working directory: %v", err)
+	}
+
+	serviceCommonContent := "---\nctx-tags: common\n---\n\n# Service Common Fragment\n"
+	if err := os.WriteFile(filepath.Join(serviceDir, ".ctx", "fragments", "common.md"), []byte(serviceCommonContent), 0o600); err != nil {
+		t.Fatalf("Failed to create service common fragment: %v", err)
+	}
+
+	build := func() string {
+		cmd := exec.Command(setup.ctxBinary, "build", "--non-interactive", "--stdout", "--tags", "common,testing", "--walk-up")
+		cmd.Dir = workDir
+
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("ctx build with --walk-up failed: %v\nOutput: %s", err, output)
+		}
+
+		return string(output)
+	}
+
+	// The deepest common.md overrides the one in the repository root, which overrides the global one
+	outputStr := build()
+
+	if !strings.Contains(outputStr, "Service Common Fragment") {
+		t.Error("Expected output to contain the service common fragment")
+	}
+
+	if strings.Contains(outputStr, "Local Common Fragment") || strings.Contains(outputStr, "Global Common Fragment") {
+		t.Errorf("Expected the service common fragment to override the parent and global ones, got: %s", outputStr)
+	}
+
+	if !strings.Contains(outputStr, "Local Only Fragment") {
+		t.Error("Expected output to contain the local-only fragment of the parent directory")
+	}
+
+	// walkUp: false in an intermediate .ctx config stops the search there
+	servicesConfigDir := filepath.Join(setup.tmpDir, "services", ".ctx")
+	if err := os.MkdirAll(servicesConfigDir, 0o750); err != nil {
+		t.Fatalf("Failed to create config directory: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(servicesConfigDir, "config.json"), []byte(`{"walkUp": false}`), 0o600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	outputStr = build()
+
+	if strings.Contains(outputStr, "Local Only Fragment") {
+		t.Errorf("Expected the walk to stop below the repository root, got: %s", outputStr)
+	}
+
+	if !strings.Contains(outputStr, "Service Common Fragment") {
+		t.Error("Expected output to contain the service common fragment")
+	}
+}
+
 func TestLocalFragmentsIntegration_LocalOnlyTags(t *testing.T) {
 	setup := setupIntegrationTest(t)
 	defer cleanupIntegrationTest(setup)
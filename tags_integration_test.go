@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTagsIntegration_Remove(t *testing.T) {
+	setup := setupIntegrationTest(t)
+	defer cleanupIntegrationTest(setup)
+
+	writeLocalFragment(t, setup, "rules.md", "---\nctx-tags: api, lint, go\nctx-priority: 2\n---\n# Rules")
+
+	path := filepath.Join(setup.tmpDir, ".ctx", "fragments", "rules.md")
+
+	cmd := exec.Command(setup.ctxBinary, "tags", "remove", "rules", "lint", "go", "--non-interactive")
+	cmd.Dir = setup.tmpDir
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("ctx tags remove failed: %v\nOutput: %s", err, output)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read fragment: %v", err)
+	}
+
+	if expected := "---\nctx-tags: api\nctx-priority: 2\n---\n# Rules"; string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+
+	// Removing the last tag must be refused without --force in non-interactive mode
+	cmd = exec.Command(setup.ctxBinary, "tags", "remove", "rules", "api", "--non-interactive")
+	cmd.Dir = setup.tmpDir
+
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("Expected removing the last tag to fail without --force, got: %s", output)
+	}
+
+	if !strings.Contains(string(output), "--force") {
+		t.Errorf("Expected error to mention --force, got: %s", output)
+	}
+
+	data, _ = os.ReadFile(path)
+	if !strings.Contains(string(data), "ctx-tags: api\n") {
+		t.Errorf("Expected fragment to keep its last tag, got %q", data)
+	}
+
+	cmd = exec.Command(setup.ctxBinary, "tags", "remove", "rules", "api", "--non-interactive", "--force")
+	cmd.Dir = setup.tmpDir
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("ctx tags remove --force failed: %v\nOutput: %s", err, output)
+	}
+
+	data, _ = os.ReadFile(path)
+	if expected := "---\nctx-tags: []\nctx-priority: 2\n---\n# Rules"; string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, data)
+	}
+}